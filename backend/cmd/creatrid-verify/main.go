@@ -0,0 +1,137 @@
+// Command creatrid-verify checks a Creatrid proof bundle offline: that the
+// platform signed it, that the original file is the one it describes, and
+// that the content hash is in the Merkle batch whose root was anchored.
+//
+//	creatrid-verify -keys creatrid-proof-keys.json -file photo.jpg photo.creatrid-proof.json
+//
+// The key set is the document served at /.well-known/creatrid-proof-keys.json;
+// save a copy once and no call to Creatrid is needed. Whether the anchor
+// itself is on chain, or the timestamp token is validly signed, is checked
+// with a block explorer or openssl, as the output explains.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/creatrid/creatrid/pkg/proofbundle"
+)
+
+func main() {
+	keysPath := flag.String("keys", "", "saved copy of "+proofbundle.WellKnownPath+" (required)")
+	filePath := flag.String("file", "", "original file to check against the bundle's SHA-256")
+	tokenOut := flag.String("token-out", "", "write the bundle's RFC 3161 timestamp token to this file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -keys keys.json [-file original] bundle.json\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *keysPath == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*keysPath, flag.Arg(0), *filePath, *tokenOut); err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(keysPath, bundlePath, filePath, tokenOut string) error {
+	kf, err := os.Open(keysPath)
+	if err != nil {
+		return err
+	}
+	defer kf.Close()
+	ks, err := proofbundle.ReadKeySet(kf)
+	if err != nil {
+		return err
+	}
+
+	bf, err := os.Open(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer bf.Close()
+	b, err := proofbundle.Read(bf)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Verify(ks); err != nil {
+		return err
+	}
+	p := b.Payload
+	fmt.Printf("OK   signature by key %s, issued by %s at %s\n", b.Signature.KeyID, p.Issuer, p.IssuedAt.Format(time.RFC3339))
+	fmt.Printf("     %q (%s) uploaded %s by %s\n", p.Content.Title, p.Content.ID, p.Content.UploadedAt.Format(time.RFC3339), owner(p.Owner))
+	if p.Content.DisputeStatus != "" {
+		fmt.Printf("WARN ownership is %s: another creator uploaded the same file earlier\n", p.Content.DisputeStatus)
+	}
+
+	if filePath != "" {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := b.VerifyFile(f); err != nil {
+			return err
+		}
+		fmt.Printf("OK   %s has SHA-256 %s\n", filePath, p.Content.SHA256)
+	} else {
+		fmt.Printf("--   no -file given; the bundle is for SHA-256 %s\n", p.Content.SHA256)
+	}
+
+	a := p.Anchor
+	if a == nil {
+		fmt.Println("--   not anchored")
+		return nil
+	}
+	if a.Policy != "" {
+		fmt.Printf("     anchored automatically under the owner's %q policy\n", a.Policy)
+	}
+	if a.Merkle != nil {
+		fmt.Printf("OK   hash is leaf %d of %d under Merkle root %s\n", a.Merkle.LeafIndex, a.Merkle.LeafCount, a.Merkle.Root)
+	}
+	// Bundles issued before anchors waited for finality say "confirmed"
+	if a.Status != "finalized" && a.Status != "confirmed" {
+		fmt.Printf("--   anchor on %s is %s, not yet final\n", a.Chain, a.Status)
+		return nil
+	}
+
+	anchored := p.Content.SHA256
+	if a.Merkle != nil {
+		anchored = a.Merkle.Root
+	}
+	if a.TimestampToken != nil {
+		fmt.Printf("--   timestamped by an RFC 3161 authority (serial %s)\n", a.TxHash)
+		if tokenOut == "" {
+			fmt.Println("     pass -token-out token.der to check the token with:")
+			fmt.Printf("     openssl ts -verify -token_in -in token.der -digest %s -CAfile <TSA CA certificate>\n", anchored)
+			return nil
+		}
+		if err := os.WriteFile(tokenOut, a.TimestampToken, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("     token written to %s; check it with:\n", tokenOut)
+		fmt.Printf("     openssl ts -verify -token_in -in %s -digest %s -CAfile <TSA CA certificate>\n", tokenOut, anchored)
+		return nil
+	}
+
+	fmt.Printf("--   anchored on %s in tx %s, block %d %s\n", a.Chain, a.TxHash, a.BlockNumber, a.BlockHash)
+	fmt.Printf("     check on a block explorer that the transaction's input data is 0x%s\n", anchored)
+	return nil
+}
+
+func owner(o proofbundle.Owner) string {
+	s := o.ID
+	if o.Username != "" {
+		s = "@" + o.Username
+	}
+	if o.Verified {
+		s += " (verified)"
+	}
+	return s
+}
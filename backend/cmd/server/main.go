@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
-	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,14 +19,18 @@ import (
 	"github.com/creatrid/creatrid/internal/blockchain"
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/email"
+	"github.com/creatrid/creatrid/internal/events"
 	"github.com/creatrid/creatrid/internal/geoip"
 	"github.com/creatrid/creatrid/internal/handler"
 	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/migrate"
 	"github.com/creatrid/creatrid/internal/platform"
 	"github.com/creatrid/creatrid/internal/scheduler"
 	"github.com/creatrid/creatrid/internal/storage"
 	"github.com/creatrid/creatrid/internal/store"
 	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/creatrid/creatrid/pkg/proofbundle"
+	"github.com/creatrid/creatrid/pkg/safehttp"
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -49,8 +56,23 @@ func main() {
 	log.Println("Connected to database")
 
 	// Run migrations
-	if err := runMigrations(cfg.DatabaseURL); err != nil {
-		log.Printf("Migration warning: %v", err)
+	migrations, err := migrate.Load(os.DirFS("migrations"))
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	migrator := migrate.New(pool, migrations)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(migrator, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	if applied, err := migrator.Up(context.Background()); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	} else {
+		log.Printf("Migrations up to date (%d applied)", applied)
 	}
 
 	// Init services
@@ -81,16 +103,35 @@ func main() {
 	}
 
 	// Init blob storage (optional)
-	var blobStore *storage.BlobStorage
-	if cfg.AzureStorageAccount != "" && cfg.AzureStorageKey != "" {
-		blobStore, err = storage.NewBlobStorage(cfg.AzureStorageAccount, cfg.AzureStorageKey, cfg.AzureStorageContainer)
+	var blobStore storage.Backend
+	var localStore *storage.LocalStorage
+	switch cfg.StorageBackend {
+	case "azure":
+		azureStore, err := storage.NewAzureStorage(cfg.AzureStorageAccount, cfg.AzureStorageKey, cfg.AzureStorageContainer)
 		if err != nil {
 			log.Printf("Warning: Failed to init blob storage: %v", err)
 		} else {
+			blobStore = azureStore
 			log.Println("Azure Blob Storage connected")
 		}
-	} else {
-		log.Println("Warning: AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_KEY not set. Image upload will be unavailable.")
+	case "s3":
+		s3Store, err := storage.NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKeyID, cfg.S3SecretKey, cfg.S3ForcePathStyle, cfg.S3PublicURL)
+		if err != nil {
+			log.Printf("Warning: Failed to init S3 storage: %v", err)
+		} else {
+			blobStore = s3Store
+			log.Printf("S3 storage configured (bucket %s)", cfg.S3Bucket)
+		}
+	case "local":
+		localStore, err = storage.NewLocalStorage(cfg.StorageLocalDir, cfg.BackendURL+"/api/files", cfg.JWTSecret)
+		if err != nil {
+			log.Printf("Warning: Failed to init local storage: %v", err)
+		} else {
+			blobStore = localStore
+			log.Printf("Local file storage at %s", cfg.StorageLocalDir)
+		}
+	default:
+		log.Printf("Warning: unknown STORAGE_BACKEND %q. File upload will be unavailable.", cfg.StorageBackend)
 	}
 
 	// Init email service (optional)
@@ -116,6 +157,17 @@ func main() {
 	// Init SSE hub for real-time notifications
 	sseHub := handler.NewSSEHub()
 
+	// Outbound requests to user-supplied URLs, such as webhook deliveries
+	outbound := safehttp.Options{RequireHTTPS: cfg.OutboundRequireHTTPS, AllowPrivate: cfg.OutboundAllowPrivate}
+	if cfg.OutboundAllowPrivate {
+		log.Println("Warning: OUTBOUND_ALLOW_PRIVATE is set. Webhooks may reach private and local addresses.")
+	}
+
+	// Anchorers are registered below; creators' anchoring policies queue
+	// uploads on whichever is the default
+	anchorers := blockchain.NewRegistry()
+	autoAnchor := blockchain.NewAutoAnchor(st, anchorers)
+
 	// Init handlers
 	authHandler := handler.NewAuthHandler(googleSvc, jwtSvc, st, cfg)
 	userHandler := handler.NewUserHandler(st, blobStore, emailSvc, jwtSvc, cfg)
@@ -124,24 +176,25 @@ func main() {
 	analyticsHandler := handler.NewAnalyticsHandler(st, geoSvc)
 	adminHandler := handler.NewAdminHandler(st)
 	digestHandler := handler.NewDigestHandler(st, emailSvc)
-	collabHandler := handler.NewCollaborationHandler(st, sseHub, emailSvc)
+	collabHandler := handler.NewCollaborationHandler(st)
 	widgetHandler := handler.NewWidgetHandler(st)
 	apiKeyHandler := handler.NewAPIKeyHandler(st)
-	verifyHandler := handler.NewVerifyHandler(st)
-	billingHandler := handler.NewBillingHandler(st, cfg, sseHub)
-	contentHandler := handler.NewContentHandler(st, blobStore, cfg, emailSvc)
-	licenseHandler := handler.NewLicenseHandler(st, cfg)
+	verifyHandler := handler.NewVerifyHandler(st, cfg)
+	billingHandler := handler.NewBillingHandler(st, cfg)
+	contentHandler := handler.NewContentHandler(st, blobStore, cfg, emailSvc, autoAnchor)
+	licenseHandler := handler.NewLicenseHandler(st, cfg, autoAnchor)
 	marketplaceHandler := handler.NewMarketplaceHandler(st)
 	dmcaHandler := handler.NewDMCAHandler(st)
 	notificationHandler := handler.NewNotificationHandler(st, sseHub)
 	contentAnalyticsHandler := handler.NewContentAnalyticsHandler(st)
 	payoutHandler := handler.NewPayoutHandler(st, cfg)
-	collectionHandler := handler.NewCollectionHandler(st)
+	collectionHandler := handler.NewCollectionHandler(st, autoAnchor)
 	searchHandler := handler.NewSearchHandler(st)
-	webhookHandler := handler.NewWebhookHandler(st)
+	webhookHandler := handler.NewWebhookHandler(st, outbound)
 	referralHandler := handler.NewReferralHandler(st)
 	recommendHandler := handler.NewRecommendHandler(st)
-	moderationHandler := handler.NewModerationHandler(st)
+	moderationHandler := handler.NewModerationHandler(st, cfg)
+	ownershipHandler := handler.NewOwnershipHandler(st)
 	errorLogHandler := handler.NewErrorLogHandler(st)
 	totpSvc := auth.NewTOTPService()
 	twoFAHandler := handler.NewTwoFAHandler(st, totpSvc, jwtSvc, cfg)
@@ -150,27 +203,111 @@ func main() {
 	tipHandler := handler.NewTipHandler(st, cfg)
 	fanSubHandler := handler.NewFanSubscriptionHandler(st, cfg)
 
-	// Init blockchain anchor service
-	var anchorSvc *blockchain.AnchorService
+	// Init webhook dispatcher and delivery worker
+	webhookDisp := webhook.NewDispatcher(st)
+	webhookWorker := webhook.NewWorker(st, cfg.WebhookConcurrency, cfg.WebhookPerEndpoint)
+	webhookWorker.SetClient(safehttp.NewClient(outbound))
+	webhookWorker.AutoDisable(time.Duration(cfg.WebhookDisableAfterHours)*time.Hour,
+		handler.NotifyWebhookDisabled(st, sseHub, emailSvc, cfg.FrontendURL))
+	go webhookWorker.Start(context.Background())
+
+	// Start the event relay, which hands domain events recorded in the
+	// outbox to webhooks, notifications and email
+	relay := events.NewRelay(st)
+	relay.Subscribe(events.All, "webhooks", webhookDisp.HandleEvent)
+	handler.RegisterEventSubscribers(relay, st, sseHub, emailSvc)
+	go relay.Start(context.Background())
+
+	// Init anchoring: EVM chains, the simulated chain and an RFC 3161
+	// timestamp authority can each be configured, with one batcher apiece
+	var evmChains []blockchain.EVMChain
 	if cfg.BlockchainRPCURL != "" {
-		var err error
-		anchorSvc, err = blockchain.New(cfg.BlockchainRPCURL, cfg.BlockchainPrivateKey, cfg.BlockchainChainID)
+		evmChains = append(evmChains, blockchain.EVMChain{
+			Name:          cfg.BlockchainChainName,
+			RPCURL:        cfg.BlockchainRPCURL,
+			PrivateKey:    cfg.BlockchainPrivateKey,
+			ChainID:       cfg.BlockchainChainID,
+			Confirmations: cfg.BlockchainConfirmations,
+		})
+	}
+	if cfg.AnchorEVMChains != "" {
+		more, err := blockchain.ParseEVMChains(cfg.AnchorEVMChains)
+		if err != nil {
+			log.Printf("WARNING: ANCHOR_EVM_CHAINS ignored: %v", err)
+		}
+		evmChains = append(evmChains, more...)
+	}
+	for _, c := range evmChains {
+		if c.PrivateKey == "" {
+			c.PrivateKey = cfg.BlockchainPrivateKey // one wallet across chains
+		}
+		anchorSvc, err := blockchain.New(c)
 		if err != nil {
 			log.Printf("WARNING: Blockchain anchor service failed to initialize: %v", err)
+			continue
+		}
+		anchorSvc.ShareNonces(st)
+		anchorers.Add(anchorSvc)
+	}
+	if cfg.AnchorTSAURL != "" {
+		anchorers.Add(blockchain.NewTimestampAuthority(cfg.AnchorTSAURL, nil))
+	}
+	if cfg.AnchorSimulated {
+		sim, err := blockchain.NewSimulated()
+		if err != nil {
+			log.Printf("WARNING: Simulated anchor chain failed to start: %v", err)
 		} else {
-			confirmWorker := blockchain.NewConfirmationWorker(st, anchorSvc)
-			go confirmWorker.Start(context.Background())
+			log.Println("WARNING: Anchoring to an in-memory simulated chain; do not use in production")
+			anchorers.Add(sim)
 		}
-	} else {
-		log.Println("Blockchain anchoring disabled (BLOCKCHAIN_RPC_URL not set)")
 	}
-	blockchainHandler := handler.NewBlockchainHandler(st, anchorSvc)
+	if cfg.AnchorDefaultChain != "" && !anchorers.SetDefault(cfg.AnchorDefaultChain) {
+		log.Printf("WARNING: ANCHOR_DEFAULT_CHAIN %q is not configured", cfg.AnchorDefaultChain)
+	}
+	if all := anchorers.All(); len(all) > 0 {
+		confirmWorker := blockchain.NewConfirmationWorker(st, anchorers)
+		if replaceAfter, err := time.ParseDuration(cfg.AnchorReplaceAfter); err == nil {
+			confirmWorker.ReplaceStuckAfter(replaceAfter)
+		}
+		go confirmWorker.Start(context.Background())
 
-	// Init webhook dispatcher and delivery worker
-	webhookDisp := webhook.NewDispatcher(st)
-	handler.SetWebhookDispatcher(webhookDisp)
-	webhookWorker := webhook.NewWorker(st)
-	go webhookWorker.Start(context.Background())
+		batchWindow, _ := time.ParseDuration(cfg.AnchorBatchWindow)
+		for _, a := range all {
+			batcher := blockchain.NewBatcher(st, a, batchWindow, cfg.AnchorBatchSize)
+			go batcher.Start(context.Background())
+		}
+		log.Printf("Anchoring enabled (default chain %s)", anchorers.Default().Chain())
+	} else {
+		log.Println("Anchoring disabled (set BLOCKCHAIN_RPC_URL, ANCHOR_EVM_CHAINS, ANCHOR_TSA_URL or ANCHOR_SIMULATED)")
+	}
+	blockchainHandler := handler.NewBlockchainHandler(st, anchorers)
+
+	// Proof bundles are signed with PROOF_SIGNING_KEY. Outside production a
+	// missing key is replaced by a generated one, whose bundles stop
+	// verifying once the server restarts; config requires it in production
+	var proofKey ed25519.PrivateKey
+	if cfg.ProofSigningKey != "" {
+		proofKey, err = proofbundle.ParsePrivateKey(cfg.ProofSigningKey)
+		if err != nil {
+			log.Fatalf("Invalid PROOF_SIGNING_KEY: %v", err)
+		}
+	} else {
+		log.Println("WARNING: PROOF_SIGNING_KEY not set; signing proof bundles with a temporary key")
+		_, proofKey, _ = ed25519.GenerateKey(rand.Reader)
+	}
+	var previousProofKeys []ed25519.PublicKey
+	for _, k := range strings.Split(cfg.ProofPreviousKeys, ",") {
+		if k = strings.TrimSpace(k); k == "" {
+			continue
+		}
+		pub, err := base64.StdEncoding.DecodeString(k)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			log.Printf("WARNING: ignoring invalid key in PROOF_PREVIOUS_KEYS: %s", k)
+			continue
+		}
+		previousProofKeys = append(previousProofKeys, pub)
+	}
+	proofHandler := handler.NewProofHandler(st, cfg, proofKey, previousProofKeys...)
 
 	// Start connection refresh scheduler
 	providerMap := make(map[string]platform.Provider)
@@ -181,7 +318,7 @@ func main() {
 	if refreshInterval == 0 {
 		refreshInterval = 6 * time.Hour
 	}
-	sched := scheduler.New(st, providerMap, refreshInterval)
+	sched := scheduler.New(st, providerMap, refreshInterval, cfg.AnomalyScoreDamping)
 	go sched.Start(context.Background())
 
 	// Start weekly digest cron
@@ -216,6 +353,48 @@ func main() {
 		}
 	}()
 
+	// Start webhook attempt cleanup (health stats only look back a week)
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := st.DeleteOldWebhookAttempts(context.Background(), time.Now().Add(-30*24*time.Hour))
+			if err != nil {
+				log.Printf("Webhook attempt cleanup failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Webhook attempt cleanup: deleted %d old attempts", deleted)
+			}
+		}
+	}()
+
+	// Start outbox cleanup (handled events are only kept for debugging)
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := st.DeleteProcessedOutboxEvents(context.Background(), time.Now().Add(-7*24*time.Hour))
+			if err != nil {
+				log.Printf("Outbox cleanup failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Outbox cleanup: deleted %d processed events", deleted)
+			}
+		}
+	}()
+
+	// Start expired upload session cleanup
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			cleaned, err := contentHandler.CleanupExpiredUploads(context.Background())
+			if err != nil {
+				log.Printf("Upload session cleanup failed: %v", err)
+			} else if cleaned > 0 {
+				log.Printf("Upload session cleanup: aborted %d expired sessions", cleaned)
+			}
+		}
+	}()
+
 	// Setup router
 	r := chi.NewRouter()
 	r.Use(chimw.Logger)
@@ -229,6 +408,12 @@ func main() {
 		r.Post("/api/errors", errorLogHandler.Report)
 	})
 
+	// Image verification decodes the upload (stricter rate limit)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimit(2, 5))
+		r.Post("/api/verify", blockchainHandler.VerifyImage)
+	})
+
 	// Auth routes (stricter rate limit)
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RateLimit(5, 10)) // 5 req/s per IP, burst 10
@@ -243,6 +428,7 @@ func main() {
 		r.Get("/api/auth/verify-email/{token}", userHandler.VerifyEmail)
 		r.Get("/api/users/{username}", userHandler.PublicProfile)
 		r.Get("/api/users/{username}/connections", connHandler.PublicList)
+		r.Get("/api/users/{username}/growth", connHandler.PublicGrowth)
 		r.Post("/api/users/{username}/view", analyticsHandler.TrackView)
 		r.Post("/api/users/{username}/click", analyticsHandler.TrackClick)
 		r.Get("/p/{username}", ogHandler.ProfilePage)
@@ -253,6 +439,8 @@ func main() {
 		r.Post("/api/billing/webhook", billingHandler.HandleWebhook)
 		r.Get("/api/users/{username}/content", contentHandler.PublicList)
 		r.Get("/api/content/{id}/proof", contentHandler.Proof)
+		r.Get("/api/content/{id}/proof/bundle", proofHandler.Bundle)
+		r.Get(proofbundle.WellKnownPath, proofHandler.Keys)
 		r.Get("/api/content/{id}/licenses", licenseHandler.ListOfferings)
 		r.Get("/api/marketplace", marketplaceHandler.Browse)
 		r.Get("/api/marketplace/{id}", marketplaceHandler.Detail)
@@ -264,6 +452,12 @@ func main() {
 		r.Get("/api/content/{id}/anchor", blockchainHandler.GetAnchor)
 		r.Get("/api/verify/{hash}", blockchainHandler.VerifyByHash)
 		r.Get("/api/users/{username}/token", tokenHandler.PublicToken)
+		r.Get("/api/webhooks/events", webhookHandler.Events)
+
+		// Locally stored uploads (STORAGE_BACKEND=local)
+		if localStore != nil {
+			r.Handle("/api/files/*", http.StripPrefix("/api/files/", localStore))
+		}
 
 		// Health check
 		r.Get("/api/health", func(w http.ResponseWriter, r *http.Request) {
@@ -327,6 +521,8 @@ func main() {
 		r.Get("/api/connections", connHandler.List)
 		r.Delete("/api/connections/{platform}", connHandler.Disconnect)
 		r.Post("/api/connections/{platform}/refresh", connHandler.Refresh)
+		r.Get("/api/connections/{platform}/history", connHandler.History)
+		r.Get("/api/connections/growth", connHandler.Growth)
 		r.Get("/api/analytics", analyticsHandler.Summary)
 		r.Post("/api/collaborations", collabHandler.SendRequest)
 		r.Get("/api/collaborations/inbox", collabHandler.Inbox)
@@ -350,6 +546,11 @@ func main() {
 		r.Patch("/api/content/{id}", contentHandler.Update)
 		r.Delete("/api/content/{id}", contentHandler.Delete)
 		r.Get("/api/content/{id}/download", contentHandler.Download)
+		r.Post("/api/content/uploads", contentHandler.CreateUpload)
+		r.Get("/api/content/uploads/{id}", contentHandler.GetUpload)
+		r.Put("/api/content/uploads/{id}/chunks/{n}", contentHandler.PutChunk)
+		r.Post("/api/content/uploads/{id}/complete", contentHandler.CompleteUpload)
+		r.Delete("/api/content/uploads/{id}", contentHandler.AbortUpload)
 
 		// Licensing
 		r.Post("/api/content/{id}/licenses", licenseHandler.CreateOffering)
@@ -391,6 +592,11 @@ func main() {
 		r.Get("/api/webhooks", webhookHandler.List)
 		r.Patch("/api/webhooks/{id}", webhookHandler.Update)
 		r.Delete("/api/webhooks/{id}", webhookHandler.Delete)
+		r.Post("/api/webhooks/{id}/rotate-secret", webhookHandler.RotateSecret)
+		r.Get("/api/webhooks/{id}/health", webhookHandler.Health)
+		r.Post("/api/webhooks/{id}/enable", webhookHandler.Enable)
+		r.Post("/api/webhooks/{id}/redeliver", webhookHandler.Redeliver)
+		r.Post("/api/webhooks/{id}/test", webhookHandler.Test)
 		r.Get("/api/webhooks/{id}/deliveries", webhookHandler.Deliveries)
 		r.Post("/api/webhooks/{id}/deliveries/{deliveryId}/retry", webhookHandler.RetryDelivery)
 
@@ -411,7 +617,15 @@ func main() {
 		r.Patch("/api/tokens", tokenHandler.Update)
 		r.Get("/api/tokens/{id}/holders", tokenHandler.Holders)
 		r.Get("/api/tokens/{id}/transactions", tokenHandler.Transactions)
+		r.Get("/api/tokens/{id}/quote", tokenHandler.Quote)
 		r.Post("/api/tokens/{id}/purchase", tokenHandler.Purchase)
+		r.Post("/api/tokens/{id}/transfer", tokenHandler.Transfer)
+		r.Get("/api/tokens/{id}/rewards", tokenHandler.ListRewards)
+		r.Post("/api/tokens/rewards", tokenHandler.CreateReward)
+		r.Patch("/api/tokens/rewards/{rewardId}", tokenHandler.UpdateReward)
+		r.Post("/api/tokens/rewards/{rewardId}/redeem", tokenHandler.Redeem)
+		r.Get("/api/tokens/redemptions", tokenHandler.Redemptions)
+		r.Post("/api/tokens/redemptions/{redemptionId}/fulfill", tokenHandler.FulfillRedemption)
 
 		// Tips
 		r.Post("/api/tips", tipHandler.Send)
@@ -456,6 +670,9 @@ func main() {
 		r.Get("/api/admin/errors", errorLogHandler.List)
 		r.Get("/api/admin/moderation", moderationHandler.List)
 		r.Post("/api/admin/moderation/{id}/resolve", moderationHandler.Resolve)
+		r.Post("/api/admin/moderation/anomalies/{id}/resolve", moderationHandler.ResolveAnomaly)
+		r.Get("/api/admin/ownership-conflicts", ownershipHandler.List)
+		r.Post("/api/admin/ownership-conflicts/{id}/resolve", ownershipHandler.Resolve)
 		r.Post("/api/agency/bulk-verify", agencyHandler.BulkVerify)
 	})
 
@@ -497,30 +714,51 @@ func main() {
 	log.Println("Server stopped")
 }
 
-func runMigrations(databaseURL string) error {
-	pool, err := pgxpool.New(context.Background(), databaseURL)
-	if err != nil {
-		return err
-	}
-	defer pool.Close()
-
-	files, err := filepath.Glob("migrations/*.up.sql")
-	if err != nil {
-		return err
+// runMigrateCommand implements "server migrate up|down [n]|status".
+func runMigrateCommand(migrator *migrate.Migrator, args []string) error {
+	ctx := context.Background()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: server migrate up|down [n]|status")
 	}
-	sort.Strings(files)
 
-	for _, f := range files {
-		data, err := os.ReadFile(f)
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
 		if err != nil {
 			return err
 		}
-		_, err = pool.Exec(context.Background(), string(data))
+		fmt.Printf("Rolled back %d migrations\n", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
 		if err != nil {
-			log.Printf("Migration note (%s): %v (may already be applied)", f, err)
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Modified {
+				state += " (modified since applied)"
+			}
+			fmt.Printf("%03d  %-32s %s\n", s.Version, s.Name, state)
 		}
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
 	}
-
-	log.Println("Migrations applied successfully")
 	return nil
 }
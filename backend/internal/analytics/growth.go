@@ -0,0 +1,130 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+)
+
+// Bucket sizes for follower growth series.
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// ValidBucket reports whether b is a supported bucket size.
+func ValidBucket(b string) bool {
+	return b == BucketDay || b == BucketWeek || b == BucketMonth
+}
+
+// TruncateBucket returns the start of the UTC bucket containing t. Weeks
+// start on Monday, matching Postgres date_trunc.
+func TruncateBucket(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case BucketWeek:
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -offset)
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// FollowerSample is the latest follower count seen for a platform in a bucket.
+type FollowerSample struct {
+	Bucket    time.Time
+	Platform  string
+	Followers int
+}
+
+// GrowthPoint is a user's total followers across platforms at the end of a
+// bucket. GrowthRate is Change relative to the previous bucket's total, and
+// is nil for the first point or when the previous total was zero.
+type GrowthPoint struct {
+	Bucket     time.Time `json:"bucket"`
+	Followers  int       `json:"followers"`
+	Change     int       `json:"change"`
+	GrowthRate *float64  `json:"growthRate"`
+}
+
+// GrowthSeries sums samples across platforms into one point per bucket from
+// the first bucket with data up to the bucket containing to. A platform with
+// no sample in a bucket keeps its last known count, so gaps between
+// refreshes do not show up as drops.
+func GrowthSeries(samples []FollowerSample, bucket string, to time.Time) []GrowthPoint {
+	if len(samples) == 0 {
+		return []GrowthPoint{}
+	}
+
+	sorted := make([]FollowerSample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bucket.Before(sorted[j].Bucket) })
+
+	end := TruncateBucket(to, bucket)
+	latest := map[string]int{}
+	points := []GrowthPoint{}
+	next := 0
+
+	for b := TruncateBucket(sorted[0].Bucket, bucket); !b.After(end); b = nextBucket(b, bucket) {
+		for next < len(sorted) && !TruncateBucket(sorted[next].Bucket, bucket).After(b) {
+			latest[sorted[next].Platform] = sorted[next].Followers
+			next++
+		}
+
+		total := 0
+		for _, n := range latest {
+			total += n
+		}
+
+		p := GrowthPoint{Bucket: b, Followers: total}
+		if len(points) > 0 {
+			prev := points[len(points)-1].Followers
+			p.Change = total - prev
+			if prev > 0 {
+				rate := float64(p.Change) / float64(prev)
+				p.GrowthRate = &rate
+			}
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// GrowthSummary is the overall change across a growth series.
+type GrowthSummary struct {
+	StartFollowers int      `json:"startFollowers"`
+	EndFollowers   int      `json:"endFollowers"`
+	Change         int      `json:"change"`
+	GrowthRate     *float64 `json:"growthRate"`
+}
+
+// Summarize compares the first and last points of a series.
+func Summarize(points []GrowthPoint) GrowthSummary {
+	if len(points) == 0 {
+		return GrowthSummary{}
+	}
+	s := GrowthSummary{
+		StartFollowers: points[0].Followers,
+		EndFollowers:   points[len(points)-1].Followers,
+	}
+	s.Change = s.EndFollowers - s.StartFollowers
+	if s.StartFollowers > 0 && len(points) > 1 {
+		rate := float64(s.Change) / float64(s.StartFollowers)
+		s.GrowthRate = &rate
+	}
+	return s
+}
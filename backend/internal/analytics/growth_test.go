@@ -0,0 +1,143 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTruncateBucket(t *testing.T) {
+	// Thursday afternoon
+	ts := time.Date(2024, time.March, 14, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		bucket string
+		in     time.Time
+		want   time.Time
+	}{
+		{BucketDay, ts, date(2024, time.March, 14)},
+		{BucketWeek, ts, date(2024, time.March, 11)},
+		{BucketWeek, date(2024, time.March, 11), date(2024, time.March, 11)},
+		{BucketWeek, date(2024, time.March, 17), date(2024, time.March, 11)},
+		{BucketWeek, date(2024, time.January, 3), date(2024, time.January, 1)},
+		{BucketMonth, ts, date(2024, time.March, 1)},
+		{BucketDay, time.Date(2024, time.March, 14, 23, 0, 0, 0, time.FixedZone("EST", -5*3600)), date(2024, time.March, 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bucket+" "+tt.in.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, TruncateBucket(tt.in, tt.bucket))
+		})
+	}
+}
+
+func TestValidBucket(t *testing.T) {
+	assert.True(t, ValidBucket("day"))
+	assert.True(t, ValidBucket("week"))
+	assert.True(t, ValidBucket("month"))
+	assert.False(t, ValidBucket("year"))
+	assert.False(t, ValidBucket(""))
+}
+
+func TestGrowthSeries_Empty(t *testing.T) {
+	points := GrowthSeries(nil, BucketDay, date(2024, time.March, 1))
+	assert.NotNil(t, points)
+	assert.Empty(t, points)
+}
+
+func TestGrowthSeries_SumsPlatformsAndCarriesForward(t *testing.T) {
+	samples := []FollowerSample{
+		{Bucket: date(2024, time.March, 1), Platform: "youtube", Followers: 1000},
+		{Bucket: date(2024, time.March, 1), Platform: "github", Followers: 100},
+		// No github refresh on the 2nd; its count carries forward.
+		{Bucket: date(2024, time.March, 2), Platform: "youtube", Followers: 1100},
+		// Nothing at all on the 3rd.
+		{Bucket: date(2024, time.March, 4), Platform: "github", Followers: 50},
+	}
+
+	points := GrowthSeries(samples, BucketDay, date(2024, time.March, 5))
+	require.Len(t, points, 5)
+
+	want := []struct {
+		followers int
+		change    int
+	}{
+		{1100, 0},
+		{1200, 100},
+		{1200, 0},
+		{1150, -50},
+		{1150, 0},
+	}
+	for i, w := range want {
+		assert.Equal(t, date(2024, time.March, 1+i), points[i].Bucket)
+		assert.Equal(t, w.followers, points[i].Followers, "day %d", i+1)
+		assert.Equal(t, w.change, points[i].Change, "day %d", i+1)
+	}
+
+	assert.Nil(t, points[0].GrowthRate)
+	require.NotNil(t, points[1].GrowthRate)
+	assert.InDelta(t, 100.0/1100.0, *points[1].GrowthRate, 1e-9)
+	assert.InDelta(t, -50.0/1200.0, *points[3].GrowthRate, 1e-9)
+}
+
+func TestGrowthSeries_UnsortedInput(t *testing.T) {
+	samples := []FollowerSample{
+		{Bucket: date(2024, time.March, 3), Platform: "x", Followers: 30},
+		{Bucket: date(2024, time.March, 1), Platform: "x", Followers: 10},
+		{Bucket: date(2024, time.March, 2), Platform: "x", Followers: 20},
+	}
+	points := GrowthSeries(samples, BucketDay, date(2024, time.March, 3))
+	require.Len(t, points, 3)
+	assert.Equal(t, []int{10, 20, 30}, []int{points[0].Followers, points[1].Followers, points[2].Followers})
+}
+
+func TestGrowthSeries_WeeksAndMonths(t *testing.T) {
+	samples := []FollowerSample{
+		{Bucket: date(2024, time.January, 1), Platform: "x", Followers: 100},
+		{Bucket: date(2024, time.January, 15), Platform: "x", Followers: 150},
+		{Bucket: date(2024, time.March, 4), Platform: "x", Followers: 300},
+	}
+
+	weeks := GrowthSeries(samples, BucketWeek, date(2024, time.March, 6))
+	require.Len(t, weeks, 10)
+	assert.Equal(t, date(2024, time.January, 1), weeks[0].Bucket)
+	assert.Equal(t, 100, weeks[1].Followers)
+	assert.Equal(t, 150, weeks[2].Followers)
+	assert.Equal(t, 300, weeks[9].Followers)
+
+	months := GrowthSeries(samples, BucketMonth, date(2024, time.March, 6))
+	require.Len(t, months, 3)
+	assert.Equal(t, []int{150, 150, 300}, []int{months[0].Followers, months[1].Followers, months[2].Followers})
+	assert.Equal(t, date(2024, time.February, 1), months[1].Bucket)
+}
+
+func TestGrowthSeries_ZeroBaseHasNoRate(t *testing.T) {
+	samples := []FollowerSample{
+		{Bucket: date(2024, time.March, 1), Platform: "x", Followers: 0},
+		{Bucket: date(2024, time.March, 2), Platform: "x", Followers: 10},
+	}
+	points := GrowthSeries(samples, BucketDay, date(2024, time.March, 2))
+	require.Len(t, points, 2)
+	assert.Equal(t, 10, points[1].Change)
+	assert.Nil(t, points[1].GrowthRate)
+}
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, GrowthSummary{}, Summarize(nil))
+
+	one := Summarize([]GrowthPoint{{Followers: 500}})
+	assert.Equal(t, 500, one.EndFollowers)
+	assert.Nil(t, one.GrowthRate)
+
+	s := Summarize([]GrowthPoint{{Followers: 200}, {Followers: 180}, {Followers: 250}})
+	assert.Equal(t, 200, s.StartFollowers)
+	assert.Equal(t, 250, s.EndFollowers)
+	assert.Equal(t, 50, s.Change)
+	require.NotNil(t, s.GrowthRate)
+	assert.InDelta(t, 0.25, *s.GrowthRate, 1e-9)
+}
@@ -4,60 +4,165 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// AnchorService handles blockchain anchoring of content hashes on Base L2.
+// ethClient is the part of an Ethereum RPC client the anchor service uses.
+// Both *ethclient.Client and the simulated backend's client satisfy it.
+type ethClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// AnchorService anchors content hashes on an EVM chain, as the data of a
+// transaction from its wallet to itself. It is an Anchorer.
 type AnchorService struct {
-	client     *ethclient.Client
+	client     ethClient
+	chain      string
 	privateKey *ecdsa.PrivateKey
 	fromAddr   common.Address
 	chainID    *big.Int
+	// confirmations is how many blocks, counting its own, a transaction
+	// needs before it is final.
+	confirmations uint64
+	// afterSend runs once a transaction is sent. The simulated chain uses
+	// it to mine the transaction straight away.
+	afterSend func()
+
+	// mu serializes sends, so each takes the next nonce. nextNonce is only
+	// valid while nonceSynced; a failed send clears it and the next send
+	// reads the nonce from the node again.
+	mu          sync.Mutex
+	nextNonce   uint64
+	nonceSynced bool
+	// nonces, when set, records the next nonce in place of nextNonce and
+	// serializes sends across every process sharing the wallet.
+	nonces NonceStore
+}
+
+// NonceStore hands out a wallet's nonces to every process sending from it.
+// *store.Store satisfies it.
+type NonceStore interface {
+	// WithWalletNonce calls send with the recorded next nonce, holding a
+	// lock on the chain and wallet, and records the one send returns.
+	WithWalletNonce(ctx context.Context, chain, wallet string, send func(next int64) (int64, error)) error
+}
+
+// TxParams are the nonce and fees an EVM anchor transaction was sent with,
+// kept so a stuck transaction can be replaced.
+type TxParams struct {
+	Nonce     uint64
+	GasLimit  uint64
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// EVMChain configures an AnchorService. Several can run side by side, one
+// per chain; ParseEVMChains reads a list of them from JSON.
+type EVMChain struct {
+	// Name is stored on the chain's anchors, such as "base".
+	Name       string `json:"name"`
+	RPCURL     string `json:"rpcUrl"`
+	PrivateKey string `json:"privateKey"`
+	// ChainID defaults to Base mainnet.
+	ChainID string `json:"chainId"`
+	// Confirmations defaults to DefaultConfirmations.
+	Confirmations int `json:"confirmations"`
+}
+
+// ParseEVMChains decodes a JSON array of chains, as in ANCHOR_EVM_CHAINS.
+func ParseEVMChains(s string) ([]EVMChain, error) {
+	var chains []EVMChain
+	if err := json.Unmarshal([]byte(s), &chains); err != nil {
+		return nil, fmt.Errorf("invalid chain list: %w", err)
+	}
+	for i, c := range chains {
+		if c.Name == "" {
+			return nil, fmt.Errorf("chain %d has no name", i)
+		}
+	}
+	return chains, nil
 }
 
-// New creates a new AnchorService connected to the given RPC endpoint.
+const (
+	// DefaultConfirmations is how many blocks a transaction needs before it
+	// is final, when its chain does not say. Reorgs deeper than this are
+	// not expected on the chains we anchor to.
+	DefaultConfirmations = 12
+
+	// gasLimitMargin is added to the estimated gas, in percent.
+	gasLimitMargin = 20
+	// feeBump raises both fees of a replacement, in percent. Nodes only
+	// accept a replacement that raises them by at least 10%.
+	feeBump = 25
+)
+
+// New creates a new AnchorService connected to the chain's RPC endpoint.
 // Returns an error if the RPC URL or private key is missing/invalid.
-func New(rpcURL, privateKeyHex, chainIDStr string) (*AnchorService, error) {
-	if rpcURL == "" {
-		return nil, fmt.Errorf("BLOCKCHAIN_RPC_URL is required")
+func New(c EVMChain) (*AnchorService, error) {
+	if c.RPCURL == "" {
+		return nil, fmt.Errorf("chain %s: RPC URL is required", c.Name)
+	}
+	if c.PrivateKey == "" {
+		return nil, fmt.Errorf("chain %s: private key is required", c.Name)
 	}
-	if privateKeyHex == "" {
-		return nil, fmt.Errorf("BLOCKCHAIN_PRIVATE_KEY is required")
+	if c.Confirmations < 0 {
+		return nil, fmt.Errorf("chain %s: invalid confirmations: %d", c.Name, c.Confirmations)
 	}
 
-	client, err := ethclient.Dial(rpcURL)
+	client, err := ethclient.Dial(c.RPCURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RPC: %w", err)
 	}
 
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	privateKey, err := crypto.HexToECDSA(c.PrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key: %w", err)
 	}
 
 	chainID := big.NewInt(8453) // Base mainnet default
-	if chainIDStr != "" {
-		if _, ok := chainID.SetString(chainIDStr, 10); !ok {
-			return nil, fmt.Errorf("invalid chain ID: %s", chainIDStr)
+	if c.ChainID != "" {
+		if _, ok := chainID.SetString(c.ChainID, 10); !ok {
+			return nil, fmt.Errorf("invalid chain ID: %s", c.ChainID)
 		}
 	}
 
+	confirmations := uint64(DefaultConfirmations)
+	if c.Confirmations > 0 {
+		confirmations = uint64(c.Confirmations)
+	}
+
 	fromAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
-	log.Printf("Blockchain anchor service connected to chain %s, wallet %s", chainID.String(), fromAddr.Hex())
+	log.Printf("Blockchain anchor service %s connected to chain %s, wallet %s, %d confirmations",
+		c.Name, chainID.String(), fromAddr.Hex(), confirmations)
 
 	return &AnchorService{
-		client:     client,
-		privateKey: privateKey,
-		fromAddr:   fromAddr,
-		chainID:    chainID,
+		client:        client,
+		chain:         c.Name,
+		privateKey:    privateKey,
+		fromAddr:      fromAddr,
+		chainID:       chainID,
+		confirmations: confirmations,
 	}, nil
 }
 
@@ -66,82 +171,317 @@ func (s *AnchorService) WalletAddress() string {
 	return s.fromAddr.Hex()
 }
 
-// AnchorHash submits a content hash to the blockchain as transaction data.
-// Returns the tx hash immediately (transaction may still be pending).
-func (s *AnchorService) AnchorHash(ctx context.Context, contentHash string) (txHash string, err error) {
+// ShareNonces allocates nonces through st, so that replicas sending from the
+// same wallet do not reuse one. Without it sends are only serialized within
+// this process.
+func (s *AnchorService) ShareNonces(st NonceStore) {
+	s.nonces = st
+}
+
+// Chain returns the name stored on the service's anchors.
+func (s *AnchorService) Chain() string {
+	return s.chain
+}
+
+// AnchorHash submits a content hash to the blockchain as transaction data,
+// in an EIP-1559 transaction with estimated gas. The receipt's Ref is the tx
+// hash, returned immediately (the transaction may still be pending).
+func (s *AnchorService) AnchorHash(ctx context.Context, contentHash string) (*Receipt, error) {
+	return s.AnchorHashRecorded(ctx, contentHash, nil)
+}
+
+// AnchorHashRecorded is AnchorHash, passing the receipt of the signed
+// transaction to record before sending it. The transaction is sent only if
+// record succeeds, and its nonce is used up from then on even if sending
+// fails, as the node may have taken it: one that never arrives holds up the
+// wallet's later transactions until ReplaceTransaction fills its nonce.
+func (s *AnchorService) AnchorHashRecorded(ctx context.Context, contentHash string, record func(*Receipt) error) (*Receipt, error) {
+	if s == nil {
+		return nil, fmt.Errorf("blockchain anchor service is not configured")
+	}
+	data, err := hex.DecodeString(contentHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content hash: %w", err)
+	}
+
+	var receipt *Receipt
+	var sendErr error
+	err = s.withNonce(ctx, func(nonce uint64) error {
+		gasLimit, err := s.estimateGas(ctx, data)
+		if err != nil {
+			return err
+		}
+		tip, feeCap, err := s.suggestFees(ctx)
+		if err != nil {
+			return err
+		}
+
+		params := &TxParams{Nonce: nonce, GasLimit: gasLimit, GasTipCap: tip, GasFeeCap: feeCap}
+		tx, err := s.sign(params, data)
+		if err != nil {
+			return err
+		}
+		signed := &Receipt{Ref: tx.Hash().Hex(), Tx: params}
+		if record == nil {
+			if err := s.broadcast(ctx, tx); err != nil {
+				return err
+			}
+			receipt = signed
+			return nil
+		}
+		if err := record(signed); err != nil {
+			return fmt.Errorf("failed to record transaction: %w", err)
+		}
+		receipt = signed
+		sendErr = s.broadcast(ctx, tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sendErr != nil {
+		return nil, sendErr
+	}
+
+	log.Printf("Blockchain anchor tx sent: %s (content hash: %s, nonce %d)", receipt.Ref, contentHash, receipt.Tx.Nonce)
+	return receipt, nil
+}
+
+// withNonce calls send with the wallet's next nonce, holding s.mu and, with
+// a NonceStore, its lock on the wallet. The nonce is used up only if send
+// succeeds. After a failure the node may or may not have taken it, so the
+// next nonce is read from the node again; with a NonceStore it is the
+// larger of the node's and the recorded one, since another replica may have
+// sent transactions the node does not show yet.
+func (s *AnchorService) withNonce(ctx context.Context, send func(nonce uint64) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nonces != nil {
+		return s.nonces.WithWalletNonce(ctx, s.chain, s.fromAddr.Hex(), func(next int64) (int64, error) {
+			nonce, err := s.client.PendingNonceAt(ctx, s.fromAddr)
+			if err != nil {
+				return 0, fmt.Errorf("failed to get nonce: %w", err)
+			}
+			if uint64(next) > nonce {
+				nonce = uint64(next)
+			}
+			if err := send(nonce); err != nil {
+				return 0, err
+			}
+			return int64(nonce + 1), nil
+		})
+	}
+
+	if !s.nonceSynced {
+		nonce, err := s.client.PendingNonceAt(ctx, s.fromAddr)
+		if err != nil {
+			return fmt.Errorf("failed to get nonce: %w", err)
+		}
+		s.nextNonce = nonce
+		s.nonceSynced = true
+	}
+	if err := send(s.nextNonce); err != nil {
+		// The node may or may not have taken the nonce; ask it next time
+		s.nonceSynced = false
+		return err
+	}
+	s.nextNonce++
+	return nil
+}
+
+// ReplaceTransaction re-sends contentHash with the nonce of the stuck
+// transaction prev, with both fees raised by at least feeBump percent, or
+// to the current suggestion if that is higher. Whichever of the two is
+// mined, the other is dropped.
+func (s *AnchorService) ReplaceTransaction(ctx context.Context, contentHash string, prev *TxParams) (*Receipt, error) {
 	if s == nil {
-		return "", fmt.Errorf("blockchain anchor service is not configured")
+		return nil, fmt.Errorf("blockchain anchor service is not configured")
+	}
+	data, err := hex.DecodeString(contentHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content hash: %w", err)
 	}
 
-	// Decode content hash to bytes for tx data
-	hashBytes, err := hex.DecodeString(contentHash)
+	tip, feeCap, err := s.suggestFees(ctx)
 	if err != nil {
-		return "", fmt.Errorf("invalid content hash: %w", err)
+		return nil, err
 	}
+	params := &TxParams{
+		Nonce:     prev.Nonce,
+		GasLimit:  prev.GasLimit,
+		GasTipCap: maxBig(tip, bump(prev.GasTipCap)),
+		GasFeeCap: maxBig(feeCap, bump(prev.GasFeeCap)),
+	}
+	if params.GasFeeCap.Cmp(params.GasTipCap) < 0 {
+		params.GasFeeCap = params.GasTipCap
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txHash, err := s.send(ctx, params, data)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Blockchain anchor tx replaced: %s (nonce %d, fee cap %s wei)", txHash, params.Nonce, params.GasFeeCap)
+	return &Receipt{Ref: txHash, Tx: params}, nil
+}
 
-	// Get nonce
-	nonce, err := s.client.PendingNonceAt(ctx, s.fromAddr)
+// TransactionParams returns the nonce and fees of a transaction the node
+// knows, mined or pending.
+func (s *AnchorService) TransactionParams(ctx context.Context, txHashHex string) (*TxParams, error) {
+	if s == nil {
+		return nil, fmt.Errorf("blockchain anchor service is not configured")
+	}
+	tx, _, err := s.client.TransactionByHash(ctx, common.HexToHash(txHashHex))
 	if err != nil {
-		return "", fmt.Errorf("failed to get nonce: %w", err)
+		return nil, err
 	}
+	return &TxParams{Nonce: tx.Nonce(), GasLimit: tx.Gas(), GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap()}, nil
+}
 
-	// Get gas price
-	gasPrice, err := s.client.SuggestGasPrice(ctx)
+// send signs and sends a transaction of data to the service's own address,
+// returning its hash. The caller holds s.mu.
+func (s *AnchorService) send(ctx context.Context, params *TxParams, data []byte) (string, error) {
+	tx, err := s.sign(params, data)
 	if err != nil {
-		return "", fmt.Errorf("failed to get gas price: %w", err)
+		return "", err
+	}
+	if err := s.broadcast(ctx, tx); err != nil {
+		return "", err
 	}
+	return tx.Hash().Hex(), nil
+}
 
-	// Create transaction: send 0 ETH to self with content hash as data
-	tx := types.NewTransaction(
-		nonce,
-		s.fromAddr,    // send to self
-		big.NewInt(0), // no value
-		50000,         // gas limit (21000 base + data cost + margin)
-		gasPrice,
-		hashBytes,
-	)
+// sign signs a transaction of data to the service's own address.
+func (s *AnchorService) sign(params *TxParams, data []byte) (*types.Transaction, error) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   s.chainID,
+		Nonce:     params.Nonce,
+		GasTipCap: params.GasTipCap,
+		GasFeeCap: params.GasFeeCap,
+		Gas:       params.GasLimit,
+		To:        &s.fromAddr, // send to self
+		Value:     big.NewInt(0),
+		Data:      data,
+	})
 
-	// Sign transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(s.chainID), s.privateKey)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(s.chainID), s.privateKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign transaction: %w", err)
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
 	}
+	return signedTx, nil
+}
 
-	// Send transaction
-	if err := s.client.SendTransaction(ctx, signedTx); err != nil {
-		return "", fmt.Errorf("failed to send transaction: %w", err)
+// broadcast sends a signed transaction to the node.
+func (s *AnchorService) broadcast(ctx context.Context, tx *types.Transaction) error {
+	if err := s.client.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("failed to send transaction: %w", err)
+	}
+	if s.afterSend != nil {
+		s.afterSend()
 	}
+	return nil
+}
 
-	txHashHex := signedTx.Hash().Hex()
-	log.Printf("Blockchain anchor tx sent: %s (content hash: %s)", txHashHex, contentHash)
+// estimateGas estimates the gas to send data to self, plus gasLimitMargin.
+func (s *AnchorService) estimateGas(ctx context.Context, data []byte) (uint64, error) {
+	gas, err := s.client.EstimateGas(ctx, ethereum.CallMsg{From: s.fromAddr, To: &s.fromAddr, Data: data})
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	return gas + gas*gasLimitMargin/100, nil
+}
 
-	return txHashHex, nil
+// suggestFees returns the node's suggested priority fee, and a fee cap of
+// twice the current base fee plus that tip, which stays valid through
+// several blocks of rising base fees.
+func (s *AnchorService) suggestFees(ctx context.Context) (tip, feeCap *big.Int, err error) {
+	tip, err = s.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get gas tip: %w", err)
+	}
+	head, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("chain does not support EIP-1559 transactions")
+	}
+	feeCap = new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+	feeCap.Add(feeCap, tip)
+	return tip, feeCap, nil
+}
+
+// bump raises fee by feeBump percent, rounding up.
+func bump(fee *big.Int) *big.Int {
+	n := new(big.Int).Mul(fee, big.NewInt(100+feeBump))
+	n.Add(n, big.NewInt(99))
+	return n.Div(n, big.NewInt(100))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
 }
 
-// CheckTransaction checks if a transaction has been mined and returns block info.
-// Returns blockNumber, timestamp, and whether it's confirmed.
-func (s *AnchorService) CheckTransaction(ctx context.Context, txHashHex string) (blockNumber int64, timestamp time.Time, confirmed bool, err error) {
+// CheckTransaction checks whether a transaction has been mined, and if so
+// whether its block has the service's number of confirmations. A receipt
+// the node reports for a block that is no longer canonical counts as
+// pending, as does a transaction the node does not know.
+func (s *AnchorService) CheckTransaction(ctx context.Context, txHashHex string) (*TxStatus, error) {
 	if s == nil {
-		return 0, time.Time{}, false, fmt.Errorf("blockchain anchor service is not configured")
+		return nil, fmt.Errorf("blockchain anchor service is not configured")
 	}
 
 	receipt, err := s.client.TransactionReceipt(ctx, common.HexToHash(txHashHex))
+	if errors.Is(err, ethereum.NotFound) || isIndexing(err) {
+		return &TxStatus{State: TxPending}, nil
+	}
 	if err != nil {
-		// Transaction not yet mined
-		return 0, time.Time{}, false, nil
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
 	}
 
-	if receipt.Status != types.ReceiptStatusSuccessful {
-		return 0, time.Time{}, false, fmt.Errorf("transaction reverted")
+	header, err := s.client.HeaderByNumber(ctx, receipt.BlockNumber)
+	if errors.Is(err, ethereum.NotFound) {
+		return &TxStatus{State: TxPending}, nil
 	}
-
-	block, err := s.client.BlockByNumber(ctx, receipt.BlockNumber)
 	if err != nil {
-		return receipt.BlockNumber.Int64(), time.Time{}, true, nil
+		return nil, fmt.Errorf("failed to get block %s: %w", receipt.BlockNumber, err)
+	}
+	if header.Hash() != receipt.BlockHash {
+		// The receipt is from a block that was reorganised away
+		return &TxStatus{State: TxPending}, nil
+	}
+	head, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
 	}
 
-	ts := time.Unix(int64(block.Time()), 0)
-	return receipt.BlockNumber.Int64(), ts, true, nil
+	status := &TxStatus{
+		State:       TxIncluded,
+		BlockNumber: receipt.BlockNumber.Int64(),
+		BlockHash:   receipt.BlockHash.Hex(),
+		Timestamp:   time.Unix(int64(header.Time), 0),
+	}
+	if head+1 < receipt.BlockNumber.Uint64()+s.confirmations {
+		return status, nil
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, ErrReverted
+	}
+	status.State = TxFinalized
+	return status, nil
+}
+
+// isIndexing reports whether err is geth's answer for a transaction it has
+// not found while its transaction index is still being built, which means
+// no more than not found.
+func isIndexing(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "transaction indexing is in progress")
 }
 
 // VerifyAnchor verifies a transaction on-chain and returns the data stored in it.
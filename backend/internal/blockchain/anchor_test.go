@@ -0,0 +1,24 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEVMChains(t *testing.T) {
+	chains, err := ParseEVMChains(`[
+		{"name": "base", "rpcUrl": "https://mainnet.base.org", "chainId": "8453", "confirmations": 30},
+		{"name": "polygon", "rpcUrl": "https://polygon-rpc.com", "chainId": "137", "privateKey": "ab"}
+	]`)
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+	assert.Equal(t, EVMChain{Name: "base", RPCURL: "https://mainnet.base.org", ChainID: "8453", Confirmations: 30}, chains[0])
+	assert.Equal(t, "ab", chains[1].PrivateKey)
+
+	_, err = ParseEVMChains(`[{"rpcUrl": "https://mainnet.base.org"}]`)
+	assert.ErrorContains(t, err, "no name")
+	_, err = ParseEVMChains(`{"name": "base"}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Anchorer records content digests with an outside party whose record can be
+// checked without trusting us: a blockchain, or an RFC 3161 timestamp
+// authority. The batcher hands it the Merkle root of each batch.
+type Anchorer interface {
+	// Chain names where digests are recorded. It is stored on every anchor
+	// and batch, and is how the worker finds the anchorer to check them with.
+	Chain() string
+
+	// AnchorHash records a hex encoded SHA-256 digest.
+	AnchorHash(ctx context.Context, digest string) (*Receipt, error)
+
+	// CheckTransaction reports how far the record a receipt referred to has
+	// got towards being final. An error wrapping ErrReverted means it failed
+	// and never will be; other errors are worth retrying.
+	CheckTransaction(ctx context.Context, ref string) (*TxStatus, error)
+}
+
+// ErrReverted is returned by CheckTransaction for a record that was mined
+// but failed, deep enough that it will not be mined again.
+var ErrReverted = errors.New("transaction reverted")
+
+// TxState is how far a record has got towards being final.
+type TxState int
+
+const (
+	// TxPending records are not in a block: not yet, or no longer, if the
+	// block they were in was reorganised away.
+	TxPending TxState = iota
+	// TxIncluded records are in a block that is not yet deep enough to be
+	// safe from a reorg.
+	TxIncluded
+	// TxFinalized records are in a block with enough confirmations on top.
+	TxFinalized
+)
+
+// TxStatus is the result of CheckTransaction. The block fields are set
+// unless the record is pending.
+type TxStatus struct {
+	State       TxState
+	BlockNumber int64
+	BlockHash   string
+	Timestamp   time.Time
+}
+
+// Receipt is what an anchorer returns for a recorded digest.
+type Receipt struct {
+	// Ref identifies the record: a transaction hash, or the serial number of
+	// a timestamp token. It is stored as the anchor's tx hash.
+	Ref string
+	// Token is the signed RFC 3161 timestamp token, if the anchorer issues one.
+	Token []byte
+	// ConfirmedAt is set when the record is final as soon as it is made, so
+	// there is nothing for the worker to wait for.
+	ConfirmedAt *time.Time
+	// Tx holds the nonce and fees of an EVM transaction.
+	Tx *TxParams
+}
+
+// Replacer is implemented by anchorers whose records can stall, like EVM
+// transactions priced below what the chain now needs. ReplaceTransaction
+// records digest again in place of the record sent with prev, on better
+// terms. TransactionParams reads back the terms of a record the chain still
+// knows, for records sent before their terms were stored.
+type Replacer interface {
+	ReplaceTransaction(ctx context.Context, digest string, prev *TxParams) (*Receipt, error)
+	TransactionParams(ctx context.Context, ref string) (*TxParams, error)
+}
+
+// Recorder is implemented by anchorers whose records may reach the outside
+// party even when sending them reports an error, like EVM transactions.
+// AnchorHashRecorded is AnchorHash, passing the signed record to record
+// before sending it, and sending it only if record succeeds, so the caller
+// never loses track of a record that may be on its way.
+type Recorder interface {
+	AnchorHashRecorded(ctx context.Context, digest string, record func(*Receipt) error) (*Receipt, error)
+}
+
+// Registry holds the configured anchorers by chain. One of them is the
+// default for new anchors. A nil Registry has no anchorers.
+type Registry struct {
+	anchorers   map[string]Anchorer
+	defaultName string
+}
+
+// NewRegistry returns a registry of anchorers. The first becomes the default.
+func NewRegistry(anchorers ...Anchorer) *Registry {
+	r := &Registry{anchorers: map[string]Anchorer{}}
+	for _, a := range anchorers {
+		r.Add(a)
+	}
+	return r
+}
+
+// Add registers a, replacing any anchorer for the same chain.
+func (r *Registry) Add(a Anchorer) {
+	if r.defaultName == "" {
+		r.defaultName = a.Chain()
+	}
+	r.anchorers[a.Chain()] = a
+}
+
+// SetDefault makes chain the default for new anchors. It reports false if
+// no anchorer is registered for chain.
+func (r *Registry) SetDefault(chain string) bool {
+	if _, ok := r.Get(chain); !ok {
+		return false
+	}
+	r.defaultName = chain
+	return true
+}
+
+// Get returns the anchorer for chain.
+func (r *Registry) Get(chain string) (Anchorer, bool) {
+	if r == nil {
+		return nil, false
+	}
+	a, ok := r.anchorers[chain]
+	return a, ok
+}
+
+// Default returns the anchorer for new anchors, or nil if there are none.
+func (r *Registry) Default() Anchorer {
+	a, _ := r.Get(r.defaultChain())
+	return a
+}
+
+func (r *Registry) defaultChain() string {
+	if r == nil {
+		return ""
+	}
+	return r.defaultName
+}
+
+// All returns every registered anchorer, ordered by chain.
+func (r *Registry) All() []Anchorer {
+	if r == nil {
+		return nil
+	}
+	all := make([]Anchorer, 0, len(r.anchorers))
+	for _, a := range r.anchorers {
+		all = append(all, a)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Chain() < all[j].Chain() })
+	return all
+}
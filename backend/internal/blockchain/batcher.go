@@ -0,0 +1,172 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/pkg/merkle"
+	"github.com/nrednav/cuid2"
+)
+
+const (
+	// DefaultBatchWindow is how long hashes queue before being anchored.
+	DefaultBatchWindow = 10 * time.Minute
+	// DefaultBatchSize caps the hashes anchored in one transaction. Proofs
+	// grow with the log of the batch size, so this mostly bounds how long a
+	// batch takes to save.
+	DefaultBatchSize = 1000
+
+	// staleBatchAge is how long a batch can go without a transaction before
+	// it is assumed abandoned and its anchors are queued again.
+	staleBatchAge = 15 * time.Minute
+)
+
+// Batcher anchors the content hashes queued for one anchorer's chain in
+// batches: every window it builds a Merkle tree over the queue and anchors
+// only the root, in a single transaction or timestamp. Each anchor stores its
+// path to the root, so it can still be verified on its own.
+type Batcher struct {
+	store    *store.Store
+	anchorer Anchorer
+	window   time.Duration
+	size     int
+}
+
+// NewBatcher creates a batcher that anchors the queue for anchorer's chain
+// every window, at most size hashes per batch. Values below one use the
+// defaults.
+func NewBatcher(st *store.Store, anchorer Anchorer, window time.Duration, size int) *Batcher {
+	if window <= 0 {
+		window = DefaultBatchWindow
+	}
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+	return &Batcher{store: st, anchorer: anchorer, window: window, size: size}
+}
+
+// Start begins the batching loop.
+func (b *Batcher) Start(ctx context.Context) {
+	log.Printf("Anchor batcher for %s started (window %s, up to %d hashes per batch)", b.anchorer.Chain(), b.window, b.size)
+	ticker := time.NewTicker(b.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush(ctx)
+		case <-ctx.Done():
+			log.Printf("Anchor batcher for %s stopped", b.anchorer.Chain())
+			return
+		}
+	}
+}
+
+// flush anchors everything queued, one batch at a time.
+func (b *Batcher) flush(ctx context.Context) {
+	if n, err := b.store.ReleaseStaleAnchorBatches(ctx, time.Now().Add(-staleBatchAge)); err != nil {
+		log.Printf("Anchor batcher: failed to release stale batches: %v", err)
+	} else if n > 0 {
+		log.Printf("Anchor batcher: requeued %d abandoned batches", n)
+	}
+
+	for {
+		batch, anchors, err := b.store.CreateAnchorBatch(ctx, b.anchorer.Chain(), b.size, buildBatch)
+		if err != nil {
+			log.Printf("Anchor batcher: failed to build batch: %v", err)
+			return
+		}
+		if batch == nil {
+			return
+		}
+
+		receipt, recorded, err := b.send(ctx, batch)
+		if err != nil && recorded {
+			// The transaction may still be mined, so the batch is kept; the
+			// confirmation worker replaces it if it never arrives
+			log.Printf("Anchor batcher: failed to send batch %s, left for the confirmation worker: %v", batch.ID, err)
+			return
+		}
+		if err != nil {
+			log.Printf("Anchor batcher: failed to send batch %s: %v", batch.ID, err)
+			if err := b.store.ReleaseAnchorBatch(ctx, batch.ID); err != nil {
+				log.Printf("Anchor batcher: failed to release batch %s: %v", batch.ID, err)
+			}
+			return
+		}
+
+		if !recorded {
+			// A record that is final when made, like a timestamp token,
+			// finalizes the batch now; anything else waits for the
+			// confirmation worker
+			var events []*store.OutboxEvent
+			if receipt.ConfirmedAt != nil {
+				events = batchEvents(anchors, receipt.Ref, 0, *receipt.ConfirmedAt, nil)
+			}
+			if err := b.store.SetAnchorBatchTx(ctx, batch.ID, sentTransaction(receipt), receipt.Token, receipt.ConfirmedAt, events...); err != nil {
+				log.Printf("Anchor batcher: failed to record tx %s for batch %s: %v", receipt.Ref, batch.ID, err)
+				return
+			}
+		}
+		log.Printf("Anchor batcher: anchored root %s of %d hashes on %s as %s", batch.MerkleRoot, batch.LeafCount, batch.Chain, receipt.Ref)
+
+		if len(anchors) < b.size {
+			return
+		}
+	}
+}
+
+// send anchors batch's root. If the anchorer is a Recorder, the transaction
+// is recorded on the batch before it is sent, and recorded reports whether
+// that happened: from then on the batch is never released, so its anchors
+// cannot be anchored twice.
+func (b *Batcher) send(ctx context.Context, batch *store.AnchorBatch) (receipt *Receipt, recorded bool, err error) {
+	r, ok := b.anchorer.(Recorder)
+	if !ok {
+		receipt, err = b.anchorer.AnchorHash(ctx, batch.MerkleRoot)
+		return receipt, false, err
+	}
+	receipt, err = r.AnchorHashRecorded(ctx, batch.MerkleRoot, func(signed *Receipt) error {
+		if err := b.store.SetAnchorBatchTx(ctx, batch.ID, sentTransaction(signed), nil, nil); err != nil {
+			return err
+		}
+		recorded = true
+		return nil
+	})
+	return receipt, recorded, err
+}
+
+// buildBatch builds the Merkle tree over anchors, in order, and returns the
+// batch for its root, setting each anchor's leaf index and proof.
+func buildBatch(anchors []*store.ContentAnchor) (*store.AnchorBatch, error) {
+	leaves := make([][]byte, len(anchors))
+	for i, a := range anchors {
+		h, err := hex.DecodeString(a.ContentHash)
+		if err != nil {
+			return nil, fmt.Errorf("anchor %s: invalid content hash: %w", a.ID, err)
+		}
+		leaves[i] = h
+	}
+	tree, err := merkle.New(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, a := range anchors {
+		idx := i
+		a.LeafIndex = &idx
+		a.MerkleProof = tree.Proof(i)
+	}
+	return &store.AnchorBatch{
+		ID:         cuid2.Generate(),
+		MerkleRoot: hex.EncodeToString(tree.Root()),
+		LeafCount:  tree.Len(),
+		Chain:      anchors[0].Chain,
+		Status:     "pending",
+		CreatedAt:  time.Now(),
+	}, nil
+}
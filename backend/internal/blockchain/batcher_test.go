@@ -0,0 +1,45 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/pkg/merkle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildBatch_ProofsVerifyAgainstRoot(t *testing.T) {
+	var anchors []*store.ContentAnchor
+	for i := 0; i < 5; i++ {
+		h := sha256.Sum256([]byte(fmt.Sprintf("upload %d", i)))
+		anchors = append(anchors, &store.ContentAnchor{ID: fmt.Sprint(i), ContentHash: hex.EncodeToString(h[:]), Chain: "base"})
+	}
+
+	batch, err := buildBatch(anchors)
+	require.NoError(t, err)
+	assert.Equal(t, 5, batch.LeafCount)
+	assert.Equal(t, "base", batch.Chain)
+	assert.Equal(t, "pending", batch.Status)
+
+	root, err := hex.DecodeString(batch.MerkleRoot)
+	require.NoError(t, err)
+	for i, a := range anchors {
+		require.NotNil(t, a.LeafIndex)
+		assert.Equal(t, i, *a.LeafIndex)
+
+		data, _ := hex.DecodeString(a.ContentHash)
+		got, err := merkle.Root(data, a.MerkleProof)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(root, got), "anchor %d", i)
+	}
+}
+
+func TestBuildBatch_RejectsInvalidHash(t *testing.T) {
+	_, err := buildBatch([]*store.ContentAnchor{{ID: "a1", ContentHash: "not-hex"}})
+	assert.ErrorContains(t, err, "a1")
+}
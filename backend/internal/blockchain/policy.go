@@ -0,0 +1,125 @@
+package blockchain
+
+import (
+	"context"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/nrednav/cuid2"
+)
+
+// Policy says which of a creator's items are anchored without being asked
+// for. Creators set one in their settings, and collections can set their
+// own for the items added to them.
+type Policy string
+
+const (
+	PolicyAll      Policy = "all"      // every upload
+	PolicyPublic   Policy = "public"   // items while they are public
+	PolicyLicensed Policy = "licensed" // items with an active license offering
+	PolicyNever    Policy = "never"    // only items anchored by hand
+)
+
+// ParsePolicy returns the policy named s.
+func ParsePolicy(s string) (Policy, bool) {
+	switch p := Policy(s); p {
+	case PolicyAll, PolicyPublic, PolicyLicensed, PolicyNever:
+		return p, true
+	}
+	return "", false
+}
+
+// tierQuotas is how many anchors a creator can queue per calendar month, by
+// creator tier. Zero is unlimited.
+var tierQuotas = map[string]int{
+	"newcomer":    50,
+	"rising":      200,
+	"established": 1000,
+	"elite":       0,
+}
+
+// Quota returns the monthly anchor quota for a creator tier, and the start
+// of the month it is counted over. Unknown tiers get the newcomer quota.
+func Quota(tier string, now time.Time) (int, time.Time) {
+	quota, ok := tierQuotas[tier]
+	if !ok {
+		quota = tierQuotas["newcomer"]
+	}
+	now = now.UTC()
+	return quota, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// PolicyStore is the storage AutoAnchor depends on.
+type PolicyStore interface {
+	store.AnchorRepository
+	ListOfferingsByContent(ctx context.Context, contentID string) ([]*store.LicenseOffering, error)
+}
+
+// AutoAnchor queues items for anchoring on the default chain when a policy
+// covers them. A nil AutoAnchor, or one with no anchorers, queues nothing.
+type AutoAnchor struct {
+	store     PolicyStore
+	anchorers *Registry
+}
+
+// NewAutoAnchor returns an AutoAnchor queuing on anchorers' default chain.
+func NewAutoAnchor(st PolicyStore, anchorers *Registry) *AutoAnchor {
+	return &AutoAnchor{store: st, anchorers: anchorers}
+}
+
+// Apply queues item for anchoring if policy covers it, within its owner's
+// quota. It returns the queued anchor, or nil if the policy does not cover
+// the item, it already has an anchor, or its ownership is disputed. It
+// returns store.ErrAnchorQuota if the owner has used up their quota for the
+// month.
+func (a *AutoAnchor) Apply(ctx context.Context, owner *model.User, item *store.ContentItem, policy Policy) (*store.ContentAnchor, error) {
+	if a == nil {
+		return nil, nil
+	}
+	anchorer := a.anchorers.Default()
+	if anchorer == nil {
+		return nil, nil
+	}
+	// The hash is another creator's claim until the dispute is resolved
+	if item.DisputeStatus != nil {
+		return nil, nil
+	}
+
+	switch policy {
+	case PolicyAll:
+	case PolicyPublic:
+		if !item.IsPublic {
+			return nil, nil
+		}
+	case PolicyLicensed:
+		offerings, err := a.store.ListOfferingsByContent(ctx, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(offerings) == 0 {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+
+	name := string(policy)
+	now := time.Now()
+	anchor := &store.ContentAnchor{
+		ID:           cuid2.Generate(),
+		ContentID:    item.ID,
+		UserID:       owner.ID,
+		ContentHash:  item.HashSHA256,
+		Chain:        anchorer.Chain(),
+		AnchorStatus: "pending",
+		CreatedAt:    now,
+		Policy:       &name,
+	}
+	quota, since := Quota(owner.CreatorTier, now)
+	queued, err := a.store.QueueContentAnchor(ctx, anchor, quota, since)
+	if err != nil || !queued {
+		return nil, err
+	}
+	return anchor, nil
+}
@@ -0,0 +1,101 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, ok := ParsePolicy("licensed")
+	assert.True(t, ok)
+	assert.Equal(t, PolicyLicensed, p)
+
+	_, ok = ParsePolicy("sometimes")
+	assert.False(t, ok)
+}
+
+func TestQuota(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	quota, since := Quota("rising", now)
+	assert.Equal(t, 200, quota)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), since, "months are counted in UTC")
+
+	quota, _ = Quota("elite", now)
+	assert.Zero(t, quota, "unlimited")
+
+	quota, _ = Quota("", now)
+	assert.Equal(t, 50, quota)
+}
+
+func TestAutoAnchor_Apply(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	auto := NewAutoAnchor(st, NewRegistry(NewTimestampAuthority("http://tsa.invalid", nil)))
+	owner := &model.User{ID: "u1", CreatorTier: "rising"}
+
+	private := &store.ContentItem{ID: "c1", UserID: "u1", HashSHA256: "aa"}
+	a, err := auto.Apply(ctx, owner, private, PolicyPublic)
+	require.NoError(t, err)
+	assert.Nil(t, a, "private items are not covered by the public policy")
+
+	a, err = auto.Apply(ctx, owner, private, PolicyLicensed)
+	require.NoError(t, err)
+	assert.Nil(t, a, "no license offering yet")
+
+	require.NoError(t, st.CreateLicenseOffering(ctx, &store.LicenseOffering{
+		ID: "o1", ContentID: "c1", LicenseType: "commercial", PriceCents: 500, Currency: "usd", IsActive: true,
+	}))
+	a, err = auto.Apply(ctx, owner, private, PolicyLicensed)
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	assert.Equal(t, TimestampChain, a.Chain)
+	assert.Equal(t, "pending", a.AnchorStatus)
+	require.NotNil(t, a.Policy)
+	assert.Equal(t, "licensed", *a.Policy)
+
+	a, err = auto.Apply(ctx, owner, private, PolicyAll)
+	require.NoError(t, err)
+	assert.Nil(t, a, "already anchored")
+
+	a, err = auto.Apply(ctx, owner, &store.ContentItem{ID: "c2", UserID: "u1", IsPublic: true}, PolicyNever)
+	require.NoError(t, err)
+	assert.Nil(t, a)
+
+	disputed := "disputed"
+	a, err = auto.Apply(ctx, owner, &store.ContentItem{ID: "c3", UserID: "u1", DisputeStatus: &disputed}, PolicyAll)
+	require.NoError(t, err)
+	assert.Nil(t, a, "disputed items are not anchored")
+
+	var none *AutoAnchor
+	a, err = none.Apply(ctx, owner, &store.ContentItem{ID: "c2", UserID: "u1"}, PolicyAll)
+	require.NoError(t, err)
+	assert.Nil(t, a)
+}
+
+func TestAutoAnchor_Quota(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	auto := NewAutoAnchor(st, NewRegistry(NewTimestampAuthority("http://tsa.invalid", nil)))
+	owner := &model.User{ID: "u1", CreatorTier: "newcomer"}
+
+	for i := 0; i < 50; i++ {
+		a, err := auto.Apply(ctx, owner, &store.ContentItem{ID: fmt.Sprintf("c%d", i), UserID: "u1"}, PolicyAll)
+		require.NoError(t, err)
+		require.NotNil(t, a)
+	}
+	_, err := auto.Apply(ctx, owner, &store.ContentItem{ID: "c50", UserID: "u1"}, PolicyAll)
+	assert.ErrorIs(t, err, store.ErrAnchorQuota)
+
+	a, err := auto.Apply(ctx, &model.User{ID: "u2", CreatorTier: "newcomer"}, &store.ContentItem{ID: "c51", UserID: "u2"}, PolicyAll)
+	require.NoError(t, err)
+	assert.NotNil(t, a, "quotas are per creator")
+}
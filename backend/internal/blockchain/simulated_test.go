@@ -0,0 +1,318 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulated_AnchorsAndConfirms(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+
+	var a Anchorer = sim
+	assert.Equal(t, SimulatedChain, a.Chain())
+
+	sum := sha256.Sum256([]byte("batch root"))
+	digest := hex.EncodeToString(sum[:])
+	receipt, err := a.AnchorHash(ctx, digest)
+	require.NoError(t, err)
+	assert.Nil(t, receipt.ConfirmedAt, "chain records wait for the worker")
+
+	status, err := a.CheckTransaction(ctx, receipt.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, TxFinalized, status.State, "sent transactions are mined, and final, at once")
+	assert.Positive(t, status.BlockNumber)
+	assert.NotEmpty(t, status.BlockHash)
+	assert.False(t, status.Timestamp.IsZero())
+
+	data, _, _, err := sim.VerifyAnchor(ctx, receipt.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, digest, data)
+}
+
+func TestRegistry_Default(t *testing.T) {
+	var empty *Registry
+	assert.Nil(t, empty.Default())
+
+	tsa := NewTimestampAuthority("http://tsa.invalid", nil)
+	sim := &Simulated{AnchorService: &AnchorService{chain: SimulatedChain}}
+	r := NewRegistry(tsa, sim)
+	assert.Equal(t, TimestampChain, r.Default().Chain(), "first added is the default")
+
+	assert.False(t, r.SetDefault("base"))
+	assert.True(t, r.SetDefault(SimulatedChain))
+	assert.Equal(t, SimulatedChain, r.Default().Chain())
+
+	_, ok := r.Get("base")
+	assert.False(t, ok)
+	require.Len(t, r.All(), 2)
+	assert.Equal(t, TimestampChain, r.All()[0].Chain(), "ordered by chain")
+}
+
+func TestAnchorService_ConcurrentSendsTakeSuccessiveNonces(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+	sim.afterSend = nil // mine them together below
+
+	receipts := make([]*Receipt, 5)
+	var wg sync.WaitGroup
+	for i := range receipts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sum := sha256.Sum256([]byte{byte(i)})
+			r, err := sim.AnchorHash(ctx, hex.EncodeToString(sum[:]))
+			assert.NoError(t, err)
+			receipts[i] = r
+		}(i)
+	}
+	wg.Wait()
+	sim.backend.Commit()
+
+	nonces := map[uint64]bool{}
+	for _, r := range receipts {
+		require.NotNil(t, r)
+		nonces[r.Tx.Nonce] = true
+		assert.Equal(t, 1, r.Tx.GasFeeCap.Cmp(r.Tx.GasTipCap), "fee cap covers the base fee")
+		status, err := sim.CheckTransaction(ctx, r.Ref)
+		require.NoError(t, err)
+		assert.Equal(t, TxFinalized, status.State)
+	}
+	assert.Len(t, nonces, 5)
+}
+
+// memNonces is a NonceStore in memory, for services standing in for
+// replicas of one process.
+type memNonces struct {
+	mu   sync.Mutex
+	next map[string]int64
+}
+
+func (m *memNonces) WithWalletNonce(ctx context.Context, chain, wallet string, send func(next int64) (int64, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	next, err := send(m.next[chain+":"+wallet])
+	if err != nil {
+		return err
+	}
+	m.next[chain+":"+wallet] = next
+	return nil
+}
+
+func TestAnchorService_SharedNoncesAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+	sim.afterSend = nil
+
+	nonces := &memNonces{next: map[string]int64{}}
+	replicas := make([]*AnchorService, 2)
+	for i := range replicas {
+		replicas[i] = &AnchorService{
+			client:     sim.client,
+			chain:      sim.chain,
+			privateKey: sim.privateKey,
+			fromAddr:   sim.fromAddr,
+			chainID:    sim.chainID,
+		}
+		replicas[i].ShareNonces(nonces)
+	}
+
+	receipts := make([]*Receipt, 6)
+	var wg sync.WaitGroup
+	for i := range receipts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sum := sha256.Sum256([]byte{byte(i)})
+			r, err := replicas[i%2].AnchorHash(ctx, hex.EncodeToString(sum[:]))
+			assert.NoError(t, err)
+			receipts[i] = r
+		}(i)
+	}
+	wg.Wait()
+
+	used := map[uint64]bool{}
+	for _, r := range receipts {
+		require.NotNil(t, r)
+		used[r.Tx.Nonce] = true
+	}
+	assert.Len(t, used, 6, "replicas never reuse a nonce")
+	assert.Equal(t, int64(6), nonces.next[sim.chain+":"+sim.fromAddr.Hex()])
+}
+
+func TestAnchorService_ReplacesStuckTransaction(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+	sim.afterSend = nil
+
+	sum := sha256.Sum256([]byte("batch root"))
+	digest := hex.EncodeToString(sum[:])
+	first, err := sim.AnchorHash(ctx, digest)
+	require.NoError(t, err)
+
+	second, err := sim.ReplaceTransaction(ctx, digest, first.Tx)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Ref, second.Ref)
+	assert.Equal(t, first.Tx.Nonce, second.Tx.Nonce)
+	assert.Equal(t, 1, second.Tx.GasTipCap.Cmp(first.Tx.GasTipCap))
+	assert.Equal(t, 1, second.Tx.GasFeeCap.Cmp(first.Tx.GasFeeCap))
+	sim.backend.Commit()
+
+	history := []*store.AnchorTransaction{sentTransaction(first), sentTransaction(second)}
+	assert.Equal(t, first.Tx, txParams(history[0]), "fees survive the round trip through the store")
+
+	txHash, status, err := checkHistory(ctx, sim, history)
+	require.NoError(t, err)
+	assert.Equal(t, TxFinalized, status.State)
+	assert.Equal(t, second.Ref, txHash, "only the replacement is mined")
+	assert.Positive(t, status.BlockNumber)
+
+	status, err = sim.CheckTransaction(ctx, first.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, TxPending, status.State)
+}
+
+func TestAnchorService_TransactionParamsRecoverUnrecordedNonce(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+	sim.afterSend = nil
+
+	// An unbatched anchor sent before its nonce and fees were stored
+	sum := sha256.Sum256([]byte("content"))
+	digest := hex.EncodeToString(sum[:])
+	legacy, err := sim.AnchorHash(ctx, digest)
+	require.NoError(t, err)
+	history := []*store.AnchorTransaction{{TxHash: legacy.Ref}}
+	require.Nil(t, txParams(history[0]))
+
+	prev, err := sim.TransactionParams(ctx, legacy.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, legacy.Tx, prev)
+
+	replacement, err := sim.ReplaceTransaction(ctx, digest, prev)
+	require.NoError(t, err)
+	sim.backend.Commit()
+
+	txHash, status, err := checkHistory(ctx, sim, append(history, sentTransaction(replacement)))
+	require.NoError(t, err)
+	assert.Equal(t, TxFinalized, status.State)
+	assert.Equal(t, replacement.Ref, txHash, "the replacement took the legacy nonce")
+
+	status, err = sim.CheckTransaction(ctx, legacy.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, TxPending, status.State, "the hash is anchored once")
+}
+
+func TestAnchorService_RecordsBeforeSending(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+	sim.afterSend = nil
+
+	sum := sha256.Sum256([]byte("batch root"))
+	digest := hex.EncodeToString(sum[:])
+
+	// A record that fails sends nothing and leaves the nonce free
+	_, err = sim.AnchorHashRecorded(ctx, digest, func(*Receipt) error { return assert.AnError })
+	require.ErrorIs(t, err, assert.AnError)
+
+	var recorded *Receipt
+	receipt, err := sim.AnchorHashRecorded(ctx, digest, func(signed *Receipt) error {
+		_, err := sim.TransactionParams(ctx, signed.Ref)
+		assert.Error(t, err, "the node has not seen the transaction when it is recorded")
+		recorded = signed
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, recorded)
+	assert.Equal(t, recorded, receipt)
+	assert.Equal(t, uint64(0), receipt.Tx.Nonce)
+
+	// Another sender takes the next nonce between recording and sending,
+	// so the send fails; the recorded nonce stays used up
+	other := &AnchorService{client: sim.client, chain: sim.chain, privateKey: sim.privateKey, fromAddr: sim.fromAddr, chainID: sim.chainID, confirmations: 1}
+	_, err = sim.AnchorHashRecorded(ctx, digest, func(signed *Receipt) error {
+		recorded = signed
+		_, err := other.AnchorHash(ctx, hex.EncodeToString(make([]byte, 32)))
+		return err
+	})
+	require.Error(t, err)
+	assert.Equal(t, uint64(1), recorded.Tx.Nonce)
+
+	next, err := sim.AnchorHash(ctx, digest)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), next.Tx.Nonce)
+}
+
+func TestAnchorService_FinalAfterConfirmations(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+	sim.confirmations = 3
+
+	sum := sha256.Sum256([]byte("batch root"))
+	receipt, err := sim.AnchorHash(ctx, hex.EncodeToString(sum[:]))
+	require.NoError(t, err)
+
+	status, err := sim.CheckTransaction(ctx, receipt.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, TxIncluded, status.State)
+	included := *status
+
+	sim.backend.Commit()
+	status, err = sim.CheckTransaction(ctx, receipt.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, TxIncluded, status.State, "two of three blocks")
+
+	sim.backend.Commit()
+	status, err = sim.CheckTransaction(ctx, receipt.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, TxFinalized, status.State)
+	assert.Equal(t, included.BlockHash, status.BlockHash)
+}
+
+func TestAnchorService_ReorgedTransactionIsPendingAgain(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulated()
+	require.NoError(t, err)
+	defer sim.Close()
+	sim.confirmations = 3
+
+	parent, err := sim.client.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("batch root"))
+	receipt, err := sim.AnchorHash(ctx, hex.EncodeToString(sum[:]))
+	require.NoError(t, err)
+	status, err := sim.CheckTransaction(ctx, receipt.Ref)
+	require.NoError(t, err)
+	require.Equal(t, TxIncluded, status.State)
+
+	// Replace the block with a longer side chain without the transaction
+	require.NoError(t, sim.backend.Fork(parent.Hash()))
+	sim.backend.Rollback()
+	sim.backend.Commit()
+	sim.backend.Commit()
+
+	status, err = sim.CheckTransaction(ctx, receipt.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, TxPending, status.State)
+}
@@ -0,0 +1,227 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimestampChain is the chain name of anchors timestamped by an RFC 3161
+// timestamp authority.
+const TimestampChain = "rfc3161"
+
+// maxTimestampResponse bounds the response read from a timestamp authority.
+// Tokens carry the authority's certificate chain, so allow for a few.
+const maxTimestampResponse = 1 << 20
+
+var (
+	oidSHA256     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+)
+
+// TimestampAuthority anchors digests with an RFC 3161 timestamp authority
+// (TSA). The TSA signs a token binding the digest to the time it was
+// received; the token is stored with the batch and served with its proofs.
+// Tokens are final when issued, so there is nothing to confirm later. Their
+// signature is checked by verifiers against the TSA's certificate, e.g.
+// with `openssl ts -verify`, not here.
+type TimestampAuthority struct {
+	url    string
+	client *http.Client
+}
+
+// NewTimestampAuthority returns an anchorer using the TSA at url. A nil
+// client uses one with a 30 second timeout.
+func NewTimestampAuthority(url string, client *http.Client) *TimestampAuthority {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &TimestampAuthority{url: url, client: client}
+}
+
+// Chain returns "rfc3161".
+func (t *TimestampAuthority) Chain() string {
+	return TimestampChain
+}
+
+// AnchorHash asks the TSA to timestamp a hex encoded SHA-256 digest. The
+// receipt's Ref is the token's serial number, in hex.
+func (t *TimestampAuthority) AnchorHash(ctx context.Context, digest string) (*Receipt, error) {
+	hashed, err := hex.DecodeString(digest)
+	if err != nil || len(hashed) != 32 {
+		return nil, fmt.Errorf("invalid digest: %s", digest)
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: hashed,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+	req.Header.Set("Accept", "application/timestamp-reply")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("timestamp request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("timestamp authority returned %s", resp.Status)
+	}
+	der, err := io.ReadAll(io.LimitReader(resp.Body, maxTimestampResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timestamp response: %w", err)
+	}
+
+	token, info, err := parseTimeStampResp(der)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(info.MessageImprint.HashedMessage, hashed) || !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) {
+		return nil, errors.New("timestamp token is for a different digest")
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("timestamp token nonce does not match the request")
+	}
+
+	genTime := info.GenTime.UTC()
+	return &Receipt{
+		Ref:         info.SerialNumber.Text(16),
+		Token:       token,
+		ConfirmedAt: &genTime,
+	}, nil
+}
+
+// CheckTransaction never has anything to wait for: tokens are final when
+// issued and their batches are finalized then. It exists for the interface.
+func (t *TimestampAuthority) CheckTransaction(ctx context.Context, ref string) (*TxStatus, error) {
+	return nil, errors.New("timestamp tokens are final when issued")
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// timeStampReq is TimeStampReq from RFC 3161 section 2.4.1.
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional"`
+}
+
+// timeStampResp is TimeStampResp from RFC 3161 section 2.4.2. The token is
+// a CMS ContentInfo.
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// signedData is CMS SignedData (RFC 5652 section 5.1), up to the content.
+// The certificates and signer infos that follow are not read.
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue `asn1:"set"`
+	EncapContentInfo struct {
+		EContentType asn1.ObjectIdentifier
+		EContent     []byte `asn1:"explicit,tag:0"`
+	}
+}
+
+// tstInfo is TSTInfo from RFC 3161 section 2.4.2, up to the nonce.
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Accuracy       accuracy  `asn1:"optional"`
+	Ordering       bool      `asn1:"optional"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// parseTimeStampResp checks a TSA's response granted the request and returns
+// the DER token and the TSTInfo signed in it.
+func parseTimeStampResp(der []byte) ([]byte, *tstInfo, error) {
+	var resp timeStampResp
+	if _, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp response: %w", err)
+	}
+	// 0 is granted, 1 granted with modifications; the rest are refusals
+	if resp.Status.Status > 1 {
+		msg := strings.Join(resp.Status.StatusString, "; ")
+		return nil, nil, fmt.Errorf("timestamp request rejected (status %d): %s", resp.Status.Status, msg)
+	}
+	token := resp.TimeStampToken.FullBytes
+	if len(token) == 0 {
+		return nil, nil, errors.New("timestamp response has no token")
+	}
+
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(token, &ci); err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, nil, fmt.Errorf("timestamp token is not signed data: %v", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, nil, fmt.Errorf("timestamp token does not contain TSTInfo: %v", sd.EncapContentInfo.EContentType)
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, nil, fmt.Errorf("invalid TSTInfo: %w", err)
+	}
+	if info.SerialNumber == nil {
+		return nil, nil, errors.New("timestamp token has no serial number")
+	}
+	return token, &info, nil
+}
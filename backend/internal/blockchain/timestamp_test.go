@@ -0,0 +1,123 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTSA answers timestamp requests with an unsigned token, after letting
+// edit change the TSTInfo or the status.
+func fakeTSA(t *testing.T, edit func(info *tstInfo, status *int)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/timestamp-query", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		var req timeStampReq
+		_, err := asn1.Unmarshal(body, &req)
+		require.NoError(t, err)
+		assert.True(t, req.CertReq)
+
+		info := tstInfo{
+			Version:        1,
+			Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+			MessageImprint: req.MessageImprint,
+			SerialNumber:   big.NewInt(0xbeef),
+			GenTime:        time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC),
+			Nonce:          req.Nonce,
+		}
+		status := 0
+		if edit != nil {
+			edit(&info, &status)
+		}
+
+		eContent, err := asn1.Marshal(info)
+		require.NoError(t, err)
+		sd, err := asn1.Marshal(struct {
+			Version          int
+			DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+			EncapContentInfo struct {
+				EContentType asn1.ObjectIdentifier
+				EContent     []byte `asn1:"explicit,tag:0"`
+			}
+			SignerInfos []asn1.RawValue `asn1:"set"`
+		}{
+			Version:          3,
+			DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+			EncapContentInfo: struct {
+				EContentType asn1.ObjectIdentifier
+				EContent     []byte `asn1:"explicit,tag:0"`
+			}{oidTSTInfo, eContent},
+		})
+		require.NoError(t, err)
+		token, err := asn1.Marshal(struct {
+			ContentType asn1.ObjectIdentifier
+			Content     asn1.RawValue
+		}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd}})
+		require.NoError(t, err)
+
+		resp := timeStampResp{Status: pkiStatusInfo{Status: status}}
+		if status <= 1 {
+			resp.TimeStampToken = asn1.RawValue{FullBytes: token}
+		} else {
+			resp.Status.StatusString = []string{"bad request"}
+		}
+		der, err := asn1.Marshal(resp)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(der)
+	}))
+}
+
+func TestTimestampAuthority_AnchorHash(t *testing.T) {
+	srv := fakeTSA(t, nil)
+	defer srv.Close()
+
+	digest := sha256.Sum256([]byte("batch root"))
+	tsa := NewTimestampAuthority(srv.URL, srv.Client())
+	receipt, err := tsa.AnchorHash(context.Background(), hex.EncodeToString(digest[:]))
+	require.NoError(t, err)
+
+	assert.Equal(t, "beef", receipt.Ref)
+	require.NotNil(t, receipt.ConfirmedAt)
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC), *receipt.ConfirmedAt)
+
+	_, info, err := parseTimeStampResp(mustWrapToken(t, receipt.Token))
+	require.NoError(t, err)
+	assert.Equal(t, digest[:], info.MessageImprint.HashedMessage, "stored token covers the digest")
+}
+
+func TestTimestampAuthority_RejectsBadResponses(t *testing.T) {
+	digest := sha256.Sum256([]byte("batch root"))
+	tests := map[string]func(info *tstInfo, status *int){
+		"rejected":     func(_ *tstInfo, status *int) { *status = 2 },
+		"wrong nonce":  func(info *tstInfo, _ *int) { info.Nonce = big.NewInt(1) },
+		"wrong digest": func(info *tstInfo, _ *int) { info.MessageImprint.HashedMessage = make([]byte, 32) },
+	}
+	for name, edit := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := fakeTSA(t, edit)
+			defer srv.Close()
+
+			_, err := NewTimestampAuthority(srv.URL, srv.Client()).AnchorHash(context.Background(), hex.EncodeToString(digest[:]))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// mustWrapToken puts a token back in a granted TimeStampResp.
+func mustWrapToken(t *testing.T, token []byte) []byte {
+	der, err := asn1.Marshal(timeStampResp{TimeStampToken: asn1.RawValue{FullBytes: token}})
+	require.NoError(t, err)
+	return der
+}
@@ -2,21 +2,48 @@ package blockchain
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math/big"
 	"time"
 
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 )
 
-// ConfirmationWorker polls for pending anchors and confirms them on-chain.
+const (
+	// DefaultReplaceAfter is how long a batch's transaction can stay pending
+	// before it is replaced with one paying higher fees.
+	DefaultReplaceAfter = 10 * time.Minute
+
+	// maxReplacements caps the replacements sent for one batch, and with
+	// them how far its fees can climb. After that it waits.
+	maxReplacements = 8
+)
+
+// ConfirmationWorker polls anchors that are not yet final and checks them
+// with the anchorer for their chain, moving them from pending to included
+// once they are in a block, and to finalized once that block is deep
+// enough. One whose block is reorganised away goes back to pending and is
+// sent again. Batch transactions that stay pending too long are replaced,
+// if their anchorer is a Replacer.
 type ConfirmationWorker struct {
-	store   *store.Store
-	service *AnchorService
+	store        *store.Store
+	anchorers    *Registry
+	replaceAfter time.Duration
 }
 
-// NewConfirmationWorker creates a new worker.
-func NewConfirmationWorker(st *store.Store, svc *AnchorService) *ConfirmationWorker {
-	return &ConfirmationWorker{store: st, service: svc}
+// NewConfirmationWorker creates a new worker. Finalizing or failing an
+// anchor records an anchor.confirmed or anchor.failed event with it. Anchors on chains with no
+// anchorer in anchorers are left pending.
+func NewConfirmationWorker(st *store.Store, anchorers *Registry) *ConfirmationWorker {
+	return &ConfirmationWorker{store: st, anchorers: anchorers, replaceAfter: DefaultReplaceAfter}
+}
+
+// ReplaceStuckAfter sets how long a batch's transaction can stay pending
+// before it is replaced. Zero or less never replaces.
+func (w *ConfirmationWorker) ReplaceStuckAfter(d time.Duration) {
+	w.replaceAfter = d
 }
 
 // Start begins the polling loop.
@@ -37,6 +64,8 @@ func (w *ConfirmationWorker) Start(ctx context.Context) {
 }
 
 func (w *ConfirmationWorker) processPending(ctx context.Context) {
+	w.processBatches(ctx)
+
 	anchors, err := w.store.ListPendingAnchors(ctx)
 	if err != nil {
 		log.Printf("Confirmation worker: failed to list pending anchors: %v", err)
@@ -47,22 +76,322 @@ func (w *ConfirmationWorker) processPending(ctx context.Context) {
 		if anchor.TxHash == nil {
 			continue
 		}
+		anchorer, ok := w.anchorers.Get(anchor.Chain)
+		if !ok {
+			continue
+		}
+		history, err := w.store.ListAnchorTransactionsForAnchor(ctx, anchor.ID)
+		if err != nil {
+			log.Printf("Confirmation worker: failed to list transactions of anchor %s: %v", anchor.ID, err)
+			continue
+		}
+		if len(history) == 0 {
+			history = []*store.AnchorTransaction{{TxHash: *anchor.TxHash}}
+		}
 
-		blockNumber, ts, confirmed, err := w.service.CheckTransaction(ctx, *anchor.TxHash)
+		txHash, status, err := checkHistory(ctx, anchorer, history)
+		if errors.Is(err, ErrReverted) {
+			log.Printf("Confirmation worker: tx %s failed: %v", txHash, err)
+			failed := store.NewEvent(anchor.UserID, webhook.EventAnchorFailed, webhook.AnchorFailed{
+				AnchorID:    anchor.ID,
+				ContentID:   anchor.ContentID,
+				ContentHash: anchor.ContentHash,
+				Chain:       anchor.Chain,
+				TxHash:      &txHash,
+				Error:       err.Error(),
+			})
+			update := store.AnchorUpdate{Status: "failed", TxHash: txHash, Error: err.Error()}
+			if updErr := w.store.UpdateAnchorStatus(ctx, anchor.ID, update, failed); updErr != nil {
+				log.Printf("Confirmation worker: failed to update anchor %s: %v", anchor.ID, updErr)
+			}
+			continue
+		}
 		if err != nil {
-			log.Printf("Confirmation worker: tx %s failed: %v", *anchor.TxHash, err)
-			if err := w.store.UpdateAnchorStatus(ctx, anchor.ID, "failed", err.Error(), 0, nil); err != nil {
+			log.Printf("Confirmation worker: failed to check tx %s: %v", txHash, err)
+			continue
+		}
+
+		switch {
+		case status.State == TxFinalized:
+			done := store.NewEvent(anchor.UserID, webhook.EventAnchorConfirmed, webhook.AnchorConfirmed{
+				AnchorID:    anchor.ID,
+				ContentID:   anchor.ContentID,
+				ContentHash: anchor.ContentHash,
+				Chain:       anchor.Chain,
+				TxHash:      txHash,
+				BlockNumber: status.BlockNumber,
+				ConfirmedAt: status.Timestamp,
+			})
+			if err := w.store.UpdateAnchorStatus(ctx, anchor.ID, includedIn("finalized", txHash, status), done); err != nil {
+				log.Printf("Confirmation worker: failed to finalize anchor %s: %v", anchor.ID, err)
+			} else {
+				log.Printf("Confirmation worker: finalized anchor %s at block %d", anchor.ID, status.BlockNumber)
+			}
+		case status.State == TxIncluded:
+			if anchor.AnchorStatus == "included" && sameBlock(anchor.BlockHash, status) && *anchor.TxHash == txHash {
+				continue
+			}
+			if err := w.store.UpdateAnchorStatus(ctx, anchor.ID, includedIn("included", txHash, status)); err != nil {
 				log.Printf("Confirmation worker: failed to update anchor %s: %v", anchor.ID, err)
 			}
+		case anchor.AnchorStatus == "included":
+			w.resubmitAnchor(ctx, anchorer, anchor, history)
+		}
+	}
+}
+
+// resubmitAnchor sends an unbatched anchor again after the block its
+// transaction was in was reorganised away, and puts it back to pending. Like
+// resubmitBatch, the replacement takes the old transaction's nonce, so only
+// one of them can be mined and the hash is never anchored twice. Anchors
+// sent before their nonce was stored get it from the node; if the node no
+// longer has the transaction either, the anchor waits for it to be mined
+// again rather than risk a second transaction.
+func (w *ConfirmationWorker) resubmitAnchor(ctx context.Context, anchorer Anchorer, anchor *store.ContentAnchor, history []*store.AnchorTransaction) {
+	latest := history[len(history)-1]
+	log.Printf("Confirmation worker: tx %s of anchor %s left the chain in a reorg", latest.TxHash, anchor.ID)
+
+	var receipt *Receipt
+	var err error
+	replacer, ok := anchorer.(Replacer)
+	if ok {
+		prev := txParams(latest)
+		if prev == nil {
+			prev, err = replacer.TransactionParams(ctx, latest.TxHash)
+		}
+		if err == nil {
+			receipt, err = replacer.ReplaceTransaction(ctx, anchor.ContentHash, prev)
+		}
+	}
+	if !ok || err != nil {
+		if err != nil {
+			log.Printf("Confirmation worker: failed to resubmit anchor %s: %v", anchor.ID, err)
+		}
+		if err := w.store.UpdateAnchorStatus(ctx, anchor.ID, store.AnchorUpdate{Status: "pending"}); err != nil {
+			log.Printf("Confirmation worker: failed to update anchor %s: %v", anchor.ID, err)
+		}
+		return
+	}
+	if err := w.store.ReplaceAnchorTx(ctx, anchor.ID, latest.TxHash, sentTransaction(receipt)); err != nil {
+		log.Printf("Confirmation worker: failed to record resubmission %s for anchor %s: %v", receipt.Ref, anchor.ID, err)
+		return
+	}
+	log.Printf("Confirmation worker: resubmitted anchor %s as %s", anchor.ID, receipt.Ref)
+}
+
+// processBatches moves sent Merkle batches towards final, or fails them,
+// moving every anchor in a batch with it.
+func (w *ConfirmationWorker) processBatches(ctx context.Context) {
+	batches, err := w.store.ListPendingAnchorBatches(ctx)
+	if err != nil {
+		log.Printf("Confirmation worker: failed to list pending batches: %v", err)
+		return
+	}
+
+	for _, batch := range batches {
+		anchorer, ok := w.anchorers.Get(batch.Chain)
+		if !ok {
+			continue
+		}
+		history, err := w.store.ListAnchorTransactions(ctx, batch.ID)
+		if err != nil {
+			log.Printf("Confirmation worker: failed to list transactions of batch %s: %v", batch.ID, err)
 			continue
 		}
+		if len(history) == 0 {
+			history = []*store.AnchorTransaction{{TxHash: *batch.TxHash}}
+		}
 
-		if confirmed {
-			if err := w.store.UpdateAnchorStatus(ctx, anchor.ID, "confirmed", "", blockNumber, &ts); err != nil {
-				log.Printf("Confirmation worker: failed to confirm anchor %s: %v", anchor.ID, err)
+		txHash, status, txErr := checkHistory(ctx, anchorer, history)
+		if txErr != nil && !errors.Is(txErr, ErrReverted) {
+			log.Printf("Confirmation worker: failed to check tx %s of batch %s: %v", txHash, batch.ID, txErr)
+			continue
+		}
+		if txErr == nil && status.State == TxPending {
+			if batch.Status == "included" {
+				w.resubmitBatch(ctx, anchorer, batch, history)
 			} else {
-				log.Printf("Confirmation worker: confirmed anchor %s at block %d", anchor.ID, blockNumber)
+				w.replaceIfStuck(ctx, anchorer, batch, history)
+			}
+			continue
+		}
+		if txErr == nil && status.State == TxIncluded {
+			if batch.Status == "included" && sameBlock(batch.BlockHash, status) && *batch.TxHash == txHash {
+				continue
+			}
+			if err := w.store.UpdateAnchorBatchStatus(ctx, batch.ID, includedIn("included", txHash, status)); err != nil {
+				log.Printf("Confirmation worker: failed to update batch %s: %v", batch.ID, err)
+			}
+			continue
+		}
+
+		anchors, err := w.store.ListAnchorsByBatch(ctx, batch.ID)
+		if err != nil {
+			log.Printf("Confirmation worker: failed to list anchors of batch %s: %v", batch.ID, err)
+			continue
+		}
+
+		if txErr != nil {
+			log.Printf("Confirmation worker: batch %s tx %s failed: %v", batch.ID, txHash, txErr)
+			events := batchEvents(anchors, txHash, 0, time.Time{}, txErr)
+			update := store.AnchorUpdate{Status: "failed", TxHash: txHash, Error: txErr.Error()}
+			if err := w.store.UpdateAnchorBatchStatus(ctx, batch.ID, update, events...); err != nil {
+				log.Printf("Confirmation worker: failed to update batch %s: %v", batch.ID, err)
 			}
+			continue
+		}
+		events := batchEvents(anchors, txHash, status.BlockNumber, status.Timestamp, nil)
+		if err := w.store.UpdateAnchorBatchStatus(ctx, batch.ID, includedIn("finalized", txHash, status), events...); err != nil {
+			log.Printf("Confirmation worker: failed to finalize batch %s: %v", batch.ID, err)
+		} else {
+			log.Printf("Confirmation worker: finalized batch %s (%d anchors) at block %d", batch.ID, len(anchors), status.BlockNumber)
+		}
+	}
+}
+
+// checkHistory checks a batch's transactions, newest first, and returns the
+// first that is in a block, successfully or not. They share a nonce, so at
+// most one of them ever is. If none is, it returns the newest, pending.
+func checkHistory(ctx context.Context, anchorer Anchorer, history []*store.AnchorTransaction) (string, *TxStatus, error) {
+	for i := len(history) - 1; i >= 0; i-- {
+		txHash := history[i].TxHash
+		status, err := anchorer.CheckTransaction(ctx, txHash)
+		if err != nil || status.State != TxPending {
+			return txHash, status, err
+		}
+	}
+	return history[len(history)-1].TxHash, &TxStatus{State: TxPending}, nil
+}
+
+// resubmitBatch sends a batch's root again after the block its transaction
+// was in was reorganised away, and puts the batch back to pending. The
+// replacement takes the same nonce where it can, so that only one of the
+// old and new transactions is mined if the old one comes back.
+func (w *ConfirmationWorker) resubmitBatch(ctx context.Context, anchorer Anchorer, batch *store.AnchorBatch, history []*store.AnchorTransaction) {
+	latest := history[len(history)-1]
+	log.Printf("Confirmation worker: tx %s of batch %s left the chain in a reorg", *batch.TxHash, batch.ID)
+
+	var receipt *Receipt
+	var err error
+	if replacer, ok := anchorer.(Replacer); ok && txParams(latest) != nil {
+		receipt, err = replacer.ReplaceTransaction(ctx, batch.MerkleRoot, txParams(latest))
+	} else {
+		receipt, err = anchorer.AnchorHash(ctx, batch.MerkleRoot)
+	}
+	if err != nil {
+		log.Printf("Confirmation worker: failed to resubmit batch %s: %v", batch.ID, err)
+		if err := w.store.UpdateAnchorBatchStatus(ctx, batch.ID, store.AnchorUpdate{Status: "pending"}); err != nil {
+			log.Printf("Confirmation worker: failed to update batch %s: %v", batch.ID, err)
+		}
+		return
+	}
+	if err := w.store.ReplaceAnchorBatchTx(ctx, batch.ID, latest.TxHash, sentTransaction(receipt)); err != nil {
+		log.Printf("Confirmation worker: failed to record resubmission %s for batch %s: %v", receipt.Ref, batch.ID, err)
+		return
+	}
+	log.Printf("Confirmation worker: resubmitted batch %s as %s", batch.ID, receipt.Ref)
+}
+
+// includedIn is the update recording that a transaction is in status's block.
+func includedIn(state, txHash string, status *TxStatus) store.AnchorUpdate {
+	ts := status.Timestamp
+	return store.AnchorUpdate{
+		Status:      state,
+		TxHash:      txHash,
+		BlockNumber: status.BlockNumber,
+		BlockHash:   status.BlockHash,
+		BlockTime:   &ts,
+	}
+}
+
+// sameBlock reports whether status is in the block recorded as blockHash.
+func sameBlock(blockHash *string, status *TxStatus) bool {
+	return blockHash != nil && *blockHash == status.BlockHash
+}
+
+// replaceIfStuck replaces the batch's latest transaction with one paying
+// higher fees once it has been pending for replaceAfter.
+func (w *ConfirmationWorker) replaceIfStuck(ctx context.Context, anchorer Anchorer, batch *store.AnchorBatch, history []*store.AnchorTransaction) {
+	replacer, ok := anchorer.(Replacer)
+	if !ok || w.replaceAfter <= 0 {
+		return
+	}
+	latest := history[len(history)-1]
+	if time.Since(latest.CreatedAt) < w.replaceAfter || len(history) > maxReplacements {
+		return
+	}
+	prev := txParams(latest)
+	if prev == nil {
+		return // sent before fees were recorded
+	}
+
+	receipt, err := replacer.ReplaceTransaction(ctx, batch.MerkleRoot, prev)
+	if err != nil {
+		// Typically the old transaction was mined in the meantime, which
+		// the next poll picks up
+		log.Printf("Confirmation worker: failed to replace tx %s of batch %s: %v", latest.TxHash, batch.ID, err)
+		return
+	}
+	if err := w.store.ReplaceAnchorBatchTx(ctx, batch.ID, latest.TxHash, sentTransaction(receipt)); err != nil {
+		log.Printf("Confirmation worker: failed to record replacement %s for batch %s: %v", receipt.Ref, batch.ID, err)
+		return
+	}
+	log.Printf("Confirmation worker: replaced stuck tx %s of batch %s with %s", latest.TxHash, batch.ID, receipt.Ref)
+}
+
+// sentTransaction is the history entry for a receipt.
+func sentTransaction(receipt *Receipt) *store.AnchorTransaction {
+	t := &store.AnchorTransaction{TxHash: receipt.Ref}
+	if p := receipt.Tx; p != nil {
+		nonce, gasLimit := int64(p.Nonce), int64(p.GasLimit)
+		tip, feeCap := p.GasTipCap.String(), p.GasFeeCap.String()
+		t.Nonce, t.GasLimit, t.GasTipCap, t.GasFeeCap = &nonce, &gasLimit, &tip, &feeCap
+	}
+	return t
+}
+
+// txParams reads back the nonce and fees of a history entry, or returns nil
+// if they were not recorded.
+func txParams(t *store.AnchorTransaction) *TxParams {
+	if t.Nonce == nil || t.GasLimit == nil || t.GasTipCap == nil || t.GasFeeCap == nil {
+		return nil
+	}
+	tip, ok := new(big.Int).SetString(*t.GasTipCap, 10)
+	if !ok {
+		return nil
+	}
+	feeCap, ok := new(big.Int).SetString(*t.GasFeeCap, 10)
+	if !ok {
+		return nil
+	}
+	return &TxParams{Nonce: uint64(*t.Nonce), GasLimit: uint64(*t.GasLimit), GasTipCap: tip, GasFeeCap: feeCap}
+}
+
+// batchEvents returns an anchor.confirmed event for each anchor of a batch
+// recorded as txHash, or an anchor.failed event if txErr is set.
+func batchEvents(anchors []*store.ContentAnchor, txHash string, blockNumber int64, ts time.Time, txErr error) []*store.OutboxEvent {
+	events := make([]*store.OutboxEvent, 0, len(anchors))
+	for _, anchor := range anchors {
+		if txErr != nil {
+			events = append(events, store.NewEvent(anchor.UserID, webhook.EventAnchorFailed, webhook.AnchorFailed{
+				AnchorID:    anchor.ID,
+				ContentID:   anchor.ContentID,
+				ContentHash: anchor.ContentHash,
+				Chain:       anchor.Chain,
+				TxHash:      &txHash,
+				Error:       txErr.Error(),
+			}))
+			continue
 		}
+		events = append(events, store.NewEvent(anchor.UserID, webhook.EventAnchorConfirmed, webhook.AnchorConfirmed{
+			AnchorID:    anchor.ID,
+			ContentID:   anchor.ContentID,
+			ContentHash: anchor.ContentHash,
+			Chain:       anchor.Chain,
+			TxHash:      txHash,
+			BlockNumber: blockNumber,
+			ConfirmedAt: ts,
+		}))
 	}
+	return events
 }
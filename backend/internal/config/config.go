@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -43,6 +44,16 @@ type Config struct {
 	AzureStorageContainer        string
 	AzureStorageContentContainer string
 
+	StorageBackend   string
+	StorageLocalDir  string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKeyID    string
+	S3SecretKey      string
+	S3ForcePathStyle bool
+	S3PublicURL      string
+
 	SMTPHost     string
 	SMTPPort     string
 	SMTPUsername string
@@ -60,8 +71,32 @@ type Config struct {
 	BlockchainRPCURL     string
 	BlockchainPrivateKey string
 	BlockchainChainID    string
+	BlockchainChainName  string
+	AnchorBatchWindow    string
+	AnchorBatchSize      int
+	AnchorReplaceAfter   string
+	AnchorSimulated      bool
+	AnchorTSAURL         string
+	AnchorDefaultChain   string
+
+	// BlockchainConfirmations is 0 for the default; AnchorEVMChains is a
+	// JSON array of further EVM chains to anchor on
+	BlockchainConfirmations int
+	AnchorEVMChains         string
+
+	ProofSigningKey   string
+	ProofPreviousKeys string
 
 	TokensTransferable bool
+
+	AnomalyScoreDamping bool
+
+	WebhookConcurrency       int
+	WebhookPerEndpoint       int
+	WebhookDisableAfterHours int
+
+	OutboundRequireHTTPS bool
+	OutboundAllowPrivate bool
 }
 
 func Load() (*Config, error) {
@@ -94,6 +129,16 @@ func Load() (*Config, error) {
 		AzureStorageContainer:        getEnv("AZURE_STORAGE_CONTAINER", "avatars"),
 		AzureStorageContentContainer: getEnv("AZURE_STORAGE_CONTENT_CONTAINER", "vault"),
 
+		StorageBackend:   os.Getenv("STORAGE_BACKEND"),
+		StorageLocalDir:  getEnv("STORAGE_LOCAL_DIR", "data/uploads"),
+		S3Endpoint:       os.Getenv("S3_ENDPOINT"),
+		S3Region:         getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:         os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:    os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretKey:      os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3ForcePathStyle: os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+		S3PublicURL:      os.Getenv("S3_PUBLIC_URL"),
+
 		SMTPHost:     os.Getenv("SMTP_HOST"),
 		SMTPPort:     getEnv("SMTP_PORT", "587"),
 		SMTPUsername: os.Getenv("SMTP_USERNAME"),
@@ -111,8 +156,37 @@ func Load() (*Config, error) {
 		BlockchainRPCURL:     os.Getenv("BLOCKCHAIN_RPC_URL"),
 		BlockchainPrivateKey: os.Getenv("BLOCKCHAIN_PRIVATE_KEY"),
 		BlockchainChainID:    getEnv("BLOCKCHAIN_CHAIN_ID", "137"),
+		BlockchainChainName:  getEnv("BLOCKCHAIN_CHAIN_NAME", "base"),
+		AnchorBatchWindow:    getEnv("ANCHOR_BATCH_WINDOW", "10m"),
+		AnchorBatchSize:      getEnvInt("ANCHOR_BATCH_SIZE", 1000),
+		AnchorReplaceAfter:   getEnv("ANCHOR_TX_REPLACE_AFTER", "10m"),
+		AnchorSimulated:      os.Getenv("ANCHOR_SIMULATED") == "true",
+		AnchorTSAURL:         os.Getenv("ANCHOR_TSA_URL"),
+		AnchorDefaultChain:   os.Getenv("ANCHOR_DEFAULT_CHAIN"),
+
+		BlockchainConfirmations: getEnvInt("BLOCKCHAIN_CONFIRMATIONS", 0),
+		AnchorEVMChains:         os.Getenv("ANCHOR_EVM_CHAINS"),
+
+		ProofSigningKey:   os.Getenv("PROOF_SIGNING_KEY"),
+		ProofPreviousKeys: os.Getenv("PROOF_PREVIOUS_KEYS"),
 
 		TokensTransferable: os.Getenv("TOKENS_TRANSFERABLE") == "true",
+
+		AnomalyScoreDamping: os.Getenv("ANOMALY_SCORE_DAMPING") == "true",
+
+		WebhookConcurrency: getEnvInt("WEBHOOK_CONCURRENCY", 8),
+		WebhookPerEndpoint: getEnvInt("WEBHOOK_PER_ENDPOINT", 1),
+
+		WebhookDisableAfterHours: getEnvInt("WEBHOOK_DISABLE_AFTER_HOURS", 72),
+
+		OutboundAllowPrivate: os.Getenv("OUTBOUND_ALLOW_PRIVATE") == "true",
+	}
+
+	// Deployments that serve secure cookies are production; require https
+	// for user-supplied outbound URLs there unless told otherwise
+	cfg.OutboundRequireHTTPS = cfg.CookieSecure
+	if v := os.Getenv("OUTBOUND_REQUIRE_HTTPS"); v != "" {
+		cfg.OutboundRequireHTTPS = v == "true"
 	}
 
 	cfg.GoogleRedirect = cfg.BackendURL + "/api/auth/google/callback"
@@ -124,6 +198,15 @@ func Load() (*Config, error) {
 	cfg.DribbbleRedirect = cfg.BackendURL + "/api/connections/dribbble/callback"
 	cfg.BehanceRedirect = cfg.BackendURL + "/api/connections/behance/callback"
 
+	// Default to Azure when credentials are present, otherwise local disk
+	if cfg.StorageBackend == "" {
+		if cfg.AzureStorageAccount != "" && cfg.AzureStorageKey != "" {
+			cfg.StorageBackend = "azure"
+		} else {
+			cfg.StorageBackend = "local"
+		}
+	}
+
 	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL is required")
 	}
@@ -136,6 +219,10 @@ func Load() (*Config, error) {
 	if cfg.GoogleSecret == "" {
 		return nil, fmt.Errorf("GOOGLE_CLIENT_SECRET is required")
 	}
+	// Bundles signed with a temporary key stop verifying on restart
+	if cfg.CookieSecure && cfg.ProofSigningKey == "" {
+		return nil, fmt.Errorf("PROOF_SIGNING_KEY is required when COOKIE_SECURE is set")
+	}
 
 	if cfg.GitHubClientID == "" || cfg.GitHubSecret == "" {
 		log.Println("Warning: GITHUB_CLIENT_ID or GITHUB_CLIENT_SECRET not set. GitHub connections will be unavailable.")
@@ -150,3 +237,10 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
@@ -100,7 +100,7 @@ func EmailVerificationEmail(name, verifyURL string) (subject, body string) {
 	subject = "Verify your email on Creatrid"
 	content := fmt.Sprintf(`
 <h2 style="margin:0 0 16px;color:#18181b;font-size:22px;">Verify Your Email</h2>
-<p style="color:#52525b;line-height:1.6;">Hi %s, please verify your email address to complete your Creator Passport and earn 10 bonus points on your Creator Score.</p>
+<p style="color:#52525b;line-height:1.6;">Hi %s, please verify your email address to complete your Creator Passport and boost your Creator Score.</p>
 <div style="margin:24px 0;">
 <a href="%s" style="display:inline-block;background:#18181b;color:#fff;padding:12px 24px;border-radius:8px;text-decoration:none;font-weight:600;font-size:14px;">Verify Email</a>
 </div>
@@ -244,3 +244,21 @@ func ProfileMilestoneEmail(name, milestone, dashboardURL string) (subject, body
 	body = wrapHTMLWithPreheader(subject, fmt.Sprintf("You've reached %s on Creatrid!", milestone), content)
 	return
 }
+
+func WebhookDisabledEmail(name, endpointURL, reason, webhooksURL string) (subject, body string) {
+	subject = "A webhook endpoint has been disabled"
+	content := fmt.Sprintf(`
+<h2 style="margin:0 0 16px;color:#18181b;font-size:22px;">Webhook Disabled</h2>
+<p style="color:#52525b;line-height:1.6;">Hi %s, we stopped sending events to your webhook endpoint because every delivery to it has been failing:</p>
+<div style="margin:16px 0;padding:16px;background:#fef2f2;border-radius:8px;border-left:4px solid #ef4444;">
+<p style="margin:0 0 8px;color:#18181b;font-weight:600;word-break:break-all;">%s</p>
+<p style="margin:0;color:#991b1b;line-height:1.6;"><strong>Reason:</strong> %s</p>
+</div>
+<p style="color:#52525b;line-height:1.6;">Events are no longer being queued for this endpoint. Once it is reachable again, re-enable it and the deliveries that failed during the outage will be sent again.</p>
+<div style="margin:24px 0;">
+<a href="%s" style="display:inline-block;background:#18181b;color:#fff;padding:12px 24px;border-radius:8px;text-decoration:none;font-weight:600;font-size:14px;">Manage Webhooks</a>
+</div>
+`, name, endpointURL, reason, webhooksURL)
+	body = wrapHTMLWithPreheader(subject, "Deliveries to your webhook endpoint keep failing", content)
+	return
+}
@@ -0,0 +1,189 @@
+// Package events hands domain events recorded in the outbox to the parts of
+// the system that react to them: webhooks, in-app notifications, SSE pushes
+// and email.
+//
+// Store methods write events in the same transaction as the change they
+// describe, so an event exists if and only if its change committed. The
+// relay then delivers each event at least once to every subscriber, and a
+// subscriber that fails is retried on its own, without running the ones that
+// already succeeded again.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/nrednav/cuid2"
+)
+
+// retryIntervals is the wait before each retry of an event with a failing
+// subscriber. Once they are used up the event is marked failed and left in
+// the outbox for inspection.
+var retryIntervals = []time.Duration{
+	10 * time.Second,
+	1 * time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+}
+
+const (
+	// batchSize is how many events the relay claims at once.
+	batchSize = 100
+
+	// leaseDuration is how long claimed events belong to a relay. A batch
+	// whose lease lapses, because its relay died or its subscribers are very
+	// slow, is picked up again by another relay.
+	leaseDuration = 2 * time.Minute
+)
+
+// All subscribes to every event type.
+const All = "*"
+
+// Subscriber handles one event. Returning an error retries the event later.
+// Events are delivered at least once, so a subscriber may see an event again
+// after a crash and should tolerate that.
+type Subscriber func(ctx context.Context, ev *store.OutboxEvent) error
+
+type subscription struct {
+	eventType string
+	name      string
+	fn        Subscriber
+}
+
+// Relay polls the outbox and passes each event to its subscribers, oldest
+// first. Events are leased in the database, so any number of replicas can
+// run a relay.
+type Relay struct {
+	store    store.OutboxRepository
+	id       string
+	interval time.Duration
+	subs     []subscription
+	stopCh   chan struct{}
+}
+
+// NewRelay creates a relay reading from st.
+func NewRelay(st store.OutboxRepository) *Relay {
+	host, _ := os.Hostname()
+	return &Relay{
+		store:    st,
+		id:       fmt.Sprintf("%s-%s", host, cuid2.Generate()),
+		interval: 2 * time.Second,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Subscribe registers fn for events of eventType, or for every event with
+// All. name is recorded against each event fn has handled, so it must be
+// unique and should not change between releases. It must be called before
+// Start.
+func (r *Relay) Subscribe(eventType, name string, fn Subscriber) {
+	for _, s := range r.subs {
+		if s.name == name {
+			panic("events: duplicate subscriber " + name)
+		}
+	}
+	r.subs = append(r.subs, subscription{eventType: eventType, name: name, fn: fn})
+}
+
+// Start begins the polling loop. It blocks until the context is canceled or
+// Stop is called.
+func (r *Relay) Start(ctx context.Context) {
+	log.Printf("Event relay %s started (%d subscribers)", r.id, len(r.subs))
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Event relay stopped (context canceled)")
+			return
+		case <-r.stopCh:
+			log.Println("Event relay stopped")
+			return
+		case <-ticker.C:
+			r.processPending(ctx)
+		}
+	}
+}
+
+// Stop signals the relay to stop its polling loop.
+func (r *Relay) Stop() {
+	close(r.stopCh)
+}
+
+// processPending handles claimed batches until the outbox has nothing ready.
+func (r *Relay) processPending(ctx context.Context) {
+	for {
+		batch, err := r.store.ClaimOutboxEvents(ctx, r.id, batchSize, leaseDuration)
+		if err != nil {
+			log.Printf("Event relay: failed to claim events: %v", err)
+			return
+		}
+		for _, ev := range batch {
+			r.handle(ctx, ev)
+		}
+		if len(batch) < batchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// handle passes ev to every matching subscriber that has not handled it yet,
+// then records the outcome.
+func (r *Relay) handle(ctx context.Context, ev *store.OutboxEvent) {
+	done := append([]string(nil), ev.Done...)
+	handled := make(map[string]bool, len(done))
+	for _, name := range done {
+		handled[name] = true
+	}
+
+	var errs []string
+	for _, s := range r.subs {
+		if handled[s.name] || (s.eventType != All && s.eventType != ev.Type) {
+			continue
+		}
+		if err := s.fn(ctx, ev); err != nil {
+			log.Printf("Event relay: %s failed on %s event %d: %v", s.name, ev.Type, ev.ID, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", s.name, err))
+			continue
+		}
+		done = append(done, s.name)
+	}
+
+	if len(errs) == 0 {
+		if err := r.store.CompleteOutboxEvent(ctx, ev.ID, r.id); err != nil {
+			log.Printf("Event relay: failed to complete event %d: %v", ev.ID, err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if ev.Attempts < len(retryIntervals) {
+		t := time.Now().Add(retryIntervals[ev.Attempts])
+		retryAt = &t
+	} else {
+		log.Printf("Event relay: giving up on %s event %d after %d attempts", ev.Type, ev.ID, ev.Attempts+1)
+	}
+	if err := r.store.FailOutboxEvent(ctx, ev.ID, r.id, done, strings.Join(errs, "; "), retryAt); err != nil {
+		log.Printf("Event relay: failed to record failure of event %d: %v", ev.ID, err)
+	}
+}
+
+// Decode unmarshals an event's data into v.
+func Decode(ev *store.OutboxEvent, v interface{}) error {
+	raw, ok := ev.Data.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(ev.Data); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noBackoff makes failed events ready again straight away.
+func noBackoff(t *testing.T, attempts int) {
+	t.Helper()
+	saved := retryIntervals
+	retryIntervals = make([]time.Duration, attempts)
+	t.Cleanup(func() { retryIntervals = saved })
+}
+
+func TestRelay_FansOutInOrder(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	require.NoError(t, st.PublishEvents(ctx,
+		store.NewEvent("u1", "tip.received", map[string]int{"amountCents": 500}),
+		store.NewEvent("u2", "collaboration.responded", map[string]string{"status": "accepted"}),
+	))
+
+	var all, tips []string
+	r := NewRelay(st)
+	r.Subscribe(All, "all", func(ctx context.Context, ev *store.OutboxEvent) error {
+		all = append(all, ev.UserID+" "+ev.Type)
+		return nil
+	})
+	r.Subscribe("tip.received", "tips", func(ctx context.Context, ev *store.OutboxEvent) error {
+		var p struct {
+			AmountCents int `json:"amountCents"`
+		}
+		require.NoError(t, Decode(ev, &p))
+		tips = append(tips, ev.UserID)
+		assert.Equal(t, 500, p.AmountCents)
+		return nil
+	})
+
+	r.processPending(ctx)
+	assert.Equal(t, []string{"u1 tip.received", "u2 collaboration.responded"}, all)
+	assert.Equal(t, []string{"u1"}, tips)
+
+	r.processPending(ctx)
+	assert.Len(t, all, 2, "handled events are not delivered again")
+}
+
+func TestRelay_RetriesOnlyFailedSubscribers(t *testing.T) {
+	noBackoff(t, 5)
+	ctx := context.Background()
+	st := storetest.New()
+	require.NoError(t, st.PublishEvents(ctx, store.NewEvent("u1", "license.sold", nil)))
+
+	var notified, emailed int
+	r := NewRelay(st)
+	r.Subscribe(All, "notify", func(ctx context.Context, ev *store.OutboxEvent) error {
+		notified++
+		return nil
+	})
+	r.Subscribe(All, "email", func(ctx context.Context, ev *store.OutboxEvent) error {
+		emailed++
+		if emailed < 3 {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	})
+
+	for i := 0; i < 4; i++ {
+		r.processPending(ctx)
+	}
+	assert.Equal(t, 1, notified)
+	assert.Equal(t, 3, emailed)
+
+	evs := st.Events()
+	require.Len(t, evs, 1)
+	assert.Equal(t, []string{"notify"}, evs[0].Done, "done holds subscribers from failed attempts")
+}
+
+func TestRelay_GivesUpAfterRetries(t *testing.T) {
+	noBackoff(t, 2)
+	ctx := context.Background()
+	st := storetest.New()
+	require.NoError(t, st.PublishEvents(ctx, store.NewEvent("u1", "payout.updated", nil)))
+
+	calls := 0
+	r := NewRelay(st)
+	r.Subscribe(All, "broken", func(ctx context.Context, ev *store.OutboxEvent) error {
+		calls++
+		return errors.New("down")
+	})
+
+	for i := 0; i < 5; i++ {
+		r.processPending(ctx)
+	}
+	assert.Equal(t, 3, calls, "the first attempt and one per retry interval")
+}
+
+func TestRelay_DuplicateSubscriberPanics(t *testing.T) {
+	r := NewRelay(storetest.New())
+	fn := func(ctx context.Context, ev *store.OutboxEvent) error { return nil }
+	r.Subscribe(All, "webhooks", fn)
+	assert.Panics(t, func() { r.Subscribe("tip.received", "webhooks", fn) })
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/creatrid/creatrid/internal/store"
 )
 
-func adminAudit(st *store.Store, r *http.Request, action, targetType, targetID string, details map[string]interface{}) {
+func adminAudit(st store.AuditRepository, r *http.Request, action, targetType, targetID string, details map[string]interface{}) {
 	user := middleware.UserFromContext(r.Context())
 	if user == nil {
 		return
@@ -13,11 +13,19 @@ import (
 	"github.com/nrednav/cuid2"
 )
 
+// AgencyStore is the storage AgencyHandler depends on.
+type AgencyStore interface {
+	store.AgencyRepository
+	store.UserRepository
+	store.APIUsageRepository
+	store.AuditRepository
+}
+
 type AgencyHandler struct {
-	store *store.Store
+	store AgencyStore
 }
 
-func NewAgencyHandler(st *store.Store) *AgencyHandler {
+func NewAgencyHandler(st AgencyStore) *AgencyHandler {
 	return &AgencyHandler{store: st}
 }
 
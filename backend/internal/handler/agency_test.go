@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgencyHandler_Create(t *testing.T) {
+	st := storetest.New()
+	h := NewAgencyHandler(st)
+	owner := seedUser(st, "owner", "owner")
+
+	rr := serve(t, h.Create, http.MethodPost, "/api/agency", "/api/agency", map[string]string{"name": ""}, owner)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(t, h.Create, http.MethodPost, "/api/agency", "/api/agency", map[string]string{"name": "Talent Co"}, owner)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	agency := decode(t, rr)["agency"].(map[string]interface{})
+	assert.Equal(t, float64(10), agency["maxCreators"])
+
+	updated, err := st.FindUserByID(context.Background(), owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleBrand, updated.Role)
+
+	rr = serve(t, h.Create, http.MethodPost, "/api/agency", "/api/agency", map[string]string{"name": "Again"}, owner)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestAgencyHandler_InviteFlow(t *testing.T) {
+	st := storetest.New()
+	h := NewAgencyHandler(st)
+	owner := seedUser(st, "owner", "owner")
+	creator := seedUser(st, "creator", "creator")
+	other := seedUser(st, "other", "other")
+
+	rr := serve(t, h.Create, http.MethodPost, "/api/agency", "/api/agency", map[string]string{"name": "Talent Co"}, owner)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = serve(t, h.Invite, http.MethodPost, "/api/agency/creators", "/api/agency/creators",
+		map[string]string{"username": "nobody"}, owner)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serve(t, h.Invite, http.MethodPost, "/api/agency/creators", "/api/agency/creators",
+		map[string]string{"username": "creator"}, owner)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	inviteID := decode(t, rr)["id"].(string)
+
+	rr = serve(t, h.Invite, http.MethodPost, "/api/agency/creators", "/api/agency/creators",
+		map[string]string{"username": "creator"}, owner)
+	assert.Equal(t, http.StatusConflict, rr.Code, "duplicate invite")
+
+	rr = serve(t, h.ListInvites, http.MethodGet, "/api/agency/invites", "/api/agency/invites", nil, creator)
+	require.Equal(t, http.StatusOK, rr.Code)
+	invites := decode(t, rr)["invites"].([]interface{})
+	require.Len(t, invites, 1)
+	assert.Equal(t, "Talent Co", invites[0].(map[string]interface{})["agencyName"])
+
+	respond := "/api/agency/invites/" + inviteID
+	rr = serve(t, h.RespondToInvite, http.MethodPost, "/api/agency/invites/{id}", respond,
+		map[string]string{"action": "accept"}, other)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serve(t, h.RespondToInvite, http.MethodPost, "/api/agency/invites/{id}", respond,
+		map[string]string{"action": "maybe"}, creator)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(t, h.RespondToInvite, http.MethodPost, "/api/agency/invites/{id}", respond,
+		map[string]string{"action": "accept"}, creator)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(t, h.RespondToInvite, http.MethodPost, "/api/agency/invites/{id}", respond,
+		map[string]string{"action": "decline"}, creator)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "already answered")
+
+	rr = serve(t, h.ListCreators, http.MethodGet, "/api/agency/creators", "/api/agency/creators", nil, owner)
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := decode(t, rr)
+	assert.Equal(t, float64(1), body["total"])
+	assert.Equal(t, "creator", body["creators"].([]interface{})[0].(map[string]interface{})["creatorUsername"])
+
+	rr = serve(t, h.Get, http.MethodGet, "/api/agency", "/api/agency", nil, owner)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, float64(1), decode(t, rr)["stats"].(map[string]interface{})["totalCreators"])
+
+	rr = serve(t, h.RemoveCreator, http.MethodDelete, "/api/agency/creators/{creatorId}", "/api/agency/creators/creator", nil, owner)
+	require.Equal(t, http.StatusOK, rr.Code)
+	count, err := st.CountActiveAgencyCreators(context.Background(), agencyIDFor(t, st, owner.ID))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func agencyIDFor(t *testing.T, st *storetest.Store, userID string) string {
+	t.Helper()
+	agency, err := st.FindAgencyByUserID(context.Background(), userID)
+	require.NoError(t, err)
+	require.NotNil(t, agency)
+	return agency.ID
+}
+
+func TestAgencyHandler_InviteLimit(t *testing.T) {
+	st := storetest.New()
+	h := NewAgencyHandler(st)
+	owner := seedUser(st, "owner", "owner")
+	seedUser(st, "c1", "c1")
+
+	rr := serve(t, h.Create, http.MethodPost, "/api/agency", "/api/agency", map[string]string{"name": "Tiny"}, owner)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	agencyID := agencyIDFor(t, st, owner.ID)
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		id := string(rune('a' + i))
+		seedUser(st, "member-"+id, "member-"+id)
+		require.NoError(t, st.InviteCreator(ctx, "inv-"+id, agencyID, "member-"+id))
+		require.NoError(t, st.RespondToAgencyInvite(ctx, "inv-"+id, true))
+	}
+
+	rr = serve(t, h.Invite, http.MethodPost, "/api/agency/creators", "/api/agency/creators",
+		map[string]string{"username": "c1"}, owner)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAgencyHandler_BulkVerify(t *testing.T) {
+	st := storetest.New()
+	h := NewAgencyHandler(st)
+	admin := st.AddUser(&model.User{ID: "admin", Email: "admin@example.com", Role: model.RoleAdmin})
+	creator := seedUser(st, "creator", "creator")
+
+	body := map[string][]string{"creatorIds": {creator.ID}}
+	rr := serve(t, h.BulkVerify, http.MethodPost, "/api/admin/verify", "/api/admin/verify", body, creator)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serve(t, h.BulkVerify, http.MethodPost, "/api/admin/verify", "/api/admin/verify",
+		map[string][]string{"creatorIds": {}}, admin)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(t, h.BulkVerify, http.MethodPost, "/api/admin/verify", "/api/admin/verify", body, admin)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	ctx := context.Background()
+	verified, err := st.FindUserByID(ctx, creator.ID)
+	require.NoError(t, err)
+	assert.True(t, verified.IsVerified)
+
+	entries, total, err := st.ListAuditLog(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, "bulk_verify_creators", entries[0].Action)
+}
+
+func TestAgencyHandler_APIUsage(t *testing.T) {
+	st := storetest.New()
+	h := NewAgencyHandler(st)
+	owner := seedUser(st, "owner", "owner")
+
+	ctx := context.Background()
+	st.AddAPIKey("key1", owner.ID)
+	require.NoError(t, st.RecordAPIUsage(ctx, "key1", "/api/v1/verify", "GET", 200, 12))
+	require.NoError(t, st.RecordAPIUsage(ctx, "key1", "/api/v1/verify", "GET", 200, 9))
+	require.NoError(t, st.RecordAPIUsage(ctx, "key2", "/api/v1/verify", "GET", 200, 9))
+
+	rr := serve(t, h.APIUsage, http.MethodGet, "/api/agency/usage", "/api/agency/usage?days=7", nil, owner)
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := decode(t, rr)
+	assert.Equal(t, float64(2), body["totalCalls"])
+	assert.Len(t, body["byEndpoint"].([]interface{}), 1)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/creatrid/creatrid/internal/geoip"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -46,13 +47,11 @@ func (h *AnalyticsHandler) TrackView(w http.ResponseWriter, r *http.Request) {
 		city = geo.City
 	}
 
-	_ = h.store.RecordProfileView(r.Context(), user.ID, ip, referrer, ua.Browser, ua.OS, ua.DeviceType, country, city)
-
-	// Dispatch webhook event for profile view
-	dispatchWebhook(user.ID, "profile.viewed", map[string]interface{}{
-		"username": username,
-		"referrer": referrer,
-	})
+	_ = h.store.RecordProfileView(r.Context(), user.ID, ip, referrer, ua.Browser, ua.OS, ua.DeviceType, country, city,
+		store.NewEvent(user.ID, webhook.EventProfileViewed, webhook.ProfileViewed{
+			Username: username,
+			Referrer: referrer,
+		}))
 
 	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
 }
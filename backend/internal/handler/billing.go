@@ -12,23 +12,33 @@ import (
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/nrednav/cuid2"
 	"github.com/stripe/stripe-go/v81"
 	portalsession "github.com/stripe/stripe-go/v81/billingportal/session"
 	checkoutsession "github.com/stripe/stripe-go/v81/checkout/session"
 	"github.com/stripe/stripe-go/v81/customer"
-	"github.com/stripe/stripe-go/v81/webhook"
+	"github.com/stripe/stripe-go/v81/refund"
+	stripewebhook "github.com/stripe/stripe-go/v81/webhook"
 )
 
+// BillingStore is the storage BillingHandler depends on.
+type BillingStore interface {
+	store.UserRepository
+	store.ContentRepository
+	store.TokenRepository
+	store.LicenseRepository
+	store.PaymentRepository
+}
+
 type BillingHandler struct {
-	store  *store.Store
+	store  BillingStore
 	config *config.Config
-	hub    *SSEHub
 }
 
-func NewBillingHandler(store *store.Store, cfg *config.Config, hub *SSEHub) *BillingHandler {
+func NewBillingHandler(store BillingStore, cfg *config.Config) *BillingHandler {
 	stripe.Key = cfg.StripeSecretKey
-	return &BillingHandler{store: store, config: cfg, hub: hub}
+	return &BillingHandler{store: store, config: cfg}
 }
 
 type checkoutRequest struct {
@@ -169,7 +179,7 @@ func (h *BillingHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := webhook.ConstructEvent(body, r.Header.Get("Stripe-Signature"), h.config.StripeWebhookSecret)
+	event, err := stripewebhook.ConstructEvent(body, r.Header.Get("Stripe-Signature"), h.config.StripeWebhookSecret)
 	if err != nil {
 		log.Printf("Stripe webhook signature verification failed: %v", err)
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid signature"})
@@ -188,6 +198,9 @@ func (h *BillingHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Settlement handlers return an error when the database fails, so the
+	// event is not recorded and Stripe retries it
+	var handleErr error
 	switch event.Type {
 	case "checkout.session.completed":
 		h.handleCheckoutCompleted(r, event)
@@ -197,6 +210,21 @@ func (h *BillingHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		h.handleSubscriptionDeleted(r, event)
 	case "invoice.payment_failed":
 		h.handlePaymentFailed(r, event)
+	case "payment_intent.succeeded":
+		handleErr = h.handlePaymentIntentSucceeded(r, event)
+	case "payment_intent.payment_failed":
+		handleErr = h.handlePaymentIntentFailed(r, event)
+	case "charge.refunded":
+		handleErr = h.handleChargeRefunded(r, event)
+	case "transfer.created":
+		h.handleTransfer(r, event, "completed")
+	case "transfer.reversed":
+		h.handleTransfer(r, event, "reversed")
+	}
+	if handleErr != nil {
+		log.Printf("Stripe webhook %s (%s) failed: %v", event.ID, event.Type, handleErr)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to process event"})
+		return
 	}
 
 	// Save event for audit trail
@@ -310,36 +338,30 @@ func (h *BillingHandler) handleLicensePurchaseCompleted(r *http.Request, session
 		CreatedAt:          time.Now(),
 	}
 
-	if err := h.store.CreateLicensePurchase(r.Context(), purchase); err != nil {
+	events := []*store.OutboxEvent{store.NewEvent(buyerUserID, webhook.EventLicensePurchased, webhook.LicensePurchased{
+		PurchaseID:  purchase.ID,
+		ContentID:   contentID,
+		OfferingID:  offeringID,
+		AmountCents: amountCents,
+	})}
+	content, err := h.store.FindContentItemByID(r.Context(), contentID)
+	if err == nil && content != nil {
+		events = append(events, store.NewEvent(content.UserID, webhook.EventLicenseSold, webhook.LicenseSold{
+			PurchaseID:         purchase.ID,
+			ContentID:          contentID,
+			OfferingID:         offeringID,
+			BuyerUserID:        buyerUserID,
+			AmountCents:        amountCents,
+			CreatorPayoutCents: creatorPayoutCents,
+		}))
+	}
+
+	if err := h.store.CreateLicensePurchase(r.Context(), purchase, events...); err != nil {
 		log.Printf("Stripe webhook: failed to create license purchase: %v", err)
 		return
 	}
 
 	log.Printf("License purchase created: buyer=%s content=%s offering=%s amount=%d", buyerUserID, contentID, offeringID, amountCents)
-
-	// Notify the content creator about the sale
-	content, err := h.store.FindContentItemByID(r.Context(), contentID)
-	if err == nil && content != nil {
-		notif := &store.Notification{
-			ID:        cuid2.Generate(),
-			UserID:    content.UserID,
-			Type:      "license_sale",
-			Title:     "New license sale!",
-			Message:   fmt.Sprintf("Your content \"%s\" was licensed for $%.2f", content.Title, float64(amountCents)/100),
-			Data:      []byte(fmt.Sprintf(`{"contentId":"%s","purchaseId":"%s","amountCents":%d}`, contentID, purchase.ID, amountCents)),
-			CreatedAt: time.Now(),
-		}
-		if err := h.store.CreateNotification(r.Context(), notif); err != nil {
-			log.Printf("Failed to create sale notification: %v", err)
-		}
-
-		// Push real-time SSE notification
-		if h.hub != nil {
-			if data, err := json.Marshal(notif); err == nil {
-				h.hub.Notify(content.UserID, data)
-			}
-		}
-	}
 }
 
 func (h *BillingHandler) handleSubscriptionUpdated(r *http.Request, event stripe.Event) {
@@ -416,6 +438,143 @@ func (h *BillingHandler) handlePaymentFailed(r *http.Request, event stripe.Event
 	}
 }
 
+// handlePaymentIntentSucceeded settles a pending token purchase or tip,
+// minting the tokens or completing the tip. It returns an error if the
+// purchase could not be settled and the event should be retried.
+func (h *BillingHandler) handlePaymentIntentSucceeded(r *http.Request, event stripe.Event) error {
+	var pi stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+		log.Printf("Stripe webhook: failed to parse payment intent: %v", err)
+		return nil
+	}
+
+	// A tip's event is recorded with the confirmation, so look the tip up
+	// first. Replays find the purchase already settled and record nothing.
+	var events []*store.OutboxEvent
+	pending, err := h.store.FindPendingPurchaseByPaymentIntent(r.Context(), pi.ID)
+	if err != nil {
+		return fmt.Errorf("load purchase for %s: %w", pi.ID, err)
+	}
+	// A token purchase paid after its reservation lapsed was priced at a
+	// supply no longer held for it, so it is refunded instead of minted
+	cutoff := time.Now().Add(-purchaseReservation)
+	if pending != nil && pending.Kind == "token_purchase" && pending.Status == "pending" && pending.CreatedAt.Before(cutoff) {
+		return h.refundExpiredPurchase(r.Context(), pending, cutoff)
+	}
+	if pending != nil && pending.Kind == "tip" && pending.TipID != nil {
+		tip, err := h.store.FindTipByID(r.Context(), *pending.TipID)
+		if err != nil {
+			return fmt.Errorf("load tip %s: %w", *pending.TipID, err)
+		}
+		if tip == nil {
+			log.Printf("Stripe webhook: tip %s for %s not found", *pending.TipID, pi.ID)
+			return nil
+		}
+		events = append(events, store.NewEvent(tip.ToUserID, webhook.EventTipReceived, webhook.TipReceived{
+			TipID:       tip.ID,
+			FromUserID:  tip.FromUserID,
+			AmountCents: tip.AmountCents,
+			Message:     tip.Message,
+		}))
+	}
+
+	purchase, err := h.store.ConfirmPendingPurchase(r.Context(), pi.ID, events...)
+	if err != nil {
+		return fmt.Errorf("confirm purchase for %s: %w", pi.ID, err)
+	}
+	if purchase == nil {
+		// Not one of ours, or already settled by an earlier delivery.
+		return nil
+	}
+
+	log.Printf("Purchase confirmed: kind=%s id=%s user=%s amount=%d", purchase.Kind, purchase.ID, purchase.UserID, purchase.Amount)
+	return nil
+}
+
+// refundExpiredPurchase refunds the payment for a token purchase whose
+// reservation lapsed before it was paid, and marks the purchase expired.
+// The refund is keyed on the purchase, so a retried event does not refund
+// twice.
+func (h *BillingHandler) refundExpiredPurchase(ctx context.Context, p *store.PendingPurchase, cutoff time.Time) error {
+	params := &stripe.RefundParams{PaymentIntent: stripe.String(p.StripePaymentIntentID)}
+	params.SetIdempotencyKey("expired-purchase-" + p.ID)
+	if _, err := refund.New(params); err != nil {
+		return fmt.Errorf("refund expired purchase %s: %w", p.ID, err)
+	}
+	if _, err := h.store.ExpirePendingPurchase(ctx, p.StripePaymentIntentID, cutoff); err != nil {
+		return fmt.Errorf("expire purchase %s: %w", p.ID, err)
+	}
+	log.Printf("Purchase %s paid after its reservation lapsed; refunded", p.ID)
+	return nil
+}
+
+func (h *BillingHandler) handlePaymentIntentFailed(r *http.Request, event stripe.Event) error {
+	var pi stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+		log.Printf("Stripe webhook: failed to parse payment intent: %v", err)
+		return nil
+	}
+
+	if _, err := h.store.FailPendingPurchase(r.Context(), pi.ID); err != nil {
+		return fmt.Errorf("mark purchase failed for %s: %w", pi.ID, err)
+	}
+	return nil
+}
+
+// handleChargeRefunded reverses a settled token purchase or tip once its
+// charge is fully refunded. Partial refunds are left for manual handling.
+func (h *BillingHandler) handleChargeRefunded(r *http.Request, event stripe.Event) error {
+	var charge stripe.Charge
+	if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+		log.Printf("Stripe webhook: failed to parse charge: %v", err)
+		return nil
+	}
+	if charge.PaymentIntent == nil || charge.PaymentIntent.ID == "" {
+		return nil
+	}
+	if !charge.Refunded {
+		log.Printf("Stripe webhook: partial refund of %d cents on %s not reversed", charge.AmountRefunded, charge.PaymentIntent.ID)
+		return nil
+	}
+
+	purchase, err := h.store.RefundPendingPurchase(r.Context(), charge.PaymentIntent.ID)
+	if err != nil {
+		return fmt.Errorf("reverse purchase for %s: %w", charge.PaymentIntent.ID, err)
+	}
+	if purchase != nil {
+		log.Printf("Purchase refunded: kind=%s id=%s user=%s", purchase.Kind, purchase.ID, purchase.UserID)
+	}
+	return nil
+}
+
+// handleTransfer moves the creator payout paid out by a Connect transfer to
+// status.
+func (h *BillingHandler) handleTransfer(r *http.Request, event stripe.Event, status string) {
+	var transfer stripe.Transfer
+	if err := json.Unmarshal(event.Data.Raw, &transfer); err != nil {
+		log.Printf("Stripe webhook: failed to parse transfer: %v", err)
+		return
+	}
+
+	payout, err := h.store.FindPayoutByTransferID(r.Context(), transfer.ID)
+	if err != nil {
+		log.Printf("Stripe webhook: failed to find payout for transfer %s: %v", transfer.ID, err)
+		return
+	}
+	if payout == nil || payout.Status == status {
+		return
+	}
+
+	var errMsg *string
+	if status == "reversed" {
+		msg := fmt.Sprintf("transfer reversed (%d of %d reversed)", transfer.AmountReversed, transfer.Amount)
+		errMsg = &msg
+	}
+	if err := updatePayoutStatus(r.Context(), h.store, payout, status, errMsg); err != nil {
+		log.Printf("Stripe webhook: failed to update payout %s: %v", payout.ID, err)
+	}
+}
+
 func (h *BillingHandler) findOrCreateCustomer(r *http.Request, email, userID string) (string, error) {
 	// Check if we already have a subscription record with a customer ID
 	sub, err := h.store.FindSubscriptionByUserID(r.Context(), userID)
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/config"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v81"
+	stripewebhook "github.com/stripe/stripe-go/v81/webhook"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func newTestBillingHandler(st BillingStore) *BillingHandler {
+	return NewBillingHandler(st, &config.Config{StripeWebhookSecret: testWebhookSecret})
+}
+
+// stripeEvent delivers a signed Stripe event of eventType wrapping object to
+// h's webhook.
+func stripeEvent(t *testing.T, h *BillingHandler, eventID, eventType string, object interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	raw, err := json.Marshal(object)
+	require.NoError(t, err)
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":          eventID,
+		"object":      "event",
+		"api_version": stripe.APIVersion,
+		"type":        eventType,
+		"data":        map[string]json.RawMessage{"object": raw},
+	})
+	require.NoError(t, err)
+
+	signed := stripewebhook.GenerateTestSignedPayload(&stripewebhook.UnsignedPayload{Payload: payload, Secret: testWebhookSecret})
+	req := httptest.NewRequest(http.MethodPost, "/api/billing/webhook", bytes.NewReader(payload))
+	req.Header.Set("Stripe-Signature", signed.Header)
+	rr := httptest.NewRecorder()
+	h.HandleWebhook(rr, req)
+	return rr
+}
+
+// seedTokenPurchase records a pending purchase of amount tokens of tokenID
+// by userID, paid with PaymentIntent pi.
+func seedTokenPurchase(t *testing.T, st *storetest.Store, id, tokenID, userID, pi string, amount int) {
+	t.Helper()
+	now := time.Now()
+	require.NoError(t, st.CreatePendingPurchase(context.Background(), &store.PendingPurchase{
+		ID: id, Kind: "token_purchase", UserID: userID, TokenID: &tokenID, Amount: amount,
+		AmountCents: int64(amount) * 100, StripePaymentIntentID: pi, Status: "pending",
+		CreatedAt: now, UpdatedAt: now,
+	}))
+}
+
+func purchaseStatus(t *testing.T, st *storetest.Store, pi string) string {
+	t.Helper()
+	p, err := st.FindPendingPurchaseByPaymentIntent(context.Background(), pi)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	return p.Status
+}
+
+func balanceOf(t *testing.T, st *storetest.Store, tokenID, userID string) int {
+	t.Helper()
+	balance, err := st.GetTokenBalance(context.Background(), tokenID, userID)
+	require.NoError(t, err)
+	return balance
+}
+
+func TestBillingWebhook_ConfirmMintsOnce(t *testing.T) {
+	st := storetest.New()
+	h := newTestBillingHandler(st)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+	seedTokenPurchase(t, st, "p1", "tok1", bob.ID, "pi_1", 3)
+
+	succeeded := map[string]interface{}{"id": "pi_1", "object": "payment_intent"}
+	rr := stripeEvent(t, h, "evt_1", "payment_intent.succeeded", succeeded)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "succeeded", purchaseStatus(t, st, "pi_1"))
+	assert.Equal(t, 3, balanceOf(t, st, "tok1", bob.ID))
+
+	// The same event delivered again is skipped
+	rr = stripeEvent(t, h, "evt_1", "payment_intent.succeeded", succeeded)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "already_processed", decode(t, rr)["status"])
+
+	// So is another event for a purchase already settled
+	rr = stripeEvent(t, h, "evt_2", "payment_intent.succeeded", succeeded)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 3, balanceOf(t, st, "tok1", bob.ID), "tokens are minted once")
+
+	token, err := st.FindTokenByID(context.Background(), "tok1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, token.TotalSupply)
+}
+
+func TestBillingWebhook_ConfirmCompletesTip(t *testing.T) {
+	st := storetest.New()
+	h := newTestBillingHandler(st)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, st.CreateTip(ctx, &store.Tip{
+		ID: "tip1", FromUserID: bob.ID, ToUserID: alice.ID, AmountCents: 500, Status: "pending", CreatedAt: now,
+	}))
+	tipID := "tip1"
+	require.NoError(t, st.CreatePendingPurchase(ctx, &store.PendingPurchase{
+		ID: "p1", Kind: "tip", UserID: bob.ID, TipID: &tipID, AmountCents: 500,
+		StripePaymentIntentID: "pi_1", Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}))
+
+	rr := stripeEvent(t, h, "evt_1", "payment_intent.succeeded", map[string]interface{}{"id": "pi_1", "object": "payment_intent"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	tip, err := st.FindTipByID(ctx, "tip1")
+	require.NoError(t, err)
+	assert.Equal(t, "completed", tip.Status)
+	received := eventsOfType(st, webhook.EventTipReceived)
+	require.Len(t, received, 1)
+	assert.Equal(t, alice.ID, received[0].UserID)
+}
+
+func TestBillingWebhook_FailedPaymentMintsNothing(t *testing.T) {
+	st := storetest.New()
+	h := newTestBillingHandler(st)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+	seedTokenPurchase(t, st, "p1", "tok1", bob.ID, "pi_1", 3)
+
+	pi := map[string]interface{}{"id": "pi_1", "object": "payment_intent"}
+	rr := stripeEvent(t, h, "evt_1", "payment_intent.payment_failed", pi)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "failed", purchaseStatus(t, st, "pi_1"))
+
+	// A late success for a failed purchase does not mint
+	rr = stripeEvent(t, h, "evt_2", "payment_intent.succeeded", pi)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "failed", purchaseStatus(t, st, "pi_1"))
+	assert.Zero(t, balanceOf(t, st, "tok1", bob.ID))
+}
+
+func TestBillingWebhook_RefundBurnsPurchasedTokens(t *testing.T) {
+	st := storetest.New()
+	h := newTestBillingHandler(st)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+	seedTokenPurchase(t, st, "p1", "tok1", bob.ID, "pi_1", 3)
+
+	rr := stripeEvent(t, h, "evt_1", "payment_intent.succeeded", map[string]interface{}{"id": "pi_1", "object": "payment_intent"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 3, balanceOf(t, st, "tok1", bob.ID))
+
+	// A partial refund is left alone
+	charge := map[string]interface{}{"id": "ch_1", "object": "charge", "payment_intent": "pi_1", "amount_refunded": 100, "refunded": false}
+	rr = stripeEvent(t, h, "evt_2", "charge.refunded", charge)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "succeeded", purchaseStatus(t, st, "pi_1"))
+
+	charge["amount_refunded"], charge["refunded"] = 300, true
+	rr = stripeEvent(t, h, "evt_3", "charge.refunded", charge)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "refunded", purchaseStatus(t, st, "pi_1"))
+	assert.Zero(t, balanceOf(t, st, "tok1", bob.ID))
+
+	token, err := st.FindTokenByID(context.Background(), "tok1")
+	require.NoError(t, err)
+	assert.Zero(t, token.TotalSupply)
+}
+
+// failingConfirmStore fails to confirm purchases while fail is set.
+type failingConfirmStore struct {
+	*storetest.Store
+	fail bool
+}
+
+func (s *failingConfirmStore) ConfirmPendingPurchase(ctx context.Context, paymentIntentID string, events ...*store.OutboxEvent) (*store.PendingPurchase, error) {
+	if s.fail {
+		return nil, errors.New("connection reset")
+	}
+	return s.Store.ConfirmPendingPurchase(ctx, paymentIntentID, events...)
+}
+
+func TestBillingWebhook_SettlementErrorIsRetried(t *testing.T) {
+	st := &failingConfirmStore{Store: storetest.New(), fail: true}
+	h := newTestBillingHandler(st)
+	alice := seedUser(st.Store, "alice", "alice")
+	bob := seedUser(st.Store, "bob", "bob")
+	seedToken(t, st.Store, "tok1", alice.ID, "ALC", true)
+	seedTokenPurchase(t, st.Store, "p1", "tok1", bob.ID, "pi_1", 3)
+
+	succeeded := map[string]interface{}{"id": "pi_1", "object": "payment_intent"}
+	rr := stripeEvent(t, h, "evt_1", "payment_intent.succeeded", succeeded)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Stripe retries the event")
+	assert.Equal(t, "pending", purchaseStatus(t, st.Store, "pi_1"))
+
+	// The event was not recorded, so Stripe's retry settles the purchase
+	st.fail = false
+	rr = stripeEvent(t, h, "evt_1", "payment_intent.succeeded", succeeded)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "ok", decode(t, rr)["status"])
+	assert.Equal(t, "succeeded", purchaseStatus(t, st.Store, "pi_1"))
+	assert.Equal(t, 3, balanceOf(t, st.Store, "tok1", bob.ID))
+}
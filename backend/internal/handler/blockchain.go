@@ -1,31 +1,45 @@
 package handler
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/creatrid/creatrid/internal/blockchain"
+	"github.com/creatrid/creatrid/internal/imaging"
 	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/model"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/pkg/merkle"
 	"github.com/go-chi/chi/v5"
 	"github.com/nrednav/cuid2"
 )
 
 type BlockchainHandler struct {
 	store     *store.Store
-	anchorSvc *blockchain.AnchorService
+	anchorers *blockchain.Registry
 }
 
-func NewBlockchainHandler(st *store.Store, anchorSvc *blockchain.AnchorService) *BlockchainHandler {
+func NewBlockchainHandler(st *store.Store, anchorers *blockchain.Registry) *BlockchainHandler {
 	return &BlockchainHandler{
 		store:     st,
-		anchorSvc: anchorSvc,
+		anchorers: anchorers,
 	}
 }
 
-// Anchor handles POST /api/content/{id}/anchor — anchor content on blockchain.
+// Anchor handles POST /api/content/{id}/anchor — queue content for anchoring.
+// The optional body {"chain": "rfc3161"} picks one of the configured chains;
+// without it the default is used. The anchor stays pending, with no
+// transaction, until its batch is sent. Anchors count against the creator
+// tier's monthly quota.
 func (h *BlockchainHandler) Anchor(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	if user == nil {
@@ -33,11 +47,27 @@ func (h *BlockchainHandler) Anchor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.anchorSvc == nil {
+	anchorer := h.anchorers.Default()
+	if anchorer == nil {
 		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Blockchain anchoring is not configured"})
 		return
 	}
 
+	var req struct {
+		Chain string `json:"chain"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+	if req.Chain != "" {
+		var ok bool
+		if anchorer, ok = h.anchorers.Get(req.Chain); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Unsupported chain"})
+			return
+		}
+	}
+
 	contentID := chi.URLParam(r, "id")
 	if contentID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Content ID is required"})
@@ -59,6 +89,10 @@ func (h *BlockchainHandler) Anchor(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not authorized"})
 		return
 	}
+	if item.DisputeStatus != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Content ownership is disputed"})
+		return
+	}
 
 	// Check if already anchored
 	existing, err := h.store.FindAnchorByContentID(r.Context(), contentID)
@@ -72,38 +106,43 @@ func (h *BlockchainHandler) Anchor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Submit transaction to blockchain
-	txHash, err := h.anchorSvc.AnchorHash(r.Context(), item.HashSHA256)
-	if err != nil {
-		log.Printf("Blockchain anchor error: %v", err)
-		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Blockchain anchoring failed: " + err.Error()})
-		return
-	}
-
-	now := time.Now()
+	// Queue the hash; the batcher anchors it with the next Merkle batch
 	anchor := &store.ContentAnchor{
 		ID:           cuid2.Generate(),
 		ContentID:    contentID,
 		UserID:       user.ID,
 		ContentHash:  item.HashSHA256,
-		TxHash:       &txHash,
-		Chain:        "base",
+		Chain:        anchorer.Chain(),
 		AnchorStatus: "pending",
-		CreatedAt:    now,
+		CreatedAt:    time.Now(),
 	}
 
-	if err := h.store.CreateContentAnchor(r.Context(), anchor); err != nil {
+	quota, since := blockchain.Quota(user.CreatorTier, anchor.CreatedAt)
+	queued, err := h.store.QueueContentAnchor(r.Context(), anchor, quota, since)
+	if errors.Is(err, store.ErrAnchorQuota) {
+		writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+			"error": "Monthly anchoring quota reached",
+			"quota": quota,
+		})
+		return
+	}
+	if err != nil {
 		log.Printf("Blockchain anchor save error: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save anchor record"})
 		return
 	}
+	if !queued {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Content is already anchored"})
+		return
+	}
 
 	writeJSON(w, http.StatusCreated, map[string]interface{}{
 		"anchor": anchor,
 	})
 }
 
-// GetAnchor handles GET /api/content/{id}/anchor — get anchor status for content.
+// GetAnchor handles GET /api/content/{id}/anchor — get anchor status for
+// content, with the history of transactions sent for it.
 func (h *BlockchainHandler) GetAnchor(w http.ResponseWriter, r *http.Request) {
 	contentID := chi.URLParam(r, "id")
 	if contentID == "" {
@@ -122,12 +161,36 @@ func (h *BlockchainHandler) GetAnchor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Every transaction sent for the anchor or its batch, replaced ones included
+	transactions := []*store.AnchorTransaction{}
+	if anchor.TxHash != nil {
+		var txs []*store.AnchorTransaction
+		if anchor.BatchID != nil {
+			txs, err = h.store.ListAnchorTransactions(r.Context(), *anchor.BatchID)
+		} else {
+			txs, err = h.store.ListAnchorTransactionsForAnchor(r.Context(), anchor.ID)
+		}
+		if err != nil {
+			log.Printf("Get anchor transactions DB error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+			return
+		}
+		if txs != nil {
+			transactions = txs
+		}
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"anchor": anchor,
+		"anchor":       anchor,
+		"transactions": transactions,
 	})
 }
 
 // VerifyByHash handles GET /api/verify/{hash} — public verification by content hash.
+// For a batched anchor the response carries the Merkle proof: hashing the
+// leaf up the path (see pkg/merkle) must give the root, which is the data of
+// the anchoring transaction, or for chain "rfc3161" the message imprint of
+// the base64 encoded timestamp token.
 func (h *BlockchainHandler) VerifyByHash(w http.ResponseWriter, r *http.Request) {
 	hash := chi.URLParam(r, "hash")
 	if hash == "" {
@@ -156,16 +219,125 @@ func (h *BlockchainHandler) VerifyByHash(w http.ResponseWriter, r *http.Request)
 	var contentInfo map[string]interface{}
 	if item != nil {
 		contentInfo = map[string]interface{}{
-			"id":          item.ID,
-			"title":       item.Title,
-			"contentType": item.ContentType,
-			"createdAt":   item.CreatedAt,
+			"id":            item.ID,
+			"title":         item.Title,
+			"contentType":   item.ContentType,
+			"createdAt":     item.CreatedAt,
+			"disputeStatus": item.DisputeStatus,
+		}
+	}
+
+	var proof map[string]interface{}
+	if anchor.BatchID != nil {
+		batch, err := h.store.FindAnchorBatchByID(r.Context(), *anchor.BatchID)
+		if err != nil {
+			log.Printf("Verify batch lookup error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+			return
+		}
+		if batch != nil {
+			proof = map[string]interface{}{
+				"algorithm":   merkle.Algorithm,
+				"leafIndex":   anchor.LeafIndex,
+				"leafCount":   batch.LeafCount,
+				"path":        anchor.MerkleProof,
+				"root":        batch.MerkleRoot,
+				"txHash":      batch.TxHash,
+				"chain":       batch.Chain,
+				"blockNumber": batch.BlockNumber,
+				"blockHash":   batch.BlockHash,
+			}
+			if batch.TimestampToken != nil {
+				proof["timestampToken"] = batch.TimestampToken
+			}
 		}
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"anchor":  anchor,
 		"content": contentInfo,
+		"proof":   proof,
+	})
+}
+
+// VerifyImage handles POST /api/verify — public lookup of an image file
+// (multipart field "file"). Besides its SHA-256, for GET /api/verify/{hash},
+// the response lists public vault items that look the same even though the
+// bytes differ, e.g. a resized or re-encoded copy, nearest first.
+func (h *BlockchainHandler) VerifyImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxThumbnailSource)
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "File too large (max 25 MB)"})
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "No file provided"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Failed to read file"})
+		return
+	}
+	phash, err := imaging.DHash(data)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Image dimensions too large"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Not a supported image (JPEG or PNG)"})
+		return
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	found, err := h.store.FindPerceptualMatches(r.Context(), phash, nearDuplicateDistance, 10, store.PerceptualFilter{PublicOnly: true})
+	if err != nil {
+		log.Printf("Verify image lookup error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+
+	owners := map[string]*model.User{}
+	matches := []map[string]interface{}{}
+	for _, m := range found {
+		match := map[string]interface{}{
+			"contentId":     m.Item.ID,
+			"title":         m.Item.Title,
+			"thumbnailUrl":  m.Item.ThumbnailURL,
+			"createdAt":     m.Item.CreatedAt,
+			"distance":      m.Distance,
+			"exact":         m.Item.HashSHA256 == digest,
+			"disputeStatus": m.Item.DisputeStatus,
+		}
+		owner, ok := owners[m.Item.UserID]
+		if !ok {
+			owner, err = h.store.FindUserByID(r.Context(), m.Item.UserID)
+			if err != nil {
+				log.Printf("Verify image owner lookup error: %v", err)
+			}
+			owners[m.Item.UserID] = owner
+		}
+		if owner != nil {
+			match["owner"] = owner.Username
+		}
+		anchor, err := h.store.FindAnchorByContentID(r.Context(), m.Item.ID)
+		if err != nil {
+			log.Printf("Verify image anchor lookup error: %v", err)
+		} else if anchor != nil {
+			match["anchorStatus"] = anchor.AnchorStatus
+			match["chain"] = anchor.Chain
+		}
+		matches = append(matches, match)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sha256":         digest,
+		"perceptualHash": fmt.Sprintf("%016x", phash),
+		"matches":        matches,
 	})
 }
 
@@ -198,3 +370,21 @@ func (h *BlockchainHandler) ListAnchors(w http.ResponseWriter, r *http.Request)
 		"total":   total,
 	})
 }
+
+// applyAnchorPolicy queues item for anchoring if policy covers it. It runs
+// after an upload or change that the policy may now cover; failures are
+// logged and never fail that request.
+func applyAnchorPolicy(ctx context.Context, auto *blockchain.AutoAnchor, owner *model.User, item *store.ContentItem, policy blockchain.Policy) {
+	anchor, err := auto.Apply(ctx, owner, item, policy)
+	if errors.Is(err, store.ErrAnchorQuota) {
+		log.Printf("Auto-anchor: %s has reached their monthly quota; %s not queued", owner.ID, item.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("Auto-anchor: failed to queue %s: %v", item.ID, err)
+		return
+	}
+	if anchor != nil {
+		log.Printf("Auto-anchor: queued %s under the %q policy", item.ID, policy)
+	}
+}
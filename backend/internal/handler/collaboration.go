@@ -2,27 +2,24 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
 	"strconv"
-	"time"
 
-	"github.com/creatrid/creatrid/internal/email"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/nrednav/cuid2"
 )
 
 type CollaborationHandler struct {
-	store    *store.Store
-	hub      *SSEHub
-	emailSvc *email.Service
+	store *store.Store
 }
 
-func NewCollaborationHandler(st *store.Store, hub *SSEHub, emailSvc *email.Service) *CollaborationHandler {
-	return &CollaborationHandler{store: st, hub: hub, emailSvc: emailSvc}
+// NewCollaborationHandler creates a collaboration handler. Recipients are
+// notified by the collaboration event subscribers.
+func NewCollaborationHandler(st *store.Store) *CollaborationHandler {
+	return &CollaborationHandler{store: st}
 }
 
 // Discover returns a paginated list of creators with optional filters
@@ -83,69 +80,19 @@ func (h *CollaborationHandler) SendRequest(w http.ResponseWriter, r *http.Reques
 	}
 
 	id := cuid2.Generate()
-	if err := h.store.CreateCollaborationRequest(r.Context(), id, user.ID, req.ToUserID, req.Message); err != nil {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "Request already sent to this user"})
-		return
-	}
-
-	// Dispatch webhook event for collaboration request
 	senderUsername := ""
 	if user.Username != nil {
 		senderUsername = *user.Username
 	}
-	dispatchWebhook(req.ToUserID, "collaboration.received", map[string]interface{}{
-		"requestId":    id,
-		"fromUser":     senderUsername,
-		"fromUserId":   user.ID,
-		"message":      req.Message,
+	received := store.NewEvent(req.ToUserID, webhook.EventCollaborationReceived, webhook.CollaborationReceived{
+		RequestID:  id,
+		FromUser:   senderUsername,
+		FromUserID: user.ID,
+		Message:    req.Message,
 	})
-
-	// Notify the recipient
-	senderName := "Someone"
-	if user.Name != nil {
-		senderName = *user.Name
-	}
-	notif := &store.Notification{
-		ID:        cuid2.Generate(),
-		UserID:    req.ToUserID,
-		Type:      "collab_request",
-		Title:     "New collaboration request",
-		Message:   fmt.Sprintf("%s wants to collaborate with you", senderName),
-		Data:      []byte(fmt.Sprintf(`{"requestId":"%s","fromUserId":"%s"}`, id, user.ID)),
-		CreatedAt: time.Now(),
-	}
-	if err := h.store.CreateNotification(r.Context(), notif); err != nil {
-		log.Printf("Failed to create collab notification: %v", err)
-	}
-
-	// Push real-time SSE notification
-	if h.hub != nil {
-		if data, err := json.Marshal(notif); err == nil {
-			h.hub.Notify(req.ToUserID, data)
-		}
-	}
-
-	// Send email notification to recipient
-	if h.emailSvc != nil {
-		recipient, err := h.store.FindUserByID(r.Context(), req.ToUserID)
-		if err == nil && recipient != nil {
-			prefs := recipient.GetEmailPrefs()
-			if prefs.Collaborations {
-				recipientName := "Creator"
-				if recipient.Name != nil {
-					recipientName = *recipient.Name
-				}
-				senderUsername := ""
-				if user.Username != nil {
-					senderUsername = *user.Username
-				}
-				inboxURL := "https://creatrid.com/collaborations"
-				subj, body := email.CollaborationRequestEmail(recipientName, senderName, senderUsername, req.Message, inboxURL)
-				if err := h.emailSvc.Send(recipient.Email, subj, body); err != nil {
-					log.Printf("Failed to send collab request email: %v", err)
-				}
-			}
-		}
+	if err := h.store.CreateCollaborationRequest(r.Context(), id, user.ID, req.ToUserID, req.Message, received); err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Request already sent to this user"})
+		return
 	}
 
 	writeJSON(w, http.StatusCreated, map[string]string{"id": id})
@@ -228,53 +175,19 @@ func (h *CollaborationHandler) Respond(w http.ResponseWriter, r *http.Request) {
 		status = "declined"
 	}
 
-	if err := h.store.UpdateCollaborationStatus(r.Context(), requestID, status); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update"})
-		return
-	}
-
-	// Notify the original sender about the response
 	responderName := "Someone"
 	if user.Name != nil {
 		responderName = *user.Name
 	}
-	notif := &store.Notification{
-		ID:        cuid2.Generate(),
-		UserID:    cr.FromUserID,
-		Type:      "collab_response",
-		Title:     fmt.Sprintf("Collaboration %s", status),
-		Message:   fmt.Sprintf("%s %s your collaboration request", responderName, status),
-		Data:      []byte(fmt.Sprintf(`{"requestId":"%s","status":"%s"}`, requestID, status)),
-		CreatedAt: time.Now(),
-	}
-	if err := h.store.CreateNotification(r.Context(), notif); err != nil {
-		log.Printf("Failed to create collab response notification: %v", err)
-	}
-
-	// Push real-time SSE notification
-	if h.hub != nil {
-		if data, err := json.Marshal(notif); err == nil {
-			h.hub.Notify(cr.FromUserID, data)
-		}
-	}
-
-	// Send email notification to the original sender
-	if h.emailSvc != nil {
-		sender, err := h.store.FindUserByID(r.Context(), cr.FromUserID)
-		if err == nil && sender != nil {
-			prefs := sender.GetEmailPrefs()
-			if prefs.Collaborations {
-				senderName := "Creator"
-				if sender.Name != nil {
-					senderName = *sender.Name
-				}
-				collabURL := "https://creatrid.com/collaborations"
-				subj, body := email.CollaborationResponseEmail(senderName, responderName, status, collabURL)
-				if err := h.emailSvc.Send(sender.Email, subj, body); err != nil {
-					log.Printf("Failed to send collab response email: %v", err)
-				}
-			}
-		}
+	responded := store.NewEvent(cr.FromUserID, eventCollaborationResponded, collaborationResponded{
+		RequestID:     requestID,
+		ResponderID:   user.ID,
+		ResponderName: responderName,
+		Status:        status,
+	})
+	if err := h.store.UpdateCollaborationStatus(r.Context(), requestID, status, responded); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update"})
+		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{"status": status})
@@ -2,11 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/creatrid/creatrid/internal/blockchain"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
 	"github.com/go-chi/chi/v5"
@@ -14,17 +16,31 @@ import (
 )
 
 type CollectionHandler struct {
-	store *store.Store
+	store      *store.Store
+	autoAnchor *blockchain.AutoAnchor
 }
 
-func NewCollectionHandler(st *store.Store) *CollectionHandler {
-	return &CollectionHandler{store: st}
+func NewCollectionHandler(st *store.Store, autoAnchor *blockchain.AutoAnchor) *CollectionHandler {
+	return &CollectionHandler{store: st, autoAnchor: autoAnchor}
 }
 
 type createCollectionRequest struct {
-	Title       string  `json:"title"`
-	Description *string `json:"description"`
-	IsPublic    bool    `json:"isPublic"`
+	Title        string  `json:"title"`
+	Description  *string `json:"description"`
+	IsPublic     bool    `json:"isPublic"`
+	AnchorPolicy *string `json:"anchorPolicy"`
+}
+
+// collectionAnchorPolicy validates a requested collection anchoring policy.
+// An empty one means the owner's policy, stored as nil.
+func collectionAnchorPolicy(requested *string) (*string, bool) {
+	if requested == nil || *requested == "" {
+		return nil, true
+	}
+	if _, ok := blockchain.ParsePolicy(*requested); !ok {
+		return nil, false
+	}
+	return requested, true
 }
 
 // Create handles POST /api/collections
@@ -46,16 +62,22 @@ func (h *CollectionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Title is required"})
 		return
 	}
+	anchorPolicy, ok := collectionAnchorPolicy(req.AnchorPolicy)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anchor policy"})
+		return
+	}
 
 	now := time.Now()
 	coll := &store.Collection{
-		ID:          cuid2.Generate(),
-		UserID:      user.ID,
-		Title:       title,
-		Description: req.Description,
-		IsPublic:    req.IsPublic,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:           cuid2.Generate(),
+		UserID:       user.ID,
+		Title:        title,
+		Description:  req.Description,
+		IsPublic:     req.IsPublic,
+		AnchorPolicy: anchorPolicy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
 
 	if err := h.store.CreateCollection(r.Context(), coll); err != nil {
@@ -133,6 +155,8 @@ type updateCollectionRequest struct {
 	Title       string  `json:"title"`
 	Description *string `json:"description"`
 	IsPublic    bool    `json:"isPublic"`
+	// AnchorPolicy is left as it is when absent; "" clears it.
+	AnchorPolicy *string `json:"anchorPolicy"`
 }
 
 // Update handles PATCH /api/collections/{id}
@@ -170,10 +194,22 @@ func (h *CollectionHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	anchorPolicy, ok := collectionAnchorPolicy(req.AnchorPolicy)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anchor policy"})
+		return
+	}
+
 	if err := h.store.UpdateCollection(r.Context(), id, title, req.Description, req.IsPublic); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update collection"})
 		return
 	}
+	if req.AnchorPolicy != nil {
+		if err := h.store.UpdateCollectionAnchorPolicy(r.Context(), id, anchorPolicy); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update collection"})
+			return
+		}
+	}
 
 	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
@@ -244,6 +280,16 @@ func (h *CollectionHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The collection's own anchoring policy covers what is added to it
+	if coll.AnchorPolicy != nil {
+		item, err := h.store.FindContentItemByID(r.Context(), req.ContentID)
+		if err != nil {
+			log.Printf("Collection %s: content lookup error: %v", id, err)
+		} else if item != nil && item.UserID == user.ID {
+			applyAnchorPolicy(r.Context(), h.autoAnchor, user, item, blockchain.Policy(*coll.AnchorPolicy))
+		}
+	}
+
 	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
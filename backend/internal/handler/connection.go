@@ -10,8 +10,10 @@ import (
 	"github.com/creatrid/creatrid/internal/email"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/moderation"
 	"github.com/creatrid/creatrid/internal/platform"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/nrednav/cuid2"
 	"golang.org/x/oauth2"
@@ -150,8 +152,11 @@ func (h *ConnectionHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, h.config.FrontendURL+"/connections?error=save_failed", http.StatusTemporaryRedirect)
 		return
 	}
+	if err := h.store.RecordConnectionMetric(r.Context(), user.ID, platformName, conn.FollowerCount, metadataJSON); err != nil {
+		log.Printf("Failed to record connection metrics (%s): %v", platformName, err)
+	}
 
-	recalcScore(r.Context(), h.store, user.ID)
+	recalcScore(r.Context(), h.store, user.ID, h.config.AnomalyScoreDamping)
 
 	// Send connection alert email (async, respecting preferences)
 	if h.email != nil && user.Name != nil && user.GetEmailPrefs().ConnectionAlert {
@@ -205,7 +210,7 @@ func (h *ConnectionHandler) Disconnect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	recalcScore(r.Context(), h.store, user.ID)
+	recalcScore(r.Context(), h.store, user.ID, h.config.AnomalyScoreDamping)
 	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
@@ -235,6 +240,13 @@ func (h *ConnectionHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		newToken, err := provider.RefreshToken(r.Context(), *conn.RefreshToken)
 		if err != nil {
 			log.Printf("Token refresh failed for %s/%s: %v", user.ID, platformName, err)
+			expired := store.NewEvent(user.ID, webhook.EventConnectionExpired, webhook.ConnectionExpired{
+				Platform: platformName,
+				Reason:   "token refresh failed",
+			})
+			if err := h.store.PublishEvents(r.Context(), expired); err != nil {
+				log.Printf("Failed to record connection.expired for %s/%s: %v", user.ID, platformName, err)
+			}
 			writeJSON(w, http.StatusOK, map[string]string{"status": "refresh_failed", "error": "Token refresh failed — reconnect required"})
 			return
 		}
@@ -262,9 +274,17 @@ func (h *ConnectionHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	metadataJSON, _ := json.Marshal(profile.Metadata)
-	_ = h.store.UpdateConnectionProfile(r.Context(), user.ID, platformName, &profile.FollowerCount, metadataJSON)
+	moderation.FlagAudienceAnomalies(r.Context(), h.store, conn, profile.FollowerCount, metadataJSON)
+	_ = h.store.UpdateConnectionProfile(r.Context(), user.ID, platformName, &profile.FollowerCount, metadataJSON,
+		store.NewEvent(user.ID, webhook.EventConnectionRefreshed, webhook.ConnectionRefreshed{
+			Platform:      platformName,
+			FollowerCount: profile.FollowerCount,
+		}))
+	if err := h.store.RecordConnectionMetric(r.Context(), user.ID, platformName, &profile.FollowerCount, metadataJSON); err != nil {
+		log.Printf("Failed to record connection metrics for %s/%s: %v", user.ID, platformName, err)
+	}
 
-	recalcScore(r.Context(), h.store, user.ID)
+	recalcScore(r.Context(), h.store, user.ID, h.config.AnomalyScoreDamping)
 	writeJSON(w, http.StatusOK, map[string]string{"status": "refreshed"})
 }
 
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/analytics"
+	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultGrowthDays is how far back a growth series reaches for each bucket
+// size when ?days= is not given.
+var defaultGrowthDays = map[string]int{
+	analytics.BucketDay:   30,
+	analytics.BucketWeek:  84,
+	analytics.BucketMonth: 365,
+}
+
+const maxGrowthDays = 730
+
+// History returns the metric snapshots recorded for one of the current
+// user's connections, oldest first.
+func (h *ConnectionHandler) History(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	platformName := chi.URLParam(r, "platform")
+	conn, err := h.store.FindConnectionByUserAndPlatform(r.Context(), user.ID, platformName)
+	if err != nil || conn == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Connection not found"})
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days <= 0 || days > maxGrowthDays {
+		days = 90
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 1000 {
+		limit = 500
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	history, err := h.store.ListConnectionMetrics(r.Context(), user.ID, platformName, since, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if history == nil {
+		history = []*store.ConnectionMetric{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"platform": platformName,
+		"history":  history,
+	})
+}
+
+// Growth returns the current user's follower growth across all connections.
+func (h *ConnectionHandler) Growth(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+	h.writeGrowth(w, r, user.ID)
+}
+
+// PublicGrowth returns a creator's follower growth by username.
+func (h *ConnectionHandler) PublicGrowth(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	user, err := h.store.FindUserByUsername(r.Context(), strings.ToLower(username))
+	if err != nil || user == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+	h.writeGrowth(w, r, user.ID)
+}
+
+// writeGrowth responds with the growth series for userID, bucketed by
+// ?bucket=day|week|month over the last ?days= days.
+func (h *ConnectionHandler) writeGrowth(w http.ResponseWriter, r *http.Request, userID string) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = analytics.BucketDay
+	}
+	if !analytics.ValidBucket(bucket) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bucket must be day, week or month"})
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days <= 0 || days > maxGrowthDays {
+		days = defaultGrowthDays[bucket]
+	}
+
+	series, err := h.growthSeries(r.Context(), userID, bucket, days)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"bucket":  bucket,
+		"days":    days,
+		"series":  series,
+		"summary": analytics.Summarize(series),
+	})
+}
+
+func (h *ConnectionHandler) growthSeries(ctx context.Context, userID, bucket string, days int) ([]analytics.GrowthPoint, error) {
+	now := time.Now()
+	since := analytics.TruncateBucket(now.AddDate(0, 0, -days), bucket)
+
+	snapshots, err := h.store.ListFollowerSnapshots(ctx, userID, bucket, since)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]analytics.FollowerSample, len(snapshots))
+	for i, s := range snapshots {
+		samples[i] = analytics.FollowerSample{Bucket: s.Bucket, Platform: s.Platform, Followers: s.FollowerCount}
+	}
+	return analytics.GrowthSeries(samples, bucket, now), nil
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -13,32 +14,54 @@ import (
 	"strings"
 	"time"
 
+	"github.com/creatrid/creatrid/internal/blockchain"
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/email"
 	"github.com/creatrid/creatrid/internal/imaging"
 	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/model"
 	"github.com/creatrid/creatrid/internal/moderation"
 	"github.com/creatrid/creatrid/internal/storage"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/nrednav/cuid2"
 )
 
 const maxContentSize = 500 << 20 // 500 MB
 
+// maxUploadMemory is how much of a multipart upload is buffered in memory.
+const maxUploadMemory = 32 << 20 // 32 MB
+
+// downloadURLExpiry is how long signed download links stay valid.
+const downloadURLExpiry = 15 * time.Minute
+
+// ContentStore is the storage ContentHandler depends on. It includes
+// OwnershipStore for the duplicate and ownership checks run on upload.
+type ContentStore interface {
+	OwnershipStore
+	store.UserRepository
+	store.LicenseRepository
+	store.UploadRepository
+	CreateModerationFlag(ctx context.Context, id, contentID, reason, details string, events ...*store.OutboxEvent) error
+	RecordContentDownload(ctx context.Context, contentID string, downloaderUserID *string) error
+}
+
 type ContentHandler struct {
-	store    *store.Store
-	blob     *storage.BlobStorage
-	config   *config.Config
-	emailSvc *email.Service
+	store      ContentStore
+	blob       storage.Backend
+	config     *config.Config
+	emailSvc   *email.Service
+	autoAnchor *blockchain.AutoAnchor
 }
 
-func NewContentHandler(st *store.Store, blob *storage.BlobStorage, cfg *config.Config, emailSvc *email.Service) *ContentHandler {
+func NewContentHandler(st ContentStore, blob storage.Backend, cfg *config.Config, emailSvc *email.Service, autoAnchor *blockchain.AutoAnchor) *ContentHandler {
 	return &ContentHandler{
-		store:    st,
-		blob:     blob,
-		config:   cfg,
-		emailSvc: emailSvc,
+		store:      st,
+		blob:       blob,
+		config:     cfg,
+		emailSvc:   emailSvc,
+		autoAnchor: autoAnchor,
 	}
 }
 
@@ -95,8 +118,9 @@ func (h *ContentHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Files beyond the in-memory limit are spooled to disk by the multipart parser
 	r.Body = http.MaxBytesReader(w, r.Body, maxContentSize)
-	if err := r.ParseMultipartForm(maxContentSize); err != nil {
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "File too large (max 500 MB)"})
 		return
 	}
@@ -144,21 +168,12 @@ func (h *ContentHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	contentType := mimeToContentType(mimeType)
 	ext := extFromMime(mimeType)
 
-	// Read file into buffer so we can compute hash and then upload
-	buf, err := io.ReadAll(file)
-	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Failed to read file"})
-		return
-	}
-
-	// Compute SHA-256 hash
-	hashBytes := sha256.Sum256(buf)
-	hashHex := hex.EncodeToString(hashBytes[:])
-
 	contentID := cuid2.Generate()
 	blobName := "vault/" + user.ID + "/" + contentID + ext
 
-	fileURL, err := h.blob.Upload(r.Context(), blobName, bytes.NewReader(buf), mimeType)
+	// Stream the file to blob storage, hashing it on the way through
+	hasher := sha256.New()
+	fileURL, err := h.blob.Upload(r.Context(), blobName, io.TeeReader(file, hasher), mimeType)
 	if err != nil {
 		log.Printf("Content upload blob error: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to upload file"})
@@ -173,51 +188,95 @@ func (h *ContentHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		Description: description,
 		ContentType: contentType,
 		MimeType:    mimeType,
-		FileSize:    int64(len(buf)),
+		FileSize:    header.Size,
 		FileURL:     fileURL,
-		HashSHA256:  hashHex,
+		HashSHA256:  hex.EncodeToString(hasher.Sum(nil)),
 		IsPublic:    isPublic,
 		Tags:        tags,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 
-	// Generate thumbnail for image content
-	if contentType == "image" {
-		thumbData, _, thumbErr := imaging.GenerateThumbnail(buf, 300)
-		if thumbErr == nil {
-			thumbExt := ext
-			thumbBlob := "vault/" + user.ID + "/" + contentID + "_thumb" + thumbExt
-			thumbURL, thumbUpErr := h.blob.Upload(r.Context(), thumbBlob, bytes.NewReader(thumbData), mimeType)
-			if thumbUpErr == nil {
-				item.ThumbnailURL = &thumbURL
-			}
-		}
+	var thumbSource io.Reader
+	if _, err := file.Seek(0, io.SeekStart); err == nil {
+		thumbSource = file
 	}
 
-	if err := h.store.CreateContentItem(r.Context(), item); err != nil {
+	if err := h.saveContentItem(r.Context(), user, item, thumbSource); err != nil {
 		log.Printf("Content upload DB error: %v", err)
-		_ = h.blob.Delete(r.Context(), fileURL)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save content record"})
 		return
 	}
 
-	// Dispatch webhook event for content upload
-	dispatchWebhook(user.ID, "content.uploaded", map[string]interface{}{
-		"contentId":   contentID,
-		"title":       title,
-		"contentType": contentType,
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// maxThumbnailSource is the largest image that is decoded for a thumbnail.
+const maxThumbnailSource = 25 << 20 // 25 MB
+
+// saveContentItem persists an item whose file is already in blob storage and
+// runs the post-upload steps: thumbnail generation, the content.uploaded
+// event, the duplicate check, moderation scanning, the creator's anchoring
+// policy and, for images, the perceptual hash check against other creators'
+// uploads.
+// thumbSource, if non-nil, supplies the image bytes for the thumbnail and
+// perceptual hash. On failure the uploaded blob is deleted.
+func (h *ContentHandler) saveContentItem(ctx context.Context, user *model.User, item *store.ContentItem, thumbSource io.Reader) error {
+	// Generate thumbnail and perceptual hash for image content
+	var phash *uint64
+	if item.ContentType == "image" && thumbSource != nil && item.FileSize <= maxThumbnailSource {
+		buf, err := io.ReadAll(io.LimitReader(thumbSource, maxThumbnailSource))
+		if err == nil {
+			thumbData, _, thumbErr := imaging.GenerateThumbnail(buf, 300)
+			if thumbErr == nil {
+				thumbBlob := "vault/" + user.ID + "/" + item.ID + "_thumb" + extFromMime(item.MimeType)
+				thumbURL, thumbUpErr := h.blob.Upload(ctx, thumbBlob, bytes.NewReader(thumbData), item.MimeType)
+				if thumbUpErr == nil {
+					item.ThumbnailURL = &thumbURL
+				}
+			}
+			if hash, hashErr := imaging.DHash(buf); hashErr == nil {
+				phash = &hash
+			}
+		}
+	}
+
+	uploaded := store.NewEvent(user.ID, webhook.EventContentUploaded, webhook.ContentUploaded{
+		ContentID:   item.ID,
+		Title:       item.Title,
+		ContentType: item.ContentType,
 	})
+	if err := h.store.CreateContentItem(ctx, item, uploaded); err != nil {
+		_ = h.blob.Delete(ctx, item.FileURL)
+		if item.ThumbnailURL != nil {
+			_ = h.blob.Delete(ctx, *item.ThumbnailURL)
+		}
+		return err
+	}
+
+	checkDuplicate(ctx, h.store, user, item)
+
+	applyAnchorPolicy(ctx, h.autoAnchor, user, item, blockchain.Policy(user.AnchorPolicy))
+
+	if phash != nil {
+		checkOwnership(ctx, h.store, user, item, *phash)
+	}
 
 	// Scan content metadata for profanity / policy violations
 	descText := ""
-	if description != nil {
-		descText = *description
+	if item.Description != nil {
+		descText = *item.Description
 	}
-	if reasons := moderation.ScanContent(title, descText); len(reasons) > 0 {
+	if reasons := moderation.ScanContent(item.Title, descText); len(reasons) > 0 {
 		for _, reason := range reasons {
 			flagID := cuid2.Generate()
-			if err := h.store.CreateModerationFlag(r.Context(), flagID, contentID, reason, "auto-detected on upload"); err != nil {
+			raised := store.NewEvent(user.ID, webhook.EventModerationFlagRaised, webhook.ModerationFlagRaised{
+				FlagID:    flagID,
+				ContentID: item.ID,
+				Reason:    reason,
+				Details:   "auto-detected on upload",
+			})
+			if err := h.store.CreateModerationFlag(ctx, flagID, item.ID, reason, "auto-detected on upload", raised); err != nil {
 				log.Printf("Failed to create moderation flag: %v", err)
 			}
 		}
@@ -229,14 +288,14 @@ func (h *ContentHandler) Upload(w http.ResponseWriter, r *http.Request) {
 			}
 			reasonStr := strings.Join(reasons, ", ")
 			vaultURL := "https://creatrid.com/dashboard"
-			subj, body := email.ContentFlaggedEmail(creatorName, title, reasonStr, vaultURL)
+			subj, body := email.ContentFlaggedEmail(creatorName, item.Title, reasonStr, vaultURL)
 			if err := h.emailSvc.Send(user.Email, subj, body); err != nil {
 				log.Printf("Failed to send content flagged email: %v", err)
 			}
 		}
 	}
 
-	writeJSON(w, http.StatusCreated, item)
+	return nil
 }
 
 // List handles GET /api/content — list authenticated user's content.
@@ -346,6 +405,10 @@ func (h *ContentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// An item made public may now fall under the creator's policy
+	item.IsPublic = req.IsPublic
+	applyAnchorPolicy(r.Context(), h.autoAnchor, user, item, blockchain.Policy(user.AnchorPolicy))
+
 	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
@@ -419,7 +482,15 @@ func (h *ContentHandler) Download(w http.ResponseWriter, r *http.Request) {
 	// Set Content-Disposition header with the original filename
 	filename := item.Title + filepath.Ext(item.FileURL)
 	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
-	http.Redirect(w, r, item.FileURL, http.StatusFound)
+
+	// Prefer a short-lived signed URL so private containers/buckets work too
+	downloadURL := item.FileURL
+	if h.blob != nil {
+		if signed, err := h.blob.SignedURL(r.Context(), item.FileURL, downloadURLExpiry); err == nil {
+			downloadURL = signed
+		}
+	}
+	http.Redirect(w, r, downloadURL, http.StatusFound)
 }
 
 // PublicList handles GET /api/users/{username}/content — list a user's public content.
@@ -526,10 +597,21 @@ func (h *ContentHandler) Proof(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
+	proof := map[string]interface{}{
 		"id":         item.ID,
 		"hashSha256": item.HashSHA256,
 		"createdAt":  item.CreatedAt,
 		"title":      item.Title,
-	})
+	}
+	if item.DisputeStatus != nil {
+		proof["disputeStatus"] = *item.DisputeStatus
+	}
+	owner, err := h.store.FindUserByID(r.Context(), item.UserID)
+	if err != nil {
+		log.Printf("Proof owner lookup error: %v", err)
+	} else if owner != nil {
+		proof["anchorPolicy"] = owner.AnchorPolicy
+	}
+
+	writeJSON(w, http.StatusOK, proof)
 }
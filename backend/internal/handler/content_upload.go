@@ -0,0 +1,484 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/storage"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/nrednav/cuid2"
+)
+
+const (
+	defaultChunkSize = 8 << 20  // 8 MB
+	minChunkSize     = 1 << 20  // 1 MB
+	maxChunkSize     = 64 << 20 // 64 MB
+	uploadSessionTTL = 24 * time.Hour
+)
+
+type createUploadRequest struct {
+	Title       string   `json:"title"`
+	Description *string  `json:"description"`
+	Tags        []string `json:"tags"`
+	IsPublic    *bool    `json:"isPublic"`
+	MimeType    string   `json:"mimeType"`
+	TotalSize   int64    `json:"totalSize"`
+	ChunkSize   int      `json:"chunkSize"`
+}
+
+// uploadSessionResponse adds the fields a client needs to resume an upload.
+func uploadSessionResponse(sess *store.UploadSession) map[string]interface{} {
+	return map[string]interface{}{
+		"session":     sess,
+		"totalChunks": sess.TotalChunks(),
+		"nextChunk":   sess.ChunksReceived,
+	}
+}
+
+// CreateUpload handles POST /api/content/uploads — start a chunked upload session.
+func (h *ContentHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	if h.blob == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "File upload is not configured"})
+		return
+	}
+
+	var req createUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Title is required"})
+		return
+	}
+	if req.TotalSize <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "totalSize must be positive"})
+		return
+	}
+	if req.TotalSize > maxContentSize {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "File too large (max 500 MB)"})
+		return
+	}
+
+	chunkSize := req.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = defaultChunkSize
+	}
+	if chunkSize < minChunkSize || chunkSize > maxChunkSize {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "chunkSize must be between 1 MB and 64 MB"})
+		return
+	}
+
+	mimeType := strings.TrimSpace(req.MimeType)
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	var description *string
+	if req.Description != nil {
+		if desc := strings.TrimSpace(*req.Description); desc != "" {
+			description = &desc
+		}
+	}
+
+	tags := []string{}
+	for _, t := range req.Tags {
+		if trimmed := strings.TrimSpace(t); trimmed != "" {
+			tags = append(tags, trimmed)
+		}
+	}
+
+	isPublic := true
+	if req.IsPublic != nil {
+		isPublic = *req.IsPublic
+	}
+
+	now := time.Now()
+	sess := &store.UploadSession{
+		ID:          cuid2.Generate(),
+		UserID:      user.ID,
+		Title:       title,
+		Description: description,
+		Tags:        tags,
+		IsPublic:    isPublic,
+		MimeType:    mimeType,
+		TotalSize:   req.TotalSize,
+		ChunkSize:   chunkSize,
+		Status:      "active",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(uploadSessionTTL),
+	}
+
+	if err := h.store.CreateUploadSession(r.Context(), sess); err != nil {
+		log.Printf("Create upload session error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create upload session"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, uploadSessionResponse(sess))
+}
+
+// GetUpload handles GET /api/content/uploads/{id} — session state, used to resume.
+func (h *ContentHandler) GetUpload(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.ownedUploadSession(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, uploadSessionResponse(sess))
+}
+
+// PutChunk handles PUT /api/content/uploads/{id}/chunks/{n} — upload one chunk.
+// Chunks must arrive in order; the raw request body is the chunk data.
+func (h *ContentHandler) PutChunk(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.ownedUploadSession(w, r)
+	if !ok {
+		return
+	}
+	if sess.Status != "active" {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Upload session is " + sess.Status})
+		return
+	}
+
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 0 || n >= sess.TotalChunks() {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid chunk number"})
+		return
+	}
+	if n < sess.ChunksReceived {
+		// Already stored (e.g. a retried request whose response was lost)
+		writeJSON(w, http.StatusOK, uploadSessionResponse(sess))
+		return
+	}
+	if n > sess.ChunksReceived {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":     "Chunks must be uploaded in order",
+			"nextChunk": sess.ChunksReceived,
+		})
+		return
+	}
+
+	hasher, err := restoreHash(sess.HashState)
+	if err != nil {
+		log.Printf("Upload session %s: corrupt hash state: %v", sess.ID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Upload session is corrupt"})
+		return
+	}
+
+	expected := sess.ChunkLength(n)
+	body := &countingReader{r: io.TeeReader(http.MaxBytesReader(w, r.Body, expected), hasher)}
+	// Every attempt gets its own object, so a retried or concurrent request
+	// for the same chunk never overwrites the one that gets recorded
+	chunkName := "uploads/" + sess.UserID + "/" + sess.ID + "/" + strconv.Itoa(n) + "-" + cuid2.Generate()
+	chunkURL, err := h.blob.Upload(r.Context(), chunkName, body, "application/octet-stream")
+	if err != nil {
+		log.Printf("Upload session %s: chunk %d upload error: %v", sess.ID, n, err)
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Failed to store chunk"})
+		return
+	}
+	if body.n != expected {
+		_ = h.blob.Delete(r.Context(), chunkURL)
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Chunk " + strconv.Itoa(n) + " must be exactly " + strconv.FormatInt(expected, 10) + " bytes",
+		})
+		return
+	}
+
+	state, err := hasher.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record chunk"})
+		return
+	}
+
+	recorded, err := h.store.RecordUploadChunk(r.Context(), sess.ID, n, expected, state, chunkURL)
+	if err != nil {
+		log.Printf("Upload session %s: record chunk %d error: %v", sess.ID, n, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record chunk"})
+		return
+	}
+	if !recorded {
+		// A concurrent request recorded this chunk first; drop our copy
+		_ = h.blob.Delete(r.Context(), chunkURL)
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Chunk was already received"})
+		return
+	}
+
+	sess.ChunksReceived++
+	sess.BytesReceived += expected
+	writeJSON(w, http.StatusOK, uploadSessionResponse(sess))
+}
+
+// CompleteUpload handles POST /api/content/uploads/{id}/complete — assemble the
+// chunks into the final vault object and create the content item.
+func (h *ContentHandler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	sess, ok := h.ownedUploadSession(w, r)
+	if !ok {
+		return
+	}
+	if sess.Status != "active" {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Upload session is " + sess.Status})
+		return
+	}
+	if sess.ChunksReceived != sess.TotalChunks() || len(sess.ChunkURLs) != sess.ChunksReceived {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":     "Upload is incomplete",
+			"nextChunk": sess.ChunksReceived,
+		})
+		return
+	}
+
+	hasher, err := restoreHash(sess.HashState)
+	if err != nil {
+		log.Printf("Upload session %s: corrupt hash state: %v", sess.ID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Upload session is corrupt"})
+		return
+	}
+
+	// Claim the session so a concurrent or retried request cannot assemble
+	// it a second time
+	claimed, err := h.store.ClaimUploadSession(r.Context(), sess.ID)
+	if err != nil {
+		log.Printf("Upload session %s: claim error: %v", sess.ID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to complete upload"})
+		return
+	}
+	if !claimed {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Upload session is already being completed"})
+		return
+	}
+
+	contentID := cuid2.Generate()
+	blobName := "vault/" + user.ID + "/" + contentID + extFromMime(sess.MimeType)
+	chunks := &chunkReader{ctx: r.Context(), blob: h.blob, urls: sess.ChunkURLs}
+	fileURL, err := h.blob.Upload(r.Context(), blobName, chunks, sess.MimeType)
+	chunks.Close()
+	if err != nil {
+		h.releaseUploadSession(sess.ID)
+		log.Printf("Upload session %s: assemble error: %v", sess.ID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to assemble upload"})
+		return
+	}
+
+	now := time.Now()
+	item := &store.ContentItem{
+		ID:          contentID,
+		UserID:      user.ID,
+		Title:       sess.Title,
+		Description: sess.Description,
+		ContentType: mimeToContentType(sess.MimeType),
+		MimeType:    sess.MimeType,
+		FileSize:    sess.TotalSize,
+		FileURL:     fileURL,
+		HashSHA256:  hex.EncodeToString(hasher.Sum(nil)),
+		IsPublic:    sess.IsPublic,
+		Tags:        sess.Tags,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	var thumbSource io.Reader
+	if item.ContentType == "image" && item.FileSize <= maxThumbnailSource {
+		if rc, err := h.blob.Open(r.Context(), fileURL); err == nil {
+			defer rc.Close()
+			thumbSource = rc
+		}
+	}
+
+	if err := h.saveContentItem(r.Context(), user, item, thumbSource); err != nil {
+		h.releaseUploadSession(sess.ID)
+		log.Printf("Upload session %s: DB error: %v", sess.ID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save content record"})
+		return
+	}
+
+	if err := h.store.CompleteUploadSession(r.Context(), sess.ID, contentID); err != nil {
+		log.Printf("Upload session %s: failed to mark completed: %v", sess.ID, err)
+	}
+	h.deleteChunks(r.Context(), sess.ChunkURLs)
+
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// AbortUpload handles DELETE /api/content/uploads/{id} — discard an upload session.
+func (h *ContentHandler) AbortUpload(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.ownedUploadSession(w, r)
+	if !ok {
+		return
+	}
+	if sess.Status != "active" {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Upload session is " + sess.Status})
+		return
+	}
+
+	aborted, err := h.store.AbortUploadSession(r.Context(), sess.ID, "active")
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to abort upload"})
+		return
+	}
+	if !aborted {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Upload session is no longer active"})
+		return
+	}
+	h.deleteChunks(r.Context(), sess.ChunkURLs)
+
+	writeJSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// CleanupExpiredUploads aborts upload sessions past their expiry, or left
+// claimed by a completion that never finished, and deletes their chunks. Returns the number of sessions cleaned up.
+func (h *ContentHandler) CleanupExpiredUploads(ctx context.Context) (int, error) {
+	sessions, err := h.store.ListExpiredUploadSessions(ctx, 100)
+	if err != nil {
+		return 0, err
+	}
+	cleaned := 0
+	for _, sess := range sessions {
+		aborted, err := h.store.AbortUploadSession(ctx, sess.ID, sess.Status)
+		if err != nil {
+			return cleaned, err
+		}
+		if !aborted {
+			continue
+		}
+		if h.blob != nil {
+			h.deleteChunks(ctx, sess.ChunkURLs)
+		}
+		cleaned++
+	}
+	return cleaned, nil
+}
+
+// releaseUploadSession hands a claimed session back after a failed
+// completion. It uses a fresh context so a canceled request still releases.
+func (h *ContentHandler) releaseUploadSession(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.store.ReleaseUploadSession(ctx, id); err != nil {
+		log.Printf("Upload session %s: release error: %v", id, err)
+	}
+}
+
+// ownedUploadSession loads the session named in the URL and checks that it
+// belongs to the authenticated user, writing an error response if not.
+func (h *ContentHandler) ownedUploadSession(w http.ResponseWriter, r *http.Request) (*store.UploadSession, bool) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return nil, false
+	}
+	if h.blob == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "File upload is not configured"})
+		return nil, false
+	}
+
+	sess, err := h.store.FindUploadSession(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return nil, false
+	}
+	if sess == nil || sess.UserID != user.ID {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Upload session not found"})
+		return nil, false
+	}
+	if sess.Status == "active" && time.Now().After(sess.ExpiresAt) {
+		writeJSON(w, http.StatusGone, map[string]string{"error": "Upload session has expired"})
+		return nil, false
+	}
+	return sess, true
+}
+
+func (h *ContentHandler) deleteChunks(ctx context.Context, urls []string) {
+	for _, u := range urls {
+		if err := h.blob.Delete(ctx, u); err != nil {
+			log.Printf("Failed to delete upload chunk %s: %v", u, err)
+		}
+	}
+}
+
+// restoreHash returns a SHA-256 hasher resumed from a serialized state, or a
+// fresh one if state is empty.
+func restoreHash(state []byte) (hash.Hash, error) {
+	hasher := sha256.New()
+	if len(state) == 0 {
+		return hasher, nil
+	}
+	if err := hasher.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+	return hasher, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// chunkReader reads a sequence of stored chunks as one stream, opening each
+// chunk only when the previous one is exhausted.
+type chunkReader struct {
+	ctx     context.Context
+	blob    storage.Backend
+	urls    []string
+	current io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.urls) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := c.blob.Open(c.ctx, c.urls[0])
+			if err != nil {
+				return 0, err
+			}
+			c.current = rc
+			c.urls = c.urls[1:]
+		}
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.current != nil {
+		return c.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/config"
+	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/storage"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadTest is a ContentHandler over an in-memory store and a local blob
+// store in a temporary directory.
+type uploadTest struct {
+	h    *ContentHandler
+	st   *storetest.Store
+	blob *hookBlob
+	dir  string
+	user *model.User
+}
+
+// hookBlob runs beforeUpload, if set, once before the next upload starts.
+type hookBlob struct {
+	storage.Backend
+	mu           sync.Mutex
+	beforeUpload func()
+}
+
+func (b *hookBlob) Upload(ctx context.Context, name string, reader io.Reader, contentType string) (string, error) {
+	b.mu.Lock()
+	hook := b.beforeUpload
+	b.beforeUpload = nil
+	b.mu.Unlock()
+	if hook != nil {
+		hook()
+	}
+	return b.Backend.Upload(ctx, name, reader, contentType)
+}
+
+func newUploadTest(t *testing.T) *uploadTest {
+	t.Helper()
+	dir := t.TempDir()
+	local, err := storage.NewLocalStorage(dir, "http://localhost:8080/api/files", "test-key")
+	require.NoError(t, err)
+
+	st := storetest.New()
+	blob := &hookBlob{Backend: local}
+	return &uploadTest{
+		h:    NewContentHandler(st, blob, &config.Config{}, nil, nil),
+		st:   st,
+		blob: blob,
+		dir:  dir,
+		user: seedUser(st, "alice", "alice"),
+	}
+}
+
+// create starts an upload of size bytes in 1 MB chunks.
+func (u *uploadTest) create(t *testing.T, size int64) string {
+	t.Helper()
+	rr := serve(t, u.h.CreateUpload, http.MethodPost, "/api/content/uploads", "/api/content/uploads", map[string]interface{}{
+		"title": "Upload", "mimeType": "application/pdf", "totalSize": size, "chunkSize": minChunkSize,
+	}, u.user)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	return decode(t, rr)["session"].(map[string]interface{})["id"].(string)
+}
+
+// put sends chunk n of session id with data as the raw body.
+func (u *uploadTest) put(t *testing.T, id string, n int, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPut, "/api/content/uploads/"+id+"/chunks/"+strconv.Itoa(n), bytes.NewReader(data))
+	req = req.WithContext(middleware.WithUser(req.Context(), u.user))
+
+	r := chi.NewRouter()
+	r.Put("/api/content/uploads/{id}/chunks/{n}", u.h.PutChunk)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func (u *uploadTest) session(t *testing.T, id string) *store.UploadSession {
+	t.Helper()
+	sess, err := u.st.FindUploadSession(context.Background(), id)
+	require.NoError(t, err)
+	require.NotNil(t, sess)
+	return sess
+}
+
+// chunkObjects returns the names of the chunk objects in blob storage.
+func (u *uploadTest) chunkObjects(t *testing.T) []string {
+	t.Helper()
+	var names []string
+	root := filepath.Join(u.dir, "uploads")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return names
+}
+
+// readObject returns the contents of a blob.
+func (u *uploadTest) readObject(t *testing.T, objectURL string) []byte {
+	t.Helper()
+	rc, err := u.blob.Open(context.Background(), objectURL)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return data
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	data := make([]byte, n)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	return data
+}
+
+func nextChunk(t *testing.T, rr *httptest.ResponseRecorder) int {
+	t.Helper()
+	return int(decode(t, rr)["nextChunk"].(float64))
+}
+
+func TestUpload_CompleteAssemblesChunks(t *testing.T) {
+	u := newUploadTest(t)
+	data := randomBytes(t, 2*minChunkSize+minChunkSize/2)
+	id := u.create(t, int64(len(data)))
+
+	for n := 0; n < 3; n++ {
+		end := min((n+1)*minChunkSize, len(data))
+		rr := u.put(t, id, n, data[n*minChunkSize:end])
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, n+1, nextChunk(t, rr))
+	}
+	assert.Len(t, u.chunkObjects(t), 3)
+
+	rr := serve(t, u.h.CompleteUpload, http.MethodPost, "/api/content/uploads/{id}/complete", "/api/content/uploads/"+id+"/complete", nil, u.user)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	body := decode(t, rr)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), body["hashSha256"])
+	assert.EqualValues(t, len(data), body["fileSize"])
+
+	item, err := u.st.FindContentItemByID(context.Background(), body["id"].(string))
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, data, u.readObject(t, item.FileURL))
+	assert.Empty(t, u.chunkObjects(t), "chunks are deleted once assembled")
+	assert.Equal(t, "completed", u.session(t, id).Status)
+}
+
+func TestPutChunk_RejectsOutOfOrderChunk(t *testing.T) {
+	u := newUploadTest(t)
+	data := randomBytes(t, 2*minChunkSize)
+	id := u.create(t, int64(len(data)))
+
+	rr := u.put(t, id, 1, data[minChunkSize:])
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, 0, nextChunk(t, rr))
+	assert.Empty(t, u.chunkObjects(t))
+	assert.Equal(t, 0, u.session(t, id).ChunksReceived)
+}
+
+func TestPutChunk_DuplicateChunkIsAcknowledged(t *testing.T) {
+	u := newUploadTest(t)
+	data := randomBytes(t, 2*minChunkSize)
+	id := u.create(t, int64(len(data)))
+
+	require.Equal(t, http.StatusOK, u.put(t, id, 0, data[:minChunkSize]).Code)
+	// A retry whose first response was lost
+	rr := u.put(t, id, 0, data[:minChunkSize])
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, 1, nextChunk(t, rr))
+
+	sess := u.session(t, id)
+	assert.Equal(t, 1, sess.ChunksReceived)
+	assert.Len(t, sess.ChunkURLs, 1)
+	assert.Len(t, u.chunkObjects(t), 1)
+}
+
+func TestPutChunk_ShortChunkIsDiscarded(t *testing.T) {
+	u := newUploadTest(t)
+	data := randomBytes(t, 2*minChunkSize)
+	id := u.create(t, int64(len(data)))
+
+	rr := u.put(t, id, 0, data[:100])
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Empty(t, u.chunkObjects(t))
+	assert.Equal(t, 0, u.session(t, id).ChunksReceived)
+
+	require.Equal(t, http.StatusOK, u.put(t, id, 0, data[:minChunkSize]).Code)
+	assert.Equal(t, 1, u.session(t, id).ChunksReceived)
+}
+
+func TestPutChunk_ConcurrentLoserKeepsWinnersChunk(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		loserBody int
+		wantCode  int
+	}{
+		{"full chunk", minChunkSize, http.StatusConflict},
+		{"short chunk", 100, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u := newUploadTest(t)
+			data := randomBytes(t, 2*minChunkSize)
+			id := u.create(t, int64(len(data)))
+
+			// The winner stores and records chunk 0 after the loser has
+			// loaded the session but before it writes its object
+			u.blob.beforeUpload = func() {
+				rr := u.put(t, id, 0, data[:minChunkSize])
+				require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			}
+			other := randomBytes(t, tc.loserBody)
+			rr := u.put(t, id, 0, other)
+			assert.Equal(t, tc.wantCode, rr.Code, rr.Body.String())
+
+			sess := u.session(t, id)
+			require.Len(t, sess.ChunkURLs, 1)
+			assert.Equal(t, data[:minChunkSize], u.readObject(t, sess.ChunkURLs[0]))
+			assert.Len(t, u.chunkObjects(t), 1, "the loser's object is deleted")
+		})
+	}
+}
+
+func TestCleanupExpiredUploads(t *testing.T) {
+	u := newUploadTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	seed := func(id string, expiresAt time.Time) string {
+		require.NoError(t, u.st.CreateUploadSession(ctx, &store.UploadSession{
+			ID: id, UserID: u.user.ID, Title: "Upload", Tags: []string{}, MimeType: "application/pdf",
+			TotalSize: 2 * minChunkSize, ChunkSize: minChunkSize, Status: "active",
+			CreatedAt: now, UpdatedAt: now, ExpiresAt: expiresAt,
+		}))
+		chunkURL, err := u.blob.Upload(ctx, "uploads/"+u.user.ID+"/"+id+"/0-x", bytes.NewReader([]byte("chunk")), "application/octet-stream")
+		require.NoError(t, err)
+		recorded, err := u.st.RecordUploadChunk(ctx, id, 0, 5, nil, chunkURL)
+		require.NoError(t, err)
+		require.True(t, recorded)
+		return chunkURL
+	}
+	seed("expired", now.Add(-time.Minute))
+	live := seed("live", now.Add(time.Hour))
+
+	cleaned, err := u.h.CleanupExpiredUploads(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cleaned)
+
+	assert.Equal(t, "aborted", u.session(t, "expired").Status)
+	assert.Equal(t, "active", u.session(t, "live").Status)
+	assert.Len(t, u.chunkObjects(t), 1)
+	assert.Equal(t, []byte("chunk"), u.readObject(t, live))
+
+	cleaned, err = u.h.CleanupExpiredUploads(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cleaned)
+}
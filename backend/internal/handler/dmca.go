@@ -9,6 +9,7 @@ import (
 
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/nrednav/cuid2"
 )
@@ -121,7 +122,28 @@ func (h *DMCAHandler) ResolveTakedown(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.ResolveTakedown(r.Context(), takedownID, user.ID, req.Status, req.Notes); err != nil {
+	takedown, err := h.store.FindTakedownRequestByID(r.Context(), takedownID)
+	if err != nil {
+		log.Printf("Failed to find takedown: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to resolve takedown"})
+		return
+	}
+	if takedown == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Takedown request not found"})
+		return
+	}
+
+	var resolved *store.OutboxEvent
+	if item, err := h.store.FindContentItemByID(r.Context(), takedown.ContentID); err == nil && item != nil {
+		resolved = store.NewEvent(item.UserID, webhook.EventTakedownResolved, webhook.TakedownResolved{
+			TakedownID: takedownID,
+			ContentID:  takedown.ContentID,
+			Status:     req.Status,
+			Notes:      req.Notes,
+		})
+	}
+
+	if err := h.store.ResolveTakedown(r.Context(), takedownID, user.ID, req.Status, req.Notes, resolved); err != nil {
 		log.Printf("Failed to resolve takedown: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to resolve takedown"})
 		return
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/email"
+	"github.com/creatrid/creatrid/internal/events"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/nrednav/cuid2"
+)
+
+// eventCollaborationResponded is raised when a collaboration request is
+// accepted or declined. It is internal: the webhook catalogue has no event
+// for it, so only the subscribers below see it.
+const eventCollaborationResponded = "collaboration.responded"
+
+type collaborationResponded struct {
+	RequestID     string `json:"requestId"`
+	ResponderID   string `json:"responderId"`
+	ResponderName string `json:"responderName"`
+	Status        string `json:"status"`
+}
+
+// RegisterEventSubscribers subscribes the in-app notifications, SSE pushes
+// and emails that follow domain events. Each side effect is its own
+// subscriber, so a failed email is retried without notifying twice.
+func RegisterEventSubscribers(relay *events.Relay, st *store.Store, hub *SSEHub, emailSvc *email.Service) {
+	s := &eventSubscribers{store: st, hub: hub, emailSvc: emailSvc}
+
+	relay.Subscribe(webhook.EventCollaborationReceived, "notify.collab_request", s.notifyCollabRequest)
+	relay.Subscribe(eventCollaborationResponded, "notify.collab_response", s.notifyCollabResponse)
+	relay.Subscribe(webhook.EventLicenseSold, "notify.license_sale", s.notifyLicenseSale)
+	relay.Subscribe(webhook.EventOwnershipDisputed, "notify.ownership_disputed", s.notifyOwnershipDisputed)
+	relay.Subscribe(webhook.EventOwnershipResolved, "notify.ownership_resolved", s.notifyOwnershipResolved)
+	if emailSvc != nil {
+		relay.Subscribe(webhook.EventCollaborationReceived, "email.collab_request", s.emailCollabRequest)
+		relay.Subscribe(eventCollaborationResponded, "email.collab_response", s.emailCollabResponse)
+	}
+}
+
+type eventSubscribers struct {
+	store    *store.Store
+	hub      *SSEHub
+	emailSvc *email.Service
+}
+
+// notify stores an in-app notification and pushes it to the user's open
+// SSE streams.
+func (s *eventSubscribers) notify(ctx context.Context, notif *store.Notification) error {
+	if err := s.store.CreateNotification(ctx, notif); err != nil {
+		return err
+	}
+	if s.hub != nil {
+		if data, err := json.Marshal(notif); err == nil {
+			s.hub.Notify(notif.UserID, data)
+		}
+	}
+	return nil
+}
+
+// displayName returns the user's name, or fallback if they have none or no
+// longer exist.
+func (s *eventSubscribers) displayName(ctx context.Context, userID, fallback string) (string, error) {
+	u, err := s.store.FindUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if u == nil || u.Name == nil {
+		return fallback, nil
+	}
+	return *u.Name, nil
+}
+
+func (s *eventSubscribers) notifyCollabRequest(ctx context.Context, ev *store.OutboxEvent) error {
+	var p webhook.CollaborationReceived
+	if err := events.Decode(ev, &p); err != nil {
+		return err
+	}
+	senderName, err := s.displayName(ctx, p.FromUserID, "Someone")
+	if err != nil {
+		return err
+	}
+	return s.notify(ctx, &store.Notification{
+		ID:        cuid2.Generate(),
+		UserID:    ev.UserID,
+		Type:      "collab_request",
+		Title:     "New collaboration request",
+		Message:   fmt.Sprintf("%s wants to collaborate with you", senderName),
+		Data:      []byte(fmt.Sprintf(`{"requestId":"%s","fromUserId":"%s"}`, p.RequestID, p.FromUserID)),
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *eventSubscribers) emailCollabRequest(ctx context.Context, ev *store.OutboxEvent) error {
+	var p webhook.CollaborationReceived
+	if err := events.Decode(ev, &p); err != nil {
+		return err
+	}
+	recipient, err := s.store.FindUserByID(ctx, ev.UserID)
+	if err != nil || recipient == nil || !recipient.GetEmailPrefs().Collaborations {
+		return err
+	}
+	recipientName := "Creator"
+	if recipient.Name != nil {
+		recipientName = *recipient.Name
+	}
+	senderName, err := s.displayName(ctx, p.FromUserID, "Someone")
+	if err != nil {
+		return err
+	}
+	inboxURL := "https://creatrid.com/collaborations"
+	subj, body := email.CollaborationRequestEmail(recipientName, senderName, p.FromUser, p.Message, inboxURL)
+	return s.emailSvc.Send(recipient.Email, subj, body)
+}
+
+func (s *eventSubscribers) notifyCollabResponse(ctx context.Context, ev *store.OutboxEvent) error {
+	var p collaborationResponded
+	if err := events.Decode(ev, &p); err != nil {
+		return err
+	}
+	return s.notify(ctx, &store.Notification{
+		ID:        cuid2.Generate(),
+		UserID:    ev.UserID,
+		Type:      "collab_response",
+		Title:     fmt.Sprintf("Collaboration %s", p.Status),
+		Message:   fmt.Sprintf("%s %s your collaboration request", p.ResponderName, p.Status),
+		Data:      []byte(fmt.Sprintf(`{"requestId":"%s","status":"%s"}`, p.RequestID, p.Status)),
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *eventSubscribers) emailCollabResponse(ctx context.Context, ev *store.OutboxEvent) error {
+	var p collaborationResponded
+	if err := events.Decode(ev, &p); err != nil {
+		return err
+	}
+	sender, err := s.store.FindUserByID(ctx, ev.UserID)
+	if err != nil || sender == nil || !sender.GetEmailPrefs().Collaborations {
+		return err
+	}
+	senderName := "Creator"
+	if sender.Name != nil {
+		senderName = *sender.Name
+	}
+	collabURL := "https://creatrid.com/collaborations"
+	subj, body := email.CollaborationResponseEmail(senderName, p.ResponderName, p.Status, collabURL)
+	return s.emailSvc.Send(sender.Email, subj, body)
+}
+
+func (s *eventSubscribers) notifyLicenseSale(ctx context.Context, ev *store.OutboxEvent) error {
+	var p webhook.LicenseSold
+	if err := events.Decode(ev, &p); err != nil {
+		return err
+	}
+	content, err := s.store.FindContentItemByID(ctx, p.ContentID)
+	if err != nil || content == nil {
+		return err
+	}
+	return s.notify(ctx, &store.Notification{
+		ID:        cuid2.Generate(),
+		UserID:    ev.UserID,
+		Type:      "license_sale",
+		Title:     "New license sale!",
+		Message:   fmt.Sprintf("Your content \"%s\" was licensed for $%.2f", content.Title, float64(p.AmountCents)/100),
+		Data:      []byte(fmt.Sprintf(`{"contentId":"%s","purchaseId":"%s","amountCents":%d}`, p.ContentID, p.PurchaseID, p.AmountCents)),
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *eventSubscribers) notifyOwnershipDisputed(ctx context.Context, ev *store.OutboxEvent) error {
+	var p webhook.OwnershipDisputed
+	if err := events.Decode(ev, &p); err != nil {
+		return err
+	}
+	contentID := p.ContentID
+	if p.Role == "original" {
+		contentID = p.OriginalContentID
+	}
+	content, err := s.store.FindContentItemByID(ctx, contentID)
+	if err != nil || content == nil {
+		return err
+	}
+	message := fmt.Sprintf("\"%s\" is identical to content another creator uploaded earlier and is under review", content.Title)
+	if p.Role == "original" {
+		message = fmt.Sprintf("Another creator uploaded a file identical to your \"%s\"; it is under review", content.Title)
+	}
+	return s.notify(ctx, &store.Notification{
+		ID:        cuid2.Generate(),
+		UserID:    ev.UserID,
+		Type:      "ownership_disputed",
+		Title:     "Ownership dispute opened",
+		Message:   message,
+		Data:      []byte(fmt.Sprintf(`{"conflictId":"%s","contentId":"%s","role":"%s"}`, p.ConflictID, contentID, p.Role)),
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *eventSubscribers) notifyOwnershipResolved(ctx context.Context, ev *store.OutboxEvent) error {
+	var p webhook.OwnershipResolved
+	if err := events.Decode(ev, &p); err != nil {
+		return err
+	}
+	contentID := p.ContentID
+	if p.Role == "original" {
+		contentID = p.OriginalContentID
+	}
+	content, err := s.store.FindContentItemByID(ctx, contentID)
+	if err != nil || content == nil {
+		return err
+	}
+	// Upholding the conflict confirms the earlier claim
+	won := (p.Status == "upheld") == (p.Role == "original")
+	message := fmt.Sprintf("The ownership dispute over \"%s\" was resolved in your favor", content.Title)
+	if !won {
+		message = fmt.Sprintf("The ownership dispute over \"%s\" was resolved against your claim", content.Title)
+	}
+	return s.notify(ctx, &store.Notification{
+		ID:        cuid2.Generate(),
+		UserID:    ev.UserID,
+		Type:      "ownership_resolved",
+		Title:     "Ownership dispute resolved",
+		Message:   message,
+		Data:      []byte(fmt.Sprintf(`{"conflictId":"%s","contentId":"%s","status":"%s"}`, p.ConflictID, contentID, p.Status)),
+		CreatedAt: time.Now(),
+	})
+}
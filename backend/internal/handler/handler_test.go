@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// seedUser adds a creator with the given id and username to st.
+func seedUser(st *storetest.Store, id, username string) *model.User {
+	return st.AddUser(&model.User{
+		ID:       id,
+		Email:    id + "@example.com",
+		Name:     strPtr("User " + id),
+		Username: strPtr(username),
+	})
+}
+
+// serve routes a single request to h through chi, so that URL parameters in
+// pattern are populated, authenticating as user if it is non-nil.
+func serve(t *testing.T, h http.HandlerFunc, method, pattern, target string, body interface{}, user *model.User) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	if user != nil {
+		req = req.WithContext(middleware.WithUser(req.Context(), user))
+	}
+
+	r := chi.NewRouter()
+	r.Method(method, pattern, h)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+// decode unmarshals a JSON response body into a generic map.
+func decode(t *testing.T, rr *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body), rr.Body.String())
+	return body
+}
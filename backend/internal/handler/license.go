@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/creatrid/creatrid/internal/blockchain"
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
@@ -15,14 +16,21 @@ import (
 	checkoutsession "github.com/stripe/stripe-go/v81/checkout/session"
 )
 
+// LicenseStore is the storage LicenseHandler depends on.
+type LicenseStore interface {
+	store.LicenseRepository
+	store.ContentRepository
+}
+
 type LicenseHandler struct {
-	store  *store.Store
-	config *config.Config
+	store      LicenseStore
+	config     *config.Config
+	autoAnchor *blockchain.AutoAnchor
 }
 
-func NewLicenseHandler(st *store.Store, cfg *config.Config) *LicenseHandler {
+func NewLicenseHandler(st LicenseStore, cfg *config.Config, autoAnchor *blockchain.AutoAnchor) *LicenseHandler {
 	stripe.Key = cfg.StripeSecretKey
-	return &LicenseHandler{store: st, config: cfg}
+	return &LicenseHandler{store: st, config: cfg, autoAnchor: autoAnchor}
 }
 
 // CreateOffering creates a new license offering for a content item.
@@ -89,6 +97,8 @@ func (h *LicenseHandler) CreateOffering(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	applyAnchorPolicy(r.Context(), h.autoAnchor, user, content, blockchain.Policy(user.AnchorPolicy))
+
 	writeJSON(w, http.StatusCreated, offering)
 }
 
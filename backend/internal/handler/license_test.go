@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/config"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLicenseHandler(t *testing.T) (*LicenseHandler, *storetest.Store) {
+	t.Helper()
+	st := storetest.New()
+	return NewLicenseHandler(st, &config.Config{FrontendURL: "http://localhost:3000"}, nil), st
+}
+
+func seedContent(t *testing.T, st *storetest.Store, id, userID string) *store.ContentItem {
+	t.Helper()
+	now := time.Now()
+	item := &store.ContentItem{
+		ID: id, UserID: userID, Title: "Item " + id, ContentType: "image", MimeType: "image/png",
+		FileURL: "https://example.com/" + id + ".png", HashSHA256: "hash-" + id,
+		IsPublic: true, Tags: []string{}, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, st.CreateContentItem(context.Background(), item))
+	return item
+}
+
+func TestLicenseHandler_CreateOffering(t *testing.T) {
+	h, st := newTestLicenseHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedContent(t, st, "c1", alice.ID)
+
+	rr := serve(t, h.CreateOffering, http.MethodPost, "/api/content/{id}/licenses", "/api/content/c1/licenses",
+		map[string]interface{}{"licenseType": "commercial", "priceCents": 5000}, alice)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, "usd", decode(t, rr)["currency"])
+
+	tests := []struct {
+		name   string
+		target string
+		body   map[string]interface{}
+		status int
+	}{
+		{"duplicate type", "/api/content/c1/licenses", map[string]interface{}{"licenseType": "commercial", "priceCents": 100}, http.StatusConflict},
+		{"invalid type", "/api/content/c1/licenses", map[string]interface{}{"licenseType": "exclusive", "priceCents": 100}, http.StatusBadRequest},
+		{"zero price", "/api/content/c1/licenses", map[string]interface{}{"licenseType": "personal", "priceCents": 0}, http.StatusBadRequest},
+		{"unknown content", "/api/content/c9/licenses", map[string]interface{}{"licenseType": "personal", "priceCents": 100}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, h.CreateOffering, http.MethodPost, "/api/content/{id}/licenses", tt.target, tt.body, alice)
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+		})
+	}
+
+	rr = serve(t, h.CreateOffering, http.MethodPost, "/api/content/{id}/licenses", "/api/content/c1/licenses",
+		map[string]interface{}{"licenseType": "personal", "priceCents": 100}, bob)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestLicenseHandler_ListUpdateDelete(t *testing.T) {
+	h, st := newTestLicenseHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedContent(t, st, "c1", alice.ID)
+
+	ctx := context.Background()
+	for _, o := range []*store.LicenseOffering{
+		{ID: "o1", ContentID: "c1", LicenseType: "commercial", PriceCents: 5000, Currency: "usd", IsActive: true},
+		{ID: "o2", ContentID: "c1", LicenseType: "personal", PriceCents: 500, Currency: "usd", IsActive: true},
+	} {
+		require.NoError(t, st.CreateLicenseOffering(ctx, o))
+	}
+
+	rr := serve(t, h.ListOfferings, http.MethodGet, "/api/content/{id}/licenses", "/api/content/c1/licenses", nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	offerings := decode(t, rr)["offerings"].([]interface{})
+	require.Len(t, offerings, 2)
+	assert.Equal(t, "o2", offerings[0].(map[string]interface{})["id"], "cheapest first")
+
+	rr = serve(t, h.UpdateOffering, http.MethodPatch, "/api/licenses/{id}", "/api/licenses/o1",
+		map[string]interface{}{"isActive": false}, bob)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serve(t, h.UpdateOffering, http.MethodPatch, "/api/licenses/{id}", "/api/licenses/o1",
+		map[string]interface{}{"priceCents": -1}, alice)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(t, h.UpdateOffering, http.MethodPatch, "/api/licenses/{id}", "/api/licenses/o1",
+		map[string]interface{}{"isActive": false}, alice)
+	require.Equal(t, http.StatusOK, rr.Code)
+	o1, err := st.FindOfferingByID(ctx, "o1")
+	require.NoError(t, err)
+	assert.False(t, o1.IsActive)
+	assert.Equal(t, 5000, o1.PriceCents)
+
+	rr = serve(t, h.ListOfferings, http.MethodGet, "/api/content/{id}/licenses", "/api/content/c1/licenses", nil, nil)
+	assert.Len(t, decode(t, rr)["offerings"].([]interface{}), 1, "inactive offerings are hidden")
+
+	rr = serve(t, h.DeleteOffering, http.MethodDelete, "/api/licenses/{id}", "/api/licenses/o2", nil, bob)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serve(t, h.DeleteOffering, http.MethodDelete, "/api/licenses/{id}", "/api/licenses/o2", nil, alice)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(t, h.DeleteOffering, http.MethodDelete, "/api/licenses/{id}", "/api/licenses/o2", nil, alice)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestLicenseHandler_CheckoutValidation(t *testing.T) {
+	h, st := newTestLicenseHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedContent(t, st, "c1", alice.ID)
+
+	ctx := context.Background()
+	require.NoError(t, st.CreateLicenseOffering(ctx, &store.LicenseOffering{
+		ID: "o1", ContentID: "c1", LicenseType: "commercial", PriceCents: 5000, Currency: "usd", IsActive: true,
+	}))
+	require.NoError(t, st.CreateLicenseOffering(ctx, &store.LicenseOffering{
+		ID: "o2", ContentID: "c1", LicenseType: "personal", PriceCents: 500, Currency: "usd", IsActive: false,
+	}))
+
+	rr := serve(t, h.Checkout, http.MethodPost, "/api/licenses/{id}/checkout", "/api/licenses/o1/checkout", nil, alice)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "cannot license own content")
+
+	rr = serve(t, h.Checkout, http.MethodPost, "/api/licenses/{id}/checkout", "/api/licenses/o2/checkout", nil, bob)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "inactive offering")
+
+	rr = serve(t, h.Checkout, http.MethodPost, "/api/licenses/{id}/checkout", "/api/licenses/o9/checkout", nil, bob)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	require.NoError(t, st.CreateLicensePurchase(ctx, &store.LicensePurchase{
+		ID: "p1", OfferingID: "o1", ContentID: "c1", BuyerUserID: &bob.ID, BuyerEmail: bob.Email,
+		AmountCents: 5000, Status: "completed", CreatedAt: time.Now(),
+	}))
+	rr = serve(t, h.Checkout, http.MethodPost, "/api/licenses/{id}/checkout", "/api/licenses/o1/checkout", nil, bob)
+	assert.Equal(t, http.StatusConflict, rr.Code, "already licensed")
+}
+
+func TestLicenseHandler_PurchasesAndSales(t *testing.T) {
+	h, st := newTestLicenseHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedContent(t, st, "c1", alice.ID)
+
+	require.NoError(t, st.CreateLicensePurchase(context.Background(), &store.LicensePurchase{
+		ID: "p1", OfferingID: "o1", ContentID: "c1", BuyerUserID: &bob.ID, BuyerEmail: bob.Email,
+		AmountCents: 5000, Status: "completed", CreatedAt: time.Now(),
+	}))
+
+	rr := serve(t, h.Purchases, http.MethodGet, "/api/licenses/purchases", "/api/licenses/purchases", nil, bob)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, decode(t, rr)["purchases"].([]interface{}), 1)
+
+	rr = serve(t, h.Sales, http.MethodGet, "/api/licenses/sales", "/api/licenses/sales", nil, alice)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, decode(t, rr)["sales"].([]interface{}), 1)
+
+	rr = serve(t, h.Sales, http.MethodGet, "/api/licenses/sales", "/api/licenses/sales", nil, bob)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, decode(t, rr)["sales"])
+
+	rr = serve(t, h.Sales, http.MethodGet, "/api/licenses/sales", "/api/licenses/sales", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
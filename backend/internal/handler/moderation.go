@@ -5,20 +5,23 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
 	"github.com/go-chi/chi/v5"
 )
 
 type ModerationHandler struct {
-	store *store.Store
+	store  *store.Store
+	config *config.Config
 }
 
-func NewModerationHandler(st *store.Store) *ModerationHandler {
-	return &ModerationHandler{store: st}
+func NewModerationHandler(st *store.Store, cfg *config.Config) *ModerationHandler {
+	return &ModerationHandler{store: st, config: cfg}
 }
 
-// List handles GET /api/admin/moderation — returns paginated moderation flags.
+// List handles GET /api/admin/moderation — returns paginated moderation flags
+// and audience anomalies.
 // Query params: status (optional), limit (default 20), offset (default 0).
 func (h *ModerationHandler) List(w http.ResponseWriter, r *http.Request) {
 	status := r.URL.Query().Get("status")
@@ -38,9 +41,17 @@ func (h *ModerationHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	anomalies, anomalyTotal, err := h.store.ListAudienceAnomalies(r.Context(), status, limit, offset)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch audience anomalies"})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"flags": flags,
-		"total": total,
+		"flags":        flags,
+		"total":        total,
+		"anomalies":    anomalies,
+		"anomalyTotal": anomalyTotal,
 	})
 }
 
@@ -81,3 +92,48 @@ func (h *ModerationHandler) Resolve(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
+
+// ResolveAnomaly handles POST /api/admin/moderation/anomalies/{id}/resolve —
+// confirms or dismisses an audience anomaly and rescores the creator.
+func (h *ModerationHandler) ResolveAnomaly(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	anomalyID := chi.URLParam(r, "id")
+
+	var req struct {
+		Status string `json:"status"` // "confirmed" or "dismissed"
+		Notes  string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.Status != "confirmed" && req.Status != "dismissed" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be confirmed or dismissed"})
+		return
+	}
+
+	userID, err := h.store.ResolveAudienceAnomaly(r.Context(), anomalyID, user.ID, req.Status, req.Notes)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to resolve anomaly"})
+		return
+	}
+	if userID == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Anomaly not found"})
+		return
+	}
+
+	recalcScore(r.Context(), h.store, userID, h.config.AnomalyScoreDamping)
+
+	adminAudit(h.store, r, "resolve_audience_anomaly", "audience_anomaly", anomalyID, map[string]interface{}{
+		"status": req.Status,
+		"notes":  req.Notes,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
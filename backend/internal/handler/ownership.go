@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/nrednav/cuid2"
+)
+
+// nearDuplicateDistance is the largest perceptual hash distance, in bits,
+// at which two images are taken to be copies of each other.
+const nearDuplicateDistance = 6
+
+// checkOwnership records item's perceptual hash and opens an ownership
+// conflict for each other creator who uploaded a near-duplicate earlier.
+// Failures are logged and never fail the upload.
+func checkOwnership(ctx context.Context, st store.OwnershipRepository, user *model.User, item *store.ContentItem, phash uint64) {
+	if err := st.SaveContentFingerprint(ctx, item.ID, phash); err != nil {
+		log.Printf("Fingerprint save error for %s: %v", item.ID, err)
+		return
+	}
+
+	matches, err := st.FindPerceptualMatches(ctx, phash, nearDuplicateDistance, 20, store.PerceptualFilter{
+		ExcludeUserID: user.ID,
+		Before:        &item.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("Fingerprint lookup error for %s: %v", item.ID, err)
+		return
+	}
+
+	// Matches come nearest first, so each creator's conflict is against
+	// their closest earlier upload
+	seen := map[string]bool{}
+	for _, m := range matches {
+		if seen[m.Item.UserID] {
+			continue
+		}
+		seen[m.Item.UserID] = true
+
+		conflict := &store.OwnershipConflict{
+			ID:                cuid2.Generate(),
+			ContentID:         item.ID,
+			UserID:            user.ID,
+			OriginalContentID: m.Item.ID,
+			OriginalUserID:    m.Item.UserID,
+			MatchType:         "perceptual",
+			Distance:          m.Distance,
+			CreatedAt:         time.Now(),
+		}
+		if _, err := st.CreateOwnershipConflict(ctx, conflict); err != nil {
+			log.Printf("Ownership conflict save error for %s: %v", item.ID, err)
+			continue
+		}
+		log.Printf("Ownership conflict %s: %s is %d bits from %s", conflict.ID, item.ID, m.Distance, m.Item.ID)
+	}
+}
+
+// checkDuplicate opens an exact ownership conflict, putting item in
+// dispute, if another creator uploaded a file with the same SHA-256 first.
+// Both creators are notified. Failures are logged and never fail the upload.
+func checkDuplicate(ctx context.Context, st OwnershipStore, user *model.User, item *store.ContentItem) {
+	prior, err := st.FindContentByHash(ctx, item.HashSHA256)
+	if err != nil {
+		log.Printf("Duplicate lookup error for %s: %v", item.ID, err)
+		return
+	}
+	if prior == nil || prior.ID == item.ID || prior.UserID == user.ID {
+		return
+	}
+
+	conflict := &store.OwnershipConflict{
+		ID:                cuid2.Generate(),
+		ContentID:         item.ID,
+		UserID:            user.ID,
+		OriginalContentID: prior.ID,
+		OriginalUserID:    prior.UserID,
+		MatchType:         "exact",
+		CreatedAt:         time.Now(),
+	}
+	disputed := func(userID, role string) *store.OutboxEvent {
+		return store.NewEvent(userID, webhook.EventOwnershipDisputed, webhook.OwnershipDisputed{
+			ConflictID:        conflict.ID,
+			ContentID:         item.ID,
+			OriginalContentID: prior.ID,
+			ContentHash:       item.HashSHA256,
+			Role:              role,
+		})
+	}
+	created, err := st.CreateOwnershipConflict(ctx, conflict, disputed(user.ID, "uploader"), disputed(prior.UserID, "original"))
+	if err != nil {
+		log.Printf("Ownership dispute save error for %s: %v", item.ID, err)
+		return
+	}
+	if created {
+		status := "disputed"
+		item.DisputeStatus = &status
+		log.Printf("Ownership dispute %s: %s has the same hash as %s", conflict.ID, item.ID, prior.ID)
+	}
+}
+
+// OwnershipStore is the storage OwnershipHandler depends on.
+type OwnershipStore interface {
+	store.OwnershipRepository
+	store.ContentRepository
+	store.AuditRepository
+}
+
+type OwnershipHandler struct {
+	store OwnershipStore
+}
+
+func NewOwnershipHandler(st OwnershipStore) *OwnershipHandler {
+	return &OwnershipHandler{store: st}
+}
+
+// List handles GET /api/admin/ownership-conflicts — the review queue of
+// uploads matching another creator's earlier content, oldest first.
+// Query params: status (default "open"; "all" for every status), limit
+// (default 20), offset (default 0).
+func (h *OwnershipHandler) List(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = "open"
+	case "all":
+		status = ""
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	conflicts, total, err := h.store.ListOwnershipConflicts(r.Context(), status, limit, offset)
+	if err != nil {
+		log.Printf("List ownership conflicts error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch ownership conflicts"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"conflicts": conflicts,
+		"total":     total,
+	})
+}
+
+// Resolve handles POST /api/admin/ownership-conflicts/{id}/resolve — upholds
+// or dismisses an open conflict and notifies both creators. Upholding it
+// rejects the later upload's claim; dismissing it lifts the dispute.
+func (h *OwnershipHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	conflictID := chi.URLParam(r, "id")
+
+	var req struct {
+		Status string `json:"status"` // "upheld" or "dismissed"
+		Notes  string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.Status != "upheld" && req.Status != "dismissed" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be upheld or dismissed"})
+		return
+	}
+
+	conflict, err := h.store.FindOwnershipConflictByID(r.Context(), conflictID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to resolve conflict"})
+		return
+	}
+	if conflict == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Open conflict not found"})
+		return
+	}
+
+	resolvedEvent := func(userID, role string) *store.OutboxEvent {
+		return store.NewEvent(userID, webhook.EventOwnershipResolved, webhook.OwnershipResolved{
+			ConflictID:        conflict.ID,
+			ContentID:         conflict.ContentID,
+			OriginalContentID: conflict.OriginalContentID,
+			Status:            req.Status,
+			Notes:             req.Notes,
+			Role:              role,
+		})
+	}
+	resolved, err := h.store.ResolveOwnershipConflict(r.Context(), conflictID, user.ID, req.Status, req.Notes,
+		resolvedEvent(conflict.UserID, "uploader"), resolvedEvent(conflict.OriginalUserID, "original"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to resolve conflict"})
+		return
+	}
+	if !resolved {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Open conflict not found"})
+		return
+	}
+
+	adminAudit(h.store, r, "resolve_ownership_conflict", "ownership_conflict", conflictID, map[string]interface{}{
+		"status": req.Status,
+		"notes":  req.Notes,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/config"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedUpload adds an item with the given hash, uploaded at the given time.
+func seedUpload(t *testing.T, st *storetest.Store, id, userID, hash string, at time.Time) *store.ContentItem {
+	t.Helper()
+	item := &store.ContentItem{
+		ID: id, UserID: userID, Title: "Item " + id, ContentType: "image", MimeType: "image/png",
+		FileURL: "https://example.com/" + id + ".png", HashSHA256: hash,
+		IsPublic: true, Tags: []string{}, CreatedAt: at, UpdatedAt: at,
+	}
+	require.NoError(t, st.CreateContentItem(context.Background(), item))
+	return item
+}
+
+// disputeStatus returns the stored dispute status of an item, "" if none.
+func disputeStatus(t *testing.T, st *storetest.Store, id string) string {
+	t.Helper()
+	item, err := st.FindContentItemByID(context.Background(), id)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	if item.DisputeStatus == nil {
+		return ""
+	}
+	return *item.DisputeStatus
+}
+
+// eventsOfType returns the outbox events of one type.
+func eventsOfType(st *storetest.Store, eventType string) []*store.OutboxEvent {
+	var out []*store.OutboxEvent
+	for _, ev := range st.Events() {
+		if ev.Type == eventType {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// openExactDispute uploads an original for alice and an identical copy for
+// bob, and returns bob's item and the conflict opened over it.
+func openExactDispute(t *testing.T, st *storetest.Store) (*store.ContentItem, *store.OwnershipConflict) {
+	t.Helper()
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	now := time.Now()
+	seedUpload(t, st, "c1", alice.ID, "samehash", now.Add(-time.Hour))
+	copied := seedUpload(t, st, "c2", bob.ID, "samehash", now)
+
+	checkDuplicate(context.Background(), st, bob, copied)
+	conflicts, total, err := st.ListOwnershipConflicts(context.Background(), "open", 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	return copied, &conflicts[0]
+}
+
+func TestCheckDuplicate_DisputesExactMatch(t *testing.T) {
+	st := storetest.New()
+	copied, conflict := openExactDispute(t, st)
+
+	assert.Equal(t, "c2", conflict.ContentID)
+	assert.Equal(t, "c1", conflict.OriginalContentID)
+	assert.Equal(t, "alice", conflict.OriginalUserID)
+	assert.Equal(t, "exact", conflict.MatchType)
+	require.NotNil(t, copied.DisputeStatus)
+	assert.Equal(t, "disputed", *copied.DisputeStatus)
+	assert.Equal(t, "disputed", disputeStatus(t, st, "c2"))
+	assert.Equal(t, "", disputeStatus(t, st, "c1"), "the original is not disputed")
+
+	events := eventsOfType(st, webhook.EventOwnershipDisputed)
+	require.Len(t, events, 2)
+	roles := map[string]string{}
+	for _, ev := range events {
+		var p webhook.OwnershipDisputed
+		require.NoError(t, json.Unmarshal(ev.Data.(json.RawMessage), &p))
+		assert.Equal(t, conflict.ID, p.ConflictID)
+		assert.Equal(t, "samehash", p.ContentHash)
+		roles[ev.UserID] = p.Role
+	}
+	assert.Equal(t, map[string]string{"bob": "uploader", "alice": "original"}, roles)
+
+	// Checking the same upload again opens nothing new
+	bob, err := st.FindUserByID(context.Background(), "bob")
+	require.NoError(t, err)
+	checkDuplicate(context.Background(), st, bob, copied)
+	_, total, err := st.ListOwnershipConflicts(context.Background(), "", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func TestCheckDuplicate_IgnoresOwnAndUniqueUploads(t *testing.T) {
+	st := storetest.New()
+	alice := seedUser(st, "alice", "alice")
+	now := time.Now()
+	seedUpload(t, st, "c1", alice.ID, "h1", now.Add(-time.Hour))
+
+	again := seedUpload(t, st, "c2", alice.ID, "h1", now)
+	checkDuplicate(context.Background(), st, alice, again)
+	unique := seedUpload(t, st, "c3", alice.ID, "h3", now)
+	checkDuplicate(context.Background(), st, alice, unique)
+
+	_, total, err := st.ListOwnershipConflicts(context.Background(), "", 10, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Nil(t, again.DisputeStatus)
+	assert.Empty(t, st.Events())
+}
+
+func TestOwnershipHandler_Resolve(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"upheld", "rejected"},
+		{"dismissed", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			st := storetest.New()
+			_, conflict := openExactDispute(t, st)
+			admin := seedUser(st, "admin", "admin")
+			h := NewOwnershipHandler(st)
+			target := "/api/admin/ownership-conflicts/" + conflict.ID + "/resolve"
+
+			rr := serve(t, h.Resolve, http.MethodPost, "/api/admin/ownership-conflicts/{id}/resolve", target,
+				map[string]string{"status": tt.status, "notes": "checked the originals"}, admin)
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.want, disputeStatus(t, st, "c2"))
+
+			resolved, err := st.FindOwnershipConflictByID(context.Background(), conflict.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resolved.Status)
+			require.NotNil(t, resolved.ResolvedBy)
+			assert.Equal(t, "admin", *resolved.ResolvedBy)
+
+			events := eventsOfType(st, webhook.EventOwnershipResolved)
+			require.Len(t, events, 2)
+			roles := map[string]string{}
+			for _, ev := range events {
+				var p webhook.OwnershipResolved
+				require.NoError(t, json.Unmarshal(ev.Data.(json.RawMessage), &p))
+				assert.Equal(t, tt.status, p.Status)
+				roles[ev.UserID] = p.Role
+			}
+			assert.Equal(t, map[string]string{"bob": "uploader", "alice": "original"}, roles)
+
+			audit, _, err := st.ListAuditLog(context.Background(), 10, 0)
+			require.NoError(t, err)
+			require.Len(t, audit, 1)
+			assert.Equal(t, "resolve_ownership_conflict", audit[0].Action)
+
+			rr = serve(t, h.Resolve, http.MethodPost, "/api/admin/ownership-conflicts/{id}/resolve", target,
+				map[string]string{"status": "upheld"}, admin)
+			assert.Equal(t, http.StatusNotFound, rr.Code, "a resolved conflict cannot be resolved again")
+			assert.Equal(t, tt.want, disputeStatus(t, st, "c2"))
+		})
+	}
+}
+
+func TestOwnershipHandler_DismissKeepsDisputeWhileAnotherExactConflictIsOpen(t *testing.T) {
+	st := storetest.New()
+	_, first := openExactDispute(t, st)
+	carol := seedUser(st, "carol", "carol")
+	seedUpload(t, st, "c0", carol.ID, "samehash", time.Now().Add(-2*time.Hour))
+	second := &store.OwnershipConflict{
+		ID: "oc2", ContentID: "c2", UserID: "bob", OriginalContentID: "c0", OriginalUserID: carol.ID,
+		MatchType: "exact", CreatedAt: time.Now(),
+	}
+	created, err := st.CreateOwnershipConflict(context.Background(), second)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	admin := seedUser(st, "admin", "admin")
+	h := NewOwnershipHandler(st)
+	dismiss := func(id string) {
+		rr := serve(t, h.Resolve, http.MethodPost, "/api/admin/ownership-conflicts/{id}/resolve",
+			"/api/admin/ownership-conflicts/"+id+"/resolve", map[string]string{"status": "dismissed"}, admin)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	dismiss(first.ID)
+	assert.Equal(t, "disputed", disputeStatus(t, st, "c2"), "carol's exact conflict is still open")
+	dismiss(second.ID)
+	assert.Equal(t, "", disputeStatus(t, st, "c2"))
+}
+
+func TestContentHandler_DeletingOriginalReleasesDispute(t *testing.T) {
+	st := storetest.New()
+	openExactDispute(t, st)
+	alice, err := st.FindUserByID(context.Background(), "alice")
+	require.NoError(t, err)
+
+	h := NewContentHandler(st, nil, &config.Config{}, nil, nil)
+	rr := serve(t, h.Delete, http.MethodDelete, "/api/content/{id}", "/api/content/c1", nil, alice)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	assert.Equal(t, "", disputeStatus(t, st, "c2"), "the copy is no longer disputed once its original is gone")
+	_, total, err := st.ListOwnershipConflicts(context.Background(), "", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
+
+func TestOwnershipHandler_ResolveValidation(t *testing.T) {
+	st := storetest.New()
+	_, conflict := openExactDispute(t, st)
+	admin := seedUser(st, "admin", "admin")
+	h := NewOwnershipHandler(st)
+	pattern := "/api/admin/ownership-conflicts/{id}/resolve"
+
+	tests := []struct {
+		name   string
+		id     string
+		body   map[string]string
+		status int
+	}{
+		{"invalid status", conflict.ID, map[string]string{"status": "open"}, http.StatusBadRequest},
+		{"unknown conflict", "nope", map[string]string{"status": "upheld"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, h.Resolve, http.MethodPost, pattern, "/api/admin/ownership-conflicts/"+tt.id+"/resolve", tt.body, admin)
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+		})
+	}
+
+	rr := serve(t, h.Resolve, http.MethodPost, pattern, "/api/admin/ownership-conflicts/"+conflict.ID+"/resolve",
+		map[string]string{"status": "upheld"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "disputed", disputeStatus(t, st, "c2"))
+}
+
+func TestOwnershipHandler_List(t *testing.T) {
+	st := storetest.New()
+	_, conflict := openExactDispute(t, st)
+	h := NewOwnershipHandler(st)
+
+	rr := serve(t, h.List, http.MethodGet, "/api/admin/ownership-conflicts", "/api/admin/ownership-conflicts", nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	body := decode(t, rr)
+	assert.EqualValues(t, 1, body["total"])
+	conflicts := body["conflicts"].([]interface{})
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, conflict.ID, conflicts[0].(map[string]interface{})["id"])
+	assert.Equal(t, "Item c1", conflicts[0].(map[string]interface{})["originalTitle"])
+
+	rr = serve(t, h.List, http.MethodGet, "/api/admin/ownership-conflicts", "/api/admin/ownership-conflicts?status=upheld", nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.EqualValues(t, 0, decode(t, rr)["total"])
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"strconv"
@@ -8,11 +9,31 @@ import (
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/stripe/stripe-go/v81"
 	"github.com/stripe/stripe-go/v81/account"
 	"github.com/stripe/stripe-go/v81/accountlink"
 )
 
+// updatePayoutStatus moves a payout to status and records a payout.updated
+// event, and payout.completed once it has been paid.
+func updatePayoutStatus(ctx context.Context, st store.PaymentRepository, payout *store.CreatorPayout, status string, errMsg *string) error {
+	data := webhook.Payout{
+		PayoutID:         payout.ID,
+		Status:           status,
+		PreviousStatus:   payout.Status,
+		AmountCents:      payout.AmountCents,
+		Currency:         payout.Currency,
+		StripeTransferID: payout.StripeTransferID,
+		Error:            errMsg,
+	}
+	events := []*store.OutboxEvent{store.NewEvent(payout.UserID, webhook.EventPayoutUpdated, data)}
+	if status == "completed" {
+		events = append(events, store.NewEvent(payout.UserID, webhook.EventPayoutCompleted, data))
+	}
+	return st.UpdatePayoutStatus(ctx, payout.ID, status, payout.StripeTransferID, errMsg, events...)
+}
+
 type PayoutHandler struct {
 	store  *store.Store
 	config *config.Config
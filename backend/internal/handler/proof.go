@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/config"
+	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/pkg/merkle"
+	"github.com/creatrid/creatrid/pkg/proofbundle"
+	"github.com/go-chi/chi/v5"
+)
+
+// ProofHandler issues signed proof bundles, which can be verified offline
+// with the keys it publishes (see pkg/proofbundle and cmd/creatrid-verify).
+type ProofHandler struct {
+	store  *store.Store
+	config *config.Config
+	key    ed25519.PrivateKey
+	keys   *proofbundle.KeySet
+}
+
+// NewProofHandler signs bundles with key, and publishes its public key along
+// with previous ones, so bundles signed before a key rotation still verify.
+func NewProofHandler(st *store.Store, cfg *config.Config, key ed25519.PrivateKey, previous ...ed25519.PublicKey) *ProofHandler {
+	pubs := append([]ed25519.PublicKey{key.Public().(ed25519.PublicKey)}, previous...)
+	return &ProofHandler{
+		store:  st,
+		config: cfg,
+		key:    key,
+		keys:   proofbundle.NewKeySet(pubs...),
+	}
+}
+
+// Keys handles GET /.well-known/creatrid-proof-keys.json.
+func (h *ProofHandler) Keys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, h.keys)
+}
+
+// Bundle handles GET /api/content/{id}/proof/bundle — download a signed proof
+// of ownership for a vault item. Access follows GET /api/content/{id}/proof.
+func (h *ProofHandler) Bundle(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	item, err := h.store.FindContentItemByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if item == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Content not found"})
+		return
+	}
+	if !item.IsPublic && (user == nil || item.UserID != user.ID) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not authorized"})
+		return
+	}
+
+	owner, err := h.store.FindUserByID(r.Context(), item.UserID)
+	if err != nil || owner == nil {
+		log.Printf("Proof bundle owner lookup error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+
+	payload := proofbundle.Payload{
+		Issuer:   h.config.FrontendURL,
+		IssuedAt: time.Now().UTC(),
+		Content: proofbundle.Content{
+			ID:         item.ID,
+			Title:      item.Title,
+			SHA256:     item.HashSHA256,
+			UploadedAt: item.CreatedAt.UTC(),
+		},
+		Owner: proofbundle.Owner{
+			ID:           owner.ID,
+			Verified:     owner.IsVerified,
+			AnchorPolicy: owner.AnchorPolicy,
+		},
+	}
+	if item.DisputeStatus != nil {
+		payload.Content.DisputeStatus = *item.DisputeStatus
+	}
+	if owner.Username != nil {
+		payload.Owner.Username = *owner.Username
+		payload.Owner.ProfileURL = h.config.FrontendURL + "/profile?u=" + url.QueryEscape(*owner.Username)
+	}
+	if owner.Name != nil {
+		payload.Owner.Name = *owner.Name
+	}
+
+	anchor, err := h.store.FindAnchorByContentID(r.Context(), item.ID)
+	if err != nil {
+		log.Printf("Proof bundle anchor lookup error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if anchor != nil {
+		payload.Anchor, err = h.bundleAnchor(r, anchor)
+		if err != nil {
+			log.Printf("Proof bundle batch lookup error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+			return
+		}
+	}
+
+	bundle, err := proofbundle.Sign(payload, h.key)
+	if err != nil {
+		log.Printf("Proof bundle signing error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to sign proof"})
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.creatrid-proof.json"`, item.ID))
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// bundleAnchor describes anchor, with its Merkle path and timestamp token
+// if it was anchored in a batch.
+func (h *ProofHandler) bundleAnchor(r *http.Request, anchor *store.ContentAnchor) (*proofbundle.Anchor, error) {
+	a := &proofbundle.Anchor{
+		Chain:       anchor.Chain,
+		Status:      anchor.AnchorStatus,
+		ConfirmedAt: anchor.ConfirmedAt,
+	}
+	if anchor.TxHash != nil {
+		a.TxHash = *anchor.TxHash
+	}
+	if anchor.BlockNumber != nil {
+		a.BlockNumber = *anchor.BlockNumber
+	}
+	if anchor.BlockHash != nil {
+		a.BlockHash = *anchor.BlockHash
+	}
+	if anchor.Policy != nil {
+		a.Policy = *anchor.Policy
+	}
+	if anchor.BatchID == nil || anchor.LeafIndex == nil {
+		return a, nil
+	}
+
+	batch, err := h.store.FindAnchorBatchByID(r.Context(), *anchor.BatchID)
+	if err != nil || batch == nil {
+		return a, err
+	}
+	a.Merkle = &proofbundle.Merkle{
+		Algorithm: merkle.Algorithm,
+		LeafIndex: *anchor.LeafIndex,
+		LeafCount: batch.LeafCount,
+		Path:      anchor.MerkleProof,
+		Root:      batch.MerkleRoot,
+	}
+	a.TimestampToken = batch.TimestampToken
+	return a, nil
+}
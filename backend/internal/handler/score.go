@@ -3,25 +3,26 @@ package handler
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/creatrid/creatrid/internal/score"
 	"github.com/creatrid/creatrid/internal/store"
 )
 
-func recalcScore(ctx context.Context, st *store.Store, userID string) {
+func recalcScore(ctx context.Context, st *store.Store, userID string, dampAnomalies bool) {
 	user, err := st.FindUserByID(ctx, userID)
 	if err != nil || user == nil {
 		log.Printf("Score recalc: failed to load user %s: %v", userID, err)
 		return
 	}
 
-	connections, err := st.FindConnectionsByUserID(ctx, userID)
+	signals, err := score.Gather(ctx, st, user, time.Now(), dampAnomalies)
 	if err != nil {
-		log.Printf("Score recalc: failed to load connections for %s: %v", userID, err)
+		log.Printf("Score recalc: failed to load signals for %s: %v", userID, err)
 		return
 	}
 
-	s := score.Calculate(user, connections)
+	s := score.CalculateV2(signals).Score
 
 	if err := st.UpdateUserScore(ctx, userID, s); err != nil {
 		log.Printf("Score recalc: failed to update score for %s: %v", userID, err)
@@ -10,6 +10,7 @@ import (
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/nrednav/cuid2"
 	"github.com/stripe/stripe-go/v81"
@@ -112,7 +113,14 @@ func (h *FanSubscriptionHandler) Subscribe(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	if err := h.store.CreateFanSubscription(r.Context(), sub); err != nil {
+	started := store.NewEvent(sub.CreatorUserID, webhook.EventFanSubscriptionStarted, webhook.FanSubscriptionStarted{
+		SubscriptionID: sub.ID,
+		FanUserID:      sub.FanUserID,
+		Tier:           sub.Tier,
+		PriceCents:     sub.PriceCents,
+		StartedAt:      sub.StartedAt,
+	})
+	if err := h.store.CreateFanSubscription(r.Context(), sub, started); err != nil {
 		log.Printf("Failed to create fan subscription: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create subscription"})
 		return
@@ -212,7 +220,13 @@ func (h *FanSubscriptionHandler) Cancel(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	if err := h.store.UpdateFanSubscriptionStatus(r.Context(), subID, "canceled"); err != nil {
+	canceled := store.NewEvent(found.CreatorUserID, webhook.EventFanSubscriptionCanceled, webhook.FanSubscriptionCanceled{
+		SubscriptionID: found.ID,
+		FanUserID:      found.FanUserID,
+		Tier:           found.Tier,
+		CanceledAt:     time.Now(),
+	})
+	if err := h.store.UpdateFanSubscriptionStatus(r.Context(), subID, "canceled", canceled); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to cancel subscription"})
 		return
 	}
@@ -115,11 +115,32 @@ func (h *TipHandler) Send(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The tip stays pending until the payment_intent.succeeded webhook
+	// confirms the payment.
 	stripeID := pi.ID
-	_ = h.store.UpdateTipStatus(r.Context(), tipID, "completed", &stripeID)
+	_ = h.store.UpdateTipStatus(r.Context(), tipID, "pending", &stripeID)
+
+	now := time.Now()
+	purchase := &store.PendingPurchase{
+		ID:                    cuid2.Generate(),
+		Kind:                  "tip",
+		UserID:                user.ID,
+		TipID:                 &tipID,
+		AmountCents:           int64(req.AmountCents),
+		StripePaymentIntentID: pi.ID,
+		Status:                "pending",
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if err := h.store.CreatePendingPurchase(r.Context(), purchase); err != nil {
+		log.Printf("Failed to record pending tip: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record tip"})
+		return
+	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"id":           tipID,
+		"status":       "pending",
 		"clientSecret": pi.ClientSecret,
 	})
 }
@@ -0,0 +1,440 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrednav/cuid2"
+)
+
+var validRewardKinds = map[string]bool{
+	"shoutout": true,
+	"file":     true,
+	"custom":   true,
+}
+
+// ListRewards returns the redemption catalogue for a token. The token's
+// creator also sees inactive rewards.
+func (h *TokenHandler) ListRewards(w http.ResponseWriter, r *http.Request) {
+	if !h.requireTransferable(w) {
+		return
+	}
+
+	token, err := h.store.FindTokenByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if token == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Token not found"})
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	isOwner := user != nil && user.ID == token.UserID
+
+	rewards, err := h.store.ListTokenRewards(r.Context(), token.ID, isOwner)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if rewards == nil {
+		rewards = []*store.TokenReward{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rewards": rewards})
+}
+
+type createRewardRequest struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Kind        string  `json:"kind"`
+	ContentID   *string `json:"contentId"`
+	Cost        int     `json:"cost"`
+	Quantity    *int    `json:"quantity"`
+}
+
+// CreateReward adds a reward to the current user's token catalogue.
+func (h *TokenHandler) CreateReward(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+	if !h.requireTransferable(w) {
+		return
+	}
+
+	token, err := h.store.FindTokenByUserID(r.Context(), user.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if token == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "No token found"})
+		return
+	}
+
+	var req createRewardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Title is required"})
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = "custom"
+	}
+	if !validRewardKinds[req.Kind] {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Kind must be shoutout, file or custom"})
+		return
+	}
+	if req.Cost <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Cost must be positive"})
+		return
+	}
+	if req.Quantity != nil && *req.Quantity < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity cannot be negative"})
+		return
+	}
+
+	var contentID *string
+	if req.Kind == "file" {
+		if req.ContentID == nil || *req.ContentID == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "contentId is required for file rewards"})
+			return
+		}
+		item, err := h.store.FindContentItemByID(r.Context(), *req.ContentID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+			return
+		}
+		if item == nil || item.UserID != user.ID {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "Content not found"})
+			return
+		}
+		contentID = &item.ID
+	}
+
+	var desc *string
+	if d := strings.TrimSpace(req.Description); d != "" {
+		desc = &d
+	}
+
+	now := time.Now()
+	reward := &store.TokenReward{
+		ID:                cuid2.Generate(),
+		TokenID:           token.ID,
+		Title:             req.Title,
+		Description:       desc,
+		Kind:              req.Kind,
+		ContentID:         contentID,
+		Cost:              req.Cost,
+		QuantityRemaining: req.Quantity,
+		IsActive:          true,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := h.store.CreateTokenReward(r.Context(), reward); err != nil {
+		log.Printf("Failed to create token reward: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create reward"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"reward": reward})
+}
+
+type updateRewardRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Cost        *int    `json:"cost"`
+	Quantity    *int    `json:"quantity"`
+	IsActive    *bool   `json:"isActive"`
+}
+
+// UpdateReward modifies a reward in the current user's catalogue.
+func (h *TokenHandler) UpdateReward(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+	if !h.requireTransferable(w) {
+		return
+	}
+
+	reward, ok := h.ownedReward(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	var req updateRewardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	title := reward.Title
+	if req.Title != nil {
+		title = strings.TrimSpace(*req.Title)
+		if title == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Title is required"})
+			return
+		}
+	}
+	desc := reward.Description
+	if req.Description != nil {
+		d := strings.TrimSpace(*req.Description)
+		desc = &d
+	}
+	cost := reward.Cost
+	if req.Cost != nil {
+		if *req.Cost <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Cost must be positive"})
+			return
+		}
+		cost = *req.Cost
+	}
+	quantity := reward.QuantityRemaining
+	if req.Quantity != nil {
+		if *req.Quantity < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity cannot be negative"})
+			return
+		}
+		quantity = req.Quantity
+	}
+	isActive := reward.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	if err := h.store.UpdateTokenReward(r.Context(), reward.ID, title, desc, cost, quantity, isActive); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update reward"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ownedReward loads the {rewardId} reward and checks it belongs to the
+// current user's token, writing an error response if not.
+func (h *TokenHandler) ownedReward(w http.ResponseWriter, r *http.Request, userID string) (*store.TokenReward, bool) {
+	reward, err := h.store.FindTokenRewardByID(r.Context(), chi.URLParam(r, "rewardId"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return nil, false
+	}
+	if reward == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Reward not found"})
+		return nil, false
+	}
+
+	token, err := h.store.FindTokenByID(r.Context(), reward.TokenID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return nil, false
+	}
+	if token == nil || token.UserID != userID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not your reward"})
+		return nil, false
+	}
+	return reward, true
+}
+
+type redeemRewardRequest struct {
+	Note string `json:"note"`
+}
+
+// Redeem burns tokens from the current user's balance in exchange for a reward.
+func (h *TokenHandler) Redeem(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+	if !h.requireTransferable(w) {
+		return
+	}
+
+	// The body is optional.
+	var req redeemRewardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	reward, err := h.store.FindTokenRewardByID(r.Context(), chi.URLParam(r, "rewardId"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if reward == nil || !reward.IsActive {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Reward not found"})
+		return
+	}
+
+	token, err := h.store.FindTokenByID(r.Context(), reward.TokenID)
+	if err != nil || token == nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if token.UserID == user.ID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Cannot redeem your own reward"})
+		return
+	}
+
+	if reward.QuantityRemaining != nil && *reward.QuantityRemaining <= 0 {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Reward is sold out"})
+		return
+	}
+	balance, err := h.store.GetTokenBalance(r.Context(), token.ID, user.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if balance < reward.Cost {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Insufficient balance"})
+		return
+	}
+
+	var note *string
+	if n := strings.TrimSpace(req.Note); n != "" {
+		note = &n
+	}
+
+	// The store charges the cost the reward has when it is taken, which an
+	// edit may have changed since it was loaded above
+	redemption := &store.TokenRedemption{
+		ID:        cuid2.Generate(),
+		RewardID:  reward.ID,
+		TokenID:   token.ID,
+		UserID:    user.ID,
+		Note:      note,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+	redeemed := func(rd *store.TokenRedemption) []*store.OutboxEvent {
+		return []*store.OutboxEvent{store.NewEvent(token.UserID, webhook.EventTokenRedeemed, webhook.TokenRedeemed{
+			TokenID:      token.ID,
+			Symbol:       token.Symbol,
+			RewardID:     reward.ID,
+			RewardTitle:  reward.Title,
+			RedemptionID: rd.ID,
+			UserID:       user.ID,
+			Cost:         rd.Cost,
+		})}
+	}
+	if err := h.store.RedeemTokenReward(r.Context(), redemption, redeemed); err != nil {
+		if err == pgx.ErrNoRows {
+			// Lost a race with another redemption or transfer.
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "Reward is no longer available"})
+			return
+		}
+		log.Printf("Failed to redeem token reward: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to redeem reward"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"redemption": redemption,
+		"contentId":  reward.ContentID,
+		"balance":    balance - redemption.Cost,
+	})
+}
+
+// Redemptions lists redemptions of the current user's rewards.
+func (h *TokenHandler) Redemptions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+	if !h.requireTransferable(w) {
+		return
+	}
+
+	token, err := h.store.FindTokenByUserID(r.Context(), user.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if token == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "No token found"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	redemptions, total, err := h.store.ListTokenRedemptions(r.Context(), token.ID, limit, offset)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if redemptions == nil {
+		redemptions = []*store.TokenRedemption{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"redemptions": redemptions,
+		"total":       total,
+	})
+}
+
+// FulfillRedemption marks a redemption of one of the current user's rewards
+// as fulfilled.
+func (h *TokenHandler) FulfillRedemption(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+	if !h.requireTransferable(w) {
+		return
+	}
+
+	redemption, err := h.store.FindTokenRedemptionByID(r.Context(), chi.URLParam(r, "redemptionId"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if redemption == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Redemption not found"})
+		return
+	}
+
+	token, err := h.store.FindTokenByID(r.Context(), redemption.TokenID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if token == nil || token.UserID != user.ID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not your redemption"})
+		return
+	}
+
+	if err := h.store.FulfillTokenRedemption(r.Context(), redemption.ID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update redemption"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"regexp"
@@ -11,31 +13,55 @@ import (
 
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/middleware"
+	"github.com/creatrid/creatrid/internal/pricing"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/nrednav/cuid2"
 	"github.com/stripe/stripe-go/v81"
 	"github.com/stripe/stripe-go/v81/paymentintent"
 )
 
+// TokenStore is the storage TokenHandler depends on.
+type TokenStore interface {
+	store.TokenRepository
+	store.UserRepository
+	store.ContentRepository
+}
+
 // TokenHandler manages creator token endpoints.
 type TokenHandler struct {
-	store  *store.Store
+	store  TokenStore
 	config *config.Config
 }
 
 // NewTokenHandler creates a new TokenHandler.
-func NewTokenHandler(st *store.Store, cfg *config.Config) *TokenHandler {
+func NewTokenHandler(st TokenStore, cfg *config.Config) *TokenHandler {
 	return &TokenHandler{store: st, config: cfg}
 }
 
 var symbolRegex = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
 
+// maxPaymentCents is the largest amount Stripe accepts for a USD charge.
+const maxPaymentCents = 99_999_999
+
+// purchaseReservation is how long a pending token purchase holds its place
+// on the pricing curve while its payment is confirmed.
+const purchaseReservation = time.Hour
+
+// errPurchaseTooLarge is returned when a purchase prices beyond
+// maxPaymentCents.
+var errPurchaseTooLarge = errors.New("purchase amount is too large")
+
 type createTokenRequest struct {
-	Name        string `json:"name"`
-	Symbol      string `json:"symbol"`
-	Description string `json:"description"`
-	PriceCents  int    `json:"priceCents"`
+	Name                 string         `json:"name"`
+	Symbol               string         `json:"symbol"`
+	Description          string         `json:"description"`
+	PriceCents           int            `json:"priceCents"`
+	PricingModel         string         `json:"pricingModel"`
+	PriceSlopeMilliCents int            `json:"priceSlopeMilliCents"`
+	PriceTiers           []pricing.Tier `json:"priceTiers"`
 }
 
 // Create creates a new creator token.
@@ -68,6 +94,19 @@ func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.PriceCents < 1 {
 		req.PriceCents = 100
 	}
+	if req.PricingModel == "" {
+		req.PricingModel = string(pricing.Flat)
+	}
+	curve := pricing.Curve{
+		Model:           pricing.Model(req.PricingModel),
+		BaseCents:       req.PriceCents,
+		SlopeMilliCents: req.PriceSlopeMilliCents,
+		Tiers:           req.PriceTiers,
+	}
+	if err := curve.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid pricing: " + err.Error()})
+		return
+	}
 
 	// Check if user already has a token
 	existing, err := h.store.FindTokenByUserID(r.Context(), user.ID)
@@ -99,16 +138,19 @@ func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	now := time.Now()
 	token := &store.CreatorToken{
-		ID:          cuid2.Generate(),
-		UserID:      user.ID,
-		Name:        req.Name,
-		Symbol:      req.Symbol,
-		Description: desc,
-		TotalSupply: 0,
-		PriceCents:  req.PriceCents,
-		IsActive:    true,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:                   cuid2.Generate(),
+		UserID:               user.ID,
+		Name:                 req.Name,
+		Symbol:               req.Symbol,
+		Description:          desc,
+		TotalSupply:          0,
+		PriceCents:           curve.BaseCents,
+		PricingModel:         curve.Model,
+		PriceSlopeMilliCents: curve.SlopeMilliCents,
+		PriceTiers:           curve.Tiers,
+		IsActive:             true,
+		CreatedAt:            now,
+		UpdatedAt:            now,
 	}
 
 	if err := h.store.CreateCreatorToken(r.Context(), token); err != nil {
@@ -142,10 +184,13 @@ func (h *TokenHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 type updateTokenRequest struct {
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
-	PriceCents  *int    `json:"priceCents"`
-	IsActive    *bool   `json:"isActive"`
+	Name                 *string        `json:"name"`
+	Description          *string        `json:"description"`
+	PriceCents           *int           `json:"priceCents"`
+	PricingModel         *string        `json:"pricingModel"`
+	PriceSlopeMilliCents *int           `json:"priceSlopeMilliCents"`
+	PriceTiers           []pricing.Tier `json:"priceTiers"`
+	IsActive             *bool          `json:"isActive"`
 }
 
 // Update modifies the current user's token.
@@ -190,7 +235,26 @@ func (h *TokenHandler) Update(w http.ResponseWriter, r *http.Request) {
 		isActive = *req.IsActive
 	}
 
-	if err := h.store.UpdateCreatorToken(r.Context(), token.ID, name, desc, priceCents, isActive); err != nil {
+	curve := token.Curve()
+	curve.BaseCents = priceCents
+	repriced := req.PricingModel != nil || req.PriceSlopeMilliCents != nil || req.PriceTiers != nil
+	if req.PricingModel != nil {
+		curve.Model = pricing.Model(*req.PricingModel)
+	}
+	if req.PriceSlopeMilliCents != nil {
+		curve.SlopeMilliCents = *req.PriceSlopeMilliCents
+	}
+	if req.PriceTiers != nil {
+		curve.Tiers = req.PriceTiers
+	}
+	if repriced {
+		if err := curve.Validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid pricing: " + err.Error()})
+			return
+		}
+	}
+
+	if err := h.store.UpdateCreatorToken(r.Context(), token.ID, name, desc, isActive, curve); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update token"})
 		return
 	}
@@ -246,11 +310,148 @@ func (h *TokenHandler) Transactions(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Quote prices a prospective purchase of ?amount= tokens at the current
+// supply, counting pending purchases, without creating a payment.
+func (h *TokenHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	amount := 1
+	if v := r.URL.Query().Get("amount"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Amount must be a positive integer"})
+			return
+		}
+		amount = n
+	}
+
+	token, err := h.store.FindTokenByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if token == nil || !token.IsActive {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Token not found or inactive"})
+		return
+	}
+
+	reserved, err := h.store.ReservedTokenAmount(r.Context(), token.ID, time.Now().Add(-purchaseReservation))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+
+	quote, err := token.Curve().Quote(token.TotalSupply+reserved, amount)
+	if err != nil || quote.TotalCents > maxPaymentCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Purchase amount is too large"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tokenId":      token.ID,
+		"pricingModel": token.Curve().Model,
+		"quote":        quote,
+	})
+}
+
+type transferTokenRequest struct {
+	ToUsername string `json:"toUsername"`
+	Amount     int    `json:"amount"`
+}
+
+// Transfer moves tokens from the current user to another holder.
+func (h *TokenHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+	if !h.requireTransferable(w) {
+		return
+	}
+
+	tokenID := chi.URLParam(r, "id")
+
+	var req transferTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	req.ToUsername = strings.TrimSpace(req.ToUsername)
+	if req.ToUsername == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "toUsername is required"})
+		return
+	}
+	if req.Amount <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Amount must be positive"})
+		return
+	}
+
+	token, err := h.store.FindTokenByID(r.Context(), tokenID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if token == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Token not found"})
+		return
+	}
+
+	recipient, err := h.store.FindUserByUsername(r.Context(), req.ToUsername)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+	if recipient == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Recipient not found"})
+		return
+	}
+	if recipient.ID == user.ID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Cannot transfer tokens to yourself"})
+		return
+	}
+
+	transferred := store.NewEvent(token.UserID, webhook.EventTokenTransferred, webhook.TokenTransferred{
+		TokenID:    token.ID,
+		Symbol:     token.Symbol,
+		FromUserID: user.ID,
+		ToUserID:   recipient.ID,
+		Amount:     req.Amount,
+	})
+	if err := h.store.TransferTokens(r.Context(), tokenID, user.ID, recipient.ID, req.Amount, transferred); err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Insufficient balance"})
+			return
+		}
+		log.Printf("Failed to transfer tokens: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to transfer tokens"})
+		return
+	}
+
+	balance, _ := h.store.GetTokenBalance(r.Context(), tokenID, user.ID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"balance": balance,
+	})
+}
+
+// requireTransferable writes a 403 and returns false unless token transfers
+// and redemptions are enabled.
+func (h *TokenHandler) requireTransferable(w http.ResponseWriter) bool {
+	if !h.config.TokensTransferable {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Token transfers are not enabled"})
+		return false
+	}
+	return true
+}
+
 type purchaseTokenRequest struct {
 	Amount int `json:"amount"`
 }
 
-// Purchase buys tokens — creates a Stripe payment intent.
+// Purchase buys tokens — creates a Stripe payment intent. The purchase is
+// priced above the supply already minted or reserved by pending purchases,
+// and reserves its own tokens until it is paid or purchaseReservation
+// passes.
 func (h *TokenHandler) Purchase(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	if user == nil {
@@ -287,47 +488,103 @@ func (h *TokenHandler) Purchase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	totalCents := int64(token.PriceCents) * int64(req.Amount)
+	// The price can only rise from here; reject early what is already too much
+	if minCents, err := token.Curve().Cost(token.TotalSupply, req.Amount); err != nil || minCents > maxPaymentCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Purchase amount is too large"})
+		return
+	}
 
 	if h.config.StripeSecretKey == "" {
 		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Payment processing is not configured"})
 		return
 	}
 
+	// The reservation is priced and committed first, so the token is not
+	// locked while Stripe is called. Tokens are minted by the
+	// payment_intent.succeeded webhook once Stripe confirms the payment.
+	now := time.Now()
+	purchase, err := h.store.ReserveTokenPurchase(r.Context(), tokenID, now.Add(-purchaseReservation), func(supply int) (*store.PendingPurchase, error) {
+		totalCents, err := token.Curve().Cost(supply, req.Amount)
+		if err != nil || totalCents > maxPaymentCents {
+			return nil, errPurchaseTooLarge
+		}
+		return &store.PendingPurchase{
+			ID:          cuid2.Generate(),
+			Kind:        "token_purchase",
+			UserID:      user.ID,
+			TokenID:     &tokenID,
+			Amount:      req.Amount,
+			AmountCents: totalCents,
+			Status:      "pending",
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}, nil
+	})
+	if errors.Is(err, errPurchaseTooLarge) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Purchase amount is too large"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to reserve token purchase: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record purchase"})
+		return
+	}
+	if purchase == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Token not found or inactive"})
+		return
+	}
+
 	params := &stripe.PaymentIntentParams{
-		Amount:   stripe.Int64(totalCents),
+		Amount:   stripe.Int64(purchase.AmountCents),
 		Currency: stripe.String(string(stripe.CurrencyUSD)),
 	}
+	// Keyed on the reservation, so a retried request cannot create a second
+	// PaymentIntent for it
+	params.SetIdempotencyKey("token-purchase-" + purchase.ID)
 	params.AddMetadata("type", "token_purchase")
+	params.AddMetadata("purchase_id", purchase.ID)
 	params.AddMetadata("token_id", tokenID)
 	params.AddMetadata("buyer_user_id", user.ID)
 	params.AddMetadata("amount", strconv.Itoa(req.Amount))
 
 	pi, err := paymentintent.New(params)
 	if err != nil {
-		log.Printf("Stripe payment intent error: %v", err)
+		log.Printf("Stripe payment intent error for purchase %s: %v", purchase.ID, err)
+		h.releasePurchase(purchase.ID)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Payment failed"})
 		return
 	}
-
-	purchaseID := cuid2.Generate()
-	if err := h.store.MintTokens(r.Context(), tokenID, user.ID, req.Amount, "purchase", purchaseID); err != nil {
-		log.Printf("Failed to mint tokens: %v", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to mint tokens"})
+	attached, err := h.store.AttachPaymentIntent(r.Context(), purchase.ID, pi.ID)
+	if err != nil || !attached {
+		// Without the link the webhook could never settle the payment
+		log.Printf("Failed to attach payment intent %s to purchase %s: %v", pi.ID, purchase.ID, err)
+		if _, cancelErr := paymentintent.Cancel(pi.ID, nil); cancelErr != nil {
+			log.Printf("Failed to cancel payment intent %s: %v", pi.ID, cancelErr)
+		}
+		h.releasePurchase(purchase.ID)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record purchase"})
 		return
 	}
 
-	resp := map[string]interface{}{
-		"success":      true,
-		"clientSecret": pi.ClientSecret,
-		"amountCents":  totalCents,
-	}
-	if h.config.TokensTransferable {
-		resp["tokensMinted"] = req.Amount
-	} else {
-		resp["supportPoints"] = req.Amount
+	// Nothing is minted until the webhook settles the payment
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":       true,
+		"purchaseId":    purchase.ID,
+		"status":        purchase.Status,
+		"clientSecret":  pi.ClientSecret,
+		"amountCents":   purchase.AmountCents,
+		"pendingAmount": purchase.Amount,
+	})
+}
+
+// releasePurchase gives up a reservation whose PaymentIntent could not be
+// set up, so it stops holding its place on the curve.
+func (h *TokenHandler) releasePurchase(purchaseID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.store.ReleaseTokenPurchase(ctx, purchaseID); err != nil {
+		log.Printf("Failed to release token purchase %s: %v", purchaseID, err)
 	}
-	writeJSON(w, http.StatusOK, resp)
 }
 
 // PublicToken returns a creator's token info by username.
@@ -0,0 +1,439 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"encoding/json"
+	"github.com/creatrid/creatrid/internal/config"
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokenHandler(t *testing.T) (*TokenHandler, *storetest.Store) {
+	t.Helper()
+	st := storetest.New()
+	return NewTokenHandler(st, &config.Config{}), st
+}
+
+func seedToken(t *testing.T, st *storetest.Store, id, userID, symbol string, active bool) *store.CreatorToken {
+	t.Helper()
+	now := time.Now()
+	token := &store.CreatorToken{
+		ID: id, UserID: userID, Name: symbol + " Token", Symbol: symbol,
+		PriceCents: 100, IsActive: active, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, st.CreateCreatorToken(context.Background(), token))
+	return token
+}
+
+func TestTokenHandler_Create(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+
+	rr := serve(t, h.Create, http.MethodPost, "/api/tokens", "/api/tokens", map[string]interface{}{
+		"name": "Alice Coin", "symbol": "alc",
+	}, alice)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	token := decode(t, rr)["token"].(map[string]interface{})
+	assert.Equal(t, "ALC", token["symbol"])
+	assert.Equal(t, float64(100), token["priceCents"], "price defaults to 100 cents")
+
+	created, err := st.FindTokenByUserID(context.Background(), alice.ID)
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	assert.True(t, created.IsActive)
+
+	tests := []struct {
+		name   string
+		body   map[string]interface{}
+		user   *model.User
+		status int
+	}{
+		{"second token for same user", map[string]interface{}{"name": "Again", "symbol": "AGN"}, alice, http.StatusConflict},
+		{"symbol taken", map[string]interface{}{"name": "Bob Coin", "symbol": "ALC"}, bob, http.StatusConflict},
+		{"invalid symbol", map[string]interface{}{"name": "Bob Coin", "symbol": "b!"}, bob, http.StatusBadRequest},
+		{"missing name", map[string]interface{}{"symbol": "BOB"}, bob, http.StatusBadRequest},
+		{"unauthenticated", map[string]interface{}{"name": "Bob Coin", "symbol": "BOB"}, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, h.Create, http.MethodPost, "/api/tokens", "/api/tokens", tt.body, tt.user)
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestTokenHandler_Update(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+
+	rr := serve(t, h.Update, http.MethodPatch, "/api/tokens", "/api/tokens", map[string]interface{}{
+		"priceCents": 250, "isActive": false,
+	}, alice)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	token, err := st.FindTokenByID(context.Background(), "tok1")
+	require.NoError(t, err)
+	assert.Equal(t, 250, token.PriceCents)
+	assert.False(t, token.IsActive)
+	assert.Equal(t, "ALC Token", token.Name, "unset fields are kept")
+
+	rr = serve(t, h.Update, http.MethodPatch, "/api/tokens", "/api/tokens", map[string]interface{}{"priceCents": 1}, bob)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestTokenHandler_GetDisclaimer(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+
+	rr := serve(t, h.Get, http.MethodGet, "/api/tokens", "/api/tokens", nil, alice)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, decode(t, rr), "disclaimer")
+
+	h.config.TokensTransferable = true
+	rr = serve(t, h.Get, http.MethodGet, "/api/tokens", "/api/tokens", nil, alice)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, decode(t, rr), "disclaimer")
+}
+
+func TestTokenHandler_HoldersAndTransactions(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	carol := seedUser(st, "carol", "carol")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+
+	ctx := context.Background()
+	require.NoError(t, st.MintTokens(ctx, "tok1", bob.ID, 5, "purchase", "p1"))
+	require.NoError(t, st.MintTokens(ctx, "tok1", carol.ID, 9, "purchase", "p2"))
+
+	rr := serve(t, h.Holders, http.MethodGet, "/api/tokens/{id}/holders", "/api/tokens/tok1/holders", nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := decode(t, rr)
+	assert.Equal(t, float64(2), body["total"])
+	holders := body["holders"].([]interface{})
+	require.Len(t, holders, 2)
+	assert.Equal(t, "carol", holders[0].(map[string]interface{})["userUsername"], "largest holder first")
+
+	rr = serve(t, h.Transactions, http.MethodGet, "/api/tokens/{id}/transactions", "/api/tokens/tok1/transactions?limit=1", nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	body = decode(t, rr)
+	assert.Equal(t, float64(2), body["total"])
+	assert.Len(t, body["transactions"].([]interface{}), 1)
+
+	rr = serve(t, h.Holders, http.MethodGet, "/api/tokens/{id}/holders", "/api/tokens/none/holders", nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []interface{}{}, decode(t, rr)["holders"])
+}
+
+func TestTokenHandler_PurchaseValidation(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+	seedToken(t, st, "tok2", bob.ID, "BOB", false)
+
+	tests := []struct {
+		name   string
+		target string
+		amount int
+		status int
+	}{
+		{"own token", "/api/tokens/tok1/purchase", 1, http.StatusBadRequest},
+		{"inactive token", "/api/tokens/tok2/purchase", 1, http.StatusNotFound},
+		{"unknown token", "/api/tokens/nope/purchase", 1, http.StatusNotFound},
+		{"non-positive amount", "/api/tokens/tok1/purchase", 0, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, h.Purchase, http.MethodPost, "/api/tokens/{id}/purchase", tt.target,
+				map[string]int{"amount": tt.amount}, alice)
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+		})
+	}
+
+	// Without Stripe configured nothing is minted.
+	rr := serve(t, h.Purchase, http.MethodPost, "/api/tokens/{id}/purchase", "/api/tokens/tok1/purchase",
+		map[string]int{"amount": 3}, bob)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	balance, err := st.GetTokenBalance(context.Background(), "tok1", bob.ID)
+	require.NoError(t, err)
+	assert.Zero(t, balance)
+}
+
+func TestTokenHandler_PublicToken(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+
+	rr := serve(t, h.PublicToken, http.MethodGet, "/api/users/{username}/token", "/api/users/alice/token", nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "ALC", decode(t, rr)["token"].(map[string]interface{})["symbol"])
+
+	rr = serve(t, h.PublicToken, http.MethodGet, "/api/users/{username}/token", "/api/users/nobody/token", nil, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestTokenHandler_Transfer(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedUser(st, "carol", "carol")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+
+	ctx := context.Background()
+	require.NoError(t, st.MintTokens(ctx, "tok1", bob.ID, 5, "purchase", "p1"))
+
+	transfer := func(body map[string]interface{}) int {
+		rr := serve(t, h.Transfer, http.MethodPost, "/api/tokens/{id}/transfer", "/api/tokens/tok1/transfer", body, bob)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, transfer(map[string]interface{}{"toUsername": "carol", "amount": 2}),
+		"transfers are off by default")
+
+	h.config.TokensTransferable = true
+	tests := []struct {
+		name   string
+		body   map[string]interface{}
+		status int
+	}{
+		{"to self", map[string]interface{}{"toUsername": "bob", "amount": 1}, http.StatusBadRequest},
+		{"unknown recipient", map[string]interface{}{"toUsername": "nobody", "amount": 1}, http.StatusNotFound},
+		{"non-positive amount", map[string]interface{}{"toUsername": "carol", "amount": 0}, http.StatusBadRequest},
+		{"insufficient balance", map[string]interface{}{"toUsername": "carol", "amount": 6}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, transfer(tt.body))
+		})
+	}
+
+	require.Equal(t, http.StatusOK, transfer(map[string]interface{}{"toUsername": "carol", "amount": 2}))
+	bobBalance, err := st.GetTokenBalance(ctx, "tok1", bob.ID)
+	require.NoError(t, err)
+	carolBalance, err := st.GetTokenBalance(ctx, "tok1", "carol")
+	require.NoError(t, err)
+	assert.Equal(t, 3, bobBalance)
+	assert.Equal(t, 2, carolBalance)
+
+	evs := st.Events()
+	require.Len(t, evs, 1, "only the successful transfer records an event")
+	assert.Equal(t, "token.transferred", evs[0].Type)
+	assert.Equal(t, alice.ID, evs[0].UserID, "sent to the token's creator")
+	assert.JSONEq(t, `{"tokenId":"tok1","symbol":"ALC","fromUserId":"bob","toUserId":"carol","amount":2}`, string(evs[0].Data.(json.RawMessage)))
+}
+
+func TestTokenHandler_Rewards(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	h.config.TokensTransferable = true
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+	seedToken(t, st, "tok1", alice.ID, "ALC", true)
+	seedContent(t, st, "c1", alice.ID)
+
+	ctx := context.Background()
+	require.NoError(t, st.MintTokens(ctx, "tok1", bob.ID, 10, "purchase", "p1"))
+
+	tests := []struct {
+		name   string
+		body   map[string]interface{}
+		status int
+	}{
+		{"missing title", map[string]interface{}{"cost": 1}, http.StatusBadRequest},
+		{"zero cost", map[string]interface{}{"title": "Hi", "cost": 0}, http.StatusBadRequest},
+		{"unknown kind", map[string]interface{}{"title": "Hi", "cost": 1, "kind": "nft"}, http.StatusBadRequest},
+		{"file without content", map[string]interface{}{"title": "Hi", "cost": 1, "kind": "file"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, h.CreateReward, http.MethodPost, "/api/tokens/rewards", "/api/tokens/rewards", tt.body, alice)
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+		})
+	}
+
+	rr := serve(t, h.CreateReward, http.MethodPost, "/api/tokens/rewards", "/api/tokens/rewards", map[string]interface{}{
+		"title": "Shout-out", "kind": "shoutout", "cost": 4, "quantity": 1,
+	}, alice)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	shoutout := decode(t, rr)["reward"].(map[string]interface{})["id"].(string)
+
+	rr = serve(t, h.CreateReward, http.MethodPost, "/api/tokens/rewards", "/api/tokens/rewards", map[string]interface{}{
+		"title": "Raw file", "kind": "file", "contentId": "c1", "cost": 8,
+	}, alice)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	file := decode(t, rr)["reward"].(map[string]interface{})["id"].(string)
+
+	rr = serve(t, h.ListRewards, http.MethodGet, "/api/tokens/{id}/rewards", "/api/tokens/tok1/rewards", nil, bob)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rewards := decode(t, rr)["rewards"].([]interface{})
+	require.Len(t, rewards, 2)
+	assert.Equal(t, shoutout, rewards[0].(map[string]interface{})["id"], "cheapest first")
+
+	redeem := func(rewardID string, user *model.User) *httptest.ResponseRecorder {
+		return serve(t, h.Redeem, http.MethodPost, "/api/tokens/rewards/{rewardId}/redeem",
+			"/api/tokens/rewards/"+rewardID+"/redeem", map[string]string{"note": "for my stream"}, user)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, redeem(shoutout, alice).Code, "own reward")
+
+	rr = redeem(shoutout, bob)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	body := decode(t, rr)
+	assert.Equal(t, float64(6), body["balance"])
+	redemptionID := body["redemption"].(map[string]interface{})["id"].(string)
+
+	assert.Equal(t, http.StatusConflict, redeem(shoutout, bob).Code, "sold out")
+	assert.Equal(t, http.StatusBadRequest, redeem(file, bob).Code, "insufficient balance")
+
+	token, err := st.FindTokenByID(ctx, "tok1")
+	require.NoError(t, err)
+	assert.Equal(t, 6, token.TotalSupply, "redeemed tokens are burned")
+
+	txs, _, err := st.ListTokenTransactions(ctx, "tok1", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "redemption", txs[0].TxType)
+	assert.Equal(t, redemptionID, *txs[0].ReferenceID)
+
+	rr = serve(t, h.Redemptions, http.MethodGet, "/api/tokens/redemptions", "/api/tokens/redemptions", nil, alice)
+	require.Equal(t, http.StatusOK, rr.Code)
+	redemptions := decode(t, rr)["redemptions"].([]interface{})
+	require.Len(t, redemptions, 1)
+	assert.Equal(t, "Shout-out", redemptions[0].(map[string]interface{})["rewardTitle"])
+
+	fulfill := "/api/tokens/redemptions/" + redemptionID + "/fulfill"
+	rr = serve(t, h.FulfillRedemption, http.MethodPost, "/api/tokens/redemptions/{redemptionId}/fulfill", fulfill, nil, bob)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serve(t, h.FulfillRedemption, http.MethodPost, "/api/tokens/redemptions/{redemptionId}/fulfill", fulfill, nil, alice)
+	require.Equal(t, http.StatusOK, rr.Code)
+	redemption, err := st.FindTokenRedemptionByID(ctx, redemptionID)
+	require.NoError(t, err)
+	assert.Equal(t, "fulfilled", redemption.Status)
+
+	h.config.TokensTransferable = false
+	assert.Equal(t, http.StatusForbidden, redeem(file, bob).Code)
+}
+
+// repricingStore raises a reward's cost to newCost just after the handler
+// has loaded it, as a concurrent edit by the creator would.
+type repricingStore struct {
+	*storetest.Store
+	newCost int
+}
+
+func (s *repricingStore) FindTokenRewardByID(ctx context.Context, id string) (*store.TokenReward, error) {
+	rw, err := s.Store.FindTokenRewardByID(ctx, id)
+	if err != nil || rw == nil {
+		return rw, err
+	}
+	return rw, s.Store.UpdateTokenReward(ctx, id, rw.Title, rw.Description, s.newCost, rw.QuantityRemaining, rw.IsActive)
+}
+
+func TestTokenHandler_RedeemChargesCurrentCost(t *testing.T) {
+	st := &repricingStore{Store: storetest.New(), newCost: 7}
+	h := NewTokenHandler(st, &config.Config{TokensTransferable: true})
+	alice := seedUser(st.Store, "alice", "alice")
+	bob := seedUser(st.Store, "bob", "bob")
+	seedToken(t, st.Store, "tok1", alice.ID, "ALC", true)
+
+	ctx := context.Background()
+	require.NoError(t, st.MintTokens(ctx, "tok1", bob.ID, 10, "purchase", "p1"))
+	require.NoError(t, st.CreateTokenReward(ctx, &store.TokenReward{
+		ID: "rw1", TokenID: "tok1", Title: "Shout-out", Kind: "shoutout", Cost: 4, IsActive: true,
+	}))
+
+	rr := serve(t, h.Redeem, http.MethodPost, "/api/tokens/rewards/{rewardId}/redeem", "/api/tokens/rewards/rw1/redeem", nil, bob)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	body := decode(t, rr)
+	assert.Equal(t, float64(7), body["redemption"].(map[string]interface{})["cost"])
+	assert.Equal(t, float64(3), body["balance"])
+
+	balance, err := st.GetTokenBalance(ctx, "tok1", bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, balance, "the cost at redemption is burned")
+
+	evs := eventsOfType(st.Store, webhook.EventTokenRedeemed)
+	require.Len(t, evs, 1)
+	var redeemed webhook.TokenRedeemed
+	require.NoError(t, json.Unmarshal(evs[0].Data.(json.RawMessage), &redeemed))
+	assert.Equal(t, 7, redeemed.Cost)
+}
+
+func TestTokenHandler_Pricing(t *testing.T) {
+	h, st := newTestTokenHandler(t)
+	alice := seedUser(st, "alice", "alice")
+	bob := seedUser(st, "bob", "bob")
+
+	rr := serve(t, h.Create, http.MethodPost, "/api/tokens", "/api/tokens", map[string]interface{}{
+		"name": "Alice Coin", "symbol": "ALC", "pricingModel": "bonding",
+	}, alice)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "unknown model")
+
+	rr = serve(t, h.Create, http.MethodPost, "/api/tokens", "/api/tokens", map[string]interface{}{
+		"name": "Alice Coin", "symbol": "ALC", "priceCents": 100,
+		"pricingModel": "linear", "priceSlopeMilliCents": 1000,
+	}, alice)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	tokenID := decode(t, rr)["token"].(map[string]interface{})["id"].(string)
+
+	ctx := context.Background()
+	require.NoError(t, st.MintTokens(ctx, tokenID, bob.ID, 10, "purchase", "p1"))
+
+	quote := func(query string) *httptest.ResponseRecorder {
+		return serve(t, h.Quote, http.MethodGet, "/api/tokens/{id}/quote", "/api/tokens/"+tokenID+"/quote"+query, nil, bob)
+	}
+
+	rr = quote("?amount=3")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	q := decode(t, rr)["quote"].(map[string]interface{})
+	assert.Equal(t, float64(110+111+112), q["totalCents"])
+	assert.Equal(t, float64(113), q["nextPriceCents"])
+
+	// Pending purchases hold their place on the curve until they lapse
+	require.NoError(t, st.CreatePendingPurchase(ctx, &store.PendingPurchase{
+		ID: "pp1", Kind: "token_purchase", UserID: bob.ID, TokenID: &tokenID, Amount: 2,
+		StripePaymentIntentID: "pi_1", Status: "pending", CreatedAt: time.Now(),
+	}))
+	require.NoError(t, st.CreatePendingPurchase(ctx, &store.PendingPurchase{
+		ID: "pp2", Kind: "token_purchase", UserID: bob.ID, TokenID: &tokenID, Amount: 5,
+		StripePaymentIntentID: "pi_2", Status: "pending", CreatedAt: time.Now().Add(-2 * purchaseReservation),
+	}))
+	rr = quote("?amount=3")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, float64(112+113+114), decode(t, rr)["quote"].(map[string]interface{})["totalCents"])
+
+	assert.Equal(t, http.StatusBadRequest, quote("?amount=0").Code)
+	assert.Equal(t, http.StatusBadRequest, quote("?amount=abc").Code)
+	assert.Equal(t, http.StatusBadRequest, quote("?amount=2000000000").Code, "beyond the payment limit")
+
+	rr = serve(t, h.Update, http.MethodPatch, "/api/tokens", "/api/tokens", map[string]interface{}{
+		"pricingModel": "tiered", "priceTiers": []map[string]int{{"fromSupply": 5, "priceCents": 100}},
+	}, alice)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "tiers must start at zero")
+
+	rr = serve(t, h.Update, http.MethodPatch, "/api/tokens", "/api/tokens", map[string]interface{}{
+		"pricingModel": "tiered",
+		"priceTiers":   []map[string]int{{"fromSupply": 0, "priceCents": 100}, {"fromSupply": 12, "priceCents": 500}},
+	}, alice)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = quote("?amount=4")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, float64(4*500), decode(t, rr)["quote"].(map[string]interface{})["totalCents"], "10 minted and 2 reserved")
+
+	rr = serve(t, h.Purchase, http.MethodPost, "/api/tokens/{id}/purchase", "/api/tokens/"+tokenID+"/purchase",
+		map[string]int{"amount": 1000000}, bob)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "priced beyond the payment limit")
+}
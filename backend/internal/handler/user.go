@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/creatrid/creatrid/internal/auth"
+	"github.com/creatrid/creatrid/internal/blockchain"
 	"github.com/creatrid/creatrid/internal/config"
 	"github.com/creatrid/creatrid/internal/email"
 	"github.com/creatrid/creatrid/internal/imaging"
@@ -37,13 +38,13 @@ var (
 
 type UserHandler struct {
 	store  *store.Store
-	blob   *storage.BlobStorage
+	blob   storage.Backend
 	email  *email.Service
 	jwt    *auth.JWTService
 	config *config.Config
 }
 
-func NewUserHandler(store *store.Store, blob *storage.BlobStorage, emailSvc *email.Service, jwtSvc *auth.JWTService, cfg *config.Config) *UserHandler {
+func NewUserHandler(store *store.Store, blob storage.Backend, emailSvc *email.Service, jwtSvc *auth.JWTService, cfg *config.Config) *UserHandler {
 	return &UserHandler{store: store, blob: blob, email: emailSvc, jwt: jwtSvc, config: cfg}
 }
 
@@ -94,7 +95,7 @@ func (h *UserHandler) Onboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	recalcScore(r.Context(), h.store, user.ID)
+	recalcScore(r.Context(), h.store, user.ID, h.config.AnomalyScoreDamping)
 
 	// Send welcome email (async, don't block response, respecting preferences)
 	if h.email != nil && user.GetEmailPrefs().Welcome {
@@ -116,12 +117,13 @@ type customLink struct {
 }
 
 type updateProfileRequest struct {
-	Name        *string          `json:"name"`
-	Bio         *string          `json:"bio"`
-	Username    *string          `json:"username"`
-	Theme       *string          `json:"theme"`
-	CustomLinks []customLink     `json:"customLinks,omitempty"`
-	EmailPrefs  *json.RawMessage `json:"emailPrefs,omitempty"`
+	Name         *string          `json:"name"`
+	Bio          *string          `json:"bio"`
+	Username     *string          `json:"username"`
+	Theme        *string          `json:"theme"`
+	CustomLinks  []customLink     `json:"customLinks,omitempty"`
+	EmailPrefs   *json.RawMessage `json:"emailPrefs,omitempty"`
+	AnchorPolicy *string          `json:"anchorPolicy,omitempty"` // "all", "public", "licensed" or "never"
 }
 
 func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
@@ -159,6 +161,13 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if req.AnchorPolicy != nil {
+		if _, ok := blockchain.ParsePolicy(*req.AnchorPolicy); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anchor policy"})
+			return
+		}
+	}
+
 	// Validate username if provided
 	if req.Username != nil {
 		username := strings.ToLower(strings.TrimSpace(*req.Username))
@@ -206,7 +215,14 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	recalcScore(r.Context(), h.store, user.ID)
+	if req.AnchorPolicy != nil {
+		if err := h.store.UpdateUserAnchorPolicy(r.Context(), user.ID, *req.AnchorPolicy); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update anchor policy"})
+			return
+		}
+	}
+
+	recalcScore(r.Context(), h.store, user.ID, h.config.AnomalyScoreDamping)
 	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
@@ -358,7 +374,7 @@ func (h *UserHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	recalcScore(r.Context(), h.store, user.ID)
+	recalcScore(r.Context(), h.store, user.ID, h.config.AnomalyScoreDamping)
 	writeJSON(w, http.StatusOK, map[string]string{"image": imageURL})
 }
 
@@ -458,7 +474,7 @@ func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Recalculate creator score (email verified adds 10 points)
-	recalcScore(r.Context(), h.store, ev.UserID)
+	recalcScore(r.Context(), h.store, ev.UserID, h.config.AnomalyScoreDamping)
 
 	http.Redirect(w, r, h.config.FrontendURL+"/dashboard?verified=true", http.StatusFound)
 }
@@ -4,17 +4,21 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/creatrid/creatrid/internal/config"
+	"github.com/creatrid/creatrid/internal/score"
 	"github.com/creatrid/creatrid/internal/store"
 	"github.com/go-chi/chi/v5"
 )
 
 type VerifyHandler struct {
-	store *store.Store
+	store  *store.Store
+	config *config.Config
 }
 
-func NewVerifyHandler(st *store.Store) *VerifyHandler {
-	return &VerifyHandler{store: st}
+func NewVerifyHandler(st *store.Store, cfg *config.Config) *VerifyHandler {
+	return &VerifyHandler{store: st, config: cfg}
 }
 
 // Verify returns full verification data for a creator
@@ -46,14 +50,23 @@ func (h *VerifyHandler) Verify(w http.ResponseWriter, r *http.Request) {
 		publicConns = append(publicConns, c.ToPublic())
 	}
 
+	// Only anomalies an admin has confirmed are shown to API consumers.
+	flagged, err := h.store.ListFlaggedPlatforms(r.Context(), user.ID, []string{"confirmed"})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"user":            user.ToPublic(),
-		"connections":     publicConns,
-		"connectionCount": len(connections),
+		"user":             user.ToPublic(),
+		"connections":      publicConns,
+		"connectionCount":  len(connections),
+		"flaggedPlatforms": flagged,
 	})
 }
 
-// Score returns just the creator score for a username
+// Score returns the creator score for a username with a per-component
+// breakdown. ?version=v1 explains the score with the original algorithm.
 func (h *VerifyHandler) Score(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
 	if username == "" {
@@ -61,6 +74,15 @@ func (h *VerifyHandler) Score(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version := r.URL.Query().Get("version")
+	if version == "" {
+		version = score.Current
+	}
+	if version != score.V1 && version != score.V2 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "version must be v1 or v2"})
+		return
+	}
+
 	user, err := h.store.FindUserByUsername(r.Context(), strings.ToLower(username))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Database error"})
@@ -71,15 +93,29 @@ func (h *VerifyHandler) Score(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var score int
-	if user.CreatorScore != nil {
-		score = *user.CreatorScore
+	signals, err := score.Gather(r.Context(), h.store, user, time.Now(), h.config.AnomalyScoreDamping)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load score signals"})
+		return
+	}
+	v1 := score.CalculateV1(user, signals.Connections)
+	v2 := score.CalculateV2(signals)
+
+	result := v2
+	if version == score.V1 {
+		result = v1
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"username": user.Username,
-		"score":    score,
-		"verified": user.IsVerified,
+		"username":   user.Username,
+		"score":      result.Score,
+		"verified":   user.IsVerified,
+		"version":    result.Version,
+		"components": result.Components,
+		"versions": map[string]int{
+			score.V1: v1.Score,
+			score.V2: v2.Score,
+		},
 	})
 }
 
@@ -1,32 +1,85 @@
 package handler
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/creatrid/creatrid/internal/email"
 	"github.com/creatrid/creatrid/internal/middleware"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
+	"github.com/creatrid/creatrid/pkg/safehttp"
 	"github.com/go-chi/chi/v5"
 	"github.com/nrednav/cuid2"
 )
 
-var validWebhookEvents = map[string]bool{
-	"license.sold":          true,
-	"content.uploaded":      true,
-	"profile.viewed":        true,
-	"collaboration.received": true,
-	"payout.completed":      true,
+const (
+	// defaultSecretGrace is how long the old secret keeps signing deliveries
+	// after a rotation when the caller does not choose.
+	defaultSecretGrace = 24 * time.Hour
+	maxSecretGrace     = 7 * 24 * time.Hour
+
+	// webhookHealthWindow is how far back endpoint health stats look.
+	webhookHealthWindow = 7 * 24 * time.Hour
+)
+
+// newWebhookSecret generates a signing secret for an endpoint.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// unknownWebhookEvent returns the first event outside the catalogue, and
+// false if there is one.
+func unknownWebhookEvent(events []string) (string, bool) {
+	for _, e := range events {
+		if !webhook.IsKnownEvent(e) {
+			return e, false
+		}
+	}
+	return "", true
 }
 
 type WebhookHandler struct {
-	store *store.Store
+	store    *store.Store
+	outbound safehttp.Options
+	client   *http.Client
+}
+
+// NewWebhookHandler creates a webhook handler. Endpoint URLs are validated
+// against outbound, and test pings are sent with a client built from it.
+func NewWebhookHandler(st *store.Store, outbound safehttp.Options) *WebhookHandler {
+	return &WebhookHandler{store: st, outbound: outbound, client: safehttp.NewClient(outbound)}
 }
 
-func NewWebhookHandler(st *store.Store) *WebhookHandler {
-	return &WebhookHandler{store: st}
+// invalidWebhookURL explains why an endpoint URL is refused, or returns ""
+// if it is acceptable.
+func (h *WebhookHandler) invalidWebhookURL(raw string) string {
+	err := safehttp.ValidateURL(raw, h.outbound)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, safehttp.ErrSchemeNotAllowed) && h.outbound.RequireHTTPS:
+		return "Webhook URL must use https"
+	case errors.Is(err, safehttp.ErrSchemeNotAllowed):
+		return "Webhook URL must use http or https"
+	case errors.Is(err, safehttp.ErrBlockedAddress):
+		return "Webhook URL must not point to a private or local address"
+	default:
+		return "Invalid webhook URL"
+	}
 }
 
 func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -49,21 +102,21 @@ func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "URL is required"})
 		return
 	}
+	if msg := h.invalidWebhookURL(req.URL); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
 
-	for _, e := range req.Events {
-		if !validWebhookEvents[e] {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid event: " + e})
-			return
-		}
+	if e, ok := unknownWebhookEvent(req.Events); !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid event: " + e})
+		return
 	}
 
-	// Generate a signing secret
-	secretBytes := make([]byte, 32)
-	if _, err := rand.Read(secretBytes); err != nil {
+	secret, err := newWebhookSecret()
+	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate secret"})
 		return
 	}
-	secret := "whsec_" + hex.EncodeToString(secretBytes)
 
 	ep := &store.WebhookEndpoint{
 		ID:       cuid2.Generate(),
@@ -87,6 +140,12 @@ func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Events returns the catalogue of events endpoints can subscribe to, with
+// each event's payload version and fields.
+func (h *WebhookHandler) Events(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"events": webhook.Catalogue()})
+}
+
 func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	if user == nil {
@@ -131,12 +190,101 @@ func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if msg := h.invalidWebhookURL(req.URL); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+	if e, ok := unknownWebhookEvent(req.Events); !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid event: " + e})
+		return
+	}
+
 	if err := h.store.UpdateWebhookEndpoint(r.Context(), id, req.URL, req.Events, req.IsActive); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update"})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	// Switching an endpoint back on clears its failure streak and resends
+	// what died while it was down
+	requeued := 0
+	if req.IsActive && !ep.IsActive {
+		requeued, err = h.store.EnableWebhookEndpoint(r.Context(), id)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to enable"})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "requeued": requeued})
+}
+
+// Health reports an endpoint's delivery health over the last week.
+func (h *WebhookHandler) Health(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	ep, err := h.store.FindWebhookEndpointByID(r.Context(), id)
+	if err != nil || ep == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		return
+	}
+	if ep.UserID != user.ID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not authorized"})
+		return
+	}
+
+	stats, err := h.store.GetWebhookAttemptStats(r.Context(), id, time.Now().Add(-webhookHealthWindow))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch health"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"windowHours":         int(webhookHealthWindow.Hours()),
+		"attempts":            stats.Attempts,
+		"successes":           stats.Successes,
+		"successRate":         stats.SuccessRate,
+		"p95LatencyMs":        stats.P95LatencyMs,
+		"lastSuccessAt":       ep.LastSuccessAt,
+		"consecutiveFailures": ep.ConsecutiveFailures,
+		"failingSince":        ep.FailingSince,
+		"isActive":            ep.IsActive,
+		"disabledAt":          ep.DisabledAt,
+		"disabledReason":      ep.DisabledReason,
+	})
+}
+
+// Enable switches an endpoint back on and requeues the deliveries that died
+// while it was failing.
+func (h *WebhookHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	ep, err := h.store.FindWebhookEndpointByID(r.Context(), id)
+	if err != nil || ep == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		return
+	}
+	if ep.UserID != user.ID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not authorized"})
+		return
+	}
+
+	requeued, err := h.store.EnableWebhookEndpoint(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to enable"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "requeued": requeued})
 }
 
 func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -213,6 +361,160 @@ func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
+// Redeliver requeues an endpoint's failed and dead deliveries in bulk,
+// optionally narrowed by creation time, event type and status.
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	ep, err := h.store.FindWebhookEndpointByID(r.Context(), id)
+	if err != nil || ep == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		return
+	}
+	if ep.UserID != user.ID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not authorized"})
+		return
+	}
+
+	var req struct {
+		Since     *time.Time `json:"since"`
+		Until     *time.Time `json:"until"`
+		EventType string     `json:"eventType"`
+		Status    string     `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	filter := store.RedeliveryFilter{Since: req.Since, Until: req.Until, EventType: req.EventType}
+	switch req.Status {
+	case "":
+		filter.Statuses = []string{"failed", "dead"}
+	case "failed", "dead":
+		filter.Statuses = []string{req.Status}
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be failed or dead"})
+		return
+	}
+	if req.EventType != "" && !webhook.IsKnownEvent(req.EventType) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid event: " + req.EventType})
+		return
+	}
+	if req.Since != nil && req.Until != nil && !req.Until.After(*req.Since) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "until must be after since"})
+		return
+	}
+	if !ep.IsActive {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Enable the webhook before redelivering"})
+		return
+	}
+
+	requeued, err := h.store.RedeliverWebhookDeliveries(r.Context(), id, filter)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to redeliver"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "requeued": requeued})
+}
+
+// Test sends a signed ping to the endpoint and returns its response inline.
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	ep, err := h.store.FindWebhookEndpointByID(r.Context(), id)
+	if err != nil || ep == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		return
+	}
+	if ep.UserID != user.ID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not authorized"})
+		return
+	}
+
+	result, err := webhook.SendPing(r.Context(), h.client, ep)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to send ping"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// RotateSecret issues a new signing secret for an endpoint. Deliveries are
+// signed with both the new and the old secret for gracePeriodHours (24 by
+// default, at most 168) so receivers can switch over without dropping any.
+// A grace period of 0 retires the old secret immediately. Rotating again
+// during a grace period retires the secret from the earlier rotation.
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	ep, err := h.store.FindWebhookEndpointByID(r.Context(), id)
+	if err != nil || ep == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		return
+	}
+	if ep.UserID != user.ID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Not authorized"})
+		return
+	}
+
+	var req struct {
+		GracePeriodHours *int `json:"gracePeriodHours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	grace := defaultSecretGrace
+	if req.GracePeriodHours != nil {
+		grace = time.Duration(*req.GracePeriodHours) * time.Hour
+		if grace < 0 || grace > maxSecretGrace {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "gracePeriodHours must be between 0 and 168"})
+			return
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate secret"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if grace > 0 {
+		t := time.Now().Add(grace)
+		expiresAt = &t
+	}
+	if err := h.store.RotateWebhookSecret(r.Context(), id, secret, expiresAt); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to rotate secret"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                      id,
+		"secret":                  secret,
+		"previousSecretExpiresAt": expiresAt,
+	})
+}
+
 func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	if user == nil {
@@ -248,3 +550,44 @@ func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
 		"total":      total,
 	})
 }
+
+// NotifyWebhookDisabled returns the worker callback that tells an endpoint's
+// owner it was switched off, in-app and by email.
+func NotifyWebhookDisabled(st *store.Store, hub *SSEHub, emailSvc *email.Service, frontendURL string) webhook.DisabledFunc {
+	return func(ctx context.Context, ep *store.WebhookEndpoint, reason string) {
+		data, _ := json.Marshal(map[string]string{"endpointId": ep.ID, "url": ep.URL, "reason": reason})
+		notif := &store.Notification{
+			ID:        cuid2.Generate(),
+			UserID:    ep.UserID,
+			Type:      "webhook_disabled",
+			Title:     "Webhook endpoint disabled",
+			Message:   fmt.Sprintf("Deliveries to %s kept failing, so it has been disabled", ep.URL),
+			Data:      data,
+			CreatedAt: time.Now(),
+		}
+		if err := st.CreateNotification(ctx, notif); err != nil {
+			log.Printf("Failed to create webhook disabled notification: %v", err)
+		}
+		if hub != nil {
+			if data, err := json.Marshal(notif); err == nil {
+				hub.Notify(ep.UserID, data)
+			}
+		}
+
+		if emailSvc == nil {
+			return
+		}
+		owner, err := st.FindUserByID(ctx, ep.UserID)
+		if err != nil || owner == nil {
+			return
+		}
+		name := "Creator"
+		if owner.Name != nil {
+			name = *owner.Name
+		}
+		subj, body := email.WebhookDisabledEmail(name, ep.URL, reason, frontendURL+"/webhooks")
+		if err := emailSvc.Send(owner.Email, subj, body); err != nil {
+			log.Printf("Failed to send webhook disabled email: %v", err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package imaging
+
+import (
+	"image"
+	"math/bits"
+
+	"golang.org/x/image/draw"
+)
+
+// DHash returns the 64-bit difference hash of an image: it is scaled down to
+// 9x8 grey pixels and each bit records whether a pixel is brighter than its
+// right-hand neighbour. Re-encoding, resizing and small edits change few
+// bits, so near-duplicates have hashes a small Distance apart. Images over
+// MaxPixels return ErrTooLarge.
+func DHash(data []byte) (uint64, error) {
+	src, _, err := decode(data)
+	if err != nil {
+		return 0, err
+	}
+	return dHash(src), nil
+}
+
+func dHash(src image.Image) uint64 {
+	grey := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.CatmullRom.Scale(grey, grey.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if grey.GrayAt(x, y).Y > grey.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance returns the number of bits in which two perceptual hashes differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scene draws a few soft shapes, enough structure for a stable hash.
+func scene(w, h int, flip bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			if flip {
+				fx = 1 - fx
+			}
+			v := uint8(255 * fx * fy)
+			if (fx-0.3)*(fx-0.3)+(fy-0.6)*(fy-0.6) < 0.04 {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{v, uint8(255 * fy), 128, 255})
+		}
+	}
+	return img
+}
+
+func TestDHash_NearDuplicates(t *testing.T) {
+	var orig bytes.Buffer
+	require.NoError(t, png.Encode(&orig, scene(640, 480, false)))
+	h1, err := DHash(orig.Bytes())
+	require.NoError(t, err)
+
+	resized, _, err := ResizeImage(orig.Bytes(), 200, 200)
+	require.NoError(t, err)
+	var reencoded bytes.Buffer
+	img, _, err := image.Decode(bytes.NewReader(resized))
+	require.NoError(t, err)
+	require.NoError(t, jpeg.Encode(&reencoded, img, &jpeg.Options{Quality: 60}))
+	h2, err := DHash(reencoded.Bytes())
+	require.NoError(t, err)
+	assert.LessOrEqual(t, Distance(h1, h2), 4, "resized and re-encoded copies stay close")
+
+	var other bytes.Buffer
+	require.NoError(t, png.Encode(&other, scene(640, 480, true)))
+	h3, err := DHash(other.Bytes())
+	require.NoError(t, err)
+	assert.Greater(t, Distance(h1, h3), 10, "a different image is far away")
+
+	_, err = DHash([]byte("not an image"))
+	assert.Error(t, err)
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance(0xf0f0, 0xf0f0))
+	assert.Equal(t, 64, Distance(0, ^uint64(0)))
+	assert.Equal(t, 2, Distance(0b1010, 0b0110))
+}
+
+// hugePNG is a valid 1x1 PNG whose header claims w x h pixels.
+func hugePNG(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+	data := buf.Bytes()
+	// The IHDR chunk follows the 8-byte signature: length, type, then data
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:], w)
+	binary.BigEndian.PutUint32(ihdr[4:], h)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	return data
+}
+
+func TestDecode_RejectsOversizedImages(t *testing.T) {
+	bomb := hugePNG(t, 100_000, 100_000)
+	_, err := DHash(bomb)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, _, err = GenerateThumbnail(bomb, 300)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, _, err = ResizeImage(bomb, 512, 512)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = DHash(hugePNG(t, 1, 1))
+	assert.NoError(t, err)
+}
@@ -2,6 +2,8 @@ package imaging
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
@@ -10,10 +12,30 @@ import (
 	"golang.org/x/image/draw"
 )
 
+// MaxPixels is the largest image, in pixels, this package decodes. A small
+// file can declare huge dimensions, and decoding allocates for all of them.
+const MaxPixels = 50_000_000
+
+// ErrTooLarge is returned for images with more than MaxPixels pixels.
+var ErrTooLarge = errors.New("image dimensions too large")
+
+// decode decodes an image after checking from its header that it is within
+// MaxPixels.
+func decode(data []byte) (image.Image, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxPixels/cfg.Height {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	return image.Decode(bytes.NewReader(data))
+}
+
 // ResizeImage resizes an image to fit within maxWidth x maxHeight while maintaining aspect ratio.
 // Returns the resized image as JPEG bytes. Returns original bytes if not a supported image format.
 func ResizeImage(data []byte, maxWidth, maxHeight int) ([]byte, string, error) {
-	src, format, err := image.Decode(bytes.NewReader(data))
+	src, format, err := decode(data)
 	if err != nil {
 		// Not a decodable image, return original
 		return data, "", err
@@ -51,8 +73,9 @@ func ResizeImage(data []byte, maxWidth, maxHeight int) ([]byte, string, error) {
 }
 
 // GenerateThumbnail creates a square center-cropped thumbnail of the given size.
+// Images over MaxPixels return ErrTooLarge.
 func GenerateThumbnail(data []byte, size int) ([]byte, string, error) {
-	src, format, err := image.Decode(bytes.NewReader(data))
+	src, format, err := decode(data)
 	if err != nil {
 		return nil, "", err
 	}
@@ -35,8 +35,7 @@ func RequireAuth(jwtSvc *auth.JWTService, st *store.Store) func(http.Handler) ht
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), userContextKey, user)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
 		})
 	}
 }
@@ -62,12 +61,16 @@ func RequireAuthRedirect(jwtSvc *auth.JWTService, st *store.Store, frontendURL s
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), userContextKey, user)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
 		})
 	}
 }
 
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user *model.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
 func UserFromContext(ctx context.Context) *model.User {
 	user, _ := ctx.Value(userContextKey).(*model.User)
 	return user
@@ -0,0 +1,348 @@
+// Package migrate applies the SQL migrations in migrations/ and records them
+// in a schema_migrations table so that each runs exactly once.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockID is the pg_advisory_lock key held while migrating, so replicas that
+// boot at the same time apply migrations one at a time.
+const lockID = 7_243_318_201
+
+var fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered pair of up/down SQL files.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of Up
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	Modified  bool // applied checksum differs from the file on disk
+}
+
+// Load reads NNN_name.up.sql and NNN_name.down.sql files from fsys and returns
+// the migrations sorted by version. Every version must have an up file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %03d has conflicting names %q and %q", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(data)
+			sum := sha256.Sum256(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Checksum == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and rolls back migrations against a database.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, migrations []Migration) *Migrator {
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// preMigratorVersion is the newest migration the old boot-time runner could
+// have applied. It ran every file on each boot and recorded nothing, so a
+// database it set up has these migrations applied but not recorded.
+const preMigratorVersion = 31
+
+// Up applies every pending migration in order, each in its own transaction.
+// It refuses to run if an applied migration's file has been modified.
+// Returns the number of migrations applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		if err := ensureTable(ctx, conn); err != nil {
+			return err
+		}
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		var hasSchema bool
+		if err := conn.QueryRow(ctx, `SELECT to_regclass('users') IS NOT NULL`).Scan(&hasSchema); err != nil {
+			return err
+		}
+
+		count, err = m.up(applied, hasSchema,
+			func(mig Migration) error { return apply(ctx, conn, mig) },
+			func(mig Migration) error { return record(ctx, conn, mig) },
+		)
+		return err
+	})
+	return count, err
+}
+
+// up runs applyFn for each migration missing from applied. On a database
+// that already has a schema, a pre-migrator migration whose objects exist
+// was run by the old runner and is recorded with recordFn instead. This is
+// decided afresh on every run, so an adoption that stopped partway resumes.
+func (m *Migrator) up(applied map[int]appliedMigration, hasSchema bool, applyFn, recordFn func(Migration) error) (int, error) {
+	count := 0
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err := applyFn(mig)
+		if err != nil && hasSchema && mig.Version <= preMigratorVersion && isDuplicateObject(err) {
+			log.Printf("Migration %03d_%s already present, recording as applied", mig.Version, mig.Name)
+			err = recordFn(mig)
+		}
+		if err != nil {
+			return count, fmt.Errorf("migration %03d_%s: %w", mig.Version, mig.Name, err)
+		}
+		log.Printf("Applied migration %03d_%s", mig.Version, mig.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Down rolls back the most recently applied migrations, newest first.
+// Returns the number of migrations rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		if err := ensureTable(ctx, conn); err != nil {
+			return err
+		}
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %03d_%s has no down file", mig.Version, mig.Name)
+			}
+			if err := revert(ctx, conn, mig); err != nil {
+				return fmt.Errorf("migration %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+			log.Printf("Rolled back migration %03d_%s", mig.Version, mig.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status reports every known migration and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	applied := map[int]appliedMigration{}
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists {
+		if applied, err = loadApplied(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if a, ok := applied[mig.Version]; ok {
+			appliedAt := a.appliedAt
+			s.AppliedAt = &appliedAt
+			s.Modified = a.checksum != mig.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// verify checks that applied migrations still match their files on disk.
+func (m *Migrator) verify(applied map[int]appliedMigration) error {
+	for _, mig := range m.migrations {
+		a, ok := applied[mig.Version]
+		if ok && a.checksum != mig.Checksum {
+			return fmt.Errorf("migration %03d_%s was modified after it was applied (checksum %s, file %s)",
+				mig.Version, mig.Name, a.checksum, mig.Checksum)
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); err != nil {
+			log.Printf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// ensureTable creates schema_migrations if needed.
+func ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			checksum   TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`,
+	)
+	return err
+}
+
+func loadApplied(ctx context.Context, conn *pgxpool.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.Query(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+func apply(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, mig.Up); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			mig.Version, mig.Name, mig.Checksum,
+		)
+		return err
+	})
+}
+
+func record(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	_, err := conn.Exec(ctx,
+		`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+		mig.Version, mig.Name, mig.Checksum,
+	)
+	return err
+}
+
+func revert(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, mig.Down); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+		return err
+	})
+}
+
+// isDuplicateObject reports whether err means an object the migration
+// creates already exists.
+func isDuplicateObject(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code {
+	case "42P07", // duplicate_table (also indexes)
+		"42710", // duplicate_object (types, triggers, constraints)
+		"42701", // duplicate_column
+		"42723": // duplicate_function
+		return true
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_posts.up.sql":   {Data: []byte("CREATE TABLE posts (id TEXT);")},
+		"002_posts.down.sql": {Data: []byte("DROP TABLE posts;")},
+		"001_users.up.sql":   {Data: []byte("CREATE TABLE users (id TEXT);")},
+		"README.md":          {Data: []byte("not a migration")},
+	}
+
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "users", migrations[0].Name)
+	assert.Empty(t, migrations[0].Down)
+	assert.Len(t, migrations[0].Checksum, 64)
+
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.Equal(t, "DROP TABLE posts;", migrations[1].Down)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+}
+
+func TestLoad_MissingUp(t *testing.T) {
+	_, err := Load(fstest.MapFS{"001_users.down.sql": {Data: []byte("DROP TABLE users;")}})
+	assert.Error(t, err)
+}
+
+func TestLoad_ConflictingNames(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"001_users.up.sql":    {Data: []byte("CREATE TABLE users (id TEXT);")},
+		"001_accounts.up.sql": {Data: []byte("CREATE TABLE accounts (id TEXT);")},
+	})
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{"001_users.up.sql": {Data: []byte("CREATE TABLE users (id TEXT);")}})
+	require.NoError(t, err)
+	m := &Migrator{migrations: migrations}
+
+	assert.NoError(t, m.verify(map[int]appliedMigration{1: {checksum: migrations[0].Checksum}}))
+	assert.Error(t, m.verify(map[int]appliedMigration{1: {checksum: "stale"}}))
+	// Versions unknown to this build (e.g. during a rollback deploy) are ignored.
+	assert.NoError(t, m.verify(map[int]appliedMigration{2: {checksum: "other"}}))
+}
+
+// fakeDB stands in for a database in tests of up: each migration's Up is the
+// name of the table it creates.
+type fakeDB struct {
+	tables  map[string]bool
+	applied map[int]appliedMigration
+	failAt  int // version whose apply fails as if the server had crashed
+}
+
+func (db *fakeDB) apply(mig Migration) error {
+	if mig.Version == db.failAt {
+		return errors.New("connection reset")
+	}
+	if db.tables[mig.Up] {
+		return &pgconn.PgError{Code: "42P07"}
+	}
+	db.tables[mig.Up] = true
+	return db.record(mig)
+}
+
+func (db *fakeDB) record(mig Migration) error {
+	db.applied[mig.Version] = appliedMigration{checksum: mig.Checksum}
+	return nil
+}
+
+func (db *fakeDB) up(m *Migrator) (int, error) {
+	return m.up(db.applied, db.tables["users"], db.apply, db.record)
+}
+
+func TestUp_ResumesInterruptedAdoption(t *testing.T) {
+	m := &Migrator{migrations: []Migration{
+		{Version: 1, Name: "users", Up: "users"},
+		{Version: 2, Name: "posts", Up: "posts"},
+		{Version: 3, Name: "tags", Up: "tags"},
+		{Version: preMigratorVersion + 1, Name: "likes", Up: "likes"},
+	}}
+	// Set up by the old runner: every pre-migrator table, nothing recorded
+	db := &fakeDB{
+		tables:  map[string]bool{"users": true, "posts": true, "tags": true},
+		applied: map[int]appliedMigration{},
+		failAt:  3,
+	}
+
+	_, err := db.up(m)
+	require.Error(t, err)
+	assert.Len(t, db.applied, 2, "adoption stopped partway")
+
+	db.failAt = 0
+	count, err := db.up(m)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, db.applied, 4)
+	assert.True(t, db.tables["likes"])
+}
+
+func TestUp_DuplicateAfterPreMigratorVersionFails(t *testing.T) {
+	m := &Migrator{migrations: []Migration{
+		{Version: 1, Name: "users", Up: "users"},
+		{Version: preMigratorVersion + 1, Name: "likes", Up: "likes"},
+	}}
+	db := &fakeDB{
+		tables:  map[string]bool{"users": true, "likes": true},
+		applied: map[int]appliedMigration{},
+	}
+
+	count, err := db.up(m)
+	require.Error(t, err, "only migrations the old runner could have run are adopted")
+	assert.Equal(t, 1, count)
+	assert.NotContains(t, db.applied, preMigratorVersion+1)
+}
+
+// TestRepoMigrations checks that every migration shipped with the server can
+// be rolled back.
+func TestRepoMigrations(t *testing.T) {
+	migrations, err := Load(os.DirFS("../../migrations"))
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, mig := range migrations {
+		assert.Equal(t, i+1, mig.Version, "migration versions should be contiguous")
+		assert.NotEmpty(t, mig.Down, "migration %03d_%s has no down file", mig.Version, mig.Name)
+	}
+}
@@ -39,6 +39,7 @@ type User struct {
 	StripeConnectAccountID  *string         `json:"-"`
 	StripeConnectOnboarded  bool            `json:"-"`
 	CreatorTier             string          `json:"creatorTier"`
+	AnchorPolicy            string          `json:"anchorPolicy"`
 	ReferralCode            *string         `json:"-"`
 	ReferredBy              *string         `json:"-"`
 	CreatedAt               time.Time       `json:"createdAt"`
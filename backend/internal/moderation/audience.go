@@ -0,0 +1,120 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Audience anomaly kinds.
+const (
+	AnomalyFollowerSpike       = "follower_spike"
+	AnomalyInactiveAudience    = "inactive_audience"
+	AnomalyViewsBelowFollowers = "views_below_followers"
+)
+
+// Anomaly severities.
+const (
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+const (
+	// A refresh that adds at least spikeMinGain followers and multiplies the
+	// count by spikeMediumFactor or spikeHighFactor is a spike.
+	spikeMinGain      = 1000
+	spikeMediumFactor = 3
+	spikeHighFactor   = 10
+
+	// Audiences of at least ratioMinFollowers are checked against the
+	// account's own activity.
+	ratioMinFollowers = 1000
+)
+
+// activityKeys names the metadata field counting what the account has
+// published on each platform.
+var activityKeys = map[string]string{
+	"github":    "public_repos",
+	"youtube":   "video_count",
+	"twitter":   "tweet_count",
+	"instagram": "media_count",
+	"dribbble":  "shots_count",
+}
+
+// AudienceSnapshot is a connection's follower count and platform metadata at
+// one refresh.
+type AudienceSnapshot struct {
+	Platform  string
+	Followers int
+	Metadata  json.RawMessage
+}
+
+// Anomaly is a suspicious pattern in a connection's audience.
+type Anomaly struct {
+	Kind     string
+	Severity string
+	Details  string
+}
+
+// DetectAudienceAnomalies compares a freshly fetched snapshot with the one
+// from the previous refresh, which is nil for a first fetch, and returns any
+// signs of a bought or botted audience.
+func DetectAudienceAnomalies(prev *AudienceSnapshot, curr AudienceSnapshot) []Anomaly {
+	var anomalies []Anomaly
+
+	if prev != nil {
+		gain := curr.Followers - prev.Followers
+		if gain >= spikeMinGain {
+			base := prev.Followers
+			if base < 1 {
+				base = 1
+			}
+			factor := float64(curr.Followers) / float64(base)
+			severity := ""
+			switch {
+			case factor >= spikeHighFactor:
+				severity = SeverityHigh
+			case factor >= spikeMediumFactor:
+				severity = SeverityMedium
+			}
+			if severity != "" {
+				anomalies = append(anomalies, Anomaly{
+					Kind:     AnomalyFollowerSpike,
+					Severity: severity,
+					Details:  fmt.Sprintf("followers jumped from %d to %d (%.1fx) since the last refresh", prev.Followers, curr.Followers, factor),
+				})
+			}
+		}
+	}
+
+	if curr.Followers < ratioMinFollowers || len(curr.Metadata) == 0 {
+		return anomalies
+	}
+	var meta map[string]interface{}
+	if err := json.Unmarshal(curr.Metadata, &meta); err != nil {
+		return anomalies
+	}
+
+	if key, ok := activityKeys[curr.Platform]; ok {
+		if n, ok := meta[key].(float64); ok && n == 0 {
+			anomalies = append(anomalies, Anomaly{
+				Kind:     AnomalyInactiveAudience,
+				Severity: SeverityHigh,
+				Details:  fmt.Sprintf("%d followers but %s is 0", curr.Followers, key),
+			})
+		}
+	}
+
+	if curr.Platform == "youtube" {
+		views, ok1 := meta["view_count"].(float64)
+		videos, ok2 := meta["video_count"].(float64)
+		if ok1 && ok2 && videos > 0 && views < float64(curr.Followers) {
+			anomalies = append(anomalies, Anomaly{
+				Kind:     AnomalyViewsBelowFollowers,
+				Severity: SeverityMedium,
+				Details:  fmt.Sprintf("%d subscribers but only %.0f total views", curr.Followers, views),
+			})
+		}
+	}
+
+	return anomalies
+}
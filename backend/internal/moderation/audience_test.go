@@ -0,0 +1,98 @@
+package moderation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func kinds(anomalies []Anomaly) []string {
+	out := []string{}
+	for _, a := range anomalies {
+		out = append(out, a.Kind)
+	}
+	return out
+}
+
+func TestDetectAudienceAnomalies_FollowerSpike(t *testing.T) {
+	prev := &AudienceSnapshot{Platform: "youtube", Followers: 2000}
+
+	high := DetectAudienceAnomalies(prev, AudienceSnapshot{Platform: "youtube", Followers: 25000})
+	require.Len(t, high, 1)
+	assert.Equal(t, AnomalyFollowerSpike, high[0].Kind)
+	assert.Equal(t, SeverityHigh, high[0].Severity)
+	assert.Contains(t, high[0].Details, "2000 to 25000")
+
+	medium := DetectAudienceAnomalies(prev, AudienceSnapshot{Platform: "youtube", Followers: 7000})
+	require.Len(t, medium, 1)
+	assert.Equal(t, SeverityMedium, medium[0].Severity)
+
+	// Doubling is normal growth.
+	assert.Empty(t, DetectAudienceAnomalies(prev, AudienceSnapshot{Platform: "youtube", Followers: 4000}))
+}
+
+func TestDetectAudienceAnomalies_SmallGainsIgnored(t *testing.T) {
+	// 10x, but only 450 new followers.
+	prev := &AudienceSnapshot{Platform: "twitter", Followers: 50}
+	assert.Empty(t, DetectAudienceAnomalies(prev, AudienceSnapshot{Platform: "twitter", Followers: 500}))
+}
+
+func TestDetectAudienceAnomalies_FromZero(t *testing.T) {
+	prev := &AudienceSnapshot{Platform: "twitter", Followers: 0}
+	got := DetectAudienceAnomalies(prev, AudienceSnapshot{Platform: "twitter", Followers: 5000})
+	assert.Equal(t, []string{AnomalyFollowerSpike}, kinds(got))
+}
+
+func TestDetectAudienceAnomalies_FirstFetchHasNoSpike(t *testing.T) {
+	assert.Empty(t, DetectAudienceAnomalies(nil, AudienceSnapshot{Platform: "youtube", Followers: 1000000}))
+}
+
+func TestDetectAudienceAnomalies_InactiveAudience(t *testing.T) {
+	curr := AudienceSnapshot{Platform: "github", Followers: 5000, Metadata: json.RawMessage(`{"public_repos":0}`)}
+	got := DetectAudienceAnomalies(nil, curr)
+	require.Len(t, got, 1)
+	assert.Equal(t, AnomalyInactiveAudience, got[0].Kind)
+	assert.Equal(t, SeverityHigh, got[0].Severity)
+
+	curr.Metadata = json.RawMessage(`{"public_repos":3}`)
+	assert.Empty(t, DetectAudienceAnomalies(nil, curr))
+
+	// Small accounts with nothing published are common.
+	small := AudienceSnapshot{Platform: "github", Followers: 20, Metadata: json.RawMessage(`{"public_repos":0}`)}
+	assert.Empty(t, DetectAudienceAnomalies(nil, small))
+
+	// Platforms without an activity count are not checked.
+	linkedin := AudienceSnapshot{Platform: "linkedin", Followers: 5000, Metadata: json.RawMessage(`{}`)}
+	assert.Empty(t, DetectAudienceAnomalies(nil, linkedin))
+}
+
+func TestDetectAudienceAnomalies_ViewsBelowFollowers(t *testing.T) {
+	curr := AudienceSnapshot{
+		Platform:  "youtube",
+		Followers: 50000,
+		Metadata:  json.RawMessage(`{"subscriber_count":50000,"video_count":12,"view_count":3000}`),
+	}
+	got := DetectAudienceAnomalies(nil, curr)
+	assert.Equal(t, []string{AnomalyViewsBelowFollowers}, kinds(got))
+
+	curr.Metadata = json.RawMessage(`{"subscriber_count":50000,"video_count":12,"view_count":900000}`)
+	assert.Empty(t, DetectAudienceAnomalies(nil, curr))
+}
+
+func TestDetectAudienceAnomalies_Combined(t *testing.T) {
+	prev := &AudienceSnapshot{Platform: "youtube", Followers: 1000}
+	curr := AudienceSnapshot{
+		Platform:  "youtube",
+		Followers: 100000,
+		Metadata:  json.RawMessage(`{"subscriber_count":100000,"video_count":0,"view_count":0}`),
+	}
+	got := DetectAudienceAnomalies(prev, curr)
+	assert.Equal(t, []string{AnomalyFollowerSpike, AnomalyInactiveAudience}, kinds(got))
+}
+
+func TestDetectAudienceAnomalies_BadMetadata(t *testing.T) {
+	curr := AudienceSnapshot{Platform: "github", Followers: 5000, Metadata: json.RawMessage(`not json`)}
+	assert.Empty(t, DetectAudienceAnomalies(nil, curr))
+}
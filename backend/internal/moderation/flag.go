@@ -0,0 +1,48 @@
+package moderation
+
+import (
+	"context"
+	"log"
+
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/nrednav/cuid2"
+)
+
+// AnomalyStore records audience anomalies. *store.Store satisfies it.
+type AnomalyStore interface {
+	CreateAudienceAnomaly(ctx context.Context, a *store.AudienceAnomaly) (bool, error)
+}
+
+// FlagAudienceAnomalies compares a freshly fetched profile with the
+// connection's previous state and queues anything suspicious for admin
+// review. Manual and scheduled refreshes both call it, so they get the same
+// checks. Failures are logged.
+func FlagAudienceAnomalies(ctx context.Context, st AnomalyStore, conn *model.Connection, followers int, metadata []byte) {
+	var prev *AudienceSnapshot
+	if conn.FollowerCount != nil {
+		prev = &AudienceSnapshot{Platform: conn.Platform, Followers: *conn.FollowerCount, Metadata: conn.Metadata}
+	}
+	curr := AudienceSnapshot{Platform: conn.Platform, Followers: followers, Metadata: metadata}
+
+	for _, a := range DetectAudienceAnomalies(prev, curr) {
+		details := a.Details
+		created, err := st.CreateAudienceAnomaly(ctx, &store.AudienceAnomaly{
+			ID:                cuid2.Generate(),
+			UserID:            conn.UserID,
+			Platform:          conn.Platform,
+			Kind:              a.Kind,
+			Severity:          a.Severity,
+			Details:           &details,
+			PreviousFollowers: conn.FollowerCount,
+			CurrentFollowers:  &followers,
+		})
+		if err != nil {
+			log.Printf("Failed to record %s anomaly for %s/%s: %v", a.Kind, conn.UserID, conn.Platform, err)
+			continue
+		}
+		if created {
+			log.Printf("Flagged %s anomaly for %s/%s: %s", a.Kind, conn.UserID, conn.Platform, a.Details)
+		}
+	}
+}
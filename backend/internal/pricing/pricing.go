@@ -0,0 +1,193 @@
+// Package pricing prices creator token purchases. A Curve gives the price of
+// each unit as a function of the token's circulating supply, so a multi-unit
+// purchase is priced by summing the curve over the units bought.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Model identifies how a curve derives the unit price from supply.
+type Model string
+
+const (
+	// Flat charges BaseCents for every unit.
+	Flat Model = "flat"
+	// Linear charges BaseCents for the first unit and SlopeMilliCents more for
+	// every unit already in circulation.
+	Linear Model = "linear"
+	// Tiered charges the price of the tier the unit falls in.
+	Tiered Model = "tiered"
+)
+
+// MaxTiers caps the number of tiers on a curve.
+const MaxTiers = 20
+
+var (
+	ErrInvalidAmount = errors.New("pricing: amount must be positive")
+	ErrOverflow      = errors.New("pricing: price out of range")
+)
+
+// Tier sets the unit price for all units minted once supply reaches
+// FromSupply, up to the next tier.
+type Tier struct {
+	FromSupply int `json:"fromSupply"`
+	PriceCents int `json:"priceCents"`
+}
+
+// Curve is a token pricing model.
+type Curve struct {
+	Model           Model
+	BaseCents       int
+	SlopeMilliCents int
+	Tiers           []Tier
+}
+
+// Validate reports whether the curve is well formed. Tiers must be sorted by
+// FromSupply, start at zero and not repeat a FromSupply. Errors are worded
+// for display to the creator configuring the curve.
+func (c Curve) Validate() error {
+	switch c.Model {
+	case Flat:
+		if c.BaseCents < 1 {
+			return errors.New("base price must be at least 1 cent")
+		}
+	case Linear:
+		if c.BaseCents < 1 {
+			return errors.New("base price must be at least 1 cent")
+		}
+		if c.SlopeMilliCents < 0 {
+			return errors.New("slope cannot be negative")
+		}
+	case Tiered:
+		if len(c.Tiers) == 0 {
+			return errors.New("tiered pricing needs at least one tier")
+		}
+		if len(c.Tiers) > MaxTiers {
+			return fmt.Errorf("at most %d tiers are allowed", MaxTiers)
+		}
+		if c.Tiers[0].FromSupply != 0 {
+			return errors.New("first tier must start at supply 0")
+		}
+		for i, t := range c.Tiers {
+			if t.PriceCents < 1 {
+				return fmt.Errorf("tier %d price must be at least 1 cent", i+1)
+			}
+			if i > 0 && t.FromSupply <= c.Tiers[i-1].FromSupply {
+				return errors.New("tiers must be in increasing supply order")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown model %q", c.Model)
+	}
+	return nil
+}
+
+// UnitPrice returns the price in cents of the next unit minted when supply
+// units are already in circulation. Linear prices are rounded up to the
+// nearest cent.
+func (c Curve) UnitPrice(supply int) int64 {
+	switch {
+	case c.Model == Linear:
+		milli := int64(c.BaseCents)*1000 + int64(c.SlopeMilliCents)*int64(supply)
+		return (milli + 999) / 1000
+	case c.Model == Tiered && len(c.Tiers) > 0:
+		return int64(c.Tiers[c.tierIndex(supply)].PriceCents)
+	default:
+		return int64(c.BaseCents)
+	}
+}
+
+// Cost returns the total price in cents of minting amount units starting at
+// the given supply. Linear totals are computed exactly and rounded up to the
+// nearest cent once, so buying in one go never costs more than the sum of
+// single-unit purchases.
+func (c Curve) Cost(supply, amount int) (int64, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if supply < 0 {
+		supply = 0
+	}
+
+	total := new(big.Int)
+	switch {
+	case c.Model == Linear:
+		// sum_{i=supply}^{supply+amount-1} (base*1000 + slope*i) / 1000
+		n := big.NewInt(int64(amount))
+		s := big.NewInt(int64(supply))
+
+		// sum of i over the range = n*s + n*(n-1)/2
+		indices := new(big.Int).Mul(n, s)
+		tri := new(big.Int).Mul(n, big.NewInt(int64(amount-1)))
+		tri.Rsh(tri, 1)
+		indices.Add(indices, tri)
+
+		milli := new(big.Int).Mul(n, big.NewInt(int64(c.BaseCents)*1000))
+		milli.Add(milli, indices.Mul(indices, big.NewInt(int64(c.SlopeMilliCents))))
+
+		total.Add(milli, big.NewInt(999))
+		total.Quo(total, big.NewInt(1000))
+	case c.Model == Tiered && len(c.Tiers) > 0:
+		remaining := int64(amount)
+		pos := int64(supply)
+		for i := c.tierIndex(supply); remaining > 0; i++ {
+			units := remaining
+			if i+1 < len(c.Tiers) {
+				if room := int64(c.Tiers[i+1].FromSupply) - pos; room < units {
+					units = room
+				}
+			}
+			part := new(big.Int).Mul(big.NewInt(units), big.NewInt(int64(c.Tiers[i].PriceCents)))
+			total.Add(total, part)
+			pos += units
+			remaining -= units
+		}
+	default:
+		total.Mul(big.NewInt(int64(amount)), big.NewInt(int64(c.BaseCents)))
+	}
+
+	if !total.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return total.Int64(), nil
+}
+
+// Quote is the price of a prospective purchase.
+type Quote struct {
+	Amount            int     `json:"amount"`
+	Supply            int     `json:"supply"`
+	TotalCents        int64   `json:"totalCents"`
+	AveragePriceCents float64 `json:"averagePriceCents"`
+	FirstUnitCents    int64   `json:"firstUnitCents"`
+	NextPriceCents    int64   `json:"nextPriceCents"`
+}
+
+// Quote prices a purchase of amount units at the given supply. NextPriceCents
+// is the unit price once the purchase has been minted.
+func (c Curve) Quote(supply, amount int) (*Quote, error) {
+	total, err := c.Cost(supply, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &Quote{
+		Amount:            amount,
+		Supply:            supply,
+		TotalCents:        total,
+		AveragePriceCents: float64(total) / float64(amount),
+		FirstUnitCents:    c.UnitPrice(supply),
+		NextPriceCents:    c.UnitPrice(supply + amount),
+	}, nil
+}
+
+// tierIndex returns the index of the tier that supply falls in.
+func (c Curve) tierIndex(supply int) int {
+	i := sort.Search(len(c.Tiers), func(i int) bool { return c.Tiers[i].FromSupply > supply })
+	if i == 0 {
+		return 0
+	}
+	return i - 1
+}
@@ -0,0 +1,204 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tiered = Curve{
+	Model: Tiered,
+	Tiers: []Tier{
+		{FromSupply: 0, PriceCents: 100},
+		{FromSupply: 10, PriceCents: 150},
+		{FromSupply: 25, PriceCents: 300},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		curve   Curve
+		wantErr bool
+	}{
+		{"flat", Curve{Model: Flat, BaseCents: 100}, false},
+		{"flat zero price", Curve{Model: Flat}, true},
+		{"linear", Curve{Model: Linear, BaseCents: 100, SlopeMilliCents: 500}, false},
+		{"linear zero slope", Curve{Model: Linear, BaseCents: 100}, false},
+		{"linear negative slope", Curve{Model: Linear, BaseCents: 100, SlopeMilliCents: -1}, true},
+		{"linear zero base", Curve{Model: Linear, SlopeMilliCents: 10}, true},
+		{"tiered", tiered, false},
+		{"tiered without tiers", Curve{Model: Tiered}, true},
+		{"tiered not starting at zero", Curve{Model: Tiered, Tiers: []Tier{{FromSupply: 5, PriceCents: 100}}}, true},
+		{"tiered out of order", Curve{Model: Tiered, Tiers: []Tier{
+			{FromSupply: 0, PriceCents: 100}, {FromSupply: 20, PriceCents: 200}, {FromSupply: 10, PriceCents: 300},
+		}}, true},
+		{"tiered duplicate start", Curve{Model: Tiered, Tiers: []Tier{
+			{FromSupply: 0, PriceCents: 100}, {FromSupply: 0, PriceCents: 200},
+		}}, true},
+		{"tiered zero price", Curve{Model: Tiered, Tiers: []Tier{
+			{FromSupply: 0, PriceCents: 100}, {FromSupply: 10, PriceCents: 0},
+		}}, true},
+		{"too many tiers", Curve{Model: Tiered, Tiers: make([]Tier, MaxTiers+1)}, true},
+		{"unknown model", Curve{Model: "exponential", BaseCents: 100}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.curve.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUnitPrice(t *testing.T) {
+	linear := Curve{Model: Linear, BaseCents: 100, SlopeMilliCents: 2500}
+
+	tests := []struct {
+		name   string
+		curve  Curve
+		supply int
+		want   int64
+	}{
+		{"flat ignores supply", Curve{Model: Flat, BaseCents: 250}, 1000, 250},
+		{"linear first unit is base", linear, 0, 100},
+		{"linear whole cents", linear, 4, 110},
+		{"linear rounds up", linear, 1, 103},
+		{"tiered first tier", tiered, 0, 100},
+		{"tiered last unit of first tier", tiered, 9, 100},
+		{"tiered tier boundary", tiered, 10, 150},
+		{"tiered beyond last tier", tiered, 1000, 300},
+		{"tiered without tiers falls back to base", Curve{Model: Tiered, BaseCents: 75}, 3, 75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.curve.UnitPrice(tt.supply))
+		})
+	}
+}
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		curve  Curve
+		supply int
+		amount int
+		want   int64
+	}{
+		{"flat", Curve{Model: Flat, BaseCents: 100}, 50, 3, 300},
+		// 100 + 101 + 102 + 103
+		{"linear whole cents", Curve{Model: Linear, BaseCents: 100, SlopeMilliCents: 1000}, 0, 4, 406},
+		// 100.0 + 100.5 + 101.0 = 301.5, rounded up once
+		{"linear rounds total once", Curve{Model: Linear, BaseCents: 100, SlopeMilliCents: 500}, 0, 3, 302},
+		// units at supply 10..12: 110 + 111 + 112
+		{"linear from non-zero supply", Curve{Model: Linear, BaseCents: 100, SlopeMilliCents: 1000}, 10, 3, 333},
+		{"linear zero slope is flat", Curve{Model: Linear, BaseCents: 100}, 500, 7, 700},
+		{"tiered within a tier", tiered, 0, 5, 500},
+		// 2 units at 100, then 3 at 150
+		{"tiered across one boundary", tiered, 8, 5, 200 + 450},
+		// 2 at 100, 15 at 150, 3 at 300
+		{"tiered across two boundaries", tiered, 8, 20, 200 + 2250 + 900},
+		{"tiered starting exactly on boundary", tiered, 10, 1, 150},
+		{"tiered beyond last tier", tiered, 100, 2, 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.curve.Cost(tt.supply, tt.amount)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCost_MatchesUnitSum(t *testing.T) {
+	curves := map[string]Curve{
+		"flat":   {Model: Flat, BaseCents: 99},
+		"tiered": tiered,
+		"linear": {Model: Linear, BaseCents: 50, SlopeMilliCents: 1000},
+	}
+	for name, c := range curves {
+		t.Run(name, func(t *testing.T) {
+			for supply := 0; supply < 40; supply += 3 {
+				for amount := 1; amount <= 30; amount++ {
+					var want int64
+					for i := 0; i < amount; i++ {
+						want += c.UnitPrice(supply + i)
+					}
+					got, err := c.Cost(supply, amount)
+					require.NoError(t, err)
+					assert.Equal(t, want, got, "supply=%d amount=%d", supply, amount)
+				}
+			}
+		})
+	}
+}
+
+func TestCost_LinearExact(t *testing.T) {
+	c := Curve{Model: Linear, BaseCents: 37, SlopeMilliCents: 333}
+	for supply := 0; supply < 200; supply += 17 {
+		for amount := 1; amount <= 50; amount++ {
+			var milli, singles int64
+			for i := supply; i < supply+amount; i++ {
+				milli += 37*1000 + 333*int64(i)
+				singles += c.UnitPrice(i)
+			}
+			got, err := c.Cost(supply, amount)
+			require.NoError(t, err)
+			assert.Equal(t, (milli+999)/1000, got, "supply=%d amount=%d", supply, amount)
+			assert.LessOrEqual(t, got, singles, "a bulk buy never costs more than single buys")
+		}
+	}
+}
+
+func TestCost_SplitPurchases(t *testing.T) {
+	// Buying a then b units costs the same as buying a+b at once for curves
+	// without rounding.
+	for _, c := range []Curve{{Model: Flat, BaseCents: 120}, tiered, {Model: Linear, BaseCents: 10, SlopeMilliCents: 2000}} {
+		whole, err := c.Cost(3, 30)
+		require.NoError(t, err)
+		first, err := c.Cost(3, 12)
+		require.NoError(t, err)
+		second, err := c.Cost(15, 18)
+		require.NoError(t, err)
+		assert.Equal(t, whole, first+second, "model %s", c.Model)
+	}
+}
+
+func TestCost_Errors(t *testing.T) {
+	c := Curve{Model: Flat, BaseCents: 100}
+
+	_, err := c.Cost(0, 0)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = c.Cost(0, -5)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	huge := Curve{Model: Linear, BaseCents: math.MaxInt32, SlopeMilliCents: math.MaxInt32}
+	_, err = huge.Cost(math.MaxInt32, math.MaxInt32)
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestCost_NegativeSupplyClamped(t *testing.T) {
+	c := Curve{Model: Linear, BaseCents: 100, SlopeMilliCents: 1000}
+	got, err := c.Cost(-10, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(201), got)
+}
+
+func TestQuote(t *testing.T) {
+	q, err := tiered.Quote(8, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 4, q.Amount)
+	assert.Equal(t, 8, q.Supply)
+	assert.Equal(t, int64(500), q.TotalCents)
+	assert.Equal(t, 125.0, q.AveragePriceCents)
+	assert.Equal(t, int64(100), q.FirstUnitCents)
+	assert.Equal(t, int64(150), q.NextPriceCents)
+
+	_, err = tiered.Quote(0, 0)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
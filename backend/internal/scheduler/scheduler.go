@@ -6,23 +6,29 @@ import (
 	"log"
 	"time"
 
+	"github.com/creatrid/creatrid/internal/moderation"
 	"github.com/creatrid/creatrid/internal/platform"
 	"github.com/creatrid/creatrid/internal/score"
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/webhook"
 	"golang.org/x/oauth2"
 )
 
 type Scheduler struct {
-	store     *store.Store
-	providers map[string]platform.Provider
-	interval  time.Duration
+	store         *store.Store
+	providers     map[string]platform.Provider
+	interval      time.Duration
+	dampAnomalies bool
 }
 
-func New(st *store.Store, providers map[string]platform.Provider, interval time.Duration) *Scheduler {
+// New returns a scheduler refreshing connections every interval.
+// dampAnomalies is passed to score.Gather when scores are recalculated.
+func New(st *store.Store, providers map[string]platform.Provider, interval time.Duration, dampAnomalies bool) *Scheduler {
 	return &Scheduler{
-		store:     st,
-		providers: providers,
-		interval:  interval,
+		store:         st,
+		providers:     providers,
+		interval:      interval,
+		dampAnomalies: dampAnomalies,
 	}
 }
 
@@ -69,7 +75,11 @@ func (s *Scheduler) refreshAll(ctx context.Context) {
 			if err != nil {
 				log.Printf("Scheduler: token refresh failed for %s/%s: %v", conn.UserID, conn.Platform, err)
 				// Touch updated_at to avoid retrying too soon
-				_ = s.store.UpdateConnectionProfile(ctx, conn.UserID, conn.Platform, conn.FollowerCount, conn.Metadata)
+				_ = s.store.UpdateConnectionProfile(ctx, conn.UserID, conn.Platform, conn.FollowerCount, conn.Metadata,
+					store.NewEvent(conn.UserID, webhook.EventConnectionExpired, webhook.ConnectionExpired{
+						Platform: conn.Platform,
+						Reason:   "token refresh failed",
+					}))
 				continue
 			}
 			accessToken = newToken.AccessToken
@@ -94,18 +104,26 @@ func (s *Scheduler) refreshAll(ctx context.Context) {
 		}
 
 		metadataJSON, _ := json.Marshal(profile.Metadata)
-		_ = s.store.UpdateConnectionProfile(ctx, conn.UserID, conn.Platform, &profile.FollowerCount, metadataJSON)
+		moderation.FlagAudienceAnomalies(ctx, s.store, conn, profile.FollowerCount, metadataJSON)
+		_ = s.store.UpdateConnectionProfile(ctx, conn.UserID, conn.Platform, &profile.FollowerCount, metadataJSON,
+			store.NewEvent(conn.UserID, webhook.EventConnectionRefreshed, webhook.ConnectionRefreshed{
+				Platform:      conn.Platform,
+				FollowerCount: profile.FollowerCount,
+			}))
+		if err := s.store.RecordConnectionMetric(ctx, conn.UserID, conn.Platform, &profile.FollowerCount, metadataJSON); err != nil {
+			log.Printf("Scheduler: failed to record metrics for %s/%s: %v", conn.UserID, conn.Platform, err)
+		}
 
 		// Recalculate score
 		user, err := s.store.FindUserByID(ctx, conn.UserID)
 		if err != nil || user == nil {
 			continue
 		}
-		allConns, err := s.store.FindConnectionsByUserID(ctx, conn.UserID)
+		signals, err := score.Gather(ctx, s.store, user, time.Now(), s.dampAnomalies)
 		if err != nil {
 			continue
 		}
-		newScore := score.Calculate(user, allConns)
+		newScore := score.CalculateV2(signals).Score
 		_ = s.store.UpdateUserScore(ctx, conn.UserID, newScore)
 
 		refreshed++
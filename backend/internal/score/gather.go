@@ -0,0 +1,65 @@
+package score
+
+import (
+	"context"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/analytics"
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+// Source is the data the v2 score reads. *store.Store satisfies it.
+type Source interface {
+	FindConnectionsByUserID(ctx context.Context, userID string) ([]*model.Connection, error)
+	ListFollowerSnapshots(ctx context.Context, userID, bucket string, since time.Time) ([]store.FollowerSnapshot, error)
+	GetContentActivity(ctx context.Context, userID string, since time.Time) (*store.ContentActivity, error)
+	ListFlaggedPlatforms(ctx context.Context, userID string, statuses []string) ([]string, error)
+}
+
+// Gather loads the signals for a v2 score of user as of now. With
+// dampAnomalies it also loads the platforms with audience anomalies under
+// review, so they damp the audience component.
+func Gather(ctx context.Context, src Source, user *model.User, now time.Time, dampAnomalies bool) (*Signals, error) {
+	connections, err := src.FindConnectionsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	since := analytics.TruncateBucket(now.AddDate(0, 0, -7*GrowthWeeks), analytics.BucketWeek)
+	snapshots, err := src.ListFollowerSnapshots(ctx, user.ID, analytics.BucketWeek, since)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]analytics.FollowerSample, len(snapshots))
+	for i, s := range snapshots {
+		samples[i] = analytics.FollowerSample{Bucket: s.Bucket, Platform: s.Platform, Followers: s.FollowerCount}
+	}
+	series := analytics.GrowthSeries(samples, analytics.BucketWeek, now)
+	weekly := make([]int, len(series))
+	for i, p := range series {
+		weekly[i] = p.Followers
+	}
+
+	activity, err := src.GetContentActivity(ctx, user.ID, now.Add(-RecentWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &Signals{
+		User:            user,
+		Connections:     connections,
+		WeeklyFollowers: weekly,
+		ContentItems:    activity.Items,
+		RecentContent:   activity.Recent,
+		AnchoredContent: activity.Anchored,
+		Now:             now,
+	}
+	if dampAnomalies {
+		sig.FlaggedPlatforms, err = src.ListFlaggedPlatforms(ctx, user.ID, []string{"pending", "confirmed"})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sig, nil
+}
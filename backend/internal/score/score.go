@@ -6,27 +6,60 @@ import (
 	"github.com/creatrid/creatrid/internal/model"
 )
 
+// Versions of the scoring algorithm.
+const (
+	V1 = "v1"
+	V2 = "v2"
+
+	// Current is the version stored as a user's creator score.
+	Current = V2
+)
+
+// Component is one weighted part of a score. Value is the normalised signal
+// in [0, 1] where one is known; Points is what it contributed to the total.
+type Component struct {
+	Name   string   `json:"name"`
+	Weight int      `json:"weight"`
+	Points int      `json:"points"`
+	Value  *float64 `json:"value,omitempty"`
+	Detail string   `json:"detail,omitempty"`
+}
+
+// Result is a score together with the components that make it up.
+type Result struct {
+	Version    string      `json:"version"`
+	Score      int         `json:"score"`
+	Components []Component `json:"components"`
+}
+
 // Calculate returns a creator score (0-100) based on profile, connections, and metrics.
+// It is the v1 algorithm; see CalculateV1 for its breakdown.
 func Calculate(user *model.User, connections []*model.Connection) int {
-	score := 0
+	return CalculateV1(user, connections).Score
+}
 
+// CalculateV1 scores profile completeness, email verification, the number of
+// connections and total followers on a log scale.
+func CalculateV1(user *model.User, connections []*model.Connection) *Result {
 	// Profile completeness: 0-20 points
+	profile := 0
 	if user.Name != nil && *user.Name != "" {
-		score += 5
+		profile += 5
 	}
 	if user.Username != nil && *user.Username != "" {
-		score += 5
+		profile += 5
 	}
 	if user.Bio != nil && *user.Bio != "" {
-		score += 5
+		profile += 5
 	}
 	if user.Image != nil && *user.Image != "" {
-		score += 5
+		profile += 5
 	}
 
 	// Email verified: 10 points
+	email := 0
 	if user.EmailVerified != nil {
-		score += 10
+		email = 10
 	}
 
 	// Connected platforms: 10 points each, max 50
@@ -34,7 +67,6 @@ func Calculate(user *model.User, connections []*model.Connection) int {
 	if connectionPoints > 50 {
 		connectionPoints = 50
 	}
-	score += connectionPoints
 
 	// Follower bonus: 0-20 points (logarithmic scale)
 	totalFollowers := 0
@@ -43,12 +75,25 @@ func Calculate(user *model.User, connections []*model.Connection) int {
 			totalFollowers += *c.FollowerCount
 		}
 	}
-	score += followerBonus(totalFollowers)
 
+	return newResult(V1, []Component{
+		{Name: "profile", Weight: 20, Points: profile},
+		{Name: "email", Weight: 10, Points: email},
+		{Name: "connections", Weight: 50, Points: connectionPoints},
+		{Name: "followers", Weight: 20, Points: followerBonus(totalFollowers)},
+	})
+}
+
+// newResult totals components into a score capped at 100.
+func newResult(version string, components []Component) *Result {
+	score := 0
+	for _, c := range components {
+		score += c.Points
+	}
 	if score > 100 {
 		score = 100
 	}
-	return score
+	return &Result{Version: version, Score: score, Components: components}
 }
 
 // followerBonus returns 0-20 points based on total followers using log10 scale.
@@ -0,0 +1,283 @@
+package score
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/model"
+)
+
+// Component weights for v2. They add up to 100.
+const (
+	weightProfile     = 10
+	weightEmail       = 5
+	weightIdentity    = 10
+	weightAccountAge  = 10
+	weightConnections = 15
+	weightAudience    = 15
+	weightEngagement  = 15
+	weightGrowth      = 10
+	weightContent     = 5
+	weightAnchored    = 5
+)
+
+const (
+	// RecentWindow is how far back an upload counts as recent vault activity.
+	RecentWindow = 30 * 24 * time.Hour
+	// GrowthWeeks is how many weeks of follower history feed the growth trend.
+	GrowthWeeks = 12
+
+	// A week-over-week jump above spikeRate on an audience of at least
+	// spikeMinBase followers is treated as bought or botted followers.
+	spikeRate    = 0.5
+	spikeMinBase = 100
+	// Audiences of at least lowEngagementMinBase followers engaging below
+	// lowEngagementRate have their audience points damped.
+	lowEngagementRate    = 0.01
+	lowEngagementMinBase = 1000
+	// audienceDamping scales audience points when any check trips.
+	audienceDamping = 0.5
+
+	// Signal levels that earn full marks.
+	fullAccountAgeDays = 365
+	fullConnections    = 3
+	fullEngagement     = 0.10
+	fullWeeklyGrowth   = 0.02
+	fullContentItems   = 10
+	fullRecentContent  = 2
+	fullAnchored       = 5
+)
+
+// Signals are everything the v2 score looks at. WeeklyFollowers is the
+// user's total followers across connections at the end of each week, oldest
+// first. FlaggedPlatforms lists connections with unresolved or confirmed
+// audience anomalies.
+type Signals struct {
+	User             *model.User
+	Connections      []*model.Connection
+	WeeklyFollowers  []int
+	ContentItems     int
+	RecentContent    int
+	AnchoredContent  int
+	FlaggedPlatforms []string
+	Now              time.Time
+}
+
+// CalculateV2 weighs profile and identity signals alongside account age,
+// audience engagement, follower growth and content vault activity. Audience
+// size is damped when followers spike, barely engage or have been flagged for
+// review, so bought followers do not raise the score on their own.
+func CalculateV2(sig *Signals) *Result {
+	user := sig.User
+
+	filled := 0
+	for _, f := range []*string{user.Name, user.Username, user.Bio, user.Image} {
+		if f != nil && *f != "" {
+			filled++
+		}
+	}
+	profile := component("profile", weightProfile, float64(filled)/4)
+	profile.Detail = fmt.Sprintf("%d of 4 profile fields filled", filled)
+
+	email := component("email", weightEmail, boolValue(user.EmailVerified != nil))
+	identity := component("identity", weightIdentity, boolValue(user.IsVerified))
+
+	ageDays := 0
+	if !user.CreatedAt.IsZero() && sig.Now.After(user.CreatedAt) {
+		ageDays = int(sig.Now.Sub(user.CreatedAt).Hours() / 24)
+	}
+	age := component("accountAge", weightAccountAge, ratio(float64(ageDays), fullAccountAgeDays))
+	age.Detail = fmt.Sprintf("%d days old", ageDays)
+
+	connections := component("connections", weightConnections, ratio(float64(len(sig.Connections)), fullConnections))
+	connections.Detail = fmt.Sprintf("%d connected platforms", len(sig.Connections))
+
+	totalFollowers := 0
+	for _, c := range sig.Connections {
+		if c.FollowerCount != nil && *c.FollowerCount > 0 {
+			totalFollowers += *c.FollowerCount
+		}
+	}
+
+	engagement := Component{Name: "engagement", Weight: weightEngagement, Detail: "no engagement data"}
+	rate, hasRate := engagementRate(sig.Connections)
+	if hasRate {
+		engagement = component("engagement", weightEngagement, ratio(rate, fullEngagement))
+		engagement.Detail = fmt.Sprintf("%.2f%% engagement", rate*100)
+	}
+
+	growth := Component{Name: "growth", Weight: weightGrowth, Detail: "not enough follower history"}
+	trend, spike, hasTrend := growthTrend(sig.WeeklyFollowers)
+	switch {
+	case spike:
+		growth = component("growth", weightGrowth, 0)
+		growth.Detail = "follower spike detected"
+	case hasTrend:
+		growth = component("growth", weightGrowth, ratio(trend, fullWeeklyGrowth))
+		growth.Detail = fmt.Sprintf("%.2f%% median weekly growth", trend*100)
+	}
+
+	audienceValue := audienceScale(totalFollowers)
+	var damped []string
+	if spike {
+		damped = append(damped, "follower spike")
+	}
+	if hasRate && rate < lowEngagementRate && totalFollowers >= lowEngagementMinBase {
+		damped = append(damped, "low engagement")
+	}
+	if len(sig.FlaggedPlatforms) > 0 {
+		damped = append(damped, "flagged audience on "+strings.Join(sig.FlaggedPlatforms, ", "))
+	}
+	if len(damped) > 0 {
+		audienceValue *= audienceDamping
+	}
+	audience := component("audience", weightAudience, audienceValue)
+	audience.Detail = fmt.Sprintf("%d followers", totalFollowers)
+	if len(damped) > 0 {
+		audience.Detail += ", damped for " + strings.Join(damped, " and ")
+	}
+
+	contentValue := 0.6*ratio(float64(sig.ContentItems), fullContentItems) +
+		0.4*ratio(float64(sig.RecentContent), fullRecentContent)
+	content := component("content", weightContent, contentValue)
+	content.Detail = fmt.Sprintf("%d items, %d in the last 30 days", sig.ContentItems, sig.RecentContent)
+
+	anchored := component("anchored", weightAnchored, ratio(float64(sig.AnchoredContent), fullAnchored))
+	anchored.Detail = fmt.Sprintf("%d items anchored on-chain", sig.AnchoredContent)
+
+	return newResult(V2, []Component{
+		profile, email, identity, age, connections,
+		audience, engagement, growth, content, anchored,
+	})
+}
+
+// EngagementRate estimates how engaged a connection's audience is from the
+// platform metadata saved on refresh: average views per video relative to
+// subscribers on YouTube, and stars on top repositories relative to
+// followers on GitHub. It reports false for platforms without usable data.
+func EngagementRate(c *model.Connection) (float64, bool) {
+	if c.FollowerCount == nil || *c.FollowerCount <= 0 || len(c.Metadata) == 0 {
+		return 0, false
+	}
+	followers := float64(*c.FollowerCount)
+
+	var meta map[string]interface{}
+	if err := json.Unmarshal(c.Metadata, &meta); err != nil {
+		return 0, false
+	}
+
+	switch c.Platform {
+	case "youtube":
+		views, ok1 := number(meta["view_count"])
+		videos, ok2 := number(meta["video_count"])
+		if !ok1 || !ok2 || videos <= 0 {
+			return 0, false
+		}
+		return views / videos / followers, true
+	case "github":
+		repos, ok := meta["top_repos"].([]interface{})
+		if !ok || len(repos) == 0 {
+			return 0, false
+		}
+		stars := 0.0
+		for _, r := range repos {
+			if repo, ok := r.(map[string]interface{}); ok {
+				n, _ := number(repo["stars"])
+				stars += n
+			}
+		}
+		return stars / followers, true
+	}
+	return 0, false
+}
+
+// engagementRate is the follower-weighted average engagement across the
+// connections that report it.
+func engagementRate(connections []*model.Connection) (float64, bool) {
+	var weighted, followers float64
+	for _, c := range connections {
+		rate, ok := EngagementRate(c)
+		if !ok {
+			continue
+		}
+		weighted += rate * float64(*c.FollowerCount)
+		followers += float64(*c.FollowerCount)
+	}
+	if followers == 0 {
+		return 0, false
+	}
+	return weighted / followers, true
+}
+
+// growthTrend returns the median week-over-week growth rate of a weekly
+// follower series and whether any week jumped suspiciously. Weeks starting
+// from zero followers are skipped.
+func growthTrend(weekly []int) (median float64, spike bool, ok bool) {
+	var rates []float64
+	for i := 1; i < len(weekly); i++ {
+		prev := weekly[i-1]
+		if prev <= 0 {
+			continue
+		}
+		rate := float64(weekly[i]-prev) / float64(prev)
+		if rate > spikeRate && prev >= spikeMinBase {
+			spike = true
+		}
+		rates = append(rates, rate)
+	}
+	if len(rates) == 0 {
+		return 0, spike, false
+	}
+
+	sort.Float64s(rates)
+	mid := len(rates) / 2
+	if len(rates)%2 == 0 {
+		return (rates[mid-1] + rates[mid]) / 2, spike, true
+	}
+	return rates[mid], spike, true
+}
+
+// audienceScale maps total followers onto [0, 1] on a log scale: 10
+// followers or fewer is 0 and 100,000 or more is 1.
+func audienceScale(followers int) float64 {
+	if followers <= 10 {
+		return 0
+	}
+	return ratio(math.Log10(float64(followers))-1, 4)
+}
+
+func component(name string, weight int, value float64) Component {
+	return Component{
+		Name:   name,
+		Weight: weight,
+		Points: int(math.Round(float64(weight) * value)),
+		Value:  &value,
+	}
+}
+
+// ratio returns v/full clamped to [0, 1].
+func ratio(v, full float64) float64 {
+	if v <= 0 || full <= 0 {
+		return 0
+	}
+	if v >= full {
+		return 1
+	}
+	return v / full
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
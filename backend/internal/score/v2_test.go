@@ -0,0 +1,199 @@
+package score
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func points(r *Result, name string) int {
+	for _, c := range r.Components {
+		if c.Name == name {
+			return c.Points
+		}
+	}
+	return -1
+}
+
+func youtube(followers, views, videos int) *model.Connection {
+	meta, _ := json.Marshal(map[string]int{"view_count": views, "video_count": videos, "subscriber_count": followers})
+	return &model.Connection{Platform: "youtube", FollowerCount: intPtr(followers), Metadata: meta}
+}
+
+func TestCalculateV2_WeightsSumTo100(t *testing.T) {
+	r := CalculateV2(&Signals{User: &model.User{}, Now: now})
+	total := 0
+	for _, c := range r.Components {
+		total += c.Weight
+	}
+	assert.Equal(t, 100, total)
+	assert.Equal(t, V2, r.Version)
+	assert.Equal(t, 0, r.Score)
+}
+
+func TestCalculateV2_FullMarks(t *testing.T) {
+	sig := &Signals{
+		User: &model.User{
+			Name:          strPtr("Test User"),
+			Username:      strPtr("testuser"),
+			Bio:           strPtr("A short bio"),
+			Image:         strPtr("https://example.com/img.png"),
+			EmailVerified: timePtr(now),
+			IsVerified:    true,
+			CreatedAt:     now.AddDate(-2, 0, 0),
+		},
+		Connections: []*model.Connection{
+			youtube(200000, 40000000, 100),
+			{Platform: "github", FollowerCount: intPtr(10)},
+			{Platform: "twitter", FollowerCount: intPtr(10)},
+		},
+		WeeklyFollowers: []int{180000, 184000, 188000, 192000, 196000, 200020},
+		ContentItems:    12,
+		RecentContent:   3,
+		AnchoredContent: 5,
+		Now:             now,
+	}
+	r := CalculateV2(sig)
+	for _, c := range r.Components {
+		assert.Equal(t, c.Weight, c.Points, c.Name)
+	}
+	assert.Equal(t, 100, r.Score)
+}
+
+func TestCalculateV2_AccountAge(t *testing.T) {
+	r := CalculateV2(&Signals{User: &model.User{CreatedAt: now.AddDate(0, 0, -73)}, Now: now})
+	assert.Equal(t, 2, points(r, "accountAge"))
+}
+
+func TestCalculateV2_IdentityVerification(t *testing.T) {
+	assert.Equal(t, 10, points(CalculateV2(&Signals{User: &model.User{IsVerified: true}, Now: now}), "identity"))
+	assert.Equal(t, 0, points(CalculateV2(&Signals{User: &model.User{}, Now: now}), "identity"))
+}
+
+func TestCalculateV2_FollowerSpikeDampsAudience(t *testing.T) {
+	base := &Signals{
+		User:            &model.User{},
+		Connections:     []*model.Connection{{Platform: "instagram", FollowerCount: intPtr(100000)}},
+		WeeklyFollowers: []int{5000, 5100, 5200, 100000},
+		Now:             now,
+	}
+	bought := CalculateV2(base)
+	assert.Equal(t, 0, points(bought, "growth"))
+	assert.Equal(t, 8, points(bought, "audience"))
+
+	organic := *base
+	organic.WeeklyFollowers = []int{96000, 98000, 100000}
+	r := CalculateV2(&organic)
+	assert.Equal(t, 15, points(r, "audience"))
+	assert.Equal(t, 10, points(r, "growth"))
+	assert.Greater(t, r.Score, bought.Score)
+}
+
+func TestCalculateV2_LowEngagementDampsAudience(t *testing.T) {
+	// 100 views per video on 100k subscribers
+	r := CalculateV2(&Signals{
+		User:        &model.User{},
+		Connections: []*model.Connection{youtube(100000, 1000, 10)},
+		Now:         now,
+	})
+	assert.Equal(t, 8, points(r, "audience"))
+	assert.Equal(t, 0, points(r, "engagement"))
+
+	for _, c := range r.Components {
+		if c.Name == "audience" {
+			assert.Contains(t, c.Detail, "low engagement")
+		}
+	}
+}
+
+func TestCalculateV2_ContentAndAnchoring(t *testing.T) {
+	r := CalculateV2(&Signals{User: &model.User{}, ContentItems: 5, RecentContent: 1, AnchoredContent: 2, Now: now})
+	// 0.6*0.5 + 0.4*0.5 = 0.5 of 5
+	assert.Equal(t, 3, points(r, "content"))
+	assert.Equal(t, 2, points(r, "anchored"))
+}
+
+func TestCalculateV2_UnknownSignalsHaveNoValue(t *testing.T) {
+	r := CalculateV2(&Signals{User: &model.User{}, Now: now})
+	for _, c := range r.Components {
+		if c.Name == "engagement" || c.Name == "growth" {
+			assert.Nil(t, c.Value, c.Name)
+			assert.NotEmpty(t, c.Detail, c.Name)
+		}
+	}
+}
+
+func TestEngagementRate(t *testing.T) {
+	rate, ok := EngagementRate(youtube(1000, 50000, 10))
+	require.True(t, ok)
+	assert.InDelta(t, 5.0, rate, 1e-9)
+
+	gh := &model.Connection{
+		Platform:      "github",
+		FollowerCount: intPtr(200),
+		Metadata:      json.RawMessage(`{"top_repos":[{"stars":30},{"stars":10}]}`),
+	}
+	rate, ok = EngagementRate(gh)
+	require.True(t, ok)
+	assert.InDelta(t, 0.2, rate, 1e-9)
+
+	_, ok = EngagementRate(&model.Connection{Platform: "twitter", FollowerCount: intPtr(10), Metadata: json.RawMessage(`{"tweet_count":5}`)})
+	assert.False(t, ok)
+	_, ok = EngagementRate(youtube(0, 100, 1))
+	assert.False(t, ok)
+	_, ok = EngagementRate(youtube(100, 100, 0))
+	assert.False(t, ok)
+}
+
+func TestGrowthTrend(t *testing.T) {
+	median, spike, ok := growthTrend([]int{100, 110, 121, 121})
+	require.True(t, ok)
+	assert.False(t, spike)
+	assert.InDelta(t, 0.1, median, 1e-9)
+
+	_, spike, _ = growthTrend([]int{1000, 2000})
+	assert.True(t, spike)
+
+	// Small accounts doubling is not a spike.
+	_, spike, _ = growthTrend([]int{10, 40})
+	assert.False(t, spike)
+
+	_, _, ok = growthTrend([]int{0, 50})
+	assert.False(t, ok)
+	_, _, ok = growthTrend(nil)
+	assert.False(t, ok)
+}
+
+func TestCalculateV1_MatchesCalculate(t *testing.T) {
+	user := &model.User{Name: strPtr("A"), EmailVerified: timePtr(now)}
+	conns := []*model.Connection{{Platform: "youtube", FollowerCount: intPtr(100000)}}
+	r := CalculateV1(user, conns)
+	assert.Equal(t, V1, r.Version)
+	assert.Equal(t, Calculate(user, conns), r.Score)
+	// 5 profile + 10 email + 10 connection + 15 followers
+	assert.Equal(t, 40, r.Score)
+}
+
+func TestCalculateV2_FlaggedAudienceIsDamped(t *testing.T) {
+	sig := &Signals{
+		User:        &model.User{},
+		Connections: []*model.Connection{{Platform: "instagram", FollowerCount: intPtr(100000)}},
+		Now:         now,
+	}
+	assert.Equal(t, 15, points(CalculateV2(sig), "audience"))
+
+	sig.FlaggedPlatforms = []string{"instagram"}
+	r := CalculateV2(sig)
+	assert.Equal(t, 8, points(r, "audience"))
+	for _, c := range r.Components {
+		if c.Name == "audience" {
+			assert.Contains(t, c.Detail, "flagged audience on instagram")
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+// AzureStorage stores objects in an Azure Blob Storage container.
+type AzureStorage struct {
+	client    *azblob.Client
+	container string
+	baseURL   string
+}
+
+func NewAzureStorage(accountName, accountKey, container string) (*AzureStorage, error) {
+	cred, err := azblob.NewSharedKeyCredential(accountName, accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage credentials: %w", err)
+	}
+
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net", accountName)
+	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob client: %w", err)
+	}
+
+	return &AzureStorage{
+		client:    client,
+		container: container,
+		baseURL:   fmt.Sprintf("%s/%s", serviceURL, container),
+	}, nil
+}
+
+func (b *AzureStorage) Upload(ctx context.Context, blobName string, reader io.Reader, contentType string) (string, error) {
+	_, err := b.client.UploadStream(ctx, b.container, blobName, reader, &blockblob.UploadStreamOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &contentType},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload blob: %w", err)
+	}
+
+	return fmt.Sprintf("%s/%s", b.baseURL, blobName), nil
+}
+
+func (b *AzureStorage) Delete(ctx context.Context, blobURL string) error {
+	blobName, ok := b.blobName(blobURL)
+	if !ok {
+		return nil
+	}
+	_, err := b.client.DeleteBlob(ctx, b.container, blobName, nil)
+	return err
+}
+
+func (b *AzureStorage) Open(ctx context.Context, blobURL string) (io.ReadCloser, error) {
+	blobName, ok := b.blobName(blobURL)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	resp, err := b.client.DownloadStream(ctx, b.container, blobName, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+	return resp.Body, nil
+}
+
+func (b *AzureStorage) Stat(ctx context.Context, blobURL string) (*ObjectInfo, error) {
+	blobName, ok := b.blobName(blobURL)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	props, err := b.blobClient(blobName).GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get blob properties: %w", err)
+	}
+
+	info := &ObjectInfo{Name: blobName}
+	if props.ContentLength != nil {
+		info.Size = *props.ContentLength
+	}
+	if props.ContentType != nil {
+		info.ContentType = *props.ContentType
+	}
+	if props.LastModified != nil {
+		info.LastModified = *props.LastModified
+	}
+	return info, nil
+}
+
+func (b *AzureStorage) SignedURL(ctx context.Context, blobURL string, expiry time.Duration) (string, error) {
+	blobName, ok := b.blobName(blobURL)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return b.blobClient(blobName).GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expiry), nil)
+}
+
+func (b *AzureStorage) blobClient(blobName string) *blob.Client {
+	return b.client.ServiceClient().NewContainerClient(b.container).NewBlobClient(blobName)
+}
+
+// blobName extracts the blob name from a URL returned by Upload.
+func (b *AzureStorage) blobName(blobURL string) (string, bool) {
+	blobName := strings.TrimPrefix(blobURL, b.baseURL+"/")
+	if blobName == blobURL {
+		return "", false
+	}
+	return blobName, true
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStorage stores objects on the local filesystem and serves them over
+// HTTP. It is intended for development and CI, where no cloud credentials
+// are available.
+type LocalStorage struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewLocalStorage creates a LocalStorage rooted at dir. baseURL is the public
+// URL prefix under which the storage is mounted as an http.Handler; signingKey
+// is used to sign and verify the URLs returned by SignedURL.
+func NewLocalStorage(dir, baseURL, signingKey string) (*LocalStorage, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage directory: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStorage{
+		root:       root,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		signingKey: []byte(signingKey),
+	}, nil
+}
+
+func (l *LocalStorage) Upload(ctx context.Context, name string, reader io.Reader, contentType string) (string, error) {
+	p, err := l.path(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial object.
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return "", fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return l.baseURL + "/" + name, nil
+}
+
+func (l *LocalStorage) Delete(ctx context.Context, objectURL string) error {
+	name, ok := l.objectName(objectURL)
+	if !ok {
+		return nil
+	}
+	p, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) Open(ctx context.Context, objectURL string) (io.ReadCloser, error) {
+	p, err := l.pathFromURL(objectURL)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *LocalStorage) Stat(ctx context.Context, objectURL string) (*ObjectInfo, error) {
+	name, ok := l.objectName(objectURL)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	p, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && fi.IsDir()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &ObjectInfo{
+		Name:         name,
+		Size:         fi.Size(),
+		ContentType:  contentType,
+		LastModified: fi.ModTime(),
+	}, nil
+}
+
+func (l *LocalStorage) SignedURL(ctx context.Context, objectURL string, expiry time.Duration) (string, error) {
+	name, ok := l.objectName(objectURL)
+	if !ok {
+		return "", ErrNotFound
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("sig", l.sign(name, expires))
+	return l.baseURL + "/" + name + "?" + q.Encode(), nil
+}
+
+// ServeHTTP serves stored objects. It must be mounted with http.StripPrefix
+// so that the request path is the object name. Objects are public, like the
+// Azure containers they stand in for, but a request carrying a signature from
+// SignedURL is rejected once the signature is invalid or expired.
+func (l *LocalStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if sig := r.URL.Query().Get("sig"); sig != "" {
+		expires := r.URL.Query().Get("expires")
+		exp, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > exp || !hmac.Equal([]byte(sig), []byte(l.sign(name, expires))) {
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+	}
+
+	p, err := l.path(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+}
+
+func (l *LocalStorage) sign(name, expires string) string {
+	mac := hmac.New(sha256.New, l.signingKey)
+	mac.Write([]byte(name + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// objectName extracts the object name from a URL returned by Upload.
+func (l *LocalStorage) objectName(objectURL string) (string, bool) {
+	name := strings.TrimPrefix(objectURL, l.baseURL+"/")
+	if name == objectURL || name == "" {
+		return "", false
+	}
+	if i := strings.IndexByte(name, '?'); i >= 0 {
+		name = name[:i]
+	}
+	return name, true
+}
+
+func (l *LocalStorage) pathFromURL(objectURL string) (string, error) {
+	name, ok := l.objectName(objectURL)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return l.path(name)
+}
+
+// path maps an object name to a file under root, rejecting names that would
+// escape it.
+func (l *LocalStorage) path(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || clean != "/"+name {
+		return "", fmt.Errorf("invalid object name: %q", name)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
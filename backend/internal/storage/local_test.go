@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+	l, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/api/files", "test-key")
+	require.NoError(t, err)
+	return l
+}
+
+func TestLocalStorage_UploadOpenStatDelete(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStorage(t)
+
+	objectURL, err := l.Upload(ctx, "vault/user1/item.txt", strings.NewReader("hello world"), "text/plain")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/api/files/vault/user1/item.txt", objectURL)
+
+	rc, err := l.Open(ctx, objectURL)
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	info, err := l.Stat(ctx, objectURL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), info.Size)
+	assert.Equal(t, "vault/user1/item.txt", info.Name)
+	assert.True(t, strings.HasPrefix(info.ContentType, "text/plain"))
+
+	require.NoError(t, l.Delete(ctx, objectURL))
+	_, err = l.Stat(ctx, objectURL)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting again, or deleting a foreign URL, is a no-op.
+	assert.NoError(t, l.Delete(ctx, objectURL))
+	assert.NoError(t, l.Delete(ctx, "https://example.com/other.png"))
+}
+
+func TestLocalStorage_RejectsPathTraversal(t *testing.T) {
+	l := newTestLocalStorage(t)
+	for _, name := range []string{"../escape.txt", "a/../../escape.txt", "/abs.txt", "a//b.txt", ""} {
+		_, err := l.Upload(context.Background(), name, strings.NewReader("x"), "text/plain")
+		assert.Error(t, err, "name %q should be rejected", name)
+	}
+}
+
+func TestLocalStorage_ServeHTTP(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStorage(t)
+	objectURL, err := l.Upload(ctx, "avatars/a.txt", strings.NewReader("avatar"), "text/plain")
+	require.NoError(t, err)
+
+	srv := http.StripPrefix("/api/files/", l)
+	get := func(target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr
+	}
+
+	rr := get("/api/files/avatars/a.txt")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "avatar", rr.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, get("/api/files/avatars/missing.txt").Code)
+
+	signed, err := l.SignedURL(ctx, objectURL, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, get(strings.TrimPrefix(signed, "http://localhost:8080")).Code)
+
+	tampered := strings.Replace(signed, "sig=", "sig=00", 1)
+	assert.Equal(t, http.StatusForbidden, get(strings.TrimPrefix(tampered, "http://localhost:8080")).Code)
+
+	expired, err := l.SignedURL(ctx, objectURL, -time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, get(strings.TrimPrefix(expired, "http://localhost:8080")).Code)
+}
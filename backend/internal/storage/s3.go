@@ -0,0 +1,465 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3PartSize is the size of each multipart upload part. Objects smaller than
+// this are sent in a single PUT. S3 requires parts of at least 5 MiB.
+const s3PartSize = 8 << 20
+
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Storage stores objects in an S3-compatible bucket (AWS S3, MinIO,
+// Cloudflare R2, ...). Requests are signed with AWS Signature Version 4.
+type S3Storage struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	publicURL string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3Storage creates an S3Storage. endpoint is the service URL, e.g.
+// https://s3.eu-west-1.amazonaws.com or http://localhost:9000 for MinIO.
+// pathStyle addresses the bucket as endpoint/bucket rather than as a
+// subdomain, which most self-hosted implementations require. publicURL, if
+// set, is used as the prefix of returned object URLs (e.g. a CDN).
+func NewS3Storage(endpoint, region, bucket, accessKey, secretKey string, pathStyle bool, publicURL string) (*S3Storage, error) {
+	if endpoint == "" || bucket == "" {
+		return nil, fmt.Errorf("S3 endpoint and bucket are required")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("S3 access key and secret key are required")
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %s", endpoint)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Storage{
+		endpoint:  u,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		pathStyle: pathStyle,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		client:    &http.Client{Timeout: 5 * time.Minute},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Upload(ctx context.Context, name string, reader io.Reader, contentType string) (string, error) {
+	buf := make([]byte, s3PartSize)
+	n, err := io.ReadFull(reader, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	if n < s3PartSize {
+		if err := s.putObject(ctx, name, buf[:n], contentType); err != nil {
+			return "", err
+		}
+		return s.objectURL(name), nil
+	}
+
+	if err := s.multipartUpload(ctx, name, buf, reader, contentType); err != nil {
+		return "", err
+	}
+	return s.objectURL(name), nil
+}
+
+func (s *S3Storage) putObject(ctx context.Context, name string, data []byte, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, name, nil, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", contentType)
+	sum := sha256.Sum256(data)
+	s.sign(req, hex.EncodeToString(sum[:]))
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type s3InitiateResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+// multipartUpload streams reader to S3 in s3PartSize parts. first holds the
+// already-read first part.
+func (s *S3Storage) multipartUpload(ctx context.Context, name string, first []byte, reader io.Reader, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPost, name, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, emptyPayloadHash)
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	var initResult s3InitiateResult
+	err = xml.NewDecoder(resp.Body).Decode(&initResult)
+	resp.Body.Close()
+	if err != nil || initResult.UploadID == "" {
+		return fmt.Errorf("failed to start multipart upload: invalid response")
+	}
+	uploadID := initResult.UploadID
+
+	var parts []s3CompletedPart
+	part := first
+	for partNumber := 1; ; partNumber++ {
+		etag, err := s.uploadPart(ctx, name, uploadID, partNumber, part)
+		if err != nil {
+			s.abortMultipart(name, uploadID)
+			return err
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: partNumber, ETag: etag})
+
+		n, err := io.ReadFull(reader, first)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			s.abortMultipart(name, uploadID)
+			return fmt.Errorf("failed to read upload: %w", err)
+		}
+		part = first[:n]
+	}
+
+	body, err := xml.Marshal(s3CompleteUpload{Parts: parts})
+	if err != nil {
+		s.abortMultipart(name, uploadID)
+		return err
+	}
+	req, err = s.newRequest(ctx, http.MethodPost, name, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body))
+	if err != nil {
+		s.abortMultipart(name, uploadID)
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	sum := sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(sum[:]))
+	resp, err = s.do(req)
+	if err != nil {
+		s.abortMultipart(name, uploadID)
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) uploadPart(ctx context.Context, name, uploadID string, partNumber int, data []byte) (string, error) {
+	q := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	req, err := s.newRequest(ctx, http.MethodPut, name, q, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(data))
+	sum := sha256.Sum256(data)
+	s.sign(req, hex.EncodeToString(sum[:]))
+	resp, err := s.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *S3Storage) abortMultipart(name, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := s.newRequest(ctx, http.MethodDelete, name, url.Values{"uploadId": {uploadID}}, nil)
+	if err != nil {
+		return
+	}
+	s.sign(req, emptyPayloadHash)
+	if resp, err := s.do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func (s *S3Storage) Delete(ctx context.Context, objectURL string) error {
+	name, ok := s.objectName(objectURL)
+	if !ok {
+		return nil
+	}
+	req, err := s.newRequest(ctx, http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, emptyPayloadHash)
+	resp, err := s.do(req)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (s *S3Storage) Open(ctx context.Context, objectURL string) (io.ReadCloser, error) {
+	name, ok := s.objectName(objectURL)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	req, err := s.newRequest(ctx, http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, emptyPayloadHash)
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, objectURL string) (*ObjectInfo, error) {
+	name, ok := s.objectName(objectURL)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	req, err := s.newRequest(ctx, http.MethodHead, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, emptyPayloadHash)
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	info := &ObjectInfo{
+		Name:        name,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lm
+	}
+	return info, nil
+}
+
+// SignedURL returns a presigned GET URL (query-string authentication).
+func (s *S3Storage) SignedURL(ctx context.Context, objectURL string, expiry time.Duration) (string, error) {
+	name, ok := s.objectName(objectURL)
+	if !ok {
+		return "", ErrNotFound
+	}
+	u := s.requestURL(name)
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	scope := s.scope(t)
+
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		uriEncode(u.Path, false),
+		canonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	signature := s.signature(t, amzDate, scope, canonical)
+
+	u.RawQuery = canonicalQuery(q) + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+func (s *S3Storage) newRequest(ctx context.Context, method, name string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := s.requestURL(name)
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return req, nil
+}
+
+// do executes a signed request and converts error responses to errors.
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req.
+func (s *S3Storage) sign(req *http.Request, payloadHash string) {
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	scope := s.scope(t)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonical := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	signature := s.signature(t, amzDate, scope, canonical)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func (s *S3Storage) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *S3Storage) signature(t time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// requestURL returns the API URL for an object.
+func (s *S3Storage) requestURL(name string) *url.URL {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = u.Path + "/" + s.bucket + "/" + name
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = u.Path + "/" + name
+	}
+	return &u
+}
+
+// objectURL returns the URL persisted for an object.
+func (s *S3Storage) objectURL(name string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + name
+	}
+	return s.requestURL(name).String()
+}
+
+// objectName extracts the object name from a URL returned by Upload.
+func (s *S3Storage) objectName(objectURL string) (string, bool) {
+	prefix := s.publicURL
+	if prefix == "" {
+		prefix = strings.TrimSuffix(s.requestURL("").String(), "/")
+	}
+	name := strings.TrimPrefix(objectURL, prefix+"/")
+	if name == objectURL || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// emptyPayloadHash is the SHA-256 of an empty body.
+var emptyPayloadHash = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist or the URL does not
+// belong to the backend.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Backend is implemented by every blob storage driver. Objects are written
+// under a name and addressed afterwards by the URL returned from Upload,
+// which is what handlers persist in the database.
+type Backend interface {
+	// Upload streams reader to the named object and returns its URL.
+	Upload(ctx context.Context, name string, reader io.Reader, contentType string) (string, error)
+	// Delete removes the object. URLs not owned by the backend are ignored.
+	Delete(ctx context.Context, objectURL string) error
+	// Open returns a reader for the object's contents.
+	Open(ctx context.Context, objectURL string) (io.ReadCloser, error)
+	// Stat returns the object's metadata.
+	Stat(ctx context.Context, objectURL string) (*ObjectInfo, error)
+	// SignedURL returns a time-limited URL granting read access to the object.
+	SignedURL(ctx context.Context, objectURL string, expiry time.Duration) (string, error)
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"contentType"`
+	LastModified time.Time `json:"lastModified"`
+}
@@ -51,13 +51,15 @@ type HourStat struct {
 	Count int `json:"count"`
 }
 
-func (s *Store) RecordProfileView(ctx context.Context, userID, viewerIP, referrer, browser, os, deviceType, country, city string) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO profile_views (user_id, viewer_ip, referrer, browser, os, device_type, country, city)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		userID, viewerIP, referrer, nilIfEmpty(browser), nilIfEmpty(os), nilIfEmpty(deviceType), nilIfEmpty(country), nilIfEmpty(city),
-	)
-	return err
+func (s *Store) RecordProfileView(ctx context.Context, userID, viewerIP, referrer, browser, os, deviceType, country, city string, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`INSERT INTO profile_views (user_id, viewer_ip, referrer, browser, os, device_type, country, city)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			userID, viewerIP, referrer, nilIfEmpty(browser), nilIfEmpty(os), nilIfEmpty(deviceType), nilIfEmpty(country), nilIfEmpty(city),
+		)
+		return err
+	})
 }
 
 func nilIfEmpty(s string) *string {
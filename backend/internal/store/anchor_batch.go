@@ -0,0 +1,430 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AnchorBatch is one record anchoring the Merkle root of a batch of content
+// hashes: a transaction on a chain, or an RFC 3161 timestamp token.
+type AnchorBatch struct {
+	ID           string     `json:"id"`
+	MerkleRoot   string     `json:"merkleRoot"`
+	LeafCount    int        `json:"leafCount"`
+	TxHash       *string    `json:"txHash"`
+	Chain        string     `json:"chain"`
+	BlockNumber  *int64     `json:"blockNumber"`
+	Status       string     `json:"status"`
+	ErrorMessage *string    `json:"errorMessage,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ConfirmedAt  *time.Time `json:"confirmedAt,omitempty"`
+	BlockHash    *string    `json:"blockHash,omitempty"`
+	FinalizedAt  *time.Time `json:"finalizedAt,omitempty"`
+	// TimestampToken is the DER encoded RFC 3161 token for the root, for
+	// batches timestamped by a timestamp authority.
+	TimestampToken []byte `json:"timestampToken,omitempty"`
+}
+
+// AnchorTransaction is one transaction sent for a batch, or for an
+// unbatched anchor. The EVM fields are nil for anchorers that have none;
+// fees are in wei.
+type AnchorTransaction struct {
+	ID         int64      `json:"-"`
+	BatchID    string     `json:"-"`
+	AnchorID   string     `json:"-"`
+	TxHash     string     `json:"txHash"`
+	Nonce      *int64     `json:"nonce,omitempty"`
+	GasLimit   *int64     `json:"gasLimit,omitempty"`
+	GasTipCap  *string    `json:"gasTipCap,omitempty"`
+	GasFeeCap  *string    `json:"gasFeeCap,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ReplacedAt *time.Time `json:"replacedAt,omitempty"`
+}
+
+// CreateAnchorBatch claims up to limit queued anchors for chain (pending,
+// with no transaction or batch), oldest first, and passes them to build, which
+// returns the batch and fills in each anchor's leaf index and proof. The
+// batch and the anchors' proofs are saved in the same transaction as the
+// claim, so concurrent batchers never put an anchor in two batches. It
+// returns nil if nothing is queued.
+func (s *Store) CreateAnchorBatch(ctx context.Context, chain string, limit int, build func([]*ContentAnchor) (*AnchorBatch, error)) (*AnchorBatch, []*ContentAnchor, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`SELECT id, content_id, user_id, content_hash, chain, created_at
+		 FROM content_anchors
+		 WHERE anchor_status = 'pending' AND tx_hash IS NULL AND batch_id IS NULL AND chain = $1
+		 ORDER BY created_at ASC, id ASC
+		 LIMIT $2
+		 FOR UPDATE SKIP LOCKED`,
+		chain, limit,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	var anchors []*ContentAnchor
+	for rows.Next() {
+		a := ContentAnchor{AnchorStatus: "pending"}
+		if err := rows.Scan(&a.ID, &a.ContentID, &a.UserID, &a.ContentHash, &a.Chain, &a.CreatedAt); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		anchors = append(anchors, &a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(anchors) == 0 {
+		return nil, nil, nil
+	}
+
+	batch, err := build(anchors)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO anchor_batches (id, merkle_root, leaf_count, chain, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		batch.ID, batch.MerkleRoot, batch.LeafCount, batch.Chain, batch.Status, batch.CreatedAt,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, a := range anchors {
+		_, err := tx.Exec(ctx,
+			`UPDATE content_anchors SET batch_id = $1, leaf_index = $2, merkle_proof = $3 WHERE id = $4`,
+			batch.ID, a.LeafIndex, a.MerkleProof, a.ID,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return batch, anchors, tx.Commit(ctx)
+}
+
+// SetAnchorBatchTx records the transaction a batch's root was sent in, on
+// the batch, on each of its anchors and in the batch's transaction history,
+// along with the timestamp token if there is one. A non-nil confirmedAt
+// also finalizes the batch and its anchors, recording events in the same
+// transaction; it is for anchorers whose records are final when made.
+// It fails for a batch that has been released or already has a transaction,
+// so a record is only sent for a batch that can no longer be released.
+func (s *Store) SetAnchorBatchTx(ctx context.Context, batchID string, sent *AnchorTransaction, token []byte, confirmedAt *time.Time, events ...*OutboxEvent) error {
+	status := "pending"
+	if confirmedAt != nil {
+		status = "finalized"
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE anchor_batches
+		 SET tx_hash = $1, timestamp_token = $2, status = $3, confirmed_at = $4, finalized_at = $4
+		 WHERE id = $5 AND tx_hash IS NULL`,
+		sent.TxHash, token, status, confirmedAt, batchID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("anchor batch %s was released or already sent", batchID)
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE content_anchors
+		 SET tx_hash = $1, anchor_status = $2, confirmed_at = $3, finalized_at = $3
+		 WHERE batch_id = $4`,
+		sent.TxHash, status, confirmedAt, batchID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := insertAnchorTransaction(ctx, tx, "batch_id", batchID, sent); err != nil {
+		return err
+	}
+	if err := writeOutbox(ctx, tx, events); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ReplaceAnchorBatchTx records next as sent in place of the batch's
+// transaction oldHash. The batch and its anchors point at next from then
+// on, and are pending again if oldHash had been included in a block that
+// was since reorganised away; oldHash stays in the history, marked replaced.
+func (s *Store) ReplaceAnchorBatchTx(ctx context.Context, batchID, oldHash string, next *AnchorTransaction) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE anchor_transactions SET replaced_at = NOW() WHERE batch_id = $1 AND tx_hash = $2 AND replaced_at IS NULL`,
+		batchID, oldHash,
+	)
+	if err != nil {
+		return err
+	}
+	if err := insertAnchorTransaction(ctx, tx, "batch_id", batchID, next); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE anchor_batches SET `+anchorUpdateSet("status")+` WHERE id = $7`,
+		append(AnchorUpdate{Status: "pending", TxHash: next.TxHash}.args(), batchID)...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE content_anchors SET `+anchorUpdateSet("anchor_status")+` WHERE batch_id = $7`,
+		append(AnchorUpdate{Status: "pending", TxHash: next.TxHash}.args(), batchID)...,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ReplaceAnchorTx is ReplaceAnchorBatchTx for an unbatched anchor. Anchors
+// sent before they kept a history have none, so oldHash is added to it
+// first, already replaced.
+func (s *Store) ReplaceAnchorTx(ctx context.Context, anchorID, oldHash string, next *AnchorTransaction) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE anchor_transactions SET replaced_at = NOW() WHERE anchor_id = $1 AND tx_hash = $2 AND replaced_at IS NULL`,
+		anchorID, oldHash,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO anchor_transactions (anchor_id, tx_hash, created_at, replaced_at)
+			 SELECT id, $2, created_at, NOW() FROM content_anchors
+			 WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM anchor_transactions WHERE anchor_id = $1)`,
+			anchorID, oldHash,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err := insertAnchorTransaction(ctx, tx, "anchor_id", anchorID, next); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE content_anchors SET `+anchorUpdateSet("anchor_status")+` WHERE id = $7`,
+		append(AnchorUpdate{Status: "pending", TxHash: next.TxHash}.args(), anchorID)...,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertAnchorTransaction adds t to the history of the batch or anchor id,
+// as ownerColumn says.
+func insertAnchorTransaction(ctx context.Context, db dbtx, ownerColumn, id string, t *AnchorTransaction) error {
+	if ownerColumn == "batch_id" {
+		t.BatchID = id
+	} else {
+		t.AnchorID = id
+	}
+	return db.QueryRow(ctx,
+		`INSERT INTO anchor_transactions (`+ownerColumn+`, tx_hash, nonce, gas_limit, gas_tip_cap, gas_fee_cap)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at`,
+		id, t.TxHash, t.Nonce, t.GasLimit, t.GasTipCap, t.GasFeeCap,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+// ListAnchorTransactions returns the transactions sent for a batch, oldest
+// first. The last is the batch's current one.
+func (s *Store) ListAnchorTransactions(ctx context.Context, batchID string) ([]*AnchorTransaction, error) {
+	return s.listAnchorTransactions(ctx, "batch_id", batchID)
+}
+
+// ListAnchorTransactionsForAnchor returns the transactions sent for an
+// unbatched anchor, oldest first. It is empty for anchors whose only
+// transaction was sent before they kept a history.
+func (s *Store) ListAnchorTransactionsForAnchor(ctx context.Context, anchorID string) ([]*AnchorTransaction, error) {
+	return s.listAnchorTransactions(ctx, "anchor_id", anchorID)
+}
+
+func (s *Store) listAnchorTransactions(ctx context.Context, ownerColumn, id string) ([]*AnchorTransaction, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, COALESCE(batch_id, ''), COALESCE(anchor_id, ''), tx_hash, nonce, gas_limit, gas_tip_cap, gas_fee_cap, created_at, replaced_at
+		 FROM anchor_transactions
+		 WHERE `+ownerColumn+` = $1
+		 ORDER BY id ASC`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*AnchorTransaction
+	for rows.Next() {
+		var t AnchorTransaction
+		if err := rows.Scan(
+			&t.ID, &t.BatchID, &t.AnchorID, &t.TxHash, &t.Nonce, &t.GasLimit,
+			&t.GasTipCap, &t.GasFeeCap, &t.CreatedAt, &t.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		txs = append(txs, &t)
+	}
+	return txs, rows.Err()
+}
+
+// ReleaseAnchorBatch deletes a batch whose root was never sent and puts its
+// anchors back in the queue. A batch whose transaction was recorded is kept,
+// even if sending it then failed: the transaction may still be mined, and is
+// replaced with the same nonce if it is not.
+func (s *Store) ReleaseAnchorBatch(ctx context.Context, batchID string) error {
+	_, err := s.releaseAnchorBatches(ctx, `id = $1 AND tx_hash IS NULL`, batchID)
+	return err
+}
+
+// ReleaseStaleAnchorBatches releases batches created before olderThan that
+// still have no transaction, left behind by a batcher that stopped between
+// building a batch and sending it.
+func (s *Store) ReleaseStaleAnchorBatches(ctx context.Context, olderThan time.Time) (int64, error) {
+	return s.releaseAnchorBatches(ctx, `tx_hash IS NULL AND created_at < $1`, olderThan)
+}
+
+func (s *Store) releaseAnchorBatches(ctx context.Context, where string, arg interface{}) (int64, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE content_anchors SET batch_id = NULL, leaf_index = NULL, merkle_proof = NULL
+		 WHERE batch_id IN (SELECT id FROM anchor_batches WHERE `+where+`)`,
+		arg,
+	)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM anchor_batches WHERE `+where, arg)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
+
+func (s *Store) FindAnchorBatchByID(ctx context.Context, id string) (*AnchorBatch, error) {
+	var b AnchorBatch
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, merkle_root, leaf_count, tx_hash, chain, block_number, status, error_message, created_at, confirmed_at, timestamp_token, block_hash, finalized_at
+		 FROM anchor_batches WHERE id = $1`, id,
+	).Scan(
+		&b.ID, &b.MerkleRoot, &b.LeafCount, &b.TxHash, &b.Chain,
+		&b.BlockNumber, &b.Status, &b.ErrorMessage, &b.CreatedAt, &b.ConfirmedAt, &b.TimestampToken, &b.BlockHash, &b.FinalizedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &b, err
+}
+
+// ListPendingAnchorBatches returns sent batches that are not yet final.
+func (s *Store) ListPendingAnchorBatches(ctx context.Context) ([]*AnchorBatch, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, merkle_root, leaf_count, tx_hash, chain, block_number, status, error_message, created_at, confirmed_at, timestamp_token, block_hash, finalized_at
+		 FROM anchor_batches
+		 WHERE status IN ('pending', 'included') AND tx_hash IS NOT NULL
+		 ORDER BY created_at ASC
+		 LIMIT 50`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*AnchorBatch
+	for rows.Next() {
+		var b AnchorBatch
+		if err := rows.Scan(
+			&b.ID, &b.MerkleRoot, &b.LeafCount, &b.TxHash, &b.Chain,
+			&b.BlockNumber, &b.Status, &b.ErrorMessage, &b.CreatedAt, &b.ConfirmedAt, &b.TimestampToken, &b.BlockHash, &b.FinalizedAt,
+		); err != nil {
+			return nil, err
+		}
+		batches = append(batches, &b)
+	}
+	return batches, rows.Err()
+}
+
+func (s *Store) ListAnchorsByBatch(ctx context.Context, batchID string) ([]*ContentAnchor, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at, batch_id, leaf_index, merkle_proof, block_hash, finalized_at, policy
+		 FROM content_anchors
+		 WHERE batch_id = $1
+		 ORDER BY leaf_index ASC`,
+		batchID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var anchors []*ContentAnchor
+	for rows.Next() {
+		var a ContentAnchor
+		if err := rows.Scan(
+			&a.ID, &a.ContentID, &a.UserID, &a.ContentHash, &a.TxHash,
+			&a.Chain, &a.BlockNumber, &a.ContractAddress, &a.AnchorStatus,
+			&a.ErrorMessage, &a.CreatedAt, &a.ConfirmedAt,
+			&a.BatchID, &a.LeafIndex, &a.MerkleProof, &a.BlockHash, &a.FinalizedAt, &a.Policy,
+		); err != nil {
+			return nil, err
+		}
+		anchors = append(anchors, &a)
+	}
+	return anchors, rows.Err()
+}
+
+// UpdateAnchorBatchStatus moves a batch and every anchor in it to u's
+// status, recording events in the same transaction. u.TxHash records which
+// of the batch's transactions was mined, when it was not the latest.
+func (s *Store) UpdateAnchorBatchStatus(ctx context.Context, id string, u AnchorUpdate, events ...*OutboxEvent) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE anchor_batches SET `+anchorUpdateSet("status")+` WHERE id = $7`, append(u.args(), id)...)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE content_anchors SET `+anchorUpdateSet("anchor_status")+` WHERE batch_id = $7`, append(u.args(), id)...)
+	if err != nil {
+		return err
+	}
+	if err := writeOutbox(ctx, tx, events); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AudienceAnomaly is a suspicious follower pattern detected on one of a
+// user's connections. Status moves from pending to confirmed or dismissed
+// once an admin has reviewed it.
+type AudienceAnomaly struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"userId"`
+	Username          *string    `json:"username"`
+	Platform          string     `json:"platform"`
+	Kind              string     `json:"kind"`
+	Severity          string     `json:"severity"`
+	Details           *string    `json:"details"`
+	PreviousFollowers *int       `json:"previousFollowers"`
+	CurrentFollowers  *int       `json:"currentFollowers"`
+	Status            string     `json:"status"`
+	ResolvedBy        *string    `json:"resolvedBy"`
+	ResolvedAt        *time.Time `json:"resolvedAt"`
+	Notes             *string    `json:"notes"`
+	CreatedAt         time.Time  `json:"createdAt"`
+}
+
+// CreateAudienceAnomaly records a pending anomaly. It returns false without
+// error when the same kind is already pending for that connection.
+func (s *Store) CreateAudienceAnomaly(ctx context.Context, a *AudienceAnomaly) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO audience_anomalies (id, user_id, platform, kind, severity, details, previous_followers, current_followers, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', NOW())
+		 ON CONFLICT (user_id, platform, kind) WHERE status = 'pending' DO NOTHING`,
+		a.ID, a.UserID, a.Platform, a.Kind, a.Severity, a.Details, a.PreviousFollowers, a.CurrentFollowers,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListAudienceAnomalies returns anomalies newest first, filtered by status
+// when one is given.
+func (s *Store) ListAudienceAnomalies(ctx context.Context, status string, limit, offset int) ([]AudienceAnomaly, int, error) {
+	var total int
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM audience_anomalies WHERE $1 = '' OR status = $1`, status,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT a.id, a.user_id, u.username, a.platform, a.kind, a.severity, a.details,
+			a.previous_followers, a.current_followers, a.status, a.resolved_by, a.resolved_at, a.notes, a.created_at
+		 FROM audience_anomalies a
+		 JOIN users u ON u.id = a.user_id
+		 WHERE $1 = '' OR a.status = $1
+		 ORDER BY a.created_at DESC
+		 LIMIT $2 OFFSET $3`,
+		status, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	anomalies := []AudienceAnomaly{}
+	for rows.Next() {
+		var a AudienceAnomaly
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.Platform, &a.Kind, &a.Severity, &a.Details,
+			&a.PreviousFollowers, &a.CurrentFollowers, &a.Status, &a.ResolvedBy, &a.ResolvedAt, &a.Notes, &a.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, total, rows.Err()
+}
+
+// ResolveAudienceAnomaly records an admin's review of an anomaly and returns
+// the affected user's ID, or an empty string if no anomaly has that ID.
+func (s *Store) ResolveAudienceAnomaly(ctx context.Context, id, resolvedBy, status, notes string) (string, error) {
+	var notesPtr *string
+	if notes != "" {
+		notesPtr = &notes
+	}
+	var userID string
+	err := s.pool.QueryRow(ctx,
+		`UPDATE audience_anomalies
+		 SET status = $1, resolved_by = $2, resolved_at = NOW(), notes = $3
+		 WHERE id = $4
+		 RETURNING user_id`,
+		status, resolvedBy, notesPtr, id,
+	).Scan(&userID)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// ListFlaggedPlatforms returns the platforms on which a user has anomalies in
+// any of the given statuses.
+func (s *Store) ListFlaggedPlatforms(ctx context.Context, userID string, statuses []string) ([]string, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT DISTINCT platform FROM audience_anomalies
+		 WHERE user_id = $1 AND status = ANY($2)
+		 ORDER BY platform`,
+		userID, statuses,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	platforms := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, rows.Err()
+}
@@ -2,11 +2,17 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/creatrid/creatrid/pkg/merkle"
 	"github.com/jackc/pgx/v5"
 )
 
+// ContentAnchor is a content hash recorded outside Creatrid. Its status goes
+// pending → included (in a block, not yet final) → finalized, or to failed.
+// A chain reorg can send an included anchor back to pending. ConfirmedAt is
+// the time of the block it was included in.
 type ContentAnchor struct {
 	ID              string     `json:"id"`
 	ContentID       string     `json:"contentId"`
@@ -20,28 +26,92 @@ type ContentAnchor struct {
 	ErrorMessage    *string    `json:"errorMessage,omitempty"`
 	CreatedAt       time.Time  `json:"createdAt"`
 	ConfirmedAt     *time.Time `json:"confirmedAt,omitempty"`
+	BlockHash       *string    `json:"blockHash,omitempty"`
+	FinalizedAt     *time.Time `json:"finalizedAt,omitempty"`
+	// Policy is the anchoring policy that queued the anchor, or nil if
+	// its owner asked for it.
+	Policy *string `json:"policy,omitempty"`
+
+	// Set once the anchor is part of a Merkle batch. Anchors without a batch
+	// were sent in a transaction of their own, with the content hash as the
+	// transaction data.
+	BatchID     *string       `json:"batchId,omitempty"`
+	LeafIndex   *int          `json:"leafIndex,omitempty"`
+	MerkleProof []merkle.Step `json:"merkleProof,omitempty"`
 }
 
-func (s *Store) CreateContentAnchor(ctx context.Context, anchor *ContentAnchor) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO content_anchors (id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+// ErrAnchorQuota is returned by QueueContentAnchor when the user has
+// already queued their quota of anchors.
+var ErrAnchorQuota = errors.New("anchoring quota reached")
+
+// QueueContentAnchor saves a new anchor unless its content already has one,
+// or its user has created quota anchors or more since the given time; a
+// quota of zero or less is unlimited. It reports whether the anchor was
+// saved, and returns ErrAnchorQuota if the quota stopped it. Queueing holds
+// a pg_advisory_xact_lock on the user, so concurrent requests cannot all
+// pass the count before any of them inserts.
+func (s *Store) QueueContentAnchor(ctx context.Context, anchor *ContentAnchor, quota int, since time.Time) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if quota > 0 {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('anchor-quota:' || $1))`, anchor.UserID); err != nil {
+			return false, err
+		}
+	}
+
+	tag, err := tx.Exec(ctx,
+		`INSERT INTO content_anchors (id, content_id, user_id, content_hash, chain, anchor_status, created_at, policy)
+		 SELECT $1, $2, $3, $4, $5, $6, $7, $8
+		 WHERE $9 <= 0 OR (SELECT COUNT(*) FROM content_anchors WHERE user_id = $3 AND created_at >= $10) < $9
+		 ON CONFLICT (content_id) DO NOTHING`,
 		anchor.ID, anchor.ContentID, anchor.UserID, anchor.ContentHash,
-		anchor.TxHash, anchor.Chain, anchor.BlockNumber, anchor.ContractAddress,
-		anchor.AnchorStatus, anchor.ErrorMessage, anchor.CreatedAt, anchor.ConfirmedAt,
+		anchor.Chain, anchor.AnchorStatus, anchor.CreatedAt, anchor.Policy,
+		quota, since,
 	)
-	return err
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 1 {
+		return true, tx.Commit(ctx)
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM content_anchors WHERE content_id = $1)`, anchor.ContentID,
+	).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, ErrAnchorQuota
+	}
+	return false, nil
+}
+
+// CountAnchorsSince returns how many anchors a user has created since the
+// given time.
+func (s *Store) CountAnchorsSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	var n int
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM content_anchors WHERE user_id = $1 AND created_at >= $2`, userID, since,
+	).Scan(&n)
+	return n, err
 }
 
 func (s *Store) FindAnchorByContentID(ctx context.Context, contentID string) (*ContentAnchor, error) {
 	var a ContentAnchor
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at
+		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at, batch_id, leaf_index, merkle_proof, block_hash, finalized_at, policy
 		 FROM content_anchors WHERE content_id = $1`, contentID,
 	).Scan(
 		&a.ID, &a.ContentID, &a.UserID, &a.ContentHash, &a.TxHash,
 		&a.Chain, &a.BlockNumber, &a.ContractAddress, &a.AnchorStatus,
 		&a.ErrorMessage, &a.CreatedAt, &a.ConfirmedAt,
+		&a.BatchID, &a.LeafIndex, &a.MerkleProof, &a.BlockHash, &a.FinalizedAt, &a.Policy,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -52,12 +122,14 @@ func (s *Store) FindAnchorByContentID(ctx context.Context, contentID string) (*C
 func (s *Store) FindAnchorByHash(ctx context.Context, hash string) (*ContentAnchor, error) {
 	var a ContentAnchor
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at
-		 FROM content_anchors WHERE content_hash = $1`, hash,
+		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at, batch_id, leaf_index, merkle_proof, block_hash, finalized_at, policy
+		 FROM content_anchors WHERE content_hash = $1
+		 ORDER BY confirmed_at ASC NULLS LAST, created_at ASC LIMIT 1`, hash,
 	).Scan(
 		&a.ID, &a.ContentID, &a.UserID, &a.ContentHash, &a.TxHash,
 		&a.Chain, &a.BlockNumber, &a.ContractAddress, &a.AnchorStatus,
 		&a.ErrorMessage, &a.CreatedAt, &a.ConfirmedAt,
+		&a.BatchID, &a.LeafIndex, &a.MerkleProof, &a.BlockHash, &a.FinalizedAt, &a.Policy,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -68,12 +140,13 @@ func (s *Store) FindAnchorByHash(ctx context.Context, hash string) (*ContentAnch
 func (s *Store) FindAnchorByTxHash(ctx context.Context, txHash string) (*ContentAnchor, error) {
 	var a ContentAnchor
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at
+		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at, batch_id, leaf_index, merkle_proof, block_hash, finalized_at, policy
 		 FROM content_anchors WHERE tx_hash = $1`, txHash,
 	).Scan(
 		&a.ID, &a.ContentID, &a.UserID, &a.ContentHash, &a.TxHash,
 		&a.Chain, &a.BlockNumber, &a.ContractAddress, &a.AnchorStatus,
 		&a.ErrorMessage, &a.CreatedAt, &a.ConfirmedAt,
+		&a.BatchID, &a.LeafIndex, &a.MerkleProof, &a.BlockHash, &a.FinalizedAt, &a.Policy,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -81,29 +154,58 @@ func (s *Store) FindAnchorByTxHash(ctx context.Context, txHash string) (*Content
 	return &a, err
 }
 
-func (s *Store) UpdateAnchorStatus(ctx context.Context, id, status string, errorMsg string, blockNumber int64, confirmedAt *time.Time) error {
-	var errPtr *string
-	if errorMsg != "" {
-		errPtr = &errorMsg
+// AnchorUpdate is a change of status of an anchor or batch. Block fields
+// that are zero are cleared; an empty TxHash keeps the current one.
+type AnchorUpdate struct {
+	Status      string
+	TxHash      string
+	BlockNumber int64
+	BlockHash   string
+	BlockTime   *time.Time // stored as confirmed_at
+	Error       string
+}
+
+// args returns the update's columns, as bound by anchorUpdateSet.
+func (u AnchorUpdate) args() []interface{} {
+	var errPtr, hashPtr *string
+	if u.Error != "" {
+		errPtr = &u.Error
+	}
+	if u.BlockHash != "" {
+		hashPtr = &u.BlockHash
 	}
 	var blkPtr *int64
-	if blockNumber > 0 {
-		blkPtr = &blockNumber
-	}
-	_, err := s.pool.Exec(ctx,
-		`UPDATE content_anchors
-		 SET anchor_status = $1, block_number = $2, confirmed_at = $3, error_message = $4
-		 WHERE id = $5`,
-		status, blkPtr, confirmedAt, errPtr, id,
-	)
-	return err
+	if u.BlockNumber > 0 {
+		blkPtr = &u.BlockNumber
+	}
+	return []interface{}{u.Status, blkPtr, hashPtr, u.BlockTime, errPtr, u.TxHash}
+}
+
+// anchorUpdateSet is the SET list for an AnchorUpdate bound as $1 to $6, in
+// a table whose status is statusColumn.
+func anchorUpdateSet(statusColumn string) string {
+	return statusColumn + ` = $1, block_number = $2, block_hash = $3, confirmed_at = $4, error_message = $5,
+		 tx_hash = COALESCE(NULLIF($6, ''), tx_hash),
+		 finalized_at = CASE WHEN $1 = 'finalized' THEN COALESCE(finalized_at, NOW()) END`
+}
+
+func (s *Store) UpdateAnchorStatus(ctx context.Context, id string, u AnchorUpdate, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`UPDATE content_anchors SET `+anchorUpdateSet("anchor_status")+` WHERE id = $7`,
+			append(u.args(), id)...,
+		)
+		return err
+	})
 }
 
+// ListPendingAnchors returns unbatched anchors whose own transaction is
+// not yet final.
 func (s *Store) ListPendingAnchors(ctx context.Context) ([]*ContentAnchor, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at
+		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at, batch_id, leaf_index, merkle_proof, block_hash, finalized_at, policy
 		 FROM content_anchors
-		 WHERE anchor_status = 'pending'
+		 WHERE anchor_status IN ('pending', 'included') AND tx_hash IS NOT NULL AND batch_id IS NULL
 		 ORDER BY created_at ASC
 		 LIMIT 50`)
 	if err != nil {
@@ -118,6 +220,7 @@ func (s *Store) ListPendingAnchors(ctx context.Context) ([]*ContentAnchor, error
 			&a.ID, &a.ContentID, &a.UserID, &a.ContentHash, &a.TxHash,
 			&a.Chain, &a.BlockNumber, &a.ContractAddress, &a.AnchorStatus,
 			&a.ErrorMessage, &a.CreatedAt, &a.ConfirmedAt,
+			&a.BatchID, &a.LeafIndex, &a.MerkleProof, &a.BlockHash, &a.FinalizedAt, &a.Policy,
 		); err != nil {
 			return nil, err
 		}
@@ -136,7 +239,7 @@ func (s *Store) ListAnchorsByUser(ctx context.Context, userID string, limit, off
 	}
 
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at
+		`SELECT id, content_id, user_id, content_hash, tx_hash, chain, block_number, contract_address, anchor_status, error_message, created_at, confirmed_at, batch_id, leaf_index, merkle_proof, block_hash, finalized_at, policy
 		 FROM content_anchors
 		 WHERE user_id = $1
 		 ORDER BY created_at DESC
@@ -155,6 +258,7 @@ func (s *Store) ListAnchorsByUser(ctx context.Context, userID string, limit, off
 			&a.ID, &a.ContentID, &a.UserID, &a.ContentHash, &a.TxHash,
 			&a.Chain, &a.BlockNumber, &a.ContractAddress, &a.AnchorStatus,
 			&a.ErrorMessage, &a.CreatedAt, &a.ConfirmedAt,
+			&a.BatchID, &a.LeafIndex, &a.MerkleProof, &a.BlockHash, &a.FinalizedAt, &a.Policy,
 		); err != nil {
 			return nil, 0, err
 		}
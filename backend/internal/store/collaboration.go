@@ -26,13 +26,15 @@ type CollaborationRequest struct {
 	ToImage      *string `json:"toImage,omitempty"`
 }
 
-func (s *Store) CreateCollaborationRequest(ctx context.Context, id, fromUserID, toUserID, message string) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO collaboration_requests (id, from_user_id, to_user_id, message)
-		 VALUES ($1, $2, $3, $4)`,
-		id, fromUserID, toUserID, message,
-	)
-	return err
+func (s *Store) CreateCollaborationRequest(ctx context.Context, id, fromUserID, toUserID, message string, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`INSERT INTO collaboration_requests (id, from_user_id, to_user_id, message)
+			 VALUES ($1, $2, $3, $4)`,
+			id, fromUserID, toUserID, message,
+		)
+		return err
+	})
 }
 
 func (s *Store) FindCollaborationRequest(ctx context.Context, id string) (*CollaborationRequest, error) {
@@ -47,12 +49,14 @@ func (s *Store) FindCollaborationRequest(ctx context.Context, id string) (*Colla
 	return &cr, err
 }
 
-func (s *Store) UpdateCollaborationStatus(ctx context.Context, id, status string) error {
-	_, err := s.pool.Exec(ctx,
-		`UPDATE collaboration_requests SET status = $1, updated_at = NOW() WHERE id = $2`,
-		status, id,
-	)
-	return err
+func (s *Store) UpdateCollaborationStatus(ctx context.Context, id, status string, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`UPDATE collaboration_requests SET status = $1, updated_at = NOW() WHERE id = $2`,
+			status, id,
+		)
+		return err
+	})
 }
 
 // IncomingCollaborations returns requests sent TO this user
@@ -14,6 +14,7 @@ type Collection struct {
 	Description   *string   `json:"description"`
 	IsPublic      bool      `json:"isPublic"`
 	CoverImageURL *string   `json:"coverImageUrl"`
+	AnchorPolicy  *string   `json:"anchorPolicy"`
 	ItemCount     int       `json:"itemCount"`
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
@@ -30,9 +31,9 @@ type CollectionItemDetail struct {
 
 func (s *Store) CreateCollection(ctx context.Context, coll *Collection) error {
 	_, err := s.pool.Exec(ctx,
-		`INSERT INTO content_collections (id, user_id, title, description, is_public, cover_image_url, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		coll.ID, coll.UserID, coll.Title, coll.Description, coll.IsPublic, coll.CoverImageURL, coll.CreatedAt, coll.UpdatedAt,
+		`INSERT INTO content_collections (id, user_id, title, description, is_public, cover_image_url, anchor_policy, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		coll.ID, coll.UserID, coll.Title, coll.Description, coll.IsPublic, coll.CoverImageURL, coll.AnchorPolicy, coll.CreatedAt, coll.UpdatedAt,
 	)
 	return err
 }
@@ -40,12 +41,12 @@ func (s *Store) CreateCollection(ctx context.Context, coll *Collection) error {
 func (s *Store) FindCollectionByID(ctx context.Context, id string) (*Collection, error) {
 	var coll Collection
 	err := s.pool.QueryRow(ctx,
-		`SELECT c.id, c.user_id, c.title, c.description, c.is_public, c.cover_image_url, c.created_at, c.updated_at,
+		`SELECT c.id, c.user_id, c.title, c.description, c.is_public, c.cover_image_url, c.anchor_policy, c.created_at, c.updated_at,
 		        COALESCE((SELECT COUNT(*) FROM collection_items ci WHERE ci.collection_id = c.id), 0) AS item_count
 		 FROM content_collections c
 		 WHERE c.id = $1`, id,
 	).Scan(
-		&coll.ID, &coll.UserID, &coll.Title, &coll.Description, &coll.IsPublic, &coll.CoverImageURL,
+		&coll.ID, &coll.UserID, &coll.Title, &coll.Description, &coll.IsPublic, &coll.CoverImageURL, &coll.AnchorPolicy,
 		&coll.CreatedAt, &coll.UpdatedAt, &coll.ItemCount,
 	)
 	if err == pgx.ErrNoRows {
@@ -64,7 +65,7 @@ func (s *Store) ListCollectionsByUser(ctx context.Context, userID string, limit,
 	}
 
 	rows, err := s.pool.Query(ctx,
-		`SELECT c.id, c.user_id, c.title, c.description, c.is_public, c.cover_image_url, c.created_at, c.updated_at,
+		`SELECT c.id, c.user_id, c.title, c.description, c.is_public, c.cover_image_url, c.anchor_policy, c.created_at, c.updated_at,
 		        COALESCE((SELECT COUNT(*) FROM collection_items ci WHERE ci.collection_id = c.id), 0) AS item_count
 		 FROM content_collections c
 		 WHERE c.user_id = $1
@@ -81,7 +82,7 @@ func (s *Store) ListCollectionsByUser(ctx context.Context, userID string, limit,
 	for rows.Next() {
 		var coll Collection
 		if err := rows.Scan(
-			&coll.ID, &coll.UserID, &coll.Title, &coll.Description, &coll.IsPublic, &coll.CoverImageURL,
+			&coll.ID, &coll.UserID, &coll.Title, &coll.Description, &coll.IsPublic, &coll.CoverImageURL, &coll.AnchorPolicy,
 			&coll.CreatedAt, &coll.UpdatedAt, &coll.ItemCount,
 		); err != nil {
 			return nil, 0, err
@@ -104,7 +105,7 @@ func (s *Store) ListPublicCollectionsByUser(ctx context.Context, userID string,
 	}
 
 	rows, err := s.pool.Query(ctx,
-		`SELECT c.id, c.user_id, c.title, c.description, c.is_public, c.cover_image_url, c.created_at, c.updated_at,
+		`SELECT c.id, c.user_id, c.title, c.description, c.is_public, c.cover_image_url, c.anchor_policy, c.created_at, c.updated_at,
 		        COALESCE((SELECT COUNT(*) FROM collection_items ci WHERE ci.collection_id = c.id), 0) AS item_count
 		 FROM content_collections c
 		 WHERE c.user_id = $1 AND c.is_public = true
@@ -121,7 +122,7 @@ func (s *Store) ListPublicCollectionsByUser(ctx context.Context, userID string,
 	for rows.Next() {
 		var coll Collection
 		if err := rows.Scan(
-			&coll.ID, &coll.UserID, &coll.Title, &coll.Description, &coll.IsPublic, &coll.CoverImageURL,
+			&coll.ID, &coll.UserID, &coll.Title, &coll.Description, &coll.IsPublic, &coll.CoverImageURL, &coll.AnchorPolicy,
 			&coll.CreatedAt, &coll.UpdatedAt, &coll.ItemCount,
 		); err != nil {
 			return nil, 0, err
@@ -142,6 +143,16 @@ func (s *Store) UpdateCollection(ctx context.Context, id, title string, descript
 	return err
 }
 
+// UpdateCollectionAnchorPolicy sets the anchoring policy for items added to
+// the collection. nil leaves them to their owner's policy.
+func (s *Store) UpdateCollectionAnchorPolicy(ctx context.Context, id string, policy *string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE content_collections SET anchor_policy = $1, updated_at = NOW() WHERE id = $2`,
+		policy, id,
+	)
+	return err
+}
+
 func (s *Store) DeleteCollection(ctx context.Context, id string) error {
 	_, err := s.pool.Exec(ctx, `DELETE FROM content_collections WHERE id = $1`, id)
 	return err
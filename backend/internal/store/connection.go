@@ -111,16 +111,18 @@ func (s *Store) UpdateConnectionTokens(ctx context.Context, userID, platform, ac
 	return err
 }
 
-func (s *Store) UpdateConnectionProfile(ctx context.Context, userID, platform string, followerCount *int, metadata []byte) error {
-	_, err := s.pool.Exec(ctx,
-		`UPDATE connections SET
-			follower_count = COALESCE($1, follower_count),
-			metadata = COALESCE($2, metadata),
-			updated_at = NOW()
-		 WHERE user_id = $3 AND platform = $4`,
-		followerCount, metadata, userID, platform,
-	)
-	return err
+func (s *Store) UpdateConnectionProfile(ctx context.Context, userID, platform string, followerCount *int, metadata []byte, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`UPDATE connections SET
+				follower_count = COALESCE($1, follower_count),
+				metadata = COALESCE($2, metadata),
+				updated_at = NOW()
+			 WHERE user_id = $3 AND platform = $4`,
+			followerCount, metadata, userID, platform,
+		)
+		return err
+	})
 }
 
 func (s *Store) FindStaleConnections(ctx context.Context, olderThan time.Duration, limit int) ([]*model.Connection, error) {
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// ConnectionMetric is a point-in-time snapshot of a connection's metrics.
+type ConnectionMetric struct {
+	ID            int64           `json:"id"`
+	Platform      string          `json:"platform"`
+	FollowerCount *int            `json:"followerCount"`
+	Metadata      json.RawMessage `json:"metadata"`
+	RecordedAt    time.Time       `json:"recordedAt"`
+}
+
+// FollowerSnapshot is the last follower count recorded for a platform within
+// a time bucket.
+type FollowerSnapshot struct {
+	Bucket        time.Time
+	Platform      string
+	FollowerCount int
+}
+
+func (s *Store) RecordConnectionMetric(ctx context.Context, userID, platform string, followerCount *int, metadata []byte) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO connection_metrics (user_id, platform, follower_count, metadata, recorded_at)
+		 VALUES ($1, $2, $3, $4, NOW())`,
+		userID, platform, followerCount, metadata,
+	)
+	return err
+}
+
+// ListConnectionMetrics returns a connection's snapshots since the given
+// time, oldest first.
+func (s *Store) ListConnectionMetrics(ctx context.Context, userID, platform string, since time.Time, limit int) ([]*ConnectionMetric, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, platform, follower_count, metadata, recorded_at FROM (
+			SELECT id, platform, follower_count, metadata, recorded_at
+			FROM connection_metrics
+			WHERE user_id = $1 AND platform = $2 AND recorded_at >= $3
+			ORDER BY recorded_at DESC
+			LIMIT $4
+		 ) recent ORDER BY recorded_at ASC`,
+		userID, platform, since, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []*ConnectionMetric
+	for rows.Next() {
+		var m ConnectionMetric
+		var metaBytes []byte
+		if err := rows.Scan(&m.ID, &m.Platform, &m.FollowerCount, &metaBytes, &m.RecordedAt); err != nil {
+			return nil, err
+		}
+		m.Metadata = json.RawMessage(metaBytes)
+		metrics = append(metrics, &m)
+	}
+	return metrics, rows.Err()
+}
+
+// ListFollowerSnapshots returns, for each platform and UTC day, week or month
+// bucket since the given time, the last follower count recorded in it. Rows
+// are ordered by bucket.
+func (s *Store) ListFollowerSnapshots(ctx context.Context, userID, bucket string, since time.Time) ([]FollowerSnapshot, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT DISTINCT ON (bucket, platform)
+			date_trunc($2, recorded_at AT TIME ZONE 'UTC') AS bucket, platform, follower_count
+		 FROM connection_metrics
+		 WHERE user_id = $1 AND recorded_at >= $3 AND follower_count IS NOT NULL
+		 ORDER BY bucket, platform, recorded_at DESC`,
+		userID, bucket, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []FollowerSnapshot
+	for rows.Next() {
+		var fs FollowerSnapshot
+		if err := rows.Scan(&fs.Bucket, &fs.Platform, &fs.FollowerCount); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, fs)
+	}
+	return snapshots, rows.Err()
+}
@@ -23,28 +23,34 @@ type ContentItem struct {
 	Tags         []string  `json:"tags"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
+	// DisputeStatus is "disputed" while an identical upload by another
+	// creator is under review, and "rejected" once an admin upholds the
+	// earlier creator's claim against this item.
+	DisputeStatus *string `json:"disputeStatus"`
 }
 
-func (s *Store) CreateContentItem(ctx context.Context, item *ContentItem) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO content_items (id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
-		item.ID, item.UserID, item.Title, item.Description, item.ContentType,
-		item.MimeType, item.FileSize, item.FileURL, item.ThumbnailURL,
-		item.HashSHA256, item.IsPublic, item.Tags, item.CreatedAt, item.UpdatedAt,
-	)
-	return err
+func (s *Store) CreateContentItem(ctx context.Context, item *ContentItem, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`INSERT INTO content_items (id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at, dispute_status)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+			item.ID, item.UserID, item.Title, item.Description, item.ContentType,
+			item.MimeType, item.FileSize, item.FileURL, item.ThumbnailURL,
+			item.HashSHA256, item.IsPublic, item.Tags, item.CreatedAt, item.UpdatedAt, item.DisputeStatus,
+		)
+		return err
+	})
 }
 
 func (s *Store) FindContentItemByID(ctx context.Context, id string) (*ContentItem, error) {
 	var item ContentItem
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at
+		`SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at, dispute_status
 		 FROM content_items WHERE id = $1`, id,
 	).Scan(
 		&item.ID, &item.UserID, &item.Title, &item.Description, &item.ContentType,
 		&item.MimeType, &item.FileSize, &item.FileURL, &item.ThumbnailURL,
-		&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt,
+		&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt, &item.DisputeStatus,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -62,7 +68,7 @@ func (s *Store) ListContentItemsByUser(ctx context.Context, userID string, limit
 	}
 
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at
+		`SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at, dispute_status
 		 FROM content_items
 		 WHERE user_id = $1
 		 ORDER BY created_at DESC
@@ -80,7 +86,7 @@ func (s *Store) ListContentItemsByUser(ctx context.Context, userID string, limit
 		if err := rows.Scan(
 			&item.ID, &item.UserID, &item.Title, &item.Description, &item.ContentType,
 			&item.MimeType, &item.FileSize, &item.FileURL, &item.ThumbnailURL,
-			&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt,
+			&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt, &item.DisputeStatus,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -100,6 +106,8 @@ func (s *Store) UpdateContentItem(ctx context.Context, id, title string, descrip
 	return err
 }
 
+// DeleteContentItem deletes an item. Its ownership conflicts cascade, and
+// the trigger from migration 053 releases items those conflicts disputed.
 func (s *Store) DeleteContentItem(ctx context.Context, id string) error {
 	_, err := s.pool.Exec(ctx, `DELETE FROM content_items WHERE id = $1`, id)
 	return err
@@ -108,12 +116,13 @@ func (s *Store) DeleteContentItem(ctx context.Context, id string) error {
 func (s *Store) FindContentByHash(ctx context.Context, hash string) (*ContentItem, error) {
 	var item ContentItem
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at
-		 FROM content_items WHERE hash_sha256 = $1`, hash,
+		`SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at, dispute_status
+		 FROM content_items WHERE hash_sha256 = $1
+		 ORDER BY created_at ASC LIMIT 1`, hash,
 	).Scan(
 		&item.ID, &item.UserID, &item.Title, &item.Description, &item.ContentType,
 		&item.MimeType, &item.FileSize, &item.FileURL, &item.ThumbnailURL,
-		&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt,
+		&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt, &item.DisputeStatus,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -145,7 +154,7 @@ func (s *Store) ListPublicContent(ctx context.Context, contentType, query string
 		return nil, 0, err
 	}
 
-	selectQuery := `SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at
+	selectQuery := `SELECT id, user_id, title, description, content_type, mime_type, file_size, file_url, thumbnail_url, hash_sha256, is_public, tags, created_at, updated_at, dispute_status
 		 FROM content_items ` + baseWhere +
 		` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", argIdx) + ` OFFSET $` + fmt.Sprintf("%d", argIdx+1)
 	args = append(args, limit, offset)
@@ -162,7 +171,7 @@ func (s *Store) ListPublicContent(ctx context.Context, contentType, query string
 		if err := rows.Scan(
 			&item.ID, &item.UserID, &item.Title, &item.Description, &item.ContentType,
 			&item.MimeType, &item.FileSize, &item.FileURL, &item.ThumbnailURL,
-			&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt,
+			&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt, &item.DisputeStatus,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -121,16 +121,18 @@ func (s *Store) DeleteOffering(ctx context.Context, id string) error {
 
 // --- License Purchases ---
 
-func (s *Store) CreateLicensePurchase(ctx context.Context, purchase *LicensePurchase) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO license_purchases (id, offering_id, content_id, buyer_user_id, buyer_email, buyer_company, stripe_session_id, amount_cents, platform_fee_cents, creator_payout_cents, status, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
-		purchase.ID, purchase.OfferingID, purchase.ContentID, purchase.BuyerUserID,
-		purchase.BuyerEmail, purchase.BuyerCompany, purchase.StripeSessionID,
-		purchase.AmountCents, purchase.PlatformFeeCents, purchase.CreatorPayoutCents,
-		purchase.Status, purchase.CreatedAt,
-	)
-	return err
+func (s *Store) CreateLicensePurchase(ctx context.Context, purchase *LicensePurchase, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`INSERT INTO license_purchases (id, offering_id, content_id, buyer_user_id, buyer_email, buyer_company, stripe_session_id, amount_cents, platform_fee_cents, creator_payout_cents, status, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			purchase.ID, purchase.OfferingID, purchase.ContentID, purchase.BuyerUserID,
+			purchase.BuyerEmail, purchase.BuyerCompany, purchase.StripeSessionID,
+			purchase.AmountCents, purchase.PlatformFeeCents, purchase.CreatorPayoutCents,
+			purchase.Status, purchase.CreatedAt,
+		)
+		return err
+	})
 }
 
 func (s *Store) ListPurchasesByBuyer(ctx context.Context, userID string) ([]*LicensePurchase, error) {
@@ -250,12 +252,26 @@ func (s *Store) ListTakedownRequests(ctx context.Context, status string, limit,
 	return requests, total, nil
 }
 
-func (s *Store) ResolveTakedown(ctx context.Context, id, resolvedBy, status, notes string) error {
-	_, err := s.pool.Exec(ctx,
-		`UPDATE takedown_requests SET status = $1, resolved_by = $2, resolution_notes = $3, resolved_at = NOW() WHERE id = $4`,
-		status, resolvedBy, notes, id,
-	)
-	return err
+func (s *Store) FindTakedownRequestByID(ctx context.Context, id string) (*TakedownRequest, error) {
+	var t TakedownRequest
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, reporter_email, reporter_name, content_id, reason, evidence_url, status, resolved_by, resolution_notes, created_at, resolved_at
+		 FROM takedown_requests WHERE id = $1`, id,
+	).Scan(&t.ID, &t.ReporterEmail, &t.ReporterName, &t.ContentID, &t.Reason, &t.EvidenceURL, &t.Status, &t.ResolvedBy, &t.ResolutionNotes, &t.CreatedAt, &t.ResolvedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &t, err
+}
+
+func (s *Store) ResolveTakedown(ctx context.Context, id, resolvedBy, status, notes string, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`UPDATE takedown_requests SET status = $1, resolved_by = $2, resolution_notes = $3, resolved_at = NOW() WHERE id = $4`,
+			status, resolvedBy, notes, id,
+		)
+		return err
+	})
 }
 
 // --- Marketplace ---
@@ -291,7 +307,7 @@ func (s *Store) ListMarketplaceContent(ctx context.Context, contentType, query,
 		orderBy = `lowest_price ASC, ci.created_at DESC`
 	}
 
-	selectQuery := `SELECT ci.id, ci.user_id, ci.title, ci.description, ci.content_type, ci.mime_type, ci.file_size, ci.file_url, ci.thumbnail_url, ci.hash_sha256, ci.is_public, ci.tags, ci.created_at, ci.updated_at,
+	selectQuery := `SELECT ci.id, ci.user_id, ci.title, ci.description, ci.content_type, ci.mime_type, ci.file_size, ci.file_url, ci.thumbnail_url, ci.hash_sha256, ci.is_public, ci.tags, ci.created_at, ci.updated_at, ci.dispute_status,
 	                       u.name, u.username, u.image,
 	                       COALESCE((SELECT MIN(lo.price_cents) FROM license_offerings lo WHERE lo.content_id = ci.id AND lo.is_active = true), 0) AS lowest_price
 	                FROM content_items ci
@@ -312,7 +328,7 @@ func (s *Store) ListMarketplaceContent(ctx context.Context, contentType, query,
 		if err := rows.Scan(
 			&m.ID, &m.UserID, &m.Title, &m.Description, &m.ContentType,
 			&m.MimeType, &m.FileSize, &m.FileURL, &m.ThumbnailURL,
-			&m.HashSHA256, &m.IsPublic, &m.Tags, &m.CreatedAt, &m.UpdatedAt,
+			&m.HashSHA256, &m.IsPublic, &m.Tags, &m.CreatedAt, &m.UpdatedAt, &m.DisputeStatus,
 			&m.CreatorName, &m.CreatorUsername, &m.CreatorImage,
 			&m.LowestPriceCents,
 		); err != nil {
@@ -19,17 +19,19 @@ type ModerationFlag struct {
 }
 
 // CreateModerationFlag inserts a new moderation flag into the database.
-func (s *Store) CreateModerationFlag(ctx context.Context, id, contentID, reason, details string) error {
-	var detailsPtr *string
-	if details != "" {
-		detailsPtr = &details
-	}
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO moderation_flags (id, content_id, reason, details, status, created_at)
-		 VALUES ($1, $2, $3, $4, 'pending', NOW())`,
-		id, contentID, reason, detailsPtr,
-	)
-	return err
+func (s *Store) CreateModerationFlag(ctx context.Context, id, contentID, reason, details string, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		var detailsPtr *string
+		if details != "" {
+			detailsPtr = &details
+		}
+		_, err := db.Exec(ctx,
+			`INSERT INTO moderation_flags (id, content_id, reason, details, status, created_at)
+			 VALUES ($1, $2, $3, $4, 'pending', NOW())`,
+			id, contentID, reason, detailsPtr,
+		)
+		return err
+	})
 }
 
 // ListModerationFlags returns a paginated list of moderation flags filtered by status.
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// OutboxEvent is a domain event. Store methods that make a change take the
+// events describing it and write them to the outbox in the same transaction,
+// so an event is recorded if and only if its change commits. The relay in
+// internal/events then hands each event to its subscribers.
+//
+// Data is marshalled to JSON when the event is written; events read back
+// from the outbox carry it as a json.RawMessage.
+type OutboxEvent struct {
+	ID        int64
+	Type      string
+	UserID    string
+	Data      interface{}
+	Done      []string
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// NewEvent returns an event of type eventType for userID.
+func NewEvent(userID, eventType string, data interface{}) *OutboxEvent {
+	return &OutboxEvent{Type: eventType, UserID: userID, Data: data}
+}
+
+// dbtx is satisfied by both the pool and a transaction, so a statement can
+// run on either.
+type dbtx interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// writeOutbox records events on db.
+func writeOutbox(ctx context.Context, db dbtx, events []*OutboxEvent) error {
+	for _, ev := range events {
+		if ev == nil {
+			continue
+		}
+		payload, err := json.Marshal(ev.Data)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(ctx,
+			`INSERT INTO outbox (event_type, user_id, payload) VALUES ($1, $2, $3)`,
+			ev.Type, ev.UserID, payload,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withEvents runs fn and records events in one transaction. With no events
+// fn runs straight on the pool.
+func (s *Store) withEvents(ctx context.Context, events []*OutboxEvent, fn func(db dbtx) error) error {
+	if len(events) == 0 {
+		return fn(s.pool)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := writeOutbox(ctx, tx, events); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// PublishEvents records events that are not tied to a change in this
+// database, such as ones reported by Stripe or the blockchain.
+func (s *Store) PublishEvents(ctx context.Context, events ...*OutboxEvent) error {
+	return writeOutbox(ctx, s.pool, events)
+}
+
+// ClaimOutboxEvents leases up to limit ready events to relayID, oldest first.
+// Events whose lease has expired are reclaimed.
+func (s *Store) ClaimOutboxEvents(ctx context.Context, relayID string, limit int, lease time.Duration) ([]*OutboxEvent, error) {
+	rows, err := s.pool.Query(ctx,
+		`UPDATE outbox o
+		 SET locked_by = $1, locked_until = NOW() + $3 * INTERVAL '1 millisecond'
+		 FROM (
+			SELECT id FROM outbox
+			WHERE processed_at IS NULL AND failed_at IS NULL
+			  AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+			  AND (locked_until IS NULL OR locked_until <= NOW())
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		 ) picked
+		 WHERE o.id = picked.id
+		 RETURNING o.id, o.event_type, o.user_id, o.payload, o.done, o.attempts, o.created_at`,
+		relayID, limit, lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*OutboxEvent
+	for rows.Next() {
+		var ev OutboxEvent
+		var payload json.RawMessage
+		if err := rows.Scan(&ev.ID, &ev.Type, &ev.UserID, &payload, &ev.Done, &ev.Attempts, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		ev.Data = payload
+		events = append(events, &ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// CompleteOutboxEvent marks an event handled by every subscriber. It does
+// nothing if relayID no longer holds the lease.
+func (s *Store) CompleteOutboxEvent(ctx context.Context, id int64, relayID string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE outbox SET processed_at = NOW(), attempts = attempts + 1, locked_by = NULL, locked_until = NULL
+		 WHERE id = $1 AND locked_by = $2`,
+		id, relayID,
+	)
+	return err
+}
+
+// FailOutboxEvent records a failed attempt at an event. done is the full list
+// of subscribers that have handled it so far. A nil retryAt gives up on the
+// event.
+func (s *Store) FailOutboxEvent(ctx context.Context, id int64, relayID string, done []string, errMsg string, retryAt *time.Time) error {
+	if done == nil {
+		done = []string{} // a nil slice would be sent as NULL
+	}
+	_, err := s.pool.Exec(ctx,
+		`UPDATE outbox
+		 SET done = $3, last_error = $4, next_attempt_at = $5, attempts = attempts + 1,
+		     failed_at = CASE WHEN $5::timestamptz IS NULL THEN NOW() END,
+		     locked_by = NULL, locked_until = NULL
+		 WHERE id = $1 AND locked_by = $2`,
+		id, relayID, done, errMsg, retryAt,
+	)
+	return err
+}
+
+// DeleteProcessedOutboxEvents removes events handled before olderThan.
+// Failed events are kept for inspection.
+func (s *Store) DeleteProcessedOutboxEvents(ctx context.Context, olderThan time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM outbox WHERE processed_at < $1`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,323 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxPerceptualDistance is the largest distance FindPerceptualMatches can
+// search: hashes closer than eight bits share at least one of their bands.
+const MaxPerceptualDistance = 7
+
+// PerceptualMatch is a content item whose perceptual hash is near another.
+type PerceptualMatch struct {
+	Item     *ContentItem `json:"item"`
+	Distance int          `json:"distance"`
+}
+
+// PerceptualFilter narrows the items FindPerceptualMatches returns. It is
+// applied in the query, before the limit.
+type PerceptualFilter struct {
+	PublicOnly bool
+	// ExcludeUserID, if set, leaves out that user's items.
+	ExcludeUserID string
+	// Before, if set, keeps only items created before it.
+	Before *time.Time
+}
+
+// phashBands splits a perceptual hash into its eight bytes, each tagged with
+// its position, for the indexed lookup in content_fingerprints.
+func phashBands(hash uint64) []int32 {
+	bands := make([]int32, 8)
+	for i := range bands {
+		bands[i] = int32(i<<8) | int32(hash>>(8*i)&0xff)
+	}
+	return bands
+}
+
+// SaveContentFingerprint stores the perceptual hash of a content item.
+func (s *Store) SaveContentFingerprint(ctx context.Context, contentID string, phash uint64) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO content_fingerprints (content_id, phash, phash_bands, created_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (content_id) DO UPDATE SET phash = EXCLUDED.phash, phash_bands = EXCLUDED.phash_bands`,
+		contentID, int64(phash), phashBands(phash),
+	)
+	return err
+}
+
+// FindPerceptualMatches returns up to limit content items matching filter
+// whose perceptual hash is within maxDistance bits of phash, nearest and
+// then earliest first. maxDistance is capped at MaxPerceptualDistance. The
+// shared bands only narrow the search through the index; the distance is
+// checked in the query, so the limit counts true matches only.
+func (s *Store) FindPerceptualMatches(ctx context.Context, phash uint64, maxDistance, limit int, filter PerceptualFilter) ([]PerceptualMatch, error) {
+	if maxDistance > MaxPerceptualDistance {
+		maxDistance = MaxPerceptualDistance
+	}
+	rows, err := s.pool.Query(ctx,
+		`SELECT c.id, c.user_id, c.title, c.description, c.content_type, c.mime_type, c.file_size, c.file_url, c.thumbnail_url, c.hash_sha256, c.is_public, c.tags, c.created_at, c.updated_at, c.dispute_status, d.distance
+		 FROM content_fingerprints f
+		 JOIN content_items c ON c.id = f.content_id
+		 CROSS JOIN LATERAL (SELECT bit_count((f.phash # $2)::bit(64))::int AS distance) d
+		 WHERE f.phash_bands && $1
+		   AND d.distance <= $3
+		   AND (NOT $4 OR c.is_public)
+		   AND ($5 = '' OR c.user_id <> $5)
+		   AND ($6::timestamptz IS NULL OR c.created_at < $6)
+		 ORDER BY d.distance, c.created_at
+		 LIMIT $7`,
+		phashBands(phash), int64(phash), maxDistance,
+		filter.PublicOnly, filter.ExcludeUserID, filter.Before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []PerceptualMatch{}
+	for rows.Next() {
+		var item ContentItem
+		var m PerceptualMatch
+		if err := rows.Scan(
+			&item.ID, &item.UserID, &item.Title, &item.Description, &item.ContentType,
+			&item.MimeType, &item.FileSize, &item.FileURL, &item.ThumbnailURL,
+			&item.HashSHA256, &item.IsPublic, &item.Tags, &item.CreatedAt, &item.UpdatedAt, &item.DisputeStatus,
+			&m.Distance,
+		); err != nil {
+			return nil, err
+		}
+		m.Item = &item
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// OwnershipConflict is an upload that matches another creator's earlier
+// content, open until an admin upholds or dismisses it. An exact match, with
+// the same SHA-256, puts the upload in dispute while it is open.
+type OwnershipConflict struct {
+	ID                string     `json:"id"`
+	ContentID         string     `json:"contentId"`
+	UserID            string     `json:"userId"`
+	OriginalContentID string     `json:"originalContentId"`
+	OriginalUserID    string     `json:"originalUserId"`
+	MatchType         string     `json:"matchType"` // "exact" or "perceptual"
+	Distance          int        `json:"distance"`
+	Status            string     `json:"status"` // "open", "upheld" or "dismissed"
+	ResolvedBy        *string    `json:"resolvedBy"`
+	ResolvedAt        *time.Time `json:"resolvedAt"`
+	Notes             *string    `json:"notes"`
+	CreatedAt         time.Time  `json:"createdAt"`
+
+	// Set by ListOwnershipConflicts for the review queue
+	ContentTitle         string  `json:"contentTitle,omitempty"`
+	ContentThumbnailURL  *string `json:"contentThumbnailUrl,omitempty"`
+	OriginalTitle        string  `json:"originalTitle,omitempty"`
+	OriginalThumbnailURL *string `json:"originalThumbnailUrl,omitempty"`
+	// Each side's anchor, and which was finalized first: "content",
+	// "original", or empty if neither is final yet
+	ContentAnchor  *AnchorEvidence `json:"contentAnchor,omitempty"`
+	OriginalAnchor *AnchorEvidence `json:"originalAnchor,omitempty"`
+	EarlierAnchor  string          `json:"earlierAnchor,omitempty"`
+}
+
+// AnchorEvidence is the anchor of one side of an ownership conflict.
+type AnchorEvidence struct {
+	Chain       string     `json:"chain"`
+	Status      string     `json:"status"`
+	TxHash      *string    `json:"txHash"`
+	BlockNumber *int64     `json:"blockNumber"`
+	ConfirmedAt *time.Time `json:"confirmedAt"`
+}
+
+// final reports whether the anchor is finalized, with its block time.
+func (a *AnchorEvidence) final() bool {
+	return a != nil && a.Status == "finalized" && a.ConfirmedAt != nil
+}
+
+// earlierAnchor returns which side of c was finalized on chain first.
+func earlierAnchor(c *OwnershipConflict) string {
+	content, original := c.ContentAnchor.final(), c.OriginalAnchor.final()
+	switch {
+	case content && original:
+		if c.ContentAnchor.ConfirmedAt.Before(*c.OriginalAnchor.ConfirmedAt) {
+			return "content"
+		}
+		return "original"
+	case content:
+		return "content"
+	case original:
+		return "original"
+	}
+	return ""
+}
+
+// scanAnchorEvidence returns the anchor evidence scanned from a LEFT JOIN,
+// or nil if there was no anchor.
+func scanAnchorEvidence(chain, status *string, txHash *string, blockNumber *int64, confirmedAt *time.Time) *AnchorEvidence {
+	if chain == nil || status == nil {
+		return nil
+	}
+	return &AnchorEvidence{Chain: *chain, Status: *status, TxHash: txHash, BlockNumber: blockNumber, ConfirmedAt: confirmedAt}
+}
+
+// CreateOwnershipConflict opens a conflict case; an exact match also puts
+// the later item in dispute, in the same transaction. It returns false if
+// one is already open or resolved for the same pair of items.
+func (s *Store) CreateOwnershipConflict(ctx context.Context, c *OwnershipConflict, events ...*OutboxEvent) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`INSERT INTO ownership_conflicts (id, content_id, user_id, original_content_id, original_user_id, match_type, distance, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, 'open', $8)
+		 ON CONFLICT (content_id, original_content_id) DO NOTHING`,
+		c.ID, c.ContentID, c.UserID, c.OriginalContentID, c.OriginalUserID, c.MatchType, c.Distance, c.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if c.MatchType == "exact" {
+		if _, err := tx.Exec(ctx,
+			`UPDATE content_items SET dispute_status = 'disputed', updated_at = NOW() WHERE id = $1`, c.ContentID,
+		); err != nil {
+			return false, err
+		}
+	}
+	if err := writeOutbox(ctx, tx, events); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// FindOwnershipConflictByID returns a conflict, or nil if there is none.
+func (s *Store) FindOwnershipConflictByID(ctx context.Context, id string) (*OwnershipConflict, error) {
+	var c OwnershipConflict
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, content_id, user_id, original_content_id, original_user_id, match_type, distance,
+		        status, resolved_by, resolved_at, notes, created_at
+		 FROM ownership_conflicts WHERE id = $1`, id,
+	).Scan(&c.ID, &c.ContentID, &c.UserID, &c.OriginalContentID, &c.OriginalUserID, &c.MatchType, &c.Distance,
+		&c.Status, &c.ResolvedBy, &c.ResolvedAt, &c.Notes, &c.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &c, err
+}
+
+// ListOwnershipConflicts returns a page of conflicts with the given status,
+// or all of them if status is empty, oldest first, with each side's anchor
+// as evidence of which came first.
+func (s *Store) ListOwnershipConflicts(ctx context.Context, status string, limit, offset int) ([]OwnershipConflict, int, error) {
+	var total int
+	if err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM ownership_conflicts WHERE $1 = '' OR status = $1`, status,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT oc.id, oc.content_id, oc.user_id, oc.original_content_id, oc.original_user_id, oc.match_type, oc.distance,
+		        oc.status, oc.resolved_by, oc.resolved_at, oc.notes, oc.created_at,
+		        c.title, c.thumbnail_url, o.title, o.thumbnail_url,
+		        ca.chain, ca.anchor_status, ca.tx_hash, ca.block_number, ca.confirmed_at,
+		        oa.chain, oa.anchor_status, oa.tx_hash, oa.block_number, oa.confirmed_at
+		 FROM ownership_conflicts oc
+		 JOIN content_items c ON c.id = oc.content_id
+		 JOIN content_items o ON o.id = oc.original_content_id
+		 LEFT JOIN content_anchors ca ON ca.content_id = oc.content_id
+		 LEFT JOIN content_anchors oa ON oa.content_id = oc.original_content_id
+		 WHERE $1 = '' OR oc.status = $1
+		 ORDER BY oc.created_at ASC
+		 LIMIT $2 OFFSET $3`,
+		status, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	conflicts := []OwnershipConflict{}
+	for rows.Next() {
+		var c OwnershipConflict
+		var ca, oa struct {
+			chain, status, txHash *string
+			blockNumber           *int64
+			confirmedAt           *time.Time
+		}
+		if err := rows.Scan(&c.ID, &c.ContentID, &c.UserID, &c.OriginalContentID, &c.OriginalUserID, &c.MatchType, &c.Distance,
+			&c.Status, &c.ResolvedBy, &c.ResolvedAt, &c.Notes, &c.CreatedAt,
+			&c.ContentTitle, &c.ContentThumbnailURL, &c.OriginalTitle, &c.OriginalThumbnailURL,
+			&ca.chain, &ca.status, &ca.txHash, &ca.blockNumber, &ca.confirmedAt,
+			&oa.chain, &oa.status, &oa.txHash, &oa.blockNumber, &oa.confirmedAt); err != nil {
+			return nil, 0, err
+		}
+		c.ContentAnchor = scanAnchorEvidence(ca.chain, ca.status, ca.txHash, ca.blockNumber, ca.confirmedAt)
+		c.OriginalAnchor = scanAnchorEvidence(oa.chain, oa.status, oa.txHash, oa.blockNumber, oa.confirmedAt)
+		c.EarlierAnchor = earlierAnchor(&c)
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, total, rows.Err()
+}
+
+// ResolveOwnershipConflict closes an open conflict as "upheld" or
+// "dismissed". Upholding it rejects the later item's claim; dismissing it
+// lifts the item's dispute unless another exact match is still open. The
+// conflict and the item change in one transaction. It returns false if no
+// open conflict has the ID.
+func (s *Store) ResolveOwnershipConflict(ctx context.Context, id, resolvedBy, status, notes string, events ...*OutboxEvent) (bool, error) {
+	var notesPtr *string
+	if notes != "" {
+		notesPtr = &notes
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var contentID string
+	err = tx.QueryRow(ctx,
+		`UPDATE ownership_conflicts
+		 SET status = $1, resolved_by = $2, resolved_at = NOW(), notes = $3
+		 WHERE id = $4 AND status = 'open'
+		 RETURNING content_id`,
+		status, resolvedBy, notesPtr, id,
+	).Scan(&contentID)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if status == "upheld" {
+		_, err = tx.Exec(ctx,
+			`UPDATE content_items SET dispute_status = 'rejected', updated_at = NOW() WHERE id = $1`, contentID)
+	} else {
+		_, err = tx.Exec(ctx,
+			`UPDATE content_items SET dispute_status = NULL, updated_at = NOW()
+			 WHERE id = $1 AND dispute_status = 'disputed'
+			   AND NOT EXISTS (SELECT 1 FROM ownership_conflicts
+			                   WHERE content_id = $1 AND match_type = 'exact' AND status = 'open')`,
+			contentID,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := writeOutbox(ctx, tx, events); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
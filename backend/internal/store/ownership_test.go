@@ -0,0 +1,38 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarlierAnchor(t *testing.T) {
+	t1 := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	final := func(at time.Time) *AnchorEvidence {
+		return &AnchorEvidence{Chain: "base", Status: "finalized", ConfirmedAt: &at}
+	}
+	pending := &AnchorEvidence{Chain: "base", Status: "pending"}
+
+	tests := []struct {
+		name              string
+		content, original *AnchorEvidence
+		want              string
+	}{
+		{"neither anchored", nil, nil, ""},
+		{"neither final", pending, pending, ""},
+		{"only content final", final(t2), pending, "content"},
+		{"only original final", nil, final(t2), "original"},
+		{"content first", final(t1), final(t2), "content"},
+		{"original first", final(t2), final(t1), "original"},
+		{"same block time goes to the original", final(t1), final(t1), "original"},
+		{"finalized without a time is not final", &AnchorEvidence{Status: "finalized"}, final(t2), "original"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &OwnershipConflict{ContentAnchor: tt.content, OriginalAnchor: tt.original}
+			assert.Equal(t, tt.want, earlierAnchor(c))
+		})
+	}
+}
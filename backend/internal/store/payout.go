@@ -37,19 +37,34 @@ func (s *Store) CreatePayout(ctx context.Context, payout *CreatorPayout) error {
 	return err
 }
 
-func (s *Store) UpdatePayoutStatus(ctx context.Context, id, status string, transferID *string, errorMsg *string) error {
-	if status == "completed" {
-		_, err := s.pool.Exec(ctx,
-			`UPDATE creator_payouts SET status = $1, stripe_transfer_id = $2, error_message = $3, completed_at = NOW() WHERE id = $4`,
+func (s *Store) UpdatePayoutStatus(ctx context.Context, id, status string, transferID *string, errorMsg *string, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		if status == "completed" {
+			_, err := db.Exec(ctx,
+				`UPDATE creator_payouts SET status = $1, stripe_transfer_id = $2, error_message = $3, completed_at = NOW() WHERE id = $4`,
+				status, transferID, errorMsg, id,
+			)
+			return err
+		}
+		_, err := db.Exec(ctx,
+			`UPDATE creator_payouts SET status = $1, stripe_transfer_id = $2, error_message = $3 WHERE id = $4`,
 			status, transferID, errorMsg, id,
 		)
 		return err
+	})
+}
+
+func (s *Store) FindPayoutByTransferID(ctx context.Context, transferID string) (*CreatorPayout, error) {
+	var p CreatorPayout
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, user_id, purchase_id, stripe_transfer_id, amount_cents, currency, status, error_message, created_at, completed_at
+		 FROM creator_payouts WHERE stripe_transfer_id = $1`, transferID,
+	).Scan(&p.ID, &p.UserID, &p.PurchaseID, &p.StripeTransferID, &p.AmountCents, &p.Currency,
+		&p.Status, &p.ErrorMessage, &p.CreatedAt, &p.CompletedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
 	}
-	_, err := s.pool.Exec(ctx,
-		`UPDATE creator_payouts SET status = $1, stripe_transfer_id = $2, error_message = $3 WHERE id = $4`,
-		status, transferID, errorMsg, id,
-	)
-	return err
+	return &p, err
 }
 
 func (s *Store) ListPayoutsByUser(ctx context.Context, userID string, limit, offset int) ([]*CreatorPayout, int, error) {
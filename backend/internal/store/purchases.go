@@ -0,0 +1,302 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nrednav/cuid2"
+)
+
+// PendingPurchase records a token purchase or tip whose Stripe PaymentIntent
+// has not yet been confirmed. Its status moves from pending to succeeded or
+// failed, and from succeeded to refunded. A token purchase is reserved
+// before its PaymentIntent exists, so StripePaymentIntentID is empty until
+// AttachPaymentIntent; one paid after its reservation lapsed is expired.
+type PendingPurchase struct {
+	ID                    string    `json:"id"`
+	Kind                  string    `json:"kind"`
+	UserID                string    `json:"userId"`
+	TokenID               *string   `json:"tokenId"`
+	TipID                 *string   `json:"tipId"`
+	Amount                int       `json:"amount"`
+	AmountCents           int64     `json:"amountCents"`
+	StripePaymentIntentID string    `json:"-"`
+	Status                string    `json:"status"`
+	CreatedAt             time.Time `json:"createdAt"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+func (s *Store) CreatePendingPurchase(ctx context.Context, p *PendingPurchase) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO pending_purchases (id, kind, user_id, token_id, tip_id, amount, amount_cents, stripe_payment_intent_id, status, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		p.ID, p.Kind, p.UserID, p.TokenID, p.TipID, p.Amount, p.AmountCents,
+		nullIfEmpty(p.StripePaymentIntentID), p.Status, p.CreatedAt, p.UpdatedAt,
+	)
+	return err
+}
+
+// ReservedTokenAmount returns the tokens in pending purchases of a token
+// made since the given time. They are priced as if already minted.
+func (s *Store) ReservedTokenAmount(ctx context.Context, tokenID string, since time.Time) (int, error) {
+	return reservedTokenAmount(ctx, s.pool, tokenID, since)
+}
+
+func reservedTokenAmount(ctx context.Context, db dbtx, tokenID string, since time.Time) (int, error) {
+	var reserved int
+	err := db.QueryRow(ctx,
+		`SELECT COALESCE(SUM(amount), 0) FROM pending_purchases
+		 WHERE token_id = $1 AND kind = 'token_purchase' AND status = 'pending' AND created_at >= $2`,
+		tokenID, since,
+	).Scan(&reserved)
+	return reserved, err
+}
+
+// ReserveTokenPurchase records the pending token purchase returned by
+// create, which is passed the supply to price it at: the minted supply plus
+// the tokens reserved by pending purchases made since the given time. The
+// token's row is locked meanwhile, so concurrent purchases price one after
+// another up the curve rather than all at the minted supply; create should
+// not call other services. It returns nil if the token does not exist; an
+// error from create rolls back.
+func (s *Store) ReserveTokenPurchase(ctx context.Context, tokenID string, since time.Time, create func(supply int) (*PendingPurchase, error)) (*PendingPurchase, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var supply int
+	err = tx.QueryRow(ctx, `SELECT total_supply FROM creator_tokens WHERE id = $1 FOR UPDATE`, tokenID).Scan(&supply)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := reservedTokenAmount(ctx, tx, tokenID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := create(supply + reserved)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO pending_purchases (id, kind, user_id, token_id, tip_id, amount, amount_cents, stripe_payment_intent_id, status, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		p.ID, p.Kind, p.UserID, p.TokenID, p.TipID, p.Amount, p.AmountCents,
+		nullIfEmpty(p.StripePaymentIntentID), p.Status, p.CreatedAt, p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, tx.Commit(ctx)
+}
+
+func (s *Store) FindPendingPurchaseByPaymentIntent(ctx context.Context, paymentIntentID string) (*PendingPurchase, error) {
+	var p PendingPurchase
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, kind, user_id, token_id, tip_id, amount, amount_cents, COALESCE(stripe_payment_intent_id, ''), status, created_at, updated_at
+		 FROM pending_purchases WHERE stripe_payment_intent_id = $1`, paymentIntentID,
+	).Scan(&p.ID, &p.Kind, &p.UserID, &p.TokenID, &p.TipID, &p.Amount, &p.AmountCents,
+		&p.StripePaymentIntentID, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
+}
+
+// ConfirmPendingPurchase marks a pending purchase as paid and, in the same
+// transaction, mints the purchased tokens or completes the tip and records
+// events. It returns nil, recording nothing, if there is no pending purchase
+// for the PaymentIntent, which makes replayed webhooks a no-op.
+func (s *Store) ConfirmPendingPurchase(ctx context.Context, paymentIntentID string, events ...*OutboxEvent) (*PendingPurchase, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := transitionPendingPurchase(ctx, tx, paymentIntentID, "pending", "succeeded")
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	switch {
+	case p.Kind == "token_purchase" && p.TokenID != nil:
+		if err := mintTokens(ctx, tx, *p.TokenID, p.UserID, p.Amount, "purchase", p.ID); err != nil {
+			return nil, err
+		}
+	case p.Kind == "tip" && p.TipID != nil:
+		if _, err := tx.Exec(ctx, `UPDATE tips SET status = 'completed' WHERE id = $1`, *p.TipID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeOutbox(ctx, tx, events); err != nil {
+		return nil, err
+	}
+
+	return p, tx.Commit(ctx)
+}
+
+// AttachPaymentIntent records the PaymentIntent created for a reserved
+// token purchase. It returns false if the reservation is no longer pending
+// or already has one.
+func (s *Store) AttachPaymentIntent(ctx context.Context, purchaseID, paymentIntentID string) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE pending_purchases SET stripe_payment_intent_id = $2, updated_at = NOW()
+		 WHERE id = $1 AND status = 'pending' AND stripe_payment_intent_id IS NULL`,
+		purchaseID, paymentIntentID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseTokenPurchase marks a pending purchase failed by its ID, giving up
+// its reservation, for purchases whose PaymentIntent could not be set up.
+func (s *Store) ReleaseTokenPurchase(ctx context.Context, purchaseID string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE pending_purchases SET status = 'failed', updated_at = NOW()
+		 WHERE id = $1 AND status = 'pending'`, purchaseID)
+	return err
+}
+
+// ExpirePendingPurchase marks a pending token purchase made before the given
+// time expired, so it is never minted at a price its lapsed reservation no
+// longer holds. It returns nil if there is no such purchase for the
+// PaymentIntent.
+func (s *Store) ExpirePendingPurchase(ctx context.Context, paymentIntentID string, before time.Time) (*PendingPurchase, error) {
+	var p PendingPurchase
+	err := s.pool.QueryRow(ctx,
+		`UPDATE pending_purchases SET status = 'expired', updated_at = NOW()
+		 WHERE stripe_payment_intent_id = $1 AND status = 'pending' AND kind = 'token_purchase' AND created_at < $2
+		 RETURNING id, kind, user_id, token_id, tip_id, amount, amount_cents, COALESCE(stripe_payment_intent_id, ''), status, created_at, updated_at`,
+		paymentIntentID, before,
+	).Scan(&p.ID, &p.Kind, &p.UserID, &p.TokenID, &p.TipID, &p.Amount, &p.AmountCents,
+		&p.StripePaymentIntentID, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
+}
+
+// nullIfEmpty returns nil for an empty string, for nullable text columns.
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// FailPendingPurchase marks a pending purchase, and its tip if any, as
+// failed. It returns nil if there is no pending purchase for the
+// PaymentIntent.
+func (s *Store) FailPendingPurchase(ctx context.Context, paymentIntentID string) (*PendingPurchase, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := transitionPendingPurchase(ctx, tx, paymentIntentID, "pending", "failed")
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	if p.Kind == "tip" && p.TipID != nil {
+		if _, err := tx.Exec(ctx, `UPDATE tips SET status = 'failed' WHERE id = $1`, *p.TipID); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, tx.Commit(ctx)
+}
+
+// RefundPendingPurchase reverses a confirmed purchase. For tokens it burns
+// up to the purchased amount from the buyer's balance (less if some have
+// since been spent) and records a "refund" transaction; for tips it marks the
+// tip refunded. It returns nil if the purchase was not in the succeeded state.
+func (s *Store) RefundPendingPurchase(ctx context.Context, paymentIntentID string) (*PendingPurchase, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := transitionPendingPurchase(ctx, tx, paymentIntentID, "succeeded", "refunded")
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	switch {
+	case p.Kind == "token_purchase" && p.TokenID != nil:
+		var balance int
+		err := tx.QueryRow(ctx,
+			`SELECT balance FROM token_balances WHERE token_id = $1 AND user_id = $2 FOR UPDATE`,
+			*p.TokenID, p.UserID,
+		).Scan(&balance)
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, err
+		}
+
+		burn := p.Amount
+		if balance < burn {
+			burn = balance
+		}
+		if burn > 0 {
+			_, err = tx.Exec(ctx,
+				`UPDATE token_balances SET balance = balance - $3, updated_at = NOW()
+				 WHERE token_id = $1 AND user_id = $2`,
+				*p.TokenID, p.UserID, burn,
+			)
+			if err != nil {
+				return nil, err
+			}
+			_, err = tx.Exec(ctx,
+				`UPDATE creator_tokens SET total_supply = total_supply - $2, updated_at = NOW() WHERE id = $1`,
+				*p.TokenID, burn,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO token_transactions (id, token_id, from_user_id, to_user_id, amount, tx_type, reference_id, created_at)
+			 VALUES ($1, $2, $3, NULL, $4, 'refund', $5, NOW())`,
+			cuid2.Generate(), *p.TokenID, p.UserID, burn, p.ID,
+		)
+		if err != nil {
+			return nil, err
+		}
+	case p.Kind == "tip" && p.TipID != nil:
+		if _, err := tx.Exec(ctx, `UPDATE tips SET status = 'refunded' WHERE id = $1`, *p.TipID); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, tx.Commit(ctx)
+}
+
+// transitionPendingPurchase moves a purchase from one status to another,
+// returning nil if it was not in the from status.
+func transitionPendingPurchase(ctx context.Context, tx pgx.Tx, paymentIntentID, from, to string) (*PendingPurchase, error) {
+	var p PendingPurchase
+	err := tx.QueryRow(ctx,
+		`UPDATE pending_purchases SET status = $3, updated_at = NOW()
+		 WHERE stripe_payment_intent_id = $1 AND status = $2
+		 RETURNING id, kind, user_id, token_id, tip_id, amount, amount_cents, COALESCE(stripe_payment_intent_id, ''), status, created_at, updated_at`,
+		paymentIntentID, from, to,
+	).Scan(&p.ID, &p.Kind, &p.UserID, &p.TokenID, &p.TipID, &p.Amount, &p.AmountCents,
+		&p.StripePaymentIntentID, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
+}
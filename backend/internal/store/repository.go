@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/pricing"
+)
+
+// The repository interfaces below split Store by domain so handlers can
+// depend on just the queries they use. Store satisfies all of them; the
+// storetest package provides an in-memory implementation for tests.
+
+type UserRepository interface {
+	FindUserByID(ctx context.Context, id string) (*model.User, error)
+	FindUserByEmail(ctx context.Context, email string) (*model.User, error)
+	FindUserByUsername(ctx context.Context, username string) (*model.User, error)
+	CreateUser(ctx context.Context, u *model.User) error
+	UpdateUserRole(ctx context.Context, userID string, role string) error
+	BulkSetVerified(ctx context.Context, creatorIDs []string, verified bool) error
+}
+
+type ContentRepository interface {
+	CreateContentItem(ctx context.Context, item *ContentItem, events ...*OutboxEvent) error
+	FindContentItemByID(ctx context.Context, id string) (*ContentItem, error)
+	ListContentItemsByUser(ctx context.Context, userID string, limit, offset int) ([]*ContentItem, int, error)
+	UpdateContentItem(ctx context.Context, id, title string, description *string, tags []string, isPublic bool) error
+	DeleteContentItem(ctx context.Context, id string) error
+	FindContentByHash(ctx context.Context, hash string) (*ContentItem, error)
+	CountContentByUser(ctx context.Context, userID string) (int, error)
+}
+
+type TokenRepository interface {
+	CreateCreatorToken(ctx context.Context, token *CreatorToken) error
+	FindTokenByUserID(ctx context.Context, userID string) (*CreatorToken, error)
+	FindTokenByID(ctx context.Context, id string) (*CreatorToken, error)
+	FindTokenBySymbol(ctx context.Context, symbol string) (*CreatorToken, error)
+	UpdateCreatorToken(ctx context.Context, id, name string, description *string, isActive bool, curve pricing.Curve) error
+	GetTokenBalance(ctx context.Context, tokenID, userID string) (int, error)
+	MintTokens(ctx context.Context, tokenID, toUserID string, amount int, txType, referenceID string) error
+	TransferTokens(ctx context.Context, tokenID, fromUserID, toUserID string, amount int, events ...*OutboxEvent) error
+	ListTokenHolders(ctx context.Context, tokenID string, limit, offset int) ([]TokenBalance, int, error)
+	RecordTokenTransaction(ctx context.Context, tx *TokenTransaction) error
+	ListTokenTransactions(ctx context.Context, tokenID string, limit, offset int) ([]TokenTransaction, int, error)
+	CreatePendingPurchase(ctx context.Context, p *PendingPurchase) error
+	ReservedTokenAmount(ctx context.Context, tokenID string, since time.Time) (int, error)
+	ReserveTokenPurchase(ctx context.Context, tokenID string, since time.Time, create func(supply int) (*PendingPurchase, error)) (*PendingPurchase, error)
+	AttachPaymentIntent(ctx context.Context, purchaseID, paymentIntentID string) (bool, error)
+	ReleaseTokenPurchase(ctx context.Context, purchaseID string) error
+	FindPendingPurchaseByPaymentIntent(ctx context.Context, paymentIntentID string) (*PendingPurchase, error)
+	CreateTokenReward(ctx context.Context, rw *TokenReward) error
+	FindTokenRewardByID(ctx context.Context, id string) (*TokenReward, error)
+	ListTokenRewards(ctx context.Context, tokenID string, includeInactive bool) ([]*TokenReward, error)
+	UpdateTokenReward(ctx context.Context, id, title string, description *string, cost int, quantityRemaining *int, isActive bool) error
+	RedeemTokenReward(ctx context.Context, rd *TokenRedemption, events func(*TokenRedemption) []*OutboxEvent) error
+	FindTokenRedemptionByID(ctx context.Context, id string) (*TokenRedemption, error)
+	ListTokenRedemptions(ctx context.Context, tokenID string, limit, offset int) ([]*TokenRedemption, int, error)
+	FulfillTokenRedemption(ctx context.Context, id string) error
+}
+
+type LicenseRepository interface {
+	CreateLicenseOffering(ctx context.Context, offering *LicenseOffering) error
+	ListOfferingsByContent(ctx context.Context, contentID string) ([]*LicenseOffering, error)
+	FindOfferingByID(ctx context.Context, id string) (*LicenseOffering, error)
+	UpdateOffering(ctx context.Context, id string, priceCents int, isActive bool, terms *string) error
+	DeleteOffering(ctx context.Context, id string) error
+	CreateLicensePurchase(ctx context.Context, purchase *LicensePurchase, events ...*OutboxEvent) error
+	ListPurchasesByBuyer(ctx context.Context, userID string) ([]*LicensePurchase, error)
+	ListSalesByCreator(ctx context.Context, userID string) ([]*LicensePurchase, error)
+	HasLicense(ctx context.Context, userID, contentID string) (bool, error)
+}
+
+type AnchorRepository interface {
+	FindAnchorByContentID(ctx context.Context, contentID string) (*ContentAnchor, error)
+	QueueContentAnchor(ctx context.Context, anchor *ContentAnchor, quota int, since time.Time) (bool, error)
+	CountAnchorsSince(ctx context.Context, userID string, since time.Time) (int, error)
+}
+
+type AgencyRepository interface {
+	CreateAgency(ctx context.Context, agency *Agency) error
+	FindAgencyByUserID(ctx context.Context, userID string) (*Agency, error)
+	FindAgencyByID(ctx context.Context, id string) (*Agency, error)
+	UpdateAgency(ctx context.Context, id, name string, website, description *string) error
+	InviteCreator(ctx context.Context, id, agencyID, creatorID string) error
+	RespondToAgencyInvite(ctx context.Context, inviteID string, accept bool) error
+	RemoveAgencyCreator(ctx context.Context, agencyID, creatorID string) error
+	ListAgencyCreators(ctx context.Context, agencyID string, limit, offset int) ([]AgencyCreator, int, error)
+	ListCreatorInvites(ctx context.Context, creatorID string) ([]AgencyCreator, error)
+	FindAgencyInvite(ctx context.Context, inviteID string) (*AgencyCreator, error)
+	CountActiveAgencyCreators(ctx context.Context, agencyID string) (int, error)
+	GetAgencyStats(ctx context.Context, agencyID string) (*AgencyStats, error)
+	GetAgencyAnalytics(ctx context.Context, agencyID string) (map[string]interface{}, error)
+}
+
+type APIUsageRepository interface {
+	RecordAPIUsage(ctx context.Context, apiKeyID, endpoint, method string, statusCode, responseTimeMs int) error
+	GetAPIUsageSummary(ctx context.Context, userID string, days int) (map[string]interface{}, error)
+}
+
+type AuditRepository interface {
+	CreateAuditEntry(ctx context.Context, adminUserID, action, targetType string, targetID *string, details json.RawMessage, ipAddress *string) error
+	ListAuditLog(ctx context.Context, limit, offset int) ([]*AuditEntry, int, error)
+}
+
+type WebhookRepository interface {
+	CreateWebhookEndpoint(ctx context.Context, ep *WebhookEndpoint) error
+	ListWebhookEndpointsByUser(ctx context.Context, userID string) ([]*WebhookEndpoint, error)
+	FindWebhookEndpointByID(ctx context.Context, id string) (*WebhookEndpoint, error)
+	UpdateWebhookEndpoint(ctx context.Context, id, url string, events []string, isActive bool) error
+	RotateWebhookSecret(ctx context.Context, id, secret string, previousExpiresAt *time.Time) error
+	DeleteWebhookEndpoint(ctx context.Context, id string) error
+	ListActiveWebhookEndpointsForEvent(ctx context.Context, userID, eventType string) ([]*WebhookEndpoint, error)
+	CreateWebhookDelivery(ctx context.Context, endpointID, eventType string, payload json.RawMessage) (int64, error)
+	QueueWebhookEvent(ctx context.Context, outboxID *int64, endpointIDs []string, eventType string, payload json.RawMessage) (int, error)
+	ListWebhookDeliveries(ctx context.Context, endpointID string, limit, offset int) ([]*WebhookDelivery, int, error)
+	FindWebhookDeliveryByID(ctx context.Context, id int64) (*WebhookDelivery, error)
+	IncrementDeliveryAttempt(ctx context.Context, id int64, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) error
+	ClaimPendingDeliveries(ctx context.Context, workerID string, limit, perEndpoint int, lease time.Duration) ([]*WebhookDelivery, error)
+	CompleteDeliveryAttempt(ctx context.Context, id int64, workerID, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) (bool, error)
+	MarkDeliveryDead(ctx context.Context, id int64) error
+	ResetDeliveryForRetry(ctx context.Context, id int64) error
+	RedeliverWebhookDeliveries(ctx context.Context, endpointID string, f RedeliveryFilter) (int, error)
+	RecordEndpointAttempt(ctx context.Context, endpointID string, deliveryID int64, success bool, responseStatus int, duration time.Duration) (int, *time.Time, error)
+	DisableWebhookEndpoint(ctx context.Context, id, reason string) (bool, error)
+	EnableWebhookEndpoint(ctx context.Context, id string) (int, error)
+	GetWebhookAttemptStats(ctx context.Context, endpointID string, since time.Time) (*WebhookAttemptStats, error)
+}
+
+type OwnershipRepository interface {
+	CreateOwnershipConflict(ctx context.Context, c *OwnershipConflict, events ...*OutboxEvent) (bool, error)
+	FindOwnershipConflictByID(ctx context.Context, id string) (*OwnershipConflict, error)
+	ListOwnershipConflicts(ctx context.Context, status string, limit, offset int) ([]OwnershipConflict, int, error)
+	ResolveOwnershipConflict(ctx context.Context, id, resolvedBy, status, notes string, events ...*OutboxEvent) (bool, error)
+	SaveContentFingerprint(ctx context.Context, contentID string, phash uint64) error
+	FindPerceptualMatches(ctx context.Context, phash uint64, maxDistance, limit int, filter PerceptualFilter) ([]PerceptualMatch, error)
+}
+
+type UploadRepository interface {
+	CreateUploadSession(ctx context.Context, u *UploadSession) error
+	FindUploadSession(ctx context.Context, id string) (*UploadSession, error)
+	RecordUploadChunk(ctx context.Context, id string, chunk int, size int64, hashState []byte, chunkURL string) (bool, error)
+	ClaimUploadSession(ctx context.Context, id string) (bool, error)
+	ReleaseUploadSession(ctx context.Context, id string) error
+	CompleteUploadSession(ctx context.Context, id, contentID string) error
+	AbortUploadSession(ctx context.Context, id, status string) (bool, error)
+	ListExpiredUploadSessions(ctx context.Context, limit int) ([]*UploadSession, error)
+}
+
+type PaymentRepository interface {
+	UpsertSubscription(ctx context.Context, sub *Subscription) error
+	FindSubscriptionByUserID(ctx context.Context, userID string) (*Subscription, error)
+	FindSubscriptionByStripeCustomerID(ctx context.Context, customerID string) (*Subscription, error)
+	UpdateSubscriptionPlan(ctx context.Context, userID, plan, status string, periodEnd *time.Time) error
+	CreatePaymentEvent(ctx context.Context, id, userID, stripeEventID, eventType string, data []byte) error
+	FindPaymentEventByStripeID(ctx context.Context, stripeEventID string) (bool, error)
+	ConfirmPendingPurchase(ctx context.Context, paymentIntentID string, events ...*OutboxEvent) (*PendingPurchase, error)
+	ExpirePendingPurchase(ctx context.Context, paymentIntentID string, before time.Time) (*PendingPurchase, error)
+	FailPendingPurchase(ctx context.Context, paymentIntentID string) (*PendingPurchase, error)
+	RefundPendingPurchase(ctx context.Context, paymentIntentID string) (*PendingPurchase, error)
+	FindTipByID(ctx context.Context, id string) (*Tip, error)
+	FindPayoutByTransferID(ctx context.Context, transferID string) (*CreatorPayout, error)
+	UpdatePayoutStatus(ctx context.Context, id, status string, transferID *string, errorMsg *string, events ...*OutboxEvent) error
+}
+
+type OutboxRepository interface {
+	PublishEvents(ctx context.Context, events ...*OutboxEvent) error
+	ClaimOutboxEvents(ctx context.Context, relayID string, limit int, lease time.Duration) ([]*OutboxEvent, error)
+	CompleteOutboxEvent(ctx context.Context, id int64, relayID string) error
+	FailOutboxEvent(ctx context.Context, id int64, relayID string, done []string, errMsg string, retryAt *time.Time) error
+}
+
+var (
+	_ UserRepository      = (*Store)(nil)
+	_ ContentRepository   = (*Store)(nil)
+	_ TokenRepository     = (*Store)(nil)
+	_ LicenseRepository   = (*Store)(nil)
+	_ AnchorRepository    = (*Store)(nil)
+	_ AgencyRepository    = (*Store)(nil)
+	_ APIUsageRepository  = (*Store)(nil)
+	_ AuditRepository     = (*Store)(nil)
+	_ WebhookRepository   = (*Store)(nil)
+	_ OwnershipRepository = (*Store)(nil)
+	_ UploadRepository    = (*Store)(nil)
+	_ PaymentRepository   = (*Store)(nil)
+	_ OutboxRepository    = (*Store)(nil)
+)
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// ContentActivity summarises a creator's content vault for scoring.
+type ContentActivity struct {
+	Items    int
+	Recent   int
+	Anchored int
+}
+
+// GetContentActivity counts a user's vault items, those uploaded since the
+// given time, and those with a finalized anchor.
+func (s *Store) GetContentActivity(ctx context.Context, userID string, since time.Time) (*ContentActivity, error) {
+	var a ContentActivity
+	err := s.pool.QueryRow(ctx,
+		`SELECT
+			(SELECT COUNT(*) FROM content_items WHERE user_id = $1),
+			(SELECT COUNT(*) FROM content_items WHERE user_id = $1 AND created_at >= $2),
+			(SELECT COUNT(*) FROM content_anchors WHERE user_id = $1 AND anchor_status = 'finalized')`,
+		userID, since,
+	).Scan(&a.Items, &a.Recent, &a.Anchored)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,321 @@
+package storetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+type apiUsageEntry struct {
+	apiKeyID   string
+	endpoint   string
+	method     string
+	statusCode int
+	createdAt  time.Time
+}
+
+func (s *Store) CreateAgency(ctx context.Context, agency *store.Agency) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.agencies {
+		if a.ID == agency.ID || a.UserID == agency.UserID {
+			return ErrDuplicate
+		}
+	}
+	cp := *agency
+	s.agencies[agency.ID] = &cp
+	return nil
+}
+
+func (s *Store) FindAgencyByUserID(ctx context.Context, userID string) (*store.Agency, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.agencies {
+		if a.UserID == userID {
+			cp := *a
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) FindAgencyByID(ctx context.Context, id string) (*store.Agency, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.agencies[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (s *Store) UpdateAgency(ctx context.Context, id, name string, website, description *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.agencies[id]; ok {
+		a.Name = name
+		a.Website = website
+		a.Description = description
+		a.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) InviteCreator(ctx context.Context, id, agencyID, creatorID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ac := range s.agencyCreators {
+		if ac.ID == id || (ac.AgencyID == agencyID && ac.CreatorID == creatorID) {
+			return ErrDuplicate
+		}
+	}
+	s.agencyCreators[id] = &store.AgencyCreator{
+		ID:        id,
+		AgencyID:  agencyID,
+		CreatorID: creatorID,
+		Status:    "pending",
+		InvitedAt: time.Now(),
+	}
+	return nil
+}
+
+func (s *Store) RespondToAgencyInvite(ctx context.Context, inviteID string, accept bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ac, ok := s.agencyCreators[inviteID]
+	if !ok || ac.Status != "pending" {
+		return nil
+	}
+	if accept {
+		now := time.Now()
+		ac.Status = "active"
+		ac.JoinedAt = &now
+	} else {
+		ac.Status = "removed"
+	}
+	return nil
+}
+
+func (s *Store) RemoveAgencyCreator(ctx context.Context, agencyID, creatorID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ac := range s.agencyCreators {
+		if ac.AgencyID == agencyID && ac.CreatorID == creatorID && ac.Status == "active" {
+			ac.Status = "removed"
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListAgencyCreators(ctx context.Context, agencyID string, limit, offset int) ([]store.AgencyCreator, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []store.AgencyCreator{}
+	for _, ac := range s.agencyCreators {
+		if ac.AgencyID != agencyID || ac.Status != "active" {
+			continue
+		}
+		cp := *ac
+		if u, ok := s.users[ac.CreatorID]; ok {
+			cp.CreatorName, cp.CreatorUsername, cp.CreatorImage, cp.CreatorScore = u.Name, u.Username, u.Image, u.CreatorScore
+		}
+		results = append(results, cp)
+	}
+	sort.Slice(results, func(i, j int) bool { return joinedAfter(results[i].JoinedAt, results[j].JoinedAt) })
+
+	total := len(results)
+	results = page(results, limit, offset)
+	if results == nil {
+		results = []store.AgencyCreator{}
+	}
+	return results, total, nil
+}
+
+// joinedAfter orders by joined_at DESC NULLS LAST.
+func joinedAfter(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a != nil
+	}
+	return a.After(*b)
+}
+
+func (s *Store) ListCreatorInvites(ctx context.Context, creatorID string) ([]store.AgencyCreator, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []store.AgencyCreator{}
+	for _, ac := range s.agencyCreators {
+		if ac.CreatorID != creatorID || ac.Status != "pending" {
+			continue
+		}
+		cp := *ac
+		if a, ok := s.agencies[ac.AgencyID]; ok {
+			name := a.Name
+			cp.AgencyName, cp.AgencyDescription = &name, a.Description
+		}
+		results = append(results, cp)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].InvitedAt.After(results[j].InvitedAt) })
+	return results, nil
+}
+
+func (s *Store) FindAgencyInvite(ctx context.Context, inviteID string) (*store.AgencyCreator, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ac, ok := s.agencyCreators[inviteID]
+	if !ok {
+		return nil, nil
+	}
+	cp := store.AgencyCreator{
+		ID:        ac.ID,
+		AgencyID:  ac.AgencyID,
+		CreatorID: ac.CreatorID,
+		Status:    ac.Status,
+		InvitedAt: ac.InvitedAt,
+		JoinedAt:  ac.JoinedAt,
+	}
+	return &cp, nil
+}
+
+func (s *Store) CountActiveAgencyCreators(ctx context.Context, agencyID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.countActiveCreators(agencyID), nil
+}
+
+func (s *Store) countActiveCreators(agencyID string) int {
+	count := 0
+	for _, ac := range s.agencyCreators {
+		if ac.AgencyID == agencyID && ac.Status == "active" {
+			count++
+		}
+	}
+	return count
+}
+
+// GetAgencyStats computes creator count, average score and API calls. The
+// fake records no profile views, so TotalViews is always zero.
+func (s *Store) GetAgencyStats(ctx context.Context, agencyID string) (*store.AgencyStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &store.AgencyStats{TotalCreators: s.countActiveCreators(agencyID)}
+
+	var sum, scored int
+	for _, ac := range s.agencyCreators {
+		if ac.AgencyID != agencyID || ac.Status != "active" {
+			continue
+		}
+		if u, ok := s.users[ac.CreatorID]; ok && u.CreatorScore != nil {
+			sum += *u.CreatorScore
+			scored++
+		}
+	}
+	if scored > 0 {
+		stats.AvgScore = float64(sum) / float64(scored)
+	}
+
+	if a, ok := s.agencies[agencyID]; ok {
+		stats.APICalls30d = s.countAPICalls(a.UserID, 30)
+	}
+	return stats, nil
+}
+
+// GetAgencyAnalytics returns the same shape as store.Store with empty
+// view and click data, since the fake does not track analytics.
+func (s *Store) GetAgencyAnalytics(ctx context.Context, agencyID string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"totalViews":  0,
+		"totalClicks": 0,
+		"viewsByDay":  []map[string]interface{}{},
+		"topCreators": []map[string]interface{}{},
+	}, nil
+}
+
+// AddAPIKey registers the owner of an API key so that usage recorded with
+// RecordAPIUsage is attributed to them.
+func (s *Store) AddAPIKey(apiKeyID, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeyOwners[apiKeyID] = userID
+}
+
+func (s *Store) RecordAPIUsage(ctx context.Context, apiKeyID, endpoint, method string, statusCode, responseTimeMs int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiUsage = append(s.apiUsage, apiUsageEntry{
+		apiKeyID:   apiKeyID,
+		endpoint:   endpoint,
+		method:     method,
+		statusCode: statusCode,
+		createdAt:  time.Now(),
+	})
+	return nil
+}
+
+func (s *Store) GetAPIUsageSummary(ctx context.Context, userID string, days int) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := time.Now().AddDate(0, 0, -days)
+	byEndpoint := map[string]int{}
+	byDay := map[string]int{}
+	for _, e := range s.apiUsage {
+		if s.apiKeyOwners[e.apiKeyID] != userID || e.createdAt.Before(since) {
+			continue
+		}
+		byEndpoint[e.endpoint]++
+		byDay[e.createdAt.Format("2006-01-02")]++
+	}
+
+	endpoints := []map[string]interface{}{}
+	for endpoint, count := range byEndpoint {
+		endpoints = append(endpoints, map[string]interface{}{"endpoint": endpoint, "count": count})
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i]["count"].(int) > endpoints[j]["count"].(int) })
+
+	dates := make([]string, 0, len(byDay))
+	for date := range byDay {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	daily := []map[string]interface{}{}
+	for _, date := range dates {
+		daily = append(daily, map[string]interface{}{"date": date, "count": byDay[date]})
+	}
+
+	return map[string]interface{}{
+		"totalCalls": s.countAPICalls(userID, days),
+		"byEndpoint": endpoints,
+		"byDay":      daily,
+	}, nil
+}
+
+func (s *Store) countAPICalls(userID string, days int) int {
+	since := time.Now().AddDate(0, 0, -days)
+	count := 0
+	for _, e := range s.apiUsage {
+		if s.apiKeyOwners[e.apiKeyID] == userID && !e.createdAt.Before(since) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,53 @@
+package storetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+func (s *Store) FindAnchorByContentID(ctx context.Context, contentID string) (*store.ContentAnchor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.anchors[contentID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (s *Store) QueueContentAnchor(ctx context.Context, anchor *store.ContentAnchor, quota int, since time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.anchors[anchor.ContentID]; ok {
+		return false, nil
+	}
+	if quota > 0 && s.countAnchorsSince(anchor.UserID, since) >= quota {
+		return false, store.ErrAnchorQuota
+	}
+	cp := *anchor
+	s.anchors[anchor.ContentID] = &cp
+	return true, nil
+}
+
+func (s *Store) CountAnchorsSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.countAnchorsSince(userID, since), nil
+}
+
+// countAnchorsSince is CountAnchorsSince for a caller holding s.mu.
+func (s *Store) countAnchorsSince(userID string, since time.Time) int {
+	n := 0
+	for _, a := range s.anchors {
+		if a.UserID == userID && !a.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n
+}
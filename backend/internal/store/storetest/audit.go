@@ -0,0 +1,42 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+func (s *Store) CreateAuditEntry(ctx context.Context, adminUserID, action, targetType string, targetID *string, details json.RawMessage, ipAddress *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit = append(s.audit, &store.AuditEntry{
+		ID:          s.nextSeq(),
+		AdminUserID: adminUserID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Details:     details,
+		IPAddress:   ipAddress,
+		CreatedAt:   time.Now(),
+	})
+	return nil
+}
+
+// ListAuditLog returns entries newest first.
+func (s *Store) ListAuditLog(ctx context.Context, limit, offset int) ([]*store.AuditEntry, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*store.AuditEntry, 0, len(s.audit))
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		cp := *s.audit[i]
+		if u, ok := s.users[cp.AdminUserID]; ok {
+			cp.AdminName = u.Name
+		}
+		entries = append(entries, &cp)
+	}
+	return page(entries, limit, offset), len(s.audit), nil
+}
@@ -0,0 +1,148 @@
+package storetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+func (s *Store) CreateContentItem(ctx context.Context, item *store.ContentItem, events ...*store.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.content[item.ID]; ok {
+		return ErrDuplicate
+	}
+	cp := *item
+	s.content[item.ID] = &cp
+	return s.recordEvents(events)
+}
+
+func (s *Store) FindContentItemByID(ctx context.Context, id string) (*store.ContentItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.content[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *item
+	return &cp, nil
+}
+
+func (s *Store) ListContentItemsByUser(ctx context.Context, userID string, limit, offset int) ([]*store.ContentItem, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []*store.ContentItem{}
+	for _, item := range s.content {
+		if item.UserID == userID {
+			cp := *item
+			items = append(items, &cp)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.After(items[j].CreatedAt) })
+
+	total := len(items)
+	items = page(items, limit, offset)
+	if items == nil {
+		items = []*store.ContentItem{}
+	}
+	return items, total, nil
+}
+
+func (s *Store) UpdateContentItem(ctx context.Context, id, title string, description *string, tags []string, isPublic bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, ok := s.content[id]; ok {
+		item.Title = title
+		item.Description = description
+		item.Tags = tags
+		item.IsPublic = isPublic
+		item.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// DeleteContentItem deletes an item and, as the foreign keys cascade, its
+// ownership conflicts, releasing items left with no open exact conflict.
+func (s *Store) DeleteContentItem(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.content, id)
+	delete(s.fingerprints, id)
+	for cid, c := range s.conflicts {
+		if c.ContentID != id && c.OriginalContentID != id {
+			continue
+		}
+		delete(s.conflicts, cid)
+		item, ok := s.content[c.ContentID]
+		if ok && c.MatchType == "exact" && c.Status == "open" &&
+			item.DisputeStatus != nil && *item.DisputeStatus == "disputed" && !s.openExactConflict(c.ContentID) {
+			item.DisputeStatus = nil
+			item.UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// FindContentByHash returns the earliest item with the given hash.
+func (s *Store) FindContentByHash(ctx context.Context, hash string) (*store.ContentItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *store.ContentItem
+	for _, item := range s.content {
+		if item.HashSHA256 == hash && (found == nil || item.CreatedAt.Before(found.CreatedAt)) {
+			found = item
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	cp := *found
+	return &cp, nil
+}
+
+func (s *Store) CountContentByUser(ctx context.Context, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, item := range s.content {
+		if item.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateModerationFlag records a pending flag; Flags returns them.
+func (s *Store) CreateModerationFlag(ctx context.Context, id, contentID, reason, details string, events ...*store.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flag := &store.ModerationFlag{ID: id, ContentID: contentID, Reason: reason, Status: "pending", CreatedAt: time.Now()}
+	if details != "" {
+		flag.Details = &details
+	}
+	s.flags = append(s.flags, flag)
+	return s.recordEvents(events)
+}
+
+// Flags returns the moderation flags raised so far.
+func (s *Store) Flags() []*store.ModerationFlag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*store.ModerationFlag(nil), s.flags...)
+}
+
+// RecordContentDownload is a no-op; download analytics are not kept.
+func (s *Store) RecordContentDownload(ctx context.Context, contentID string, downloaderUserID *string) error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package storetest
+
+import (
+	"context"
+	"sort"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+func (s *Store) CreateLicenseOffering(ctx context.Context, offering *store.LicenseOffering) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, o := range s.offerings {
+		if o.ID == offering.ID || (o.ContentID == offering.ContentID && o.LicenseType == offering.LicenseType) {
+			return ErrDuplicate
+		}
+	}
+	cp := *offering
+	s.offerings[offering.ID] = &cp
+	return nil
+}
+
+// ListOfferingsByContent returns the active offerings, cheapest first.
+func (s *Store) ListOfferingsByContent(ctx context.Context, contentID string) ([]*store.LicenseOffering, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offerings := []*store.LicenseOffering{}
+	for _, o := range s.offerings {
+		if o.ContentID == contentID && o.IsActive {
+			cp := *o
+			offerings = append(offerings, &cp)
+		}
+	}
+	sort.Slice(offerings, func(i, j int) bool { return offerings[i].PriceCents < offerings[j].PriceCents })
+	return offerings, nil
+}
+
+func (s *Store) FindOfferingByID(ctx context.Context, id string) (*store.LicenseOffering, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.offerings[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *o
+	return &cp, nil
+}
+
+func (s *Store) UpdateOffering(ctx context.Context, id string, priceCents int, isActive bool, terms *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if o, ok := s.offerings[id]; ok {
+		o.PriceCents = priceCents
+		o.IsActive = isActive
+		o.TermsText = terms
+	}
+	return nil
+}
+
+func (s *Store) DeleteOffering(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.offerings, id)
+	return nil
+}
+
+func (s *Store) CreateLicensePurchase(ctx context.Context, purchase *store.LicensePurchase, events ...*store.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *purchase
+	s.purchases = append(s.purchases, &cp)
+	return s.recordEvents(events)
+}
+
+func (s *Store) ListPurchasesByBuyer(ctx context.Context, userID string) ([]*store.LicensePurchase, error) {
+	return s.listPurchases(func(p *store.LicensePurchase) bool {
+		return p.BuyerUserID != nil && *p.BuyerUserID == userID
+	}), nil
+}
+
+func (s *Store) ListSalesByCreator(ctx context.Context, userID string) ([]*store.LicensePurchase, error) {
+	return s.listPurchases(func(p *store.LicensePurchase) bool {
+		item, ok := s.content[p.ContentID]
+		return ok && item.UserID == userID
+	}), nil
+}
+
+// listPurchases returns matching purchases, newest first.
+func (s *Store) listPurchases(match func(*store.LicensePurchase) bool) []*store.LicensePurchase {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purchases := []*store.LicensePurchase{}
+	for _, p := range s.purchases {
+		if match(p) {
+			cp := *p
+			purchases = append(purchases, &cp)
+		}
+	}
+	sort.SliceStable(purchases, func(i, j int) bool { return purchases[i].CreatedAt.After(purchases[j].CreatedAt) })
+	return purchases
+}
+
+func (s *Store) HasLicense(ctx context.Context, userID, contentID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.purchases {
+		if p.BuyerUserID != nil && *p.BuyerUserID == userID && p.ContentID == contentID && p.Status == "completed" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
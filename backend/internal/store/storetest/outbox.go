@@ -0,0 +1,125 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+// outboxRow is a row of the outbox table.
+type outboxRow struct {
+	ev          store.OutboxEvent
+	lockedBy    string
+	lockedUntil time.Time
+	nextAttempt *time.Time
+	lastError   string
+	processed   bool
+	failed      bool
+}
+
+// recordEvents appends events to the outbox, with Data stored as JSON as
+// Postgres would. The caller must hold s.mu.
+func (s *Store) recordEvents(events []*store.OutboxEvent) error {
+	for _, ev := range events {
+		if ev == nil {
+			continue
+		}
+		payload, err := json.Marshal(ev.Data)
+		if err != nil {
+			return err
+		}
+		s.outbox = append(s.outbox, &outboxRow{ev: store.OutboxEvent{
+			ID:        s.nextSeq(),
+			Type:      ev.Type,
+			UserID:    ev.UserID,
+			Data:      json.RawMessage(payload),
+			CreatedAt: time.Now(),
+		}})
+	}
+	return nil
+}
+
+// Events returns every event written to the outbox, oldest first.
+func (s *Store) Events() []*store.OutboxEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*store.OutboxEvent, 0, len(s.outbox))
+	for _, row := range s.outbox {
+		ev := row.ev
+		ev.Done = append([]string(nil), row.ev.Done...)
+		out = append(out, &ev)
+	}
+	return out
+}
+
+func (s *Store) PublishEvents(ctx context.Context, events ...*store.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recordEvents(events)
+}
+
+func (s *Store) ClaimOutboxEvents(ctx context.Context, relayID string, limit int, lease time.Duration) ([]*store.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var claimed []*store.OutboxEvent
+	for _, row := range s.outbox {
+		if len(claimed) == limit {
+			break
+		}
+		if row.processed || row.failed || row.lockedUntil.After(now) {
+			continue
+		}
+		if row.nextAttempt != nil && row.nextAttempt.After(now) {
+			continue
+		}
+		row.lockedBy = relayID
+		row.lockedUntil = now.Add(lease)
+		ev := row.ev
+		ev.Done = append([]string(nil), row.ev.Done...)
+		claimed = append(claimed, &ev)
+	}
+	return claimed, nil
+}
+
+func (s *Store) CompleteOutboxEvent(ctx context.Context, id int64, relayID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if row := s.outboxRow(id); row != nil && row.lockedBy == relayID {
+		row.processed = true
+		row.ev.Attempts++
+		row.lockedBy, row.lockedUntil = "", time.Time{}
+	}
+	return nil
+}
+
+func (s *Store) FailOutboxEvent(ctx context.Context, id int64, relayID string, done []string, errMsg string, retryAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if row := s.outboxRow(id); row != nil && row.lockedBy == relayID {
+		row.ev.Done = append([]string(nil), done...)
+		row.ev.Attempts++
+		row.lastError = errMsg
+		row.nextAttempt = retryAt
+		row.failed = retryAt == nil
+		row.lockedBy, row.lockedUntil = "", time.Time{}
+	}
+	return nil
+}
+
+// outboxRow finds an outbox row by ID. The caller must hold s.mu.
+func (s *Store) outboxRow(id int64) *outboxRow {
+	for _, row := range s.outbox {
+		if row.ev.ID == id {
+			return row
+		}
+	}
+	return nil
+}
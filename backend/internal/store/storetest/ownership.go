@@ -0,0 +1,159 @@
+package storetest
+
+import (
+	"context"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+// CreateOwnershipConflict opens a conflict, unless the pair of items has
+// one, and puts the later item of an exact match in dispute.
+func (s *Store) CreateOwnershipConflict(ctx context.Context, c *store.OwnershipConflict, events ...*store.OutboxEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.conflicts {
+		if existing.ContentID == c.ContentID && existing.OriginalContentID == c.OriginalContentID {
+			return false, nil
+		}
+	}
+	cp := *c
+	cp.Status = "open"
+	s.conflicts[c.ID] = &cp
+	if item, ok := s.content[c.ContentID]; ok && c.MatchType == "exact" {
+		status := "disputed"
+		item.DisputeStatus = &status
+		item.UpdatedAt = time.Now()
+	}
+	return true, s.recordEvents(events)
+}
+
+func (s *Store) FindOwnershipConflictByID(ctx context.Context, id string) (*store.OwnershipConflict, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.conflicts[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *c
+	return &cp, nil
+}
+
+// ListOwnershipConflicts returns conflicts with the given status, oldest
+// first, with both items' titles. Anchor evidence is not filled in.
+func (s *Store) ListOwnershipConflicts(ctx context.Context, status string, limit, offset int) ([]store.OwnershipConflict, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conflicts := []store.OwnershipConflict{}
+	for _, c := range s.conflicts {
+		if status != "" && c.Status != status {
+			continue
+		}
+		cp := *c
+		if item, ok := s.content[c.ContentID]; ok {
+			cp.ContentTitle, cp.ContentThumbnailURL = item.Title, item.ThumbnailURL
+		}
+		if item, ok := s.content[c.OriginalContentID]; ok {
+			cp.OriginalTitle, cp.OriginalThumbnailURL = item.Title, item.ThumbnailURL
+		}
+		conflicts = append(conflicts, cp)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].CreatedAt.Before(conflicts[j].CreatedAt) })
+
+	total := len(conflicts)
+	conflicts = page(conflicts, limit, offset)
+	if conflicts == nil {
+		conflicts = []store.OwnershipConflict{}
+	}
+	return conflicts, total, nil
+}
+
+// ResolveOwnershipConflict closes an open conflict. Upholding it rejects the
+// item's claim; dismissing it clears the dispute unless another exact
+// conflict over the item is still open.
+func (s *Store) ResolveOwnershipConflict(ctx context.Context, id, resolvedBy, status, notes string, events ...*store.OutboxEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.conflicts[id]
+	if !ok || c.Status != "open" {
+		return false, nil
+	}
+	now := time.Now()
+	c.Status = status
+	c.ResolvedBy = &resolvedBy
+	c.ResolvedAt = &now
+	c.Notes = nil
+	if notes != "" {
+		c.Notes = &notes
+	}
+
+	item, ok := s.content[c.ContentID]
+	switch {
+	case !ok:
+	case status == "upheld":
+		rejected := "rejected"
+		item.DisputeStatus = &rejected
+		item.UpdatedAt = now
+	case item.DisputeStatus != nil && *item.DisputeStatus == "disputed" && !s.openExactConflict(c.ContentID):
+		item.DisputeStatus = nil
+		item.UpdatedAt = now
+	}
+	return true, s.recordEvents(events)
+}
+
+// openExactConflict reports whether an exact conflict over contentID is
+// open. The caller must hold s.mu.
+func (s *Store) openExactConflict(contentID string) bool {
+	for _, c := range s.conflicts {
+		if c.ContentID == contentID && c.MatchType == "exact" && c.Status == "open" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) SaveContentFingerprint(ctx context.Context, contentID string, phash uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fingerprints[contentID] = phash
+	return nil
+}
+
+// FindPerceptualMatches compares phash against every stored fingerprint
+// rather than going through the bands index.
+func (s *Store) FindPerceptualMatches(ctx context.Context, phash uint64, maxDistance, limit int, filter store.PerceptualFilter) ([]store.PerceptualMatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if maxDistance > store.MaxPerceptualDistance {
+		maxDistance = store.MaxPerceptualDistance
+	}
+	matches := []store.PerceptualMatch{}
+	for id, fp := range s.fingerprints {
+		item, ok := s.content[id]
+		distance := bits.OnesCount64(fp ^ phash)
+		switch {
+		case !ok, distance > maxDistance:
+		case filter.PublicOnly && !item.IsPublic:
+		case filter.ExcludeUserID != "" && item.UserID == filter.ExcludeUserID:
+		case filter.Before != nil && !item.CreatedAt.Before(*filter.Before):
+		default:
+			cp := *item
+			matches = append(matches, store.PerceptualMatch{Item: &cp, Distance: distance})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Item.CreatedAt.Before(matches[j].Item.CreatedAt)
+	})
+	return page(matches, limit, 0), nil
+}
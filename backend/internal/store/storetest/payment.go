@@ -0,0 +1,244 @@
+package storetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/nrednav/cuid2"
+)
+
+func (s *Store) UpsertSubscription(ctx context.Context, sub *store.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *sub
+	if existing, ok := s.subscriptions[sub.UserID]; ok {
+		cp.ID, cp.CreatedAt = existing.ID, existing.CreatedAt
+		cp.UpdatedAt = time.Now()
+	}
+	s.subscriptions[sub.UserID] = &cp
+	return nil
+}
+
+func (s *Store) FindSubscriptionByUserID(ctx context.Context, userID string) (*store.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub, ok := s.subscriptions[userID]; ok {
+		cp := *sub
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (s *Store) FindSubscriptionByStripeCustomerID(ctx context.Context, customerID string) (*store.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subscriptions {
+		if sub.StripeCustomerID == customerID {
+			cp := *sub
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) UpdateSubscriptionPlan(ctx context.Context, userID, plan, status string, periodEnd *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub, ok := s.subscriptions[userID]; ok {
+		sub.Plan, sub.Status, sub.CurrentPeriodEnd = plan, status, periodEnd
+		sub.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// CreatePaymentEvent mirrors store.Store, returning ErrDuplicate for an
+// event already recorded, as the unique stripe_event_id would.
+func (s *Store) CreatePaymentEvent(ctx context.Context, id, userID, stripeEventID, eventType string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paymentEvents[stripeEventID] {
+		return ErrDuplicate
+	}
+	s.paymentEvents[stripeEventID] = true
+	return nil
+}
+
+func (s *Store) FindPaymentEventByStripeID(ctx context.Context, stripeEventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paymentEvents[stripeEventID], nil
+}
+
+func (s *Store) ConfirmPendingPurchase(ctx context.Context, paymentIntentID string, events ...*store.OutboxEvent) (*store.PendingPurchase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.transitionPending(paymentIntentID, "pending", "succeeded")
+	if p == nil {
+		return nil, nil
+	}
+
+	switch {
+	case p.Kind == "token_purchase" && p.TokenID != nil:
+		s.credit(*p.TokenID, p.UserID, p.Amount)
+		if t, ok := s.tokens[*p.TokenID]; ok {
+			t.TotalSupply += p.Amount
+			t.UpdatedAt = time.Now()
+		}
+		s.tokenTxs = append(s.tokenTxs, &store.TokenTransaction{
+			ID:          cuid2.Generate(),
+			TokenID:     *p.TokenID,
+			ToUserID:    &p.UserID,
+			Amount:      p.Amount,
+			TxType:      "purchase",
+			ReferenceID: &p.ID,
+			CreatedAt:   time.Now(),
+		})
+	case p.Kind == "tip" && p.TipID != nil:
+		s.setTipStatus(*p.TipID, "completed")
+	}
+
+	if err := s.recordEvents(events); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *Store) FailPendingPurchase(ctx context.Context, paymentIntentID string) (*store.PendingPurchase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.transitionPending(paymentIntentID, "pending", "failed")
+	if p != nil && p.Kind == "tip" && p.TipID != nil {
+		s.setTipStatus(*p.TipID, "failed")
+	}
+	return p, nil
+}
+
+// RefundPendingPurchase mirrors store.Store: it burns up to the purchased
+// tokens from the buyer's balance, or marks the tip refunded.
+func (s *Store) RefundPendingPurchase(ctx context.Context, paymentIntentID string) (*store.PendingPurchase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.transitionPending(paymentIntentID, "succeeded", "refunded")
+	if p == nil {
+		return nil, nil
+	}
+
+	switch {
+	case p.Kind == "token_purchase" && p.TokenID != nil:
+		burn := 0
+		if b, ok := s.balances[balanceKey{*p.TokenID, p.UserID}]; ok {
+			burn = min(p.Amount, b.Balance)
+			b.Balance -= burn
+			b.UpdatedAt = time.Now()
+		}
+		if t, ok := s.tokens[*p.TokenID]; ok {
+			t.TotalSupply -= burn
+			t.UpdatedAt = time.Now()
+		}
+		s.tokenTxs = append(s.tokenTxs, &store.TokenTransaction{
+			ID:          cuid2.Generate(),
+			TokenID:     *p.TokenID,
+			FromUserID:  &p.UserID,
+			Amount:      burn,
+			TxType:      "refund",
+			ReferenceID: &p.ID,
+			CreatedAt:   time.Now(),
+		})
+	case p.Kind == "tip" && p.TipID != nil:
+		s.setTipStatus(*p.TipID, "refunded")
+	}
+	return p, nil
+}
+
+// transitionPending moves the purchase with the given PaymentIntent from one
+// status to another, returning a copy, or nil if it was not in the from
+// status. The caller must hold s.mu.
+func (s *Store) transitionPending(paymentIntentID, from, to string) *store.PendingPurchase {
+	p := s.pendingByIntent(paymentIntentID)
+	if p == nil || p.Status != from {
+		return nil
+	}
+	p.Status = to
+	p.UpdatedAt = time.Now()
+	cp := *p
+	return &cp
+}
+
+func (s *Store) CreateTip(ctx context.Context, tip *store.Tip) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tips[tip.ID]; ok {
+		return ErrDuplicate
+	}
+	cp := *tip
+	s.tips[tip.ID] = &cp
+	return nil
+}
+
+func (s *Store) FindTipByID(ctx context.Context, id string) (*store.Tip, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tip, ok := s.tips[id]; ok {
+		cp := *tip
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+// setTipStatus updates a tip if it exists. The caller must hold s.mu.
+func (s *Store) setTipStatus(id, status string) {
+	if tip, ok := s.tips[id]; ok {
+		tip.Status = status
+	}
+}
+
+func (s *Store) CreatePayout(ctx context.Context, payout *store.CreatorPayout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.payouts[payout.ID]; ok {
+		return ErrDuplicate
+	}
+	cp := *payout
+	s.payouts[payout.ID] = &cp
+	return nil
+}
+
+func (s *Store) FindPayoutByTransferID(ctx context.Context, transferID string) (*store.CreatorPayout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.payouts {
+		if p.StripeTransferID != nil && *p.StripeTransferID == transferID {
+			cp := *p
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) UpdatePayoutStatus(ctx context.Context, id, status string, transferID *string, errorMsg *string, events ...*store.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.payouts[id]; ok {
+		p.Status, p.StripeTransferID, p.ErrorMessage = status, transferID, errorMsg
+		if status == "completed" {
+			now := time.Now()
+			p.CompletedAt = &now
+		}
+	}
+	return s.recordEvents(events)
+}
@@ -0,0 +1,120 @@
+// Package storetest provides an in-memory implementation of the store
+// repository interfaces, so handlers can be tested without Postgres.
+package storetest
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/creatrid/creatrid/internal/model"
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+// ErrDuplicate is returned where the database would raise a unique violation.
+var ErrDuplicate = errors.New("storetest: duplicate key")
+
+// Store is an in-memory stand-in for store.Store. The zero value is not
+// usable; create one with New. It is safe for concurrent use.
+type Store struct {
+	mu sync.Mutex
+
+	users            map[string]*model.User
+	content          map[string]*store.ContentItem
+	tokens           map[string]*store.CreatorToken
+	balances         map[balanceKey]*store.TokenBalance
+	tokenTxs         []*store.TokenTransaction
+	pending          map[string]*store.PendingPurchase
+	rewards          map[string]*store.TokenReward
+	redemptions      []*store.TokenRedemption
+	offerings        map[string]*store.LicenseOffering
+	purchases        []*store.LicensePurchase
+	anchors          map[string]*store.ContentAnchor
+	agencies         map[string]*store.Agency
+	agencyCreators   map[string]*store.AgencyCreator
+	apiKeyOwners     map[string]string
+	apiUsage         []apiUsageEntry
+	audit            []*store.AuditEntry
+	endpoints        map[string]*store.WebhookEndpoint
+	deliveries       map[int64]*store.WebhookDelivery
+	outboxDeliveries map[outboxDelivery]bool
+	leases           map[int64]lease
+	attempts         []webhookAttempt
+	outbox           []*outboxRow
+	conflicts        map[string]*store.OwnershipConflict
+	fingerprints     map[string]uint64
+	uploads          map[string]*store.UploadSession
+	flags            []*store.ModerationFlag
+	subscriptions    map[string]*store.Subscription
+	paymentEvents    map[string]bool
+	tips             map[string]*store.Tip
+	payouts          map[string]*store.CreatorPayout
+
+	seq int64
+}
+
+type balanceKey struct {
+	tokenID string
+	userID  string
+}
+
+var (
+	_ store.UserRepository      = (*Store)(nil)
+	_ store.ContentRepository   = (*Store)(nil)
+	_ store.TokenRepository     = (*Store)(nil)
+	_ store.LicenseRepository   = (*Store)(nil)
+	_ store.AnchorRepository    = (*Store)(nil)
+	_ store.AgencyRepository    = (*Store)(nil)
+	_ store.APIUsageRepository  = (*Store)(nil)
+	_ store.AuditRepository     = (*Store)(nil)
+	_ store.WebhookRepository   = (*Store)(nil)
+	_ store.OwnershipRepository = (*Store)(nil)
+	_ store.UploadRepository    = (*Store)(nil)
+	_ store.PaymentRepository   = (*Store)(nil)
+	_ store.OutboxRepository    = (*Store)(nil)
+)
+
+func New() *Store {
+	return &Store{
+		users:            map[string]*model.User{},
+		content:          map[string]*store.ContentItem{},
+		tokens:           map[string]*store.CreatorToken{},
+		balances:         map[balanceKey]*store.TokenBalance{},
+		pending:          map[string]*store.PendingPurchase{},
+		rewards:          map[string]*store.TokenReward{},
+		offerings:        map[string]*store.LicenseOffering{},
+		anchors:          map[string]*store.ContentAnchor{},
+		agencies:         map[string]*store.Agency{},
+		agencyCreators:   map[string]*store.AgencyCreator{},
+		apiKeyOwners:     map[string]string{},
+		endpoints:        map[string]*store.WebhookEndpoint{},
+		deliveries:       map[int64]*store.WebhookDelivery{},
+		outboxDeliveries: map[outboxDelivery]bool{},
+		leases:           map[int64]lease{},
+		conflicts:        map[string]*store.OwnershipConflict{},
+		fingerprints:     map[string]uint64{},
+		uploads:          map[string]*store.UploadSession{},
+		subscriptions:    map[string]*store.Subscription{},
+		paymentEvents:    map[string]bool{},
+		tips:             map[string]*store.Tip{},
+		payouts:          map[string]*store.CreatorPayout{},
+	}
+}
+
+// nextSeq returns a monotonically increasing number for serial IDs. The
+// caller must hold s.mu.
+func (s *Store) nextSeq() int64 {
+	s.seq++
+	return s.seq
+}
+
+// page applies LIMIT/OFFSET semantics to items.
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
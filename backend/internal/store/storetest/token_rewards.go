@@ -0,0 +1,166 @@
+package storetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrednav/cuid2"
+)
+
+func (s *Store) CreateTokenReward(ctx context.Context, rw *store.TokenReward) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rewards[rw.ID]; ok {
+		return ErrDuplicate
+	}
+	cp := *rw
+	s.rewards[rw.ID] = &cp
+	return nil
+}
+
+func (s *Store) FindTokenRewardByID(ctx context.Context, id string) (*store.TokenReward, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rw, ok := s.rewards[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *rw
+	return &cp, nil
+}
+
+// ListTokenRewards returns a token's catalogue, cheapest first.
+func (s *Store) ListTokenRewards(ctx context.Context, tokenID string, includeInactive bool) ([]*store.TokenReward, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rewards []*store.TokenReward
+	for _, rw := range s.rewards {
+		if rw.TokenID == tokenID && (rw.IsActive || includeInactive) {
+			cp := *rw
+			rewards = append(rewards, &cp)
+		}
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		if rewards[i].Cost != rewards[j].Cost {
+			return rewards[i].Cost < rewards[j].Cost
+		}
+		return rewards[i].CreatedAt.Before(rewards[j].CreatedAt)
+	})
+	return rewards, nil
+}
+
+func (s *Store) UpdateTokenReward(ctx context.Context, id, title string, description *string, cost int, quantityRemaining *int, isActive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rw, ok := s.rewards[id]; ok {
+		rw.Title = title
+		rw.Description = description
+		rw.Cost = cost
+		rw.QuantityRemaining = quantityRemaining
+		rw.IsActive = isActive
+		rw.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// RedeemTokenReward mirrors store.Store, returning pgx.ErrNoRows when the
+// balance is insufficient or the reward is inactive or sold out.
+func (s *Store) RedeemTokenReward(ctx context.Context, rd *store.TokenRedemption, events func(*store.TokenRedemption) []*store.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rw, ok := s.rewards[rd.RewardID]
+	if !ok || !rw.IsActive || (rw.QuantityRemaining != nil && *rw.QuantityRemaining <= 0) {
+		return pgx.ErrNoRows
+	}
+	rd.Cost = rw.Cost
+	b, ok := s.balances[balanceKey{rd.TokenID, rd.UserID}]
+	if !ok || b.Balance < rd.Cost {
+		return pgx.ErrNoRows
+	}
+
+	if rw.QuantityRemaining != nil {
+		q := *rw.QuantityRemaining - 1
+		rw.QuantityRemaining = &q
+	}
+	b.Balance -= rd.Cost
+	b.UpdatedAt = time.Now()
+	if t, ok := s.tokens[rd.TokenID]; ok {
+		t.TotalSupply -= rd.Cost
+	}
+
+	cp := *rd
+	s.redemptions = append(s.redemptions, &cp)
+	s.tokenTxs = append(s.tokenTxs, &store.TokenTransaction{
+		ID:          cuid2.Generate(),
+		TokenID:     rd.TokenID,
+		FromUserID:  &rd.UserID,
+		Amount:      rd.Cost,
+		TxType:      "redemption",
+		ReferenceID: &cp.ID,
+		CreatedAt:   time.Now(),
+	})
+	if events == nil {
+		return nil
+	}
+	return s.recordEvents(events(rd))
+}
+
+func (s *Store) FindTokenRedemptionByID(ctx context.Context, id string) (*store.TokenRedemption, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rd := range s.redemptions {
+		if rd.ID == id {
+			cp := *rd
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListTokenRedemptions returns a token's redemptions, newest first.
+func (s *Store) ListTokenRedemptions(ctx context.Context, tokenID string, limit, offset int) ([]*store.TokenRedemption, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var redemptions []*store.TokenRedemption
+	for i := len(s.redemptions) - 1; i >= 0; i-- {
+		rd := s.redemptions[i]
+		if rd.TokenID != tokenID {
+			continue
+		}
+		cp := *rd
+		if rw, ok := s.rewards[rd.RewardID]; ok {
+			cp.RewardTitle = rw.Title
+		}
+		if u, ok := s.users[rd.UserID]; ok {
+			cp.UserName, cp.UserUsername = u.Name, u.Username
+		}
+		redemptions = append(redemptions, &cp)
+	}
+
+	total := len(redemptions)
+	return page(redemptions, limit, offset), total, nil
+}
+
+func (s *Store) FulfillTokenRedemption(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rd := range s.redemptions {
+		if rd.ID == id && rd.Status == "pending" {
+			now := time.Now()
+			rd.Status = "fulfilled"
+			rd.FulfilledAt = &now
+		}
+	}
+	return nil
+}
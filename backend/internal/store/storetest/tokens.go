@@ -0,0 +1,307 @@
+package storetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/pricing"
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrednav/cuid2"
+)
+
+func (s *Store) CreateCreatorToken(ctx context.Context, token *store.CreatorToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tokens {
+		if t.ID == token.ID || t.UserID == token.UserID || t.Symbol == token.Symbol {
+			return ErrDuplicate
+		}
+	}
+	cp := *token
+	s.tokens[token.ID] = &cp
+	return nil
+}
+
+func (s *Store) FindTokenByUserID(ctx context.Context, userID string) (*store.CreatorToken, error) {
+	return s.findToken(func(t *store.CreatorToken) bool { return t.UserID == userID })
+}
+
+func (s *Store) FindTokenByID(ctx context.Context, id string) (*store.CreatorToken, error) {
+	return s.findToken(func(t *store.CreatorToken) bool { return t.ID == id })
+}
+
+func (s *Store) FindTokenBySymbol(ctx context.Context, symbol string) (*store.CreatorToken, error) {
+	return s.findToken(func(t *store.CreatorToken) bool { return t.Symbol == symbol })
+}
+
+func (s *Store) findToken(match func(*store.CreatorToken) bool) (*store.CreatorToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tokens {
+		if match(t) {
+			cp := *t
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) UpdateCreatorToken(ctx context.Context, id, name string, description *string, isActive bool, curve pricing.Curve) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.tokens[id]; ok {
+		t.Name = name
+		t.Description = description
+		t.IsActive = isActive
+		t.PricingModel = curve.Model
+		t.PriceCents = curve.BaseCents
+		t.PriceSlopeMilliCents = curve.SlopeMilliCents
+		t.PriceTiers = curve.Tiers
+		t.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) GetTokenBalance(ctx context.Context, tokenID, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.balances[balanceKey{tokenID, userID}]; ok {
+		return b.Balance, nil
+	}
+	return 0, nil
+}
+
+func (s *Store) MintTokens(ctx context.Context, tokenID, toUserID string, amount int, txType, referenceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.credit(tokenID, toUserID, amount)
+	if t, ok := s.tokens[tokenID]; ok {
+		t.TotalSupply += amount
+		t.UpdatedAt = time.Now()
+	}
+
+	var refID *string
+	if referenceID != "" {
+		refID = &referenceID
+	}
+	s.tokenTxs = append(s.tokenTxs, &store.TokenTransaction{
+		ID:          cuid2.Generate(),
+		TokenID:     tokenID,
+		ToUserID:    &toUserID,
+		Amount:      amount,
+		TxType:      txType,
+		ReferenceID: refID,
+		CreatedAt:   time.Now(),
+	})
+	return nil
+}
+
+// TransferTokens mirrors store.Store, returning pgx.ErrNoRows when the
+// sender's balance is missing or insufficient.
+func (s *Store) TransferTokens(ctx context.Context, tokenID, fromUserID, toUserID string, amount int, events ...*store.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, ok := s.balances[balanceKey{tokenID, fromUserID}]
+	if !ok || from.Balance < amount {
+		return pgx.ErrNoRows
+	}
+	from.Balance -= amount
+	from.UpdatedAt = time.Now()
+	s.credit(tokenID, toUserID, amount)
+
+	s.tokenTxs = append(s.tokenTxs, &store.TokenTransaction{
+		ID:         cuid2.Generate(),
+		TokenID:    tokenID,
+		FromUserID: &fromUserID,
+		ToUserID:   &toUserID,
+		Amount:     amount,
+		TxType:     "transfer",
+		CreatedAt:  time.Now(),
+	})
+	return s.recordEvents(events)
+}
+
+// credit adds amount to a balance, creating it if needed. The caller must
+// hold s.mu.
+func (s *Store) credit(tokenID, userID string, amount int) {
+	key := balanceKey{tokenID, userID}
+	b, ok := s.balances[key]
+	if !ok {
+		b = &store.TokenBalance{ID: cuid2.Generate(), TokenID: tokenID, UserID: userID}
+		s.balances[key] = b
+	}
+	b.Balance += amount
+	b.UpdatedAt = time.Now()
+}
+
+func (s *Store) ListTokenHolders(ctx context.Context, tokenID string, limit, offset int) ([]store.TokenBalance, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var holders []store.TokenBalance
+	for key, b := range s.balances {
+		if key.tokenID != tokenID || b.Balance <= 0 {
+			continue
+		}
+		h := *b
+		if u, ok := s.users[b.UserID]; ok {
+			h.UserName, h.UserUsername, h.UserImage = u.Name, u.Username, u.Image
+		}
+		holders = append(holders, h)
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i].Balance > holders[j].Balance })
+
+	total := len(holders)
+	return page(holders, limit, offset), total, nil
+}
+
+func (s *Store) RecordTokenTransaction(ctx context.Context, tx *store.TokenTransaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *tx
+	s.tokenTxs = append(s.tokenTxs, &cp)
+	return nil
+}
+
+func (s *Store) ListTokenTransactions(ctx context.Context, tokenID string, limit, offset int) ([]store.TokenTransaction, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var txs []store.TokenTransaction
+	for i := len(s.tokenTxs) - 1; i >= 0; i-- {
+		if s.tokenTxs[i].TokenID == tokenID {
+			txs = append(txs, *s.tokenTxs[i])
+		}
+	}
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].CreatedAt.After(txs[j].CreatedAt) })
+
+	total := len(txs)
+	return page(txs, limit, offset), total, nil
+}
+
+func (s *Store) CreatePendingPurchase(ctx context.Context, p *store.PendingPurchase) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pendingByIntent(p.StripePaymentIntentID) != nil || s.pending[p.ID] != nil {
+		return ErrDuplicate
+	}
+	cp := *p
+	s.pending[p.ID] = &cp
+	return nil
+}
+
+// pendingByIntent returns the purchase with the given PaymentIntent, or nil.
+// The caller must hold s.mu.
+func (s *Store) pendingByIntent(paymentIntentID string) *store.PendingPurchase {
+	if paymentIntentID == "" {
+		return nil
+	}
+	for _, p := range s.pending {
+		if p.StripePaymentIntentID == paymentIntentID {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Store) ReservedTokenAmount(ctx context.Context, tokenID string, since time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reservedTokenAmount(tokenID, since), nil
+}
+
+// reservedTokenAmount sums pending purchases of a token. The caller must
+// hold s.mu.
+func (s *Store) reservedTokenAmount(tokenID string, since time.Time) int {
+	reserved := 0
+	for _, p := range s.pending {
+		if p.Kind == "token_purchase" && p.Status == "pending" && p.TokenID != nil && *p.TokenID == tokenID && !p.CreatedAt.Before(since) {
+			reserved += p.Amount
+		}
+	}
+	return reserved
+}
+
+func (s *Store) ReserveTokenPurchase(ctx context.Context, tokenID string, since time.Time, create func(supply int) (*store.PendingPurchase, error)) (*store.PendingPurchase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[tokenID]
+	if !ok {
+		return nil, nil
+	}
+	p, err := create(t.TotalSupply + s.reservedTokenAmount(tokenID, since))
+	if err != nil {
+		return nil, err
+	}
+	if s.pendingByIntent(p.StripePaymentIntentID) != nil || s.pending[p.ID] != nil {
+		return nil, ErrDuplicate
+	}
+	cp := *p
+	s.pending[p.ID] = &cp
+	return p, nil
+}
+
+func (s *Store) AttachPaymentIntent(ctx context.Context, purchaseID, paymentIntentID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pending[purchaseID]
+	if !ok || p.Status != "pending" || p.StripePaymentIntentID != "" {
+		return false, nil
+	}
+	if s.pendingByIntent(paymentIntentID) != nil {
+		return false, ErrDuplicate
+	}
+	p.StripePaymentIntentID = paymentIntentID
+	p.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *Store) ReleaseTokenPurchase(ctx context.Context, purchaseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.pending[purchaseID]; ok && p.Status == "pending" {
+		p.Status = "failed"
+		p.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) ExpirePendingPurchase(ctx context.Context, paymentIntentID string, before time.Time) (*store.PendingPurchase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.pendingByIntent(paymentIntentID)
+	if p == nil || p.Status != "pending" || p.Kind != "token_purchase" || !p.CreatedAt.Before(before) {
+		return nil, nil
+	}
+	p.Status = "expired"
+	p.UpdatedAt = time.Now()
+	cp := *p
+	return &cp, nil
+}
+
+func (s *Store) FindPendingPurchaseByPaymentIntent(ctx context.Context, paymentIntentID string) (*store.PendingPurchase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.pendingByIntent(paymentIntentID)
+	if p == nil {
+		return nil, nil
+	}
+	cp := *p
+	return &cp, nil
+}
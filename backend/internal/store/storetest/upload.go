@@ -0,0 +1,120 @@
+package storetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+// completingTimeout mirrors the store's: how long a session may stay
+// claimed before cleanup takes it to be abandoned.
+const completingTimeout = time.Hour
+
+func (s *Store) CreateUploadSession(ctx context.Context, u *store.UploadSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.uploads[u.ID]; ok {
+		return ErrDuplicate
+	}
+	cp := *u
+	s.uploads[u.ID] = &cp
+	return nil
+}
+
+func (s *Store) FindUploadSession(ctx context.Context, id string) (*store.UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyUpload(u), nil
+}
+
+// RecordUploadChunk advances an active session by one chunk if chunk is the
+// next one expected.
+func (s *Store) RecordUploadChunk(ctx context.Context, id string, chunk int, size int64, hashState []byte, chunkURL string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.uploads[id]
+	if !ok || u.Status != "active" || u.ChunksReceived != chunk {
+		return false, nil
+	}
+	u.ChunksReceived++
+	u.BytesReceived += size
+	u.HashState = append([]byte(nil), hashState...)
+	u.ChunkURLs = append(u.ChunkURLs, chunkURL)
+	u.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *Store) ClaimUploadSession(ctx context.Context, id string) (bool, error) {
+	return s.moveUpload(id, "active", "completing", nil), nil
+}
+
+func (s *Store) ReleaseUploadSession(ctx context.Context, id string) error {
+	s.moveUpload(id, "completing", "active", nil)
+	return nil
+}
+
+func (s *Store) CompleteUploadSession(ctx context.Context, id, contentID string) error {
+	s.moveUpload(id, "completing", "completed", func(u *store.UploadSession) {
+		u.ContentID = &contentID
+		u.ChunkURLs = nil
+	})
+	return nil
+}
+
+func (s *Store) AbortUploadSession(ctx context.Context, id, status string) (bool, error) {
+	return s.moveUpload(id, status, "aborted", func(u *store.UploadSession) {
+		u.ChunkURLs = nil
+	}), nil
+}
+
+// ListExpiredUploadSessions returns active sessions past their expiry and
+// claimed sessions idle for longer than completingTimeout.
+func (s *Store) ListExpiredUploadSessions(ctx context.Context, limit int) ([]*store.UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var sessions []*store.UploadSession
+	for _, u := range s.uploads {
+		if (u.Status == "active" && u.ExpiresAt.Before(now)) ||
+			(u.Status == "completing" && u.UpdatedAt.Before(now.Add(-completingTimeout))) {
+			sessions = append(sessions, copyUpload(u))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ExpiresAt.Before(sessions[j].ExpiresAt) })
+	return page(sessions, limit, 0), nil
+}
+
+// moveUpload changes a session from one status to another, applying update
+// as well if given. Reports whether the session was in the from status.
+func (s *Store) moveUpload(id, from, to string, update func(*store.UploadSession)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.uploads[id]
+	if !ok || u.Status != from {
+		return false
+	}
+	u.Status = to
+	u.UpdatedAt = time.Now()
+	if update != nil {
+		update(u)
+	}
+	return true
+}
+
+func copyUpload(u *store.UploadSession) *store.UploadSession {
+	cp := *u
+	cp.HashState = append([]byte(nil), u.HashState...)
+	cp.ChunkURLs = append([]string(nil), u.ChunkURLs...)
+	return &cp
+}
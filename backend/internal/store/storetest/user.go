@@ -0,0 +1,108 @@
+package storetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/model"
+)
+
+// AddUser inserts u, filling in the defaults the users table would apply.
+// It is a convenience for seeding tests; CreateUser behaves the same way.
+func (s *Store) AddUser(u *model.User) *model.User {
+	_ = s.CreateUser(context.Background(), u)
+	return u
+}
+
+func (s *Store) FindUserByID(ctx context.Context, id string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (s *Store) FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) FindUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username != nil && *u.Username == username {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) CreateUser(ctx context.Context, u *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.users {
+		if existing.ID == u.ID || existing.Email == u.Email {
+			return ErrDuplicate
+		}
+	}
+
+	if u.Role == "" {
+		u.Role = model.RoleCreator
+	}
+	if u.Theme == "" {
+		u.Theme = "default"
+	}
+	if u.CreatorTier == "" {
+		u.CreatorTier = "newcomer"
+	}
+	if u.AnchorPolicy == "" {
+		u.AnchorPolicy = "never"
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+		u.UpdatedAt = u.CreatedAt
+	}
+	cp := *u
+	s.users[u.ID] = &cp
+	return nil
+}
+
+func (s *Store) UpdateUserRole(ctx context.Context, userID string, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok {
+		u.Role = model.UserRole(role)
+		u.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) BulkSetVerified(ctx context.Context, creatorIDs []string, verified bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range creatorIDs {
+		if u, ok := s.users[id]; ok {
+			u.IsVerified = verified
+			u.UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
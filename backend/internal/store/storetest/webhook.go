@@ -0,0 +1,461 @@
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+)
+
+func (s *Store) CreateWebhookEndpoint(ctx context.Context, ep *store.WebhookEndpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.endpoints[ep.ID]; ok {
+		return ErrDuplicate
+	}
+	cp := *ep
+	s.endpoints[ep.ID] = &cp
+	return nil
+}
+
+func (s *Store) ListWebhookEndpointsByUser(ctx context.Context, userID string) ([]*store.WebhookEndpoint, error) {
+	return s.listEndpoints(func(ep *store.WebhookEndpoint) bool { return ep.UserID == userID }), nil
+}
+
+func (s *Store) ListActiveWebhookEndpointsForEvent(ctx context.Context, userID, eventType string) ([]*store.WebhookEndpoint, error) {
+	return s.listEndpoints(func(ep *store.WebhookEndpoint) bool {
+		if ep.UserID != userID || !ep.IsActive {
+			return false
+		}
+		for _, e := range ep.Events {
+			if e == eventType {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// listEndpoints returns matching endpoints, newest first.
+func (s *Store) listEndpoints(match func(*store.WebhookEndpoint) bool) []*store.WebhookEndpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoints := []*store.WebhookEndpoint{}
+	for _, ep := range s.endpoints {
+		if match(ep) {
+			cp := *ep
+			endpoints = append(endpoints, &cp)
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].CreatedAt.After(endpoints[j].CreatedAt) })
+	return endpoints
+}
+
+func (s *Store) FindWebhookEndpointByID(ctx context.Context, id string) (*store.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ep, ok := s.endpoints[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *ep
+	return &cp, nil
+}
+
+func (s *Store) UpdateWebhookEndpoint(ctx context.Context, id, url string, events []string, isActive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ep, ok := s.endpoints[id]; ok {
+		ep.URL = url
+		ep.Events = events
+		ep.IsActive = isActive
+	}
+	return nil
+}
+
+func (s *Store) RotateWebhookSecret(ctx context.Context, id, secret string, previousExpiresAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ep, ok := s.endpoints[id]; ok {
+		ep.PreviousSecret = nil
+		if previousExpiresAt != nil {
+			prev := ep.Secret
+			ep.PreviousSecret = &prev
+		}
+		ep.PreviousSecretExpiresAt = previousExpiresAt
+		ep.Secret = secret
+	}
+	return nil
+}
+
+// DeleteWebhookEndpoint removes the endpoint and, like the ON DELETE CASCADE
+// foreign key, its deliveries.
+func (s *Store) DeleteWebhookEndpoint(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.endpoints, id)
+	for did, d := range s.deliveries {
+		if d.EndpointID == id {
+			delete(s.deliveries, did)
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, endpointID, eventType string, payload json.RawMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextSeq()
+	s.deliveries[id] = &store.WebhookDelivery{
+		ID:          id,
+		EndpointID:  endpointID,
+		EventType:   eventType,
+		Payload:     payload,
+		CreatedAt:   time.Now(),
+		MaxAttempts: 5,
+		Status:      "pending",
+	}
+	return id, nil
+}
+
+// QueueWebhookEvent creates a delivery per endpoint, skipping endpoints that
+// already have the outbox event.
+func (s *Store) QueueWebhookEvent(ctx context.Context, outboxID *int64, endpointIDs []string, eventType string, payload json.RawMessage) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queued := 0
+	for _, endpointID := range endpointIDs {
+		if outboxID != nil {
+			key := outboxDelivery{outboxID: *outboxID, endpointID: endpointID}
+			if s.outboxDeliveries[key] {
+				continue
+			}
+			s.outboxDeliveries[key] = true
+		}
+		id := s.nextSeq()
+		s.deliveries[id] = &store.WebhookDelivery{
+			ID:          id,
+			EndpointID:  endpointID,
+			EventType:   eventType,
+			Payload:     payload,
+			CreatedAt:   time.Now(),
+			MaxAttempts: 5,
+			Status:      "pending",
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// ListWebhookDeliveries returns an endpoint's deliveries, newest first.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, endpointID string, limit, offset int) ([]*store.WebhookDelivery, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deliveries := []*store.WebhookDelivery{}
+	for _, d := range s.deliveries {
+		if d.EndpointID == endpointID {
+			cp := *d
+			deliveries = append(deliveries, &cp)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+
+	total := len(deliveries)
+	deliveries = page(deliveries, limit, offset)
+	if deliveries == nil {
+		deliveries = []*store.WebhookDelivery{}
+	}
+	return deliveries, total, nil
+}
+
+func (s *Store) FindWebhookDeliveryByID(ctx context.Context, id int64) (*store.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (s *Store) IncrementDeliveryAttempt(ctx context.Context, id int64, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordAttempt(id, status, responseStatus, responseBody, nextRetryAt)
+	return nil
+}
+
+// recordAttempt applies an attempt's result to a delivery. The caller must
+// hold s.mu.
+func (s *Store) recordAttempt(id int64, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) {
+	d, ok := s.deliveries[id]
+	if !ok {
+		return
+	}
+	d.Attempts++
+	d.Status = status
+	d.ResponseStatus = &responseStatus
+	d.ResponseBody = &responseBody
+	d.NextRetryAt = nextRetryAt
+	d.DeliveredAt = nil
+	if status == "success" {
+		now := time.Now()
+		d.DeliveredAt = &now
+	}
+	delete(s.leases, id)
+}
+
+// lease is a worker's claim on a delivery, the locked_by and locked_until
+// columns in Postgres.
+type lease struct {
+	workerID string
+	until    time.Time
+}
+
+// ClaimPendingDeliveries mirrors the Postgres claim: oldest ready deliveries
+// to active endpoints first, at most perEndpoint leased per endpoint, expired
+// leases reclaimed.
+func (s *Store) ClaimPendingDeliveries(ctx context.Context, workerID string, limit, perEndpoint int, leaseFor time.Duration) ([]*store.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	inflight := map[string]int{}
+	var queued []*store.WebhookDelivery
+	for id, d := range s.deliveries {
+		if d.Status != "pending" {
+			continue
+		}
+		if l, ok := s.leases[id]; ok && l.until.After(now) {
+			inflight[d.EndpointID]++
+			continue
+		}
+		if ep, ok := s.endpoints[d.EndpointID]; !ok || !ep.IsActive {
+			continue
+		}
+		queued = append(queued, d)
+	}
+	sort.Slice(queued, func(i, j int) bool { return queued[i].ID < queued[j].ID })
+
+	// A delivery waiting out its backoff holds back its endpoint's later ones
+	waiting := map[string]bool{}
+	claimed := []*store.WebhookDelivery{}
+	for _, d := range queued {
+		if len(claimed) >= limit {
+			break
+		}
+		if d.NextRetryAt != nil && d.NextRetryAt.After(now) {
+			waiting[d.EndpointID] = true
+		}
+		if waiting[d.EndpointID] || inflight[d.EndpointID] >= perEndpoint {
+			continue
+		}
+		inflight[d.EndpointID]++
+		s.leases[d.ID] = lease{workerID: workerID, until: now.Add(leaseFor)}
+		cp := *d
+		claimed = append(claimed, &cp)
+	}
+	return claimed, nil
+}
+
+func (s *Store) CompleteDeliveryAttempt(ctx context.Context, id int64, workerID, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.leases[id]; !ok || l.workerID != workerID {
+		return false, nil
+	}
+	s.recordAttempt(id, status, responseStatus, responseBody, nextRetryAt)
+	return true, nil
+}
+
+func (s *Store) MarkDeliveryDead(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.deliveries[id]; ok {
+		d.Status = "dead"
+		d.NextRetryAt = nil
+	}
+	delete(s.leases, id)
+	return nil
+}
+
+func (s *Store) ResetDeliveryForRetry(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.deliveries[id]; ok {
+		d.Status = "pending"
+		d.NextRetryAt = nil
+		d.Attempts = 0
+	}
+	delete(s.leases, id)
+	return nil
+}
+
+func (s *Store) RedeliverWebhookDeliveries(ctx context.Context, endpointID string, f store.RedeliveryFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := map[string]bool{}
+	for _, st := range f.Statuses {
+		statuses[st] = true
+	}
+	n := 0
+	for id, d := range s.deliveries {
+		if d.EndpointID != endpointID || !statuses[d.Status] {
+			continue
+		}
+		if f.Since != nil && d.CreatedAt.Before(*f.Since) {
+			continue
+		}
+		if f.Until != nil && !d.CreatedAt.Before(*f.Until) {
+			continue
+		}
+		if f.EventType != "" && d.EventType != f.EventType {
+			continue
+		}
+		d.Status = "pending"
+		d.NextRetryAt = nil
+		d.Attempts = 0
+		delete(s.leases, id)
+		n++
+	}
+	return n, nil
+}
+
+// webhookAttempt is a row of webhook_attempts.
+type webhookAttempt struct {
+	endpointID string
+	deliveryID int64
+	success    bool
+	duration   time.Duration
+	at         time.Time
+}
+
+func (s *Store) RecordEndpointAttempt(ctx context.Context, endpointID string, deliveryID int64, success bool, responseStatus int, duration time.Duration) (int, *time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ep, ok := s.endpoints[endpointID]
+	if !ok {
+		return 0, nil, nil
+	}
+	now := time.Now()
+	s.attempts = append(s.attempts, webhookAttempt{endpointID: endpointID, deliveryID: deliveryID, success: success, duration: duration, at: now})
+
+	if success {
+		ep.ConsecutiveFailures = 0
+		ep.FailingSince = nil
+		ep.LastSuccessAt = &now
+		return 0, nil, nil
+	}
+	ep.ConsecutiveFailures++
+	if ep.FailingSince == nil {
+		ep.FailingSince = &now
+	}
+	since := *ep.FailingSince
+	return ep.ConsecutiveFailures, &since, nil
+}
+
+func (s *Store) DisableWebhookEndpoint(ctx context.Context, id, reason string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ep, ok := s.endpoints[id]
+	if !ok || !ep.IsActive {
+		return false, nil
+	}
+	now := time.Now()
+	ep.IsActive = false
+	ep.DisabledAt = &now
+	ep.DisabledReason = &reason
+	return true, nil
+}
+
+func (s *Store) EnableWebhookEndpoint(ctx context.Context, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ep, ok := s.endpoints[id]
+	if !ok {
+		return 0, nil
+	}
+
+	requeued := 0
+	if ep.FailingSince != nil {
+		attempted := map[int64]bool{}
+		for _, a := range s.attempts {
+			if a.endpointID == id && !a.at.Before(*ep.FailingSince) {
+				attempted[a.deliveryID] = true
+			}
+		}
+		for did, d := range s.deliveries {
+			if d.EndpointID == id && d.Status == "dead" && attempted[did] {
+				d.Status = "pending"
+				d.Attempts = 0
+				d.NextRetryAt = nil
+				delete(s.leases, did)
+				requeued++
+			}
+		}
+	}
+
+	ep.IsActive = true
+	ep.ConsecutiveFailures = 0
+	ep.FailingSince = nil
+	ep.DisabledAt = nil
+	ep.DisabledReason = nil
+	return requeued, nil
+}
+
+func (s *Store) GetWebhookAttemptStats(ctx context.Context, endpointID string, since time.Time) (*store.WebhookAttemptStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stats store.WebhookAttemptStats
+	var latencies []int
+	for _, a := range s.attempts {
+		if a.endpointID != endpointID || a.at.Before(since) {
+			continue
+		}
+		stats.Attempts++
+		if a.success {
+			stats.Successes++
+		}
+		latencies = append(latencies, int(a.duration.Milliseconds()))
+	}
+	if stats.Attempts > 0 {
+		rate := float64(stats.Successes) / float64(stats.Attempts)
+		stats.SuccessRate = &rate
+
+		// percentile_disc: the first value whose cumulative share reaches 95%
+		sort.Ints(latencies)
+		idx := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+		p95 := latencies[idx]
+		stats.P95LatencyMs = &p95
+	}
+	return &stats, nil
+}
+
+// outboxDelivery is the unique key of a delivery queued from the outbox.
+type outboxDelivery struct {
+	outboxID   int64
+	endpointID string
+}
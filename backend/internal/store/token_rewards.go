@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nrednav/cuid2"
+)
+
+// TokenReward is an item in a creator's redemption catalogue.
+type TokenReward struct {
+	ID                string    `json:"id"`
+	TokenID           string    `json:"tokenId"`
+	Title             string    `json:"title"`
+	Description       *string   `json:"description"`
+	Kind              string    `json:"kind"`
+	ContentID         *string   `json:"contentId"`
+	Cost              int       `json:"cost"`
+	QuantityRemaining *int      `json:"quantityRemaining"`
+	IsActive          bool      `json:"isActive"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// TokenRedemption records a holder burning tokens for a reward.
+type TokenRedemption struct {
+	ID          string     `json:"id"`
+	RewardID    string     `json:"rewardId"`
+	TokenID     string     `json:"tokenId"`
+	UserID      string     `json:"userId"`
+	Cost        int        `json:"cost"`
+	Note        *string    `json:"note"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	FulfilledAt *time.Time `json:"fulfilledAt"`
+	// Display fields
+	RewardTitle  string  `json:"rewardTitle,omitempty"`
+	UserName     *string `json:"userName,omitempty"`
+	UserUsername *string `json:"userUsername,omitempty"`
+}
+
+func (s *Store) CreateTokenReward(ctx context.Context, rw *TokenReward) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO token_rewards (id, token_id, title, description, kind, content_id, cost, quantity_remaining, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		rw.ID, rw.TokenID, rw.Title, rw.Description, rw.Kind, rw.ContentID, rw.Cost,
+		rw.QuantityRemaining, rw.IsActive, rw.CreatedAt, rw.UpdatedAt,
+	)
+	return err
+}
+
+func (s *Store) FindTokenRewardByID(ctx context.Context, id string) (*TokenReward, error) {
+	var rw TokenReward
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, token_id, title, description, kind, content_id, cost, quantity_remaining, is_active, created_at, updated_at
+		 FROM token_rewards WHERE id = $1`, id,
+	).Scan(&rw.ID, &rw.TokenID, &rw.Title, &rw.Description, &rw.Kind, &rw.ContentID, &rw.Cost,
+		&rw.QuantityRemaining, &rw.IsActive, &rw.CreatedAt, &rw.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &rw, err
+}
+
+// ListTokenRewards returns a token's catalogue, cheapest first. Inactive
+// rewards are included only if includeInactive is set.
+func (s *Store) ListTokenRewards(ctx context.Context, tokenID string, includeInactive bool) ([]*TokenReward, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, token_id, title, description, kind, content_id, cost, quantity_remaining, is_active, created_at, updated_at
+		 FROM token_rewards
+		 WHERE token_id = $1 AND (is_active OR $2)
+		 ORDER BY cost ASC, created_at ASC`, tokenID, includeInactive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rewards []*TokenReward
+	for rows.Next() {
+		var rw TokenReward
+		if err := rows.Scan(&rw.ID, &rw.TokenID, &rw.Title, &rw.Description, &rw.Kind, &rw.ContentID, &rw.Cost,
+			&rw.QuantityRemaining, &rw.IsActive, &rw.CreatedAt, &rw.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rewards = append(rewards, &rw)
+	}
+	return rewards, nil
+}
+
+func (s *Store) UpdateTokenReward(ctx context.Context, id, title string, description *string, cost int, quantityRemaining *int, isActive bool) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE token_rewards SET title = $2, description = $3, cost = $4, quantity_remaining = $5, is_active = $6, updated_at = NOW()
+		 WHERE id = $1`,
+		id, title, description, cost, quantityRemaining, isActive,
+	)
+	return err
+}
+
+// RedeemTokenReward burns the reward's cost from the user's balance, takes one
+// from its remaining quantity and records the redemption and a "redemption"
+// token transaction. The cost is read as the reward is taken and set on rd,
+// so an edit since the caller loaded the reward cannot change what is
+// charged; events, which may be nil, is then called with rd for the events
+// to record. It returns pgx.ErrNoRows if the balance is insufficient or the
+// reward is inactive or sold out.
+func (s *Store) RedeemTokenReward(ctx context.Context, rd *TokenRedemption, events func(*TokenRedemption) []*OutboxEvent) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`UPDATE token_rewards SET quantity_remaining = quantity_remaining - 1, updated_at = NOW()
+		 WHERE id = $1 AND is_active AND (quantity_remaining IS NULL OR quantity_remaining > 0)
+		 RETURNING cost`,
+		rd.RewardID,
+	).Scan(&rd.Cost)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE token_balances SET balance = balance - $3, updated_at = NOW()
+		 WHERE token_id = $1 AND user_id = $2 AND balance >= $3`,
+		rd.TokenID, rd.UserID, rd.Cost,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows // insufficient balance
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE creator_tokens SET total_supply = total_supply - $2, updated_at = NOW() WHERE id = $1`,
+		rd.TokenID, rd.Cost,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO token_redemptions (id, reward_id, token_id, user_id, cost, note, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		rd.ID, rd.RewardID, rd.TokenID, rd.UserID, rd.Cost, rd.Note, rd.Status, rd.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO token_transactions (id, token_id, from_user_id, to_user_id, amount, tx_type, reference_id, created_at)
+		 VALUES ($1, $2, $3, NULL, $4, 'redemption', $5, NOW())`,
+		cuid2.Generate(), rd.TokenID, rd.UserID, rd.Cost, rd.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if events != nil {
+		if err := writeOutbox(ctx, tx, events(rd)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *Store) FindTokenRedemptionByID(ctx context.Context, id string) (*TokenRedemption, error) {
+	var rd TokenRedemption
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, reward_id, token_id, user_id, cost, note, status, created_at, fulfilled_at
+		 FROM token_redemptions WHERE id = $1`, id,
+	).Scan(&rd.ID, &rd.RewardID, &rd.TokenID, &rd.UserID, &rd.Cost, &rd.Note, &rd.Status, &rd.CreatedAt, &rd.FulfilledAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &rd, err
+}
+
+// ListTokenRedemptions returns a token's redemptions, newest first.
+func (s *Store) ListTokenRedemptions(ctx context.Context, tokenID string, limit, offset int) ([]*TokenRedemption, int, error) {
+	var total int
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM token_redemptions WHERE token_id = $1`, tokenID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT rd.id, rd.reward_id, rd.token_id, rd.user_id, rd.cost, rd.note, rd.status, rd.created_at, rd.fulfilled_at,
+		        rw.title, u.name, u.username
+		 FROM token_redemptions rd
+		 JOIN token_rewards rw ON rw.id = rd.reward_id
+		 JOIN users u ON u.id = rd.user_id
+		 WHERE rd.token_id = $1
+		 ORDER BY rd.created_at DESC
+		 LIMIT $2 OFFSET $3`, tokenID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var redemptions []*TokenRedemption
+	for rows.Next() {
+		var rd TokenRedemption
+		if err := rows.Scan(&rd.ID, &rd.RewardID, &rd.TokenID, &rd.UserID, &rd.Cost, &rd.Note, &rd.Status,
+			&rd.CreatedAt, &rd.FulfilledAt, &rd.RewardTitle, &rd.UserName, &rd.UserUsername); err != nil {
+			return nil, 0, err
+		}
+		redemptions = append(redemptions, &rd)
+	}
+	return redemptions, total, nil
+}
+
+func (s *Store) FulfillTokenRedemption(ctx context.Context, id string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE token_redemptions SET status = 'fulfilled', fulfilled_at = NOW() WHERE id = $1 AND status = 'pending'`,
+		id,
+	)
+	return err
+}
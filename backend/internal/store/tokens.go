@@ -4,22 +4,41 @@ import (
 	"context"
 	"time"
 
+	"github.com/creatrid/creatrid/internal/pricing"
 	"github.com/jackc/pgx/v5"
 	"github.com/nrednav/cuid2"
 )
 
 // CreatorToken represents a creator's social token.
 type CreatorToken struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"userId"`
-	Name        string    `json:"name"`
-	Symbol      string    `json:"symbol"`
-	Description *string   `json:"description"`
-	TotalSupply int       `json:"totalSupply"`
-	PriceCents  int       `json:"priceCents"`
-	IsActive    bool      `json:"isActive"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID                   string         `json:"id"`
+	UserID               string         `json:"userId"`
+	Name                 string         `json:"name"`
+	Symbol               string         `json:"symbol"`
+	Description          *string        `json:"description"`
+	TotalSupply          int            `json:"totalSupply"`
+	PriceCents           int            `json:"priceCents"`
+	PricingModel         pricing.Model  `json:"pricingModel"`
+	PriceSlopeMilliCents int            `json:"priceSlopeMilliCents"`
+	PriceTiers           []pricing.Tier `json:"priceTiers"`
+	IsActive             bool           `json:"isActive"`
+	CreatedAt            time.Time      `json:"createdAt"`
+	UpdatedAt            time.Time      `json:"updatedAt"`
+}
+
+// Curve returns the token's pricing curve. Tokens without a pricing model
+// are priced flat at PriceCents.
+func (t *CreatorToken) Curve() pricing.Curve {
+	model := t.PricingModel
+	if model == "" {
+		model = pricing.Flat
+	}
+	return pricing.Curve{
+		Model:           model,
+		BaseCents:       t.PriceCents,
+		SlopeMilliCents: t.PriceSlopeMilliCents,
+		Tiers:           t.PriceTiers,
+	}
 }
 
 // TokenBalance represents a user's balance of a creator token.
@@ -104,10 +123,12 @@ type TipStats struct {
 
 func (s *Store) CreateCreatorToken(ctx context.Context, token *CreatorToken) error {
 	_, err := s.pool.Exec(ctx,
-		`INSERT INTO creator_tokens (id, user_id, name, symbol, description, total_supply, price_cents, is_active, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		`INSERT INTO creator_tokens (id, user_id, name, symbol, description, total_supply, price_cents,
+		                             pricing_model, price_slope_millicents, price_tiers, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
 		token.ID, token.UserID, token.Name, token.Symbol, token.Description,
-		token.TotalSupply, token.PriceCents, token.IsActive, token.CreatedAt, token.UpdatedAt,
+		token.TotalSupply, token.PriceCents, token.Curve().Model, token.PriceSlopeMilliCents, priceTiers(token.PriceTiers),
+		token.IsActive, token.CreatedAt, token.UpdatedAt,
 	)
 	return err
 }
@@ -115,10 +136,12 @@ func (s *Store) CreateCreatorToken(ctx context.Context, token *CreatorToken) err
 func (s *Store) FindTokenByUserID(ctx context.Context, userID string) (*CreatorToken, error) {
 	var t CreatorToken
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, user_id, name, symbol, description, total_supply, price_cents, is_active, created_at, updated_at
+		`SELECT id, user_id, name, symbol, description, total_supply, price_cents,
+		        pricing_model, price_slope_millicents, price_tiers, is_active, created_at, updated_at
 		 FROM creator_tokens WHERE user_id = $1`, userID,
 	).Scan(&t.ID, &t.UserID, &t.Name, &t.Symbol, &t.Description,
-		&t.TotalSupply, &t.PriceCents, &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+		&t.TotalSupply, &t.PriceCents, &t.PricingModel, &t.PriceSlopeMilliCents, &t.PriceTiers,
+		&t.IsActive, &t.CreatedAt, &t.UpdatedAt)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -128,10 +151,12 @@ func (s *Store) FindTokenByUserID(ctx context.Context, userID string) (*CreatorT
 func (s *Store) FindTokenByID(ctx context.Context, id string) (*CreatorToken, error) {
 	var t CreatorToken
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, user_id, name, symbol, description, total_supply, price_cents, is_active, created_at, updated_at
+		`SELECT id, user_id, name, symbol, description, total_supply, price_cents,
+		        pricing_model, price_slope_millicents, price_tiers, is_active, created_at, updated_at
 		 FROM creator_tokens WHERE id = $1`, id,
 	).Scan(&t.ID, &t.UserID, &t.Name, &t.Symbol, &t.Description,
-		&t.TotalSupply, &t.PriceCents, &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+		&t.TotalSupply, &t.PriceCents, &t.PricingModel, &t.PriceSlopeMilliCents, &t.PriceTiers,
+		&t.IsActive, &t.CreatedAt, &t.UpdatedAt)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -141,25 +166,39 @@ func (s *Store) FindTokenByID(ctx context.Context, id string) (*CreatorToken, er
 func (s *Store) FindTokenBySymbol(ctx context.Context, symbol string) (*CreatorToken, error) {
 	var t CreatorToken
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, user_id, name, symbol, description, total_supply, price_cents, is_active, created_at, updated_at
+		`SELECT id, user_id, name, symbol, description, total_supply, price_cents,
+		        pricing_model, price_slope_millicents, price_tiers, is_active, created_at, updated_at
 		 FROM creator_tokens WHERE symbol = $1`, symbol,
 	).Scan(&t.ID, &t.UserID, &t.Name, &t.Symbol, &t.Description,
-		&t.TotalSupply, &t.PriceCents, &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+		&t.TotalSupply, &t.PriceCents, &t.PricingModel, &t.PriceSlopeMilliCents, &t.PriceTiers,
+		&t.IsActive, &t.CreatedAt, &t.UpdatedAt)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
 	return &t, err
 }
 
-func (s *Store) UpdateCreatorToken(ctx context.Context, id, name string, description *string, priceCents int, isActive bool) error {
+// UpdateCreatorToken sets a token's details and pricing curve in one
+// statement, so a purchase never sees one without the other.
+func (s *Store) UpdateCreatorToken(ctx context.Context, id, name string, description *string, isActive bool, curve pricing.Curve) error {
 	_, err := s.pool.Exec(ctx,
-		`UPDATE creator_tokens SET name = $2, description = $3, price_cents = $4, is_active = $5, updated_at = NOW()
+		`UPDATE creator_tokens
+		 SET name = $2, description = $3, is_active = $4,
+		     pricing_model = $5, price_cents = $6, price_slope_millicents = $7, price_tiers = $8, updated_at = NOW()
 		 WHERE id = $1`,
-		id, name, description, priceCents, isActive,
+		id, name, description, isActive, curve.Model, curve.BaseCents, curve.SlopeMilliCents, priceTiers(curve.Tiers),
 	)
 	return err
 }
 
+// priceTiers stores a nil tier list as an empty JSON array.
+func priceTiers(tiers []pricing.Tier) []pricing.Tier {
+	if tiers == nil {
+		return []pricing.Tier{}
+	}
+	return tiers
+}
+
 // --- Token Balances ---
 
 func (s *Store) GetTokenBalance(ctx context.Context, tokenID, userID string) (int, error) {
@@ -181,8 +220,18 @@ func (s *Store) MintTokens(ctx context.Context, tokenID, toUserID string, amount
 	}
 	defer tx.Rollback(ctx)
 
+	if err := mintTokens(ctx, tx, tokenID, toUserID, amount, txType, referenceID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// mintTokens credits amount to a balance, grows the total supply and records
+// the transaction within tx.
+func mintTokens(ctx context.Context, tx pgx.Tx, tokenID, toUserID string, amount int, txType, referenceID string) error {
 	// Upsert balance
-	_, err = tx.Exec(ctx,
+	_, err := tx.Exec(ctx,
 		`INSERT INTO token_balances (id, token_id, user_id, balance, updated_at)
 		 VALUES ($1, $2, $3, $4, NOW())
 		 ON CONFLICT (token_id, user_id) DO UPDATE SET balance = token_balances.balance + $4, updated_at = NOW()`,
@@ -211,14 +260,10 @@ func (s *Store) MintTokens(ctx context.Context, tokenID, toUserID string, amount
 		 VALUES ($1, $2, NULL, $3, $4, $5, $6, NOW())`,
 		cuid2.Generate(), tokenID, toUserID, amount, txType, refID,
 	)
-	if err != nil {
-		return err
-	}
-
-	return tx.Commit(ctx)
+	return err
 }
 
-func (s *Store) TransferTokens(ctx context.Context, tokenID, fromUserID, toUserID string, amount int) error {
+func (s *Store) TransferTokens(ctx context.Context, tokenID, fromUserID, toUserID string, amount int, events ...*OutboxEvent) error {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return err
@@ -269,6 +314,10 @@ func (s *Store) TransferTokens(ctx context.Context, tokenID, fromUserID, toUserI
 		return err
 	}
 
+	if err := writeOutbox(ctx, tx, events); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
@@ -318,6 +367,19 @@ func (s *Store) CreateTip(ctx context.Context, tip *Tip) error {
 	return err
 }
 
+func (s *Store) FindTipByID(ctx context.Context, id string) (*Tip, error) {
+	var tip Tip
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, from_user_id, to_user_id, amount_cents, message, stripe_payment_id, status, created_at
+		 FROM tips WHERE id = $1`, id,
+	).Scan(&tip.ID, &tip.FromUserID, &tip.ToUserID, &tip.AmountCents, &tip.Message,
+		&tip.StripePaymentID, &tip.Status, &tip.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &tip, err
+}
+
 func (s *Store) UpdateTipStatus(ctx context.Context, id, status string, stripePaymentID *string) error {
 	_, err := s.pool.Exec(ctx,
 		`UPDATE tips SET status = $2, stripe_payment_id = COALESCE($3, stripe_payment_id) WHERE id = $1`,
@@ -433,14 +495,16 @@ func (s *Store) GetTipStats(ctx context.Context, userID string) (*TipStats, erro
 
 // --- Fan Subscriptions ---
 
-func (s *Store) CreateFanSubscription(ctx context.Context, sub *FanSubscription) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO fan_subscriptions (id, fan_user_id, creator_user_id, tier, price_cents, stripe_subscription_id, status, started_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		sub.ID, sub.FanUserID, sub.CreatorUserID, sub.Tier, sub.PriceCents,
-		sub.StripeSubscriptionID, sub.Status, sub.StartedAt,
-	)
-	return err
+func (s *Store) CreateFanSubscription(ctx context.Context, sub *FanSubscription, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		_, err := db.Exec(ctx,
+			`INSERT INTO fan_subscriptions (id, fan_user_id, creator_user_id, tier, price_cents, stripe_subscription_id, status, started_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			sub.ID, sub.FanUserID, sub.CreatorUserID, sub.Tier, sub.PriceCents,
+			sub.StripeSubscriptionID, sub.Status, sub.StartedAt,
+		)
+		return err
+	})
 }
 
 func (s *Store) FindFanSubscription(ctx context.Context, fanUserID, creatorUserID string) (*FanSubscription, error) {
@@ -457,13 +521,15 @@ func (s *Store) FindFanSubscription(ctx context.Context, fanUserID, creatorUserI
 	return &sub, err
 }
 
-func (s *Store) UpdateFanSubscriptionStatus(ctx context.Context, id, status string) error {
-	q := `UPDATE fan_subscriptions SET status = $2 WHERE id = $1`
-	if status == "canceled" {
-		q = `UPDATE fan_subscriptions SET status = $2, canceled_at = NOW() WHERE id = $1`
-	}
-	_, err := s.pool.Exec(ctx, q, id, status)
-	return err
+func (s *Store) UpdateFanSubscriptionStatus(ctx context.Context, id, status string, events ...*OutboxEvent) error {
+	return s.withEvents(ctx, events, func(db dbtx) error {
+		q := `UPDATE fan_subscriptions SET status = $2 WHERE id = $1`
+		if status == "canceled" {
+			q = `UPDATE fan_subscriptions SET status = $2, canceled_at = NOW() WHERE id = $1`
+		}
+		_, err := db.Exec(ctx, q, id, status)
+		return err
+	})
 }
 
 func (s *Store) ListFansByCreator(ctx context.Context, creatorUserID string, limit, offset int) ([]FanSubscription, int, error) {
@@ -572,6 +638,22 @@ func (s *Store) CheckContentAccess(ctx context.Context, contentID, userID string
 		return true, nil
 	}
 
+	// Redeeming a file reward unlocks its content
+	var redeemed bool
+	err = s.pool.QueryRow(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM token_redemptions rd
+			JOIN token_rewards rw ON rw.id = rd.reward_id
+			WHERE rw.content_id = $1 AND rd.user_id = $2
+		)`, contentID, userID,
+	).Scan(&redeemed)
+	if err != nil {
+		return false, err
+	}
+	if redeemed {
+		return true, nil
+	}
+
 	// Check token balance if token gating is set
 	if gated.TokenID != nil {
 		balance, err := s.GetTokenBalance(ctx, *gated.TokenID, userID)
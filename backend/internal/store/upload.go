@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UploadSession tracks a resumable, chunked content upload.
+type UploadSession struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"userId"`
+	Title          string    `json:"title"`
+	Description    *string   `json:"description"`
+	Tags           []string  `json:"tags"`
+	IsPublic       bool      `json:"isPublic"`
+	MimeType       string    `json:"mimeType"`
+	TotalSize      int64     `json:"totalSize"`
+	ChunkSize      int       `json:"chunkSize"`
+	ChunksReceived int       `json:"chunksReceived"`
+	BytesReceived  int64     `json:"bytesReceived"`
+	HashState      []byte    `json:"-"`
+	ChunkURLs      []string  `json:"-"`
+	Status         string    `json:"status"`
+	ContentID      *string   `json:"contentId"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// TotalChunks returns the number of chunks the upload is split into.
+func (u *UploadSession) TotalChunks() int {
+	if u.TotalSize == 0 {
+		return 0
+	}
+	return int((u.TotalSize + int64(u.ChunkSize) - 1) / int64(u.ChunkSize))
+}
+
+// ChunkLength returns the expected size in bytes of chunk n.
+func (u *UploadSession) ChunkLength(n int) int64 {
+	remaining := u.TotalSize - int64(n)*int64(u.ChunkSize)
+	if remaining > int64(u.ChunkSize) {
+		return int64(u.ChunkSize)
+	}
+	return remaining
+}
+
+func (s *Store) CreateUploadSession(ctx context.Context, u *UploadSession) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO upload_sessions (id, user_id, title, description, tags, is_public, mime_type, total_size, chunk_size, status, created_at, updated_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		u.ID, u.UserID, u.Title, u.Description, u.Tags, u.IsPublic, u.MimeType,
+		u.TotalSize, u.ChunkSize, u.Status, u.CreatedAt, u.UpdatedAt, u.ExpiresAt,
+	)
+	return err
+}
+
+func (s *Store) FindUploadSession(ctx context.Context, id string) (*UploadSession, error) {
+	var u UploadSession
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, user_id, title, description, tags, is_public, mime_type, total_size, chunk_size,
+		        chunks_received, bytes_received, hash_state, chunk_urls, status, content_id, created_at, updated_at, expires_at
+		 FROM upload_sessions WHERE id = $1`, id,
+	).Scan(&u.ID, &u.UserID, &u.Title, &u.Description, &u.Tags, &u.IsPublic, &u.MimeType,
+		&u.TotalSize, &u.ChunkSize, &u.ChunksReceived, &u.BytesReceived, &u.HashState, &u.ChunkURLs,
+		&u.Status, &u.ContentID, &u.CreatedAt, &u.UpdatedAt, &u.ExpiresAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &u, err
+}
+
+// RecordUploadChunk advances a session by one chunk. The update only applies
+// if chunk is the next expected chunk, so concurrent or replayed requests for
+// the same chunk cannot both succeed. Returns false if the session moved on.
+func (s *Store) RecordUploadChunk(ctx context.Context, id string, chunk int, size int64, hashState []byte, chunkURL string) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE upload_sessions
+		 SET chunks_received = chunks_received + 1,
+		     bytes_received = bytes_received + $3,
+		     hash_state = $4,
+		     chunk_urls = array_append(chunk_urls, $5),
+		     updated_at = NOW()
+		 WHERE id = $1 AND chunks_received = $2 AND status = 'active'`,
+		id, chunk, size, hashState, chunkURL,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ClaimUploadSession moves an active session to "completing", so only one
+// request assembles it. Returns false if the session is no longer active.
+func (s *Store) ClaimUploadSession(ctx context.Context, id string) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE upload_sessions SET status = 'completing', updated_at = NOW() WHERE id = $1 AND status = 'active'`, id,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseUploadSession returns a claimed session to "active" after its
+// completion failed, so the client can retry.
+func (s *Store) ReleaseUploadSession(ctx context.Context, id string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE upload_sessions SET status = 'active', updated_at = NOW() WHERE id = $1 AND status = 'completing'`, id,
+	)
+	return err
+}
+
+// CompleteUploadSession marks a claimed session completed and links the
+// created content item.
+func (s *Store) CompleteUploadSession(ctx context.Context, id, contentID string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE upload_sessions SET status = 'completed', content_id = $2, chunk_urls = '{}', updated_at = NOW()
+		 WHERE id = $1 AND status = 'completing'`,
+		id, contentID,
+	)
+	return err
+}
+
+// AbortUploadSession aborts a session if it is still in the given status.
+// Returns false if it moved on, e.g. a completion claimed it.
+func (s *Store) AbortUploadSession(ctx context.Context, id, status string) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE upload_sessions SET status = 'aborted', chunk_urls = '{}', updated_at = NOW() WHERE id = $1 AND status = $2`,
+		id, status,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// completingTimeout is how long a session may stay claimed before it is
+// taken to have been left behind by a crashed completion.
+const completingTimeout = time.Hour
+
+// ListExpiredUploadSessions returns active sessions past their expiry, and
+// sessions whose completion was claimed but never finished.
+func (s *Store) ListExpiredUploadSessions(ctx context.Context, limit int) ([]*UploadSession, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, user_id, title, description, tags, is_public, mime_type, total_size, chunk_size,
+		        chunks_received, bytes_received, hash_state, chunk_urls, status, content_id, created_at, updated_at, expires_at
+		 FROM upload_sessions
+		 WHERE (status = 'active' AND expires_at < NOW())
+		    OR (status = 'completing' AND updated_at < $2)
+		 ORDER BY expires_at ASC
+		 LIMIT $1`, limit, time.Now().Add(-completingTimeout),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*UploadSession
+	for rows.Next() {
+		var u UploadSession
+		if err := rows.Scan(&u.ID, &u.UserID, &u.Title, &u.Description, &u.Tags, &u.IsPublic, &u.MimeType,
+			&u.TotalSize, &u.ChunkSize, &u.ChunksReceived, &u.BytesReceived, &u.HashState, &u.ChunkURLs,
+			&u.Status, &u.ContentID, &u.CreatedAt, &u.UpdatedAt, &u.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &u)
+	}
+	return sessions, nil
+}
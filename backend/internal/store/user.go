@@ -24,7 +24,7 @@ func (s *Store) FindUserByID(ctx context.Context, id string) (*model.User, error
 		`SELECT id, name, email, email_verified, image, username, bio, role,
 		        creator_score, is_verified, onboarded, theme, custom_links, email_prefs,
 		        stripe_connect_account_id, COALESCE(stripe_connect_onboarded, false),
-		        creator_tier, referral_code, referred_by,
+		        creator_tier, anchor_policy, referral_code, referred_by,
 		        COALESCE(totp_enabled, false),
 		        created_at, updated_at
 		 FROM users WHERE id = $1`, id,
@@ -33,7 +33,7 @@ func (s *Store) FindUserByID(ctx context.Context, id string) (*model.User, error
 		&u.Username, &u.Bio, &u.Role, &u.CreatorScore,
 		&u.IsVerified, &u.Onboarded, &u.Theme, &u.CustomLinks, &u.EmailPrefsRaw,
 		&u.StripeConnectAccountID, &u.StripeConnectOnboarded,
-		&u.CreatorTier, &u.ReferralCode, &u.ReferredBy,
+		&u.CreatorTier, &u.AnchorPolicy, &u.ReferralCode, &u.ReferredBy,
 		&u.TOTPEnabled,
 		&u.CreatedAt, &u.UpdatedAt,
 	)
@@ -49,7 +49,7 @@ func (s *Store) FindUserByEmail(ctx context.Context, email string) (*model.User,
 		`SELECT id, name, email, email_verified, image, username, bio, role,
 		        creator_score, is_verified, onboarded, theme, custom_links, email_prefs,
 		        stripe_connect_account_id, COALESCE(stripe_connect_onboarded, false),
-		        creator_tier, referral_code, referred_by,
+		        creator_tier, anchor_policy, referral_code, referred_by,
 		        COALESCE(totp_enabled, false),
 		        created_at, updated_at
 		 FROM users WHERE email = $1`, email,
@@ -58,7 +58,7 @@ func (s *Store) FindUserByEmail(ctx context.Context, email string) (*model.User,
 		&u.Username, &u.Bio, &u.Role, &u.CreatorScore,
 		&u.IsVerified, &u.Onboarded, &u.Theme, &u.CustomLinks, &u.EmailPrefsRaw,
 		&u.StripeConnectAccountID, &u.StripeConnectOnboarded,
-		&u.CreatorTier, &u.ReferralCode, &u.ReferredBy,
+		&u.CreatorTier, &u.AnchorPolicy, &u.ReferralCode, &u.ReferredBy,
 		&u.TOTPEnabled,
 		&u.CreatedAt, &u.UpdatedAt,
 	)
@@ -74,7 +74,7 @@ func (s *Store) FindUserByUsername(ctx context.Context, username string) (*model
 		`SELECT id, name, email, email_verified, image, username, bio, role,
 		        creator_score, is_verified, onboarded, theme, custom_links, email_prefs,
 		        stripe_connect_account_id, COALESCE(stripe_connect_onboarded, false),
-		        creator_tier, referral_code, referred_by,
+		        creator_tier, anchor_policy, referral_code, referred_by,
 		        COALESCE(totp_enabled, false),
 		        created_at, updated_at
 		 FROM users WHERE username = $1`, username,
@@ -83,7 +83,7 @@ func (s *Store) FindUserByUsername(ctx context.Context, username string) (*model
 		&u.Username, &u.Bio, &u.Role, &u.CreatorScore,
 		&u.IsVerified, &u.Onboarded, &u.Theme, &u.CustomLinks, &u.EmailPrefsRaw,
 		&u.StripeConnectAccountID, &u.StripeConnectOnboarded,
-		&u.CreatorTier, &u.ReferralCode, &u.ReferredBy,
+		&u.CreatorTier, &u.AnchorPolicy, &u.ReferralCode, &u.ReferredBy,
 		&u.TOTPEnabled,
 		&u.CreatedAt, &u.UpdatedAt,
 	)
@@ -155,6 +155,16 @@ func (s *Store) UpdateUserTier(ctx context.Context, id string, tier string) erro
 	return err
 }
 
+// UpdateUserAnchorPolicy sets which of the user's uploads are anchored
+// without being asked for.
+func (s *Store) UpdateUserAnchorPolicy(ctx context.Context, id string, policy string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE users SET anchor_policy = $1 WHERE id = $2`,
+		policy, id,
+	)
+	return err
+}
+
 func (s *Store) UpdateUserEmailPrefs(ctx context.Context, id string, prefs []byte) error {
 	_, err := s.pool.Exec(ctx,
 		`UPDATE users SET email_prefs = $1 WHERE id = $2`,
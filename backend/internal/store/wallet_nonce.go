@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WithWalletNonce calls send with the next nonce recorded for a wallet on a
+// chain, 0 if none is, and records the nonce send returns as the next one.
+// It holds a pg_advisory_xact_lock on the chain and wallet until send
+// returns, so processes sharing the wallet send one at a time. When send
+// fails nothing is recorded.
+func (s *Store) WithWalletNonce(ctx context.Context, chain, wallet string, send func(next int64) (int64, error)) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`, chain, wallet); err != nil {
+		return err
+	}
+
+	var next int64
+	err = tx.QueryRow(ctx,
+		`SELECT next_nonce FROM wallet_nonces WHERE chain = $1 AND wallet = $2`,
+		chain, wallet,
+	).Scan(&next)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	next, err = send(next)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO wallet_nonces (chain, wallet, next_nonce, updated_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (chain, wallet) DO UPDATE SET next_nonce = EXCLUDED.next_nonce, updated_at = NOW()`,
+		chain, wallet, next,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
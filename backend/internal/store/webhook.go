@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -16,6 +17,38 @@ type WebhookEndpoint struct {
 	Events    []string  `json:"events"`
 	IsActive  bool      `json:"isActive"`
 	CreatedAt time.Time `json:"createdAt"`
+	// PreviousSecret is the secret replaced by the last rotation. Deliveries
+	// are signed with it as well until PreviousSecretExpiresAt.
+	PreviousSecret          *string    `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt,omitempty"`
+	// Health. FailingSince is when the current run of failed attempts
+	// started; DisabledAt and DisabledReason are set when the endpoint was
+	// switched off automatically for failing too long.
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	FailingSince        *time.Time `json:"failingSince,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+	DisabledReason      *string    `json:"disabledReason,omitempty"`
+}
+
+// WebhookAttemptStats summarises an endpoint's delivery attempts over a
+// window. SuccessRate and P95LatencyMs are nil when there were none.
+type WebhookAttemptStats struct {
+	Attempts     int      `json:"attempts"`
+	Successes    int      `json:"successes"`
+	SuccessRate  *float64 `json:"successRate"`
+	P95LatencyMs *int     `json:"p95LatencyMs"`
+}
+
+// SigningSecrets returns the secrets deliveries should be signed with at
+// now: the current secret, then the previous one while its grace period
+// lasts.
+func (ep *WebhookEndpoint) SigningSecrets(now time.Time) []string {
+	secrets := []string{ep.Secret}
+	if ep.PreviousSecret != nil && ep.PreviousSecretExpiresAt != nil && now.Before(*ep.PreviousSecretExpiresAt) {
+		secrets = append(secrets, *ep.PreviousSecret)
+	}
+	return secrets
 }
 
 type WebhookDelivery struct {
@@ -44,7 +77,8 @@ func (s *Store) CreateWebhookEndpoint(ctx context.Context, ep *WebhookEndpoint)
 
 func (s *Store) ListWebhookEndpointsByUser(ctx context.Context, userID string) ([]*WebhookEndpoint, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, user_id, url, secret, events, is_active, created_at
+		`SELECT id, user_id, url, secret, events, is_active, created_at, previous_secret, previous_secret_expires_at,
+		        consecutive_failures, failing_since, last_success_at, disabled_at, disabled_reason
 		 FROM webhook_endpoints WHERE user_id = $1 ORDER BY created_at DESC`, userID,
 	)
 	if err != nil {
@@ -55,7 +89,9 @@ func (s *Store) ListWebhookEndpointsByUser(ctx context.Context, userID string) (
 	var endpoints []*WebhookEndpoint
 	for rows.Next() {
 		var ep WebhookEndpoint
-		if err := rows.Scan(&ep.ID, &ep.UserID, &ep.URL, &ep.Secret, &ep.Events, &ep.IsActive, &ep.CreatedAt); err != nil {
+		if err := rows.Scan(&ep.ID, &ep.UserID, &ep.URL, &ep.Secret, &ep.Events, &ep.IsActive, &ep.CreatedAt,
+			&ep.PreviousSecret, &ep.PreviousSecretExpiresAt,
+			&ep.ConsecutiveFailures, &ep.FailingSince, &ep.LastSuccessAt, &ep.DisabledAt, &ep.DisabledReason); err != nil {
 			return nil, err
 		}
 		endpoints = append(endpoints, &ep)
@@ -69,9 +105,12 @@ func (s *Store) ListWebhookEndpointsByUser(ctx context.Context, userID string) (
 func (s *Store) FindWebhookEndpointByID(ctx context.Context, id string) (*WebhookEndpoint, error) {
 	var ep WebhookEndpoint
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, user_id, url, secret, events, is_active, created_at
+		`SELECT id, user_id, url, secret, events, is_active, created_at, previous_secret, previous_secret_expires_at,
+		        consecutive_failures, failing_since, last_success_at, disabled_at, disabled_reason
 		 FROM webhook_endpoints WHERE id = $1`, id,
-	).Scan(&ep.ID, &ep.UserID, &ep.URL, &ep.Secret, &ep.Events, &ep.IsActive, &ep.CreatedAt)
+	).Scan(&ep.ID, &ep.UserID, &ep.URL, &ep.Secret, &ep.Events, &ep.IsActive, &ep.CreatedAt,
+		&ep.PreviousSecret, &ep.PreviousSecretExpiresAt,
+		&ep.ConsecutiveFailures, &ep.FailingSince, &ep.LastSuccessAt, &ep.DisabledAt, &ep.DisabledReason)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -86,6 +125,21 @@ func (s *Store) UpdateWebhookEndpoint(ctx context.Context, id, url string, event
 	return err
 }
 
+// RotateWebhookSecret replaces an endpoint's signing secret, keeping the old
+// one as the previous secret until previousExpiresAt. A nil expiry drops the
+// old secret immediately.
+func (s *Store) RotateWebhookSecret(ctx context.Context, id, secret string, previousExpiresAt *time.Time) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE webhook_endpoints
+		 SET previous_secret = CASE WHEN $2::timestamptz IS NULL THEN NULL ELSE secret END,
+		     previous_secret_expires_at = $2,
+		     secret = $1
+		 WHERE id = $3`,
+		secret, previousExpiresAt, id,
+	)
+	return err
+}
+
 func (s *Store) DeleteWebhookEndpoint(ctx context.Context, id string) error {
 	_, err := s.pool.Exec(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
 	return err
@@ -93,7 +147,8 @@ func (s *Store) DeleteWebhookEndpoint(ctx context.Context, id string) error {
 
 func (s *Store) ListActiveWebhookEndpointsForEvent(ctx context.Context, userID, eventType string) ([]*WebhookEndpoint, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, user_id, url, secret, events, is_active, created_at
+		`SELECT id, user_id, url, secret, events, is_active, created_at, previous_secret, previous_secret_expires_at,
+		        consecutive_failures, failing_since, last_success_at, disabled_at, disabled_reason
 		 FROM webhook_endpoints
 		 WHERE user_id = $1 AND is_active = true AND $2 = ANY(events)`,
 		userID, eventType,
@@ -106,7 +161,9 @@ func (s *Store) ListActiveWebhookEndpointsForEvent(ctx context.Context, userID,
 	var endpoints []*WebhookEndpoint
 	for rows.Next() {
 		var ep WebhookEndpoint
-		if err := rows.Scan(&ep.ID, &ep.UserID, &ep.URL, &ep.Secret, &ep.Events, &ep.IsActive, &ep.CreatedAt); err != nil {
+		if err := rows.Scan(&ep.ID, &ep.UserID, &ep.URL, &ep.Secret, &ep.Events, &ep.IsActive, &ep.CreatedAt,
+			&ep.PreviousSecret, &ep.PreviousSecretExpiresAt,
+			&ep.ConsecutiveFailures, &ep.FailingSince, &ep.LastSuccessAt, &ep.DisabledAt, &ep.DisabledReason); err != nil {
 			return nil, err
 		}
 		endpoints = append(endpoints, &ep)
@@ -127,6 +184,24 @@ func (s *Store) CreateWebhookDelivery(ctx context.Context, endpointID, eventType
 	return id, err
 }
 
+// QueueWebhookEvent creates a delivery of an event to each endpoint in one
+// statement, so either all are queued or none. Deliveries of an outbox event
+// are unique per endpoint: queueing the event again, e.g. when the relay
+// retries it, skips endpoints that already have it. outboxID is nil for
+// events that did not come from the outbox. It returns the number queued.
+func (s *Store) QueueWebhookEvent(ctx context.Context, outboxID *int64, endpointIDs []string, eventType string, payload json.RawMessage) (int, error) {
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO webhook_deliveries (endpoint_id, event_type, payload, outbox_id, created_at)
+		 SELECT endpoint_id, $2, $3, $4, $5 FROM unnest($1::text[]) AS endpoint_id
+		 ON CONFLICT (outbox_id, endpoint_id) WHERE outbox_id IS NOT NULL DO NOTHING`,
+		endpointIDs, eventType, payload, outboxID, time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
 func (s *Store) UpdateWebhookDelivery(ctx context.Context, id int64, status int, body string) error {
 	now := time.Now()
 	_, err := s.pool.Exec(ctx,
@@ -174,7 +249,7 @@ func (s *Store) ListPendingDeliveries(ctx context.Context, limit int) ([]*Webhoo
 		`SELECT id, endpoint_id, event_type, payload, response_status, response_body, delivered_at, created_at,
 		        COALESCE(attempts, 0), COALESCE(max_attempts, 5), next_retry_at, COALESCE(status, 'pending')
 		 FROM webhook_deliveries
-		 WHERE COALESCE(status, 'pending') = 'pending'
+		 WHERE status = 'pending'
 		   AND (next_retry_at IS NULL OR next_retry_at <= NOW())
 		 ORDER BY created_at ASC
 		 LIMIT $1`, limit,
@@ -195,6 +270,119 @@ func (s *Store) ListPendingDeliveries(ctx context.Context, limit int) ([]*Webhoo
 	return deliveries, nil
 }
 
+// claimLockID is the pg_advisory_xact_lock key held while claiming
+// deliveries, so per-endpoint in-flight counts are exact across replicas.
+const claimLockID = 7_243_318_202
+
+// ClaimPendingDeliveries leases up to limit ready deliveries to workerID for
+// the given duration. Each endpoint's pending deliveries form a queue in
+// creation order: only a due prefix of it is claimed, so a delivery waiting
+// out its retry backoff holds back every later one until it succeeds or
+// dies, and an endpoint never has more than perEndpoint deliveries leased at
+// once. Leases that have expired, for example because a worker crashed
+// mid-delivery, are reclaimed. Deliveries to inactive endpoints wait until
+// the endpoint is enabled again.
+func (s *Store) ClaimPendingDeliveries(ctx context.Context, workerID string, limit, perEndpoint int, lease time.Duration) ([]*WebhookDelivery, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, claimLockID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx,
+		`WITH inflight AS (
+			SELECT endpoint_id, COUNT(*) AS n
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND locked_until > NOW()
+			GROUP BY endpoint_id
+		), queued AS (
+			SELECT id, endpoint_id, created_at,
+			       ROW_NUMBER() OVER w AS rn,
+			       COUNT(*) FILTER (WHERE next_retry_at > NOW()) OVER w AS waiting
+			FROM webhook_deliveries
+			WHERE status = 'pending'
+			  AND (locked_until IS NULL OR locked_until <= NOW())
+			  AND endpoint_id IN (SELECT id FROM webhook_endpoints WHERE is_active)
+			WINDOW w AS (PARTITION BY endpoint_id ORDER BY created_at, id)
+		), picked AS (
+			SELECT q.id
+			FROM queued q
+			LEFT JOIN inflight i ON i.endpoint_id = q.endpoint_id
+			WHERE q.waiting = 0 AND q.rn <= $2 - COALESCE(i.n, 0)
+			ORDER BY q.created_at, q.id
+			LIMIT $3
+		)
+		UPDATE webhook_deliveries d
+		SET locked_by = $1, locked_until = NOW() + $4 * INTERVAL '1 millisecond'
+		FROM picked
+		WHERE d.id = picked.id
+		RETURNING d.id, d.endpoint_id, d.event_type, d.payload, d.response_status, d.response_body, d.delivered_at, d.created_at,
+		          COALESCE(d.attempts, 0), COALESCE(d.max_attempts, 5), d.next_retry_at, COALESCE(d.status, 'pending')`,
+		workerID, perEndpoint, limit, lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.EventType, &d.Payload, &d.ResponseStatus, &d.ResponseBody, &d.DeliveredAt, &d.CreatedAt, &d.Attempts, &d.MaxAttempts, &d.NextRetryAt, &d.Status); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	// RETURNING does not follow the CTE's ordering
+	sort.Slice(deliveries, func(i, j int) bool {
+		if deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].ID < deliveries[j].ID
+		}
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	return deliveries, nil
+}
+
+// CompleteDeliveryAttempt records the result of an attempt made under
+// workerID's lease and releases it. It returns false without recording
+// anything if the lease has since passed to another worker.
+func (s *Store) CompleteDeliveryAttempt(ctx context.Context, id int64, workerID, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) (bool, error) {
+	var deliveredAt *time.Time
+	if status == "success" {
+		now := time.Now()
+		deliveredAt = &now
+	}
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET attempts = COALESCE(attempts, 0) + 1,
+		     status = $3,
+		     response_status = $4,
+		     response_body = $5,
+		     delivered_at = $6,
+		     next_retry_at = $7,
+		     locked_by = NULL,
+		     locked_until = NULL
+		 WHERE id = $1 AND locked_by = $2`,
+		id, workerID, status, responseStatus, responseBody, deliveredAt, nextRetryAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // IncrementDeliveryAttempt records a delivery attempt with the result.
 func (s *Store) IncrementDeliveryAttempt(ctx context.Context, id int64, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) error {
 	var deliveredAt *time.Time
@@ -209,7 +397,9 @@ func (s *Store) IncrementDeliveryAttempt(ctx context.Context, id int64, status s
 		     response_status = $3,
 		     response_body = $4,
 		     delivered_at = $5,
-		     next_retry_at = $6
+		     next_retry_at = $6,
+		     locked_by = NULL,
+		     locked_until = NULL
 		 WHERE id = $1`,
 		id, status, responseStatus, responseBody, deliveredAt, nextRetryAt,
 	)
@@ -219,7 +409,7 @@ func (s *Store) IncrementDeliveryAttempt(ctx context.Context, id int64, status s
 // MarkDeliveryDead marks a delivery as dead (all retries exhausted).
 func (s *Store) MarkDeliveryDead(ctx context.Context, id int64) error {
 	_, err := s.pool.Exec(ctx,
-		`UPDATE webhook_deliveries SET status = 'dead', next_retry_at = NULL WHERE id = $1`, id,
+		`UPDATE webhook_deliveries SET status = 'dead', next_retry_at = NULL, locked_by = NULL, locked_until = NULL WHERE id = $1`, id,
 	)
 	return err
 }
@@ -227,11 +417,39 @@ func (s *Store) MarkDeliveryDead(ctx context.Context, id int64) error {
 // ResetDeliveryForRetry resets a failed or dead delivery back to pending for manual retry.
 func (s *Store) ResetDeliveryForRetry(ctx context.Context, id int64) error {
 	_, err := s.pool.Exec(ctx,
-		`UPDATE webhook_deliveries SET status = 'pending', next_retry_at = NULL, attempts = 0 WHERE id = $1`, id,
+		`UPDATE webhook_deliveries SET status = 'pending', next_retry_at = NULL, attempts = 0, locked_by = NULL, locked_until = NULL WHERE id = $1`, id,
 	)
 	return err
 }
 
+// RedeliveryFilter selects deliveries for a bulk redelivery. Nil times and
+// an empty event type match everything.
+type RedeliveryFilter struct {
+	Since     *time.Time
+	Until     *time.Time
+	EventType string
+	Statuses  []string
+}
+
+// RedeliverWebhookDeliveries resets an endpoint's deliveries matching the
+// filter back to pending, as ResetDeliveryForRetry does for one, and returns
+// how many it reset.
+func (s *Store) RedeliverWebhookDeliveries(ctx context.Context, endpointID string, f RedeliveryFilter) (int, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = 'pending', next_retry_at = NULL, attempts = 0, locked_by = NULL, locked_until = NULL
+		 WHERE endpoint_id = $1 AND status = ANY($2)
+		   AND ($3::timestamptz IS NULL OR created_at >= $3)
+		   AND ($4::timestamptz IS NULL OR created_at < $4)
+		   AND ($5 = '' OR event_type = $5)`,
+		endpointID, f.Statuses, f.Since, f.Until, f.EventType,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
 // FindWebhookDeliveryByID finds a single delivery by its ID.
 func (s *Store) FindWebhookDeliveryByID(ctx context.Context, id int64) (*WebhookDelivery, error) {
 	var d WebhookDelivery
@@ -245,3 +463,122 @@ func (s *Store) FindWebhookDeliveryByID(ctx context.Context, id int64) (*Webhook
 	}
 	return &d, err
 }
+
+// RecordEndpointAttempt logs a delivery attempt against its endpoint and
+// updates the endpoint's failure streak. It returns the streak afterwards:
+// the number of consecutive failed attempts and when the first of them was
+// made, both zero after a success.
+func (s *Store) RecordEndpointAttempt(ctx context.Context, endpointID string, deliveryID int64, success bool, responseStatus int, duration time.Duration) (int, *time.Time, error) {
+	var failures int
+	var failingSince *time.Time
+	err := s.pool.QueryRow(ctx,
+		`WITH attempt AS (
+			INSERT INTO webhook_attempts (endpoint_id, delivery_id, success, response_status, duration_ms, created_at)
+			VALUES ($1, $2, $3, NULLIF($4, 0), $5, NOW())
+		)
+		UPDATE webhook_endpoints
+		SET consecutive_failures = CASE WHEN $3 THEN 0 ELSE consecutive_failures + 1 END,
+		    failing_since = CASE WHEN $3 THEN NULL ELSE COALESCE(failing_since, NOW()) END,
+		    last_success_at = CASE WHEN $3 THEN NOW() ELSE last_success_at END
+		WHERE id = $1
+		RETURNING consecutive_failures, failing_since`,
+		endpointID, deliveryID, success, responseStatus, duration.Milliseconds(),
+	).Scan(&failures, &failingSince)
+	if err == pgx.ErrNoRows {
+		return 0, nil, nil
+	}
+	return failures, failingSince, err
+}
+
+// DisableWebhookEndpoint switches an active endpoint off for reason. It
+// returns false if the endpoint was already inactive, so only one caller
+// acts on the change.
+func (s *Store) DisableWebhookEndpoint(ctx context.Context, id, reason string) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE webhook_endpoints
+		 SET is_active = false, disabled_at = NOW(), disabled_reason = $2
+		 WHERE id = $1 AND is_active`,
+		id, reason,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EnableWebhookEndpoint switches an endpoint back on and clears its failure
+// streak. Deliveries that went dead during the streak are queued again from
+// scratch; it returns how many.
+func (s *Store) EnableWebhookEndpoint(ctx context.Context, id string) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var failingSince *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT failing_since FROM webhook_endpoints WHERE id = $1 FOR UPDATE`, id,
+	).Scan(&failingSince)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	if failingSince != nil {
+		tag, err := tx.Exec(ctx,
+			`UPDATE webhook_deliveries
+			 SET status = 'pending', attempts = 0, next_retry_at = NULL, locked_by = NULL, locked_until = NULL
+			 WHERE endpoint_id = $1 AND status = 'dead'
+			   AND id IN (SELECT delivery_id FROM webhook_attempts WHERE endpoint_id = $1 AND created_at >= $2)`,
+			id, failingSince,
+		)
+		if err != nil {
+			return 0, err
+		}
+		requeued = int(tag.RowsAffected())
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE webhook_endpoints
+		 SET is_active = true, consecutive_failures = 0, failing_since = NULL, disabled_at = NULL, disabled_reason = NULL
+		 WHERE id = $1`, id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return requeued, tx.Commit(ctx)
+}
+
+// GetWebhookAttemptStats summarises an endpoint's attempts since the given
+// time.
+func (s *Store) GetWebhookAttemptStats(ctx context.Context, endpointID string, since time.Time) (*WebhookAttemptStats, error) {
+	var stats WebhookAttemptStats
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE success),
+		        percentile_disc(0.95) WITHIN GROUP (ORDER BY duration_ms)
+		 FROM webhook_attempts
+		 WHERE endpoint_id = $1 AND created_at >= $2`,
+		endpointID, since,
+	).Scan(&stats.Attempts, &stats.Successes, &stats.P95LatencyMs)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Attempts > 0 {
+		rate := float64(stats.Successes) / float64(stats.Attempts)
+		stats.SuccessRate = &rate
+	}
+	return &stats, nil
+}
+
+// DeleteOldWebhookAttempts prunes attempt history older than the given time.
+func (s *Store) DeleteOldWebhookAttempts(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := s.pool.Exec(ctx, `DELETE FROM webhook_attempts WHERE created_at < $1`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -3,6 +3,7 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
@@ -11,36 +12,74 @@ import (
 
 // Dispatcher queues webhook events for delivery by the background worker.
 type Dispatcher struct {
-	store *store.Store
+	store store.WebhookRepository
 }
 
 // NewDispatcher creates a new webhook dispatcher.
-func NewDispatcher(st *store.Store) *Dispatcher {
+func NewDispatcher(st store.WebhookRepository) *Dispatcher {
 	return &Dispatcher{store: st}
 }
 
 // Dispatch queues a webhook event for all matching endpoints of a user.
-// It creates delivery records for the background worker to process.
+// It creates delivery records for the background worker to process. The
+// payload should be the event's payload type from the catalogue; events
+// outside the catalogue are dropped. A nil Dispatcher does nothing.
 func (d *Dispatcher) Dispatch(ctx context.Context, userID, eventType string, payload interface{}) {
+	if err := d.Enqueue(ctx, userID, eventType, payload); err != nil {
+		log.Printf("Webhook dispatch error for %s: %v", eventType, err)
+	}
+}
+
+// Enqueue is Dispatch that returns the error instead of logging it. The
+// event is queued for all endpoints or none.
+func (d *Dispatcher) Enqueue(ctx context.Context, userID, eventType string, payload interface{}) error {
+	return d.enqueue(ctx, nil, userID, eventType, payload)
+}
+
+// enqueue queues an event for the user's endpoints. With an outbox ID,
+// queueing the same event again skips endpoints that already have it.
+func (d *Dispatcher) enqueue(ctx context.Context, outboxID *int64, userID, eventType string, payload interface{}) error {
+	if d == nil {
+		return nil
+	}
+	ev, ok := LookupEvent(eventType)
+	if !ok {
+		return fmt.Errorf("unknown event %q", eventType)
+	}
+
 	endpoints, err := d.store.ListActiveWebhookEndpointsForEvent(ctx, userID, eventType)
-	if err != nil || len(endpoints) == 0 {
-		return
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return nil
 	}
 
 	payloadJSON, err := json.Marshal(map[string]interface{}{
 		"event":     eventType,
+		"version":   ev.Version,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"data":      payload,
 	})
 	if err != nil {
-		log.Printf("Webhook dispatch marshal error: %v", err)
-		return
+		return err
 	}
 
-	for _, ep := range endpoints {
-		_, err := d.store.CreateWebhookDelivery(ctx, ep.ID, eventType, payloadJSON)
-		if err != nil {
-			log.Printf("Webhook delivery creation error for endpoint %s: %v", ep.ID, err)
-		}
+	endpointIDs := make([]string, len(endpoints))
+	for i, ep := range endpoints {
+		endpointIDs[i] = ep.ID
+	}
+	_, err = d.store.QueueWebhookEvent(ctx, outboxID, endpointIDs, eventType, payloadJSON)
+	return err
+}
+
+// HandleEvent queues an outbox event for the user's webhook endpoints. Event
+// types outside the catalogue are internal and are ignored. A retried event
+// is not queued twice for the same endpoint. It has the shape of an
+// events.Subscriber.
+func (d *Dispatcher) HandleEvent(ctx context.Context, ev *store.OutboxEvent) error {
+	if !IsKnownEvent(ev.Type) {
+		return nil
 	}
+	return d.enqueue(ctx, &ev.ID, ev.UserID, ev.Type, ev.Data)
 }
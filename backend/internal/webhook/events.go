@@ -0,0 +1,308 @@
+package webhook
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Event names. Endpoints can only subscribe to events in the catalogue.
+const (
+	EventProfileViewed           = "profile.viewed"
+	EventContentUploaded         = "content.uploaded"
+	EventCollaborationReceived   = "collaboration.received"
+	EventTokenTransferred        = "token.transferred"
+	EventTokenRedeemed           = "token.redeemed"
+	EventTipReceived             = "tip.received"
+	EventLicenseSold             = "license.sold"
+	EventLicensePurchased        = "license.purchased"
+	EventFanSubscriptionStarted  = "fan_subscription.started"
+	EventFanSubscriptionCanceled = "fan_subscription.canceled"
+	EventAnchorConfirmed         = "anchor.confirmed"
+	EventAnchorFailed            = "anchor.failed"
+	EventModerationFlagRaised    = "moderation.flag_raised"
+	EventTakedownResolved        = "takedown.resolved"
+	EventConnectionRefreshed     = "connection.refreshed"
+	EventConnectionExpired       = "connection.expired"
+	EventPayoutUpdated           = "payout.updated"
+	EventPayoutCompleted         = "payout.completed"
+	EventOwnershipDisputed       = "ownership.disputed"
+	EventOwnershipResolved       = "ownership.resolved"
+)
+
+// Payload types. Each event's data follows its payload type; the desc tags
+// document the fields in the published catalogue. Adding an optional field
+// is backwards compatible. Renaming, removing or retyping one is not, and
+// must bump the event's version in the catalogue.
+
+// ProfileViewed is sent to a creator when their public profile is viewed.
+type ProfileViewed struct {
+	Username string `json:"username" desc:"Username of the viewed profile"`
+	Referrer string `json:"referrer" desc:"Referrer reported by the viewer, empty if none"`
+}
+
+// ContentUploaded is sent to a creator when they add an item to their vault.
+type ContentUploaded struct {
+	ContentID   string `json:"contentId" desc:"Content item ID"`
+	Title       string `json:"title" desc:"Content title"`
+	ContentType string `json:"contentType" desc:"Content type, such as image or video"`
+}
+
+// CollaborationReceived is sent to a creator when another creator sends them
+// a collaboration request.
+type CollaborationReceived struct {
+	RequestID  string `json:"requestId" desc:"Collaboration request ID"`
+	FromUser   string `json:"fromUser" desc:"Sender's username, empty if they have none"`
+	FromUserID string `json:"fromUserId" desc:"Sender's user ID"`
+	Message    string `json:"message" desc:"Message sent with the request"`
+}
+
+// TokenTransferred is sent to a token's creator when holders transfer it.
+type TokenTransferred struct {
+	TokenID    string `json:"tokenId" desc:"Creator token ID"`
+	Symbol     string `json:"symbol" desc:"Token symbol"`
+	FromUserID string `json:"fromUserId" desc:"Sending holder's user ID"`
+	ToUserID   string `json:"toUserId" desc:"Receiving holder's user ID"`
+	Amount     int    `json:"amount" desc:"Tokens transferred"`
+}
+
+// TokenRedeemed is sent to a token's creator when a holder redeems a reward.
+type TokenRedeemed struct {
+	TokenID      string `json:"tokenId" desc:"Creator token ID"`
+	Symbol       string `json:"symbol" desc:"Token symbol"`
+	RewardID     string `json:"rewardId" desc:"Redeemed reward ID"`
+	RewardTitle  string `json:"rewardTitle" desc:"Redeemed reward title"`
+	RedemptionID string `json:"redemptionId" desc:"Redemption ID"`
+	UserID       string `json:"userId" desc:"Redeeming holder's user ID"`
+	Cost         int    `json:"cost" desc:"Tokens spent"`
+}
+
+// TipReceived is sent to a creator once a tip to them has been paid.
+type TipReceived struct {
+	TipID       string  `json:"tipId" desc:"Tip ID"`
+	FromUserID  string  `json:"fromUserId" desc:"Tipper's user ID"`
+	AmountCents int     `json:"amountCents" desc:"Tip amount in US cents"`
+	Message     *string `json:"message" desc:"Message left with the tip"`
+}
+
+// LicenseSold is sent to a creator when someone buys a license to their
+// content.
+type LicenseSold struct {
+	PurchaseID         string `json:"purchaseId" desc:"License purchase ID"`
+	ContentID          string `json:"contentId" desc:"Licensed content item ID"`
+	OfferingID         string `json:"offeringId" desc:"License offering ID"`
+	BuyerUserID        string `json:"buyerUserId" desc:"Buyer's user ID"`
+	AmountCents        int    `json:"amountCents" desc:"Price paid in US cents"`
+	CreatorPayoutCents int    `json:"creatorPayoutCents" desc:"Creator's share after platform fees, in US cents"`
+}
+
+// LicensePurchased is sent to a buyer once their license purchase completes.
+type LicensePurchased struct {
+	PurchaseID  string `json:"purchaseId" desc:"License purchase ID"`
+	ContentID   string `json:"contentId" desc:"Licensed content item ID"`
+	OfferingID  string `json:"offeringId" desc:"License offering ID"`
+	AmountCents int    `json:"amountCents" desc:"Price paid in US cents"`
+}
+
+// FanSubscriptionStarted is sent to a creator when a fan subscribes to them.
+type FanSubscriptionStarted struct {
+	SubscriptionID string    `json:"subscriptionId" desc:"Fan subscription ID"`
+	FanUserID      string    `json:"fanUserId" desc:"Subscribing fan's user ID"`
+	Tier           string    `json:"tier" desc:"Subscription tier"`
+	PriceCents     int       `json:"priceCents" desc:"Monthly price in US cents"`
+	StartedAt      time.Time `json:"startedAt" desc:"When the subscription started"`
+}
+
+// FanSubscriptionCanceled is sent to a creator when a fan cancels their
+// subscription.
+type FanSubscriptionCanceled struct {
+	SubscriptionID string    `json:"subscriptionId" desc:"Fan subscription ID"`
+	FanUserID      string    `json:"fanUserId" desc:"Canceling fan's user ID"`
+	Tier           string    `json:"tier" desc:"Subscription tier"`
+	CanceledAt     time.Time `json:"canceledAt" desc:"When the subscription was canceled"`
+}
+
+// AnchorConfirmed is sent to a creator once a content hash they anchored is
+// confirmed on-chain.
+type AnchorConfirmed struct {
+	AnchorID    string    `json:"anchorId" desc:"Anchor ID"`
+	ContentID   string    `json:"contentId" desc:"Anchored content item ID"`
+	ContentHash string    `json:"contentHash" desc:"SHA-256 of the content, hex encoded"`
+	Chain       string    `json:"chain" desc:"Chain the hash was anchored on"`
+	TxHash      string    `json:"txHash" desc:"Anchoring transaction hash"`
+	BlockNumber int64     `json:"blockNumber" desc:"Block the transaction was included in"`
+	ConfirmedAt time.Time `json:"confirmedAt" desc:"Block timestamp"`
+}
+
+// AnchorFailed is sent to a creator when anchoring their content fails.
+type AnchorFailed struct {
+	AnchorID    string  `json:"anchorId" desc:"Anchor ID"`
+	ContentID   string  `json:"contentId" desc:"Content item ID"`
+	ContentHash string  `json:"contentHash" desc:"SHA-256 of the content, hex encoded"`
+	Chain       string  `json:"chain" desc:"Chain the hash was sent to"`
+	TxHash      *string `json:"txHash" desc:"Failed transaction hash, if one was sent"`
+	Error       string  `json:"error" desc:"Why anchoring failed"`
+}
+
+// ModerationFlagRaised is sent to a creator when one of their content items
+// is flagged for moderation review.
+type ModerationFlagRaised struct {
+	FlagID    string `json:"flagId" desc:"Moderation flag ID"`
+	ContentID string `json:"contentId" desc:"Flagged content item ID"`
+	Reason    string `json:"reason" desc:"Why the content was flagged"`
+	Details   string `json:"details" desc:"How the flag was raised"`
+}
+
+// TakedownResolved is sent to a creator when an admin resolves a takedown
+// request against their content.
+type TakedownResolved struct {
+	TakedownID string `json:"takedownId" desc:"Takedown request ID"`
+	ContentID  string `json:"contentId" desc:"Content item the request targets"`
+	Status     string `json:"status" desc:"Resolution: approved or denied"`
+	Notes      string `json:"notes" desc:"Admin's resolution notes"`
+}
+
+// ConnectionRefreshed is sent to a creator after one of their platform
+// connections is refreshed.
+type ConnectionRefreshed struct {
+	Platform      string `json:"platform" desc:"Connected platform"`
+	FollowerCount int    `json:"followerCount" desc:"Follower count after the refresh"`
+}
+
+// ConnectionExpired is sent to a creator when a platform connection can no
+// longer be refreshed and has to be reconnected.
+type ConnectionExpired struct {
+	Platform string `json:"platform" desc:"Connected platform"`
+	Reason   string `json:"reason" desc:"Why the refresh failed"`
+}
+
+// Payout is the payload of payout.updated and payout.completed.
+type Payout struct {
+	PayoutID         string  `json:"payoutId" desc:"Payout ID"`
+	Status           string  `json:"status" desc:"New payout status"`
+	PreviousStatus   string  `json:"previousStatus" desc:"Payout status before the change"`
+	AmountCents      int     `json:"amountCents" desc:"Payout amount in the smallest currency unit"`
+	Currency         string  `json:"currency" desc:"ISO currency code"`
+	StripeTransferID *string `json:"stripeTransferId" desc:"Stripe transfer ID"`
+	Error            *string `json:"error" desc:"Failure reason, if the payout failed"`
+}
+
+// OwnershipDisputed is sent to both creators when an upload is identical to
+// another creator's earlier content and goes to admin review.
+type OwnershipDisputed struct {
+	ConflictID        string `json:"conflictId" desc:"Ownership conflict ID"`
+	ContentID         string `json:"contentId" desc:"Disputed upload"`
+	OriginalContentID string `json:"originalContentId" desc:"Earlier content item with the same hash"`
+	ContentHash       string `json:"contentHash" desc:"SHA-256 both items share, hex encoded"`
+	Role              string `json:"role" desc:"Your side of the dispute: uploader or original"`
+}
+
+// OwnershipResolved is sent to both creators when an admin resolves an
+// ownership conflict between their content.
+type OwnershipResolved struct {
+	ConflictID        string `json:"conflictId" desc:"Ownership conflict ID"`
+	ContentID         string `json:"contentId" desc:"Later upload"`
+	OriginalContentID string `json:"originalContentId" desc:"Earlier content item"`
+	Status            string `json:"status" desc:"Resolution: upheld (the earlier claim stands) or dismissed"`
+	Notes             string `json:"notes" desc:"Admin's resolution notes"`
+	Role              string `json:"role" desc:"Your side of the conflict: uploader or original"`
+}
+
+// EventType is one entry in the event catalogue.
+type EventType struct {
+	Name        string  `json:"name"`
+	Version     int     `json:"version"`
+	Description string  `json:"description"`
+	Fields      []Field `json:"fields"`
+}
+
+// Field documents one field of an event's data.
+type Field struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Nullable    bool   `json:"nullable,omitempty"`
+	Description string `json:"description"`
+}
+
+var catalogue = []EventType{
+	event(EventProfileViewed, 1, "A visitor viewed your public profile.", ProfileViewed{}),
+	event(EventContentUploaded, 1, "You added a content item to your vault.", ContentUploaded{}),
+	event(EventCollaborationReceived, 1, "Another creator sent you a collaboration request.", CollaborationReceived{}),
+	event(EventTokenTransferred, 1, "Holders transferred your creator token.", TokenTransferred{}),
+	event(EventTokenRedeemed, 1, "A holder redeemed one of your token rewards.", TokenRedeemed{}),
+	event(EventTipReceived, 1, "A tip to you was paid.", TipReceived{}),
+	event(EventLicenseSold, 1, "Someone bought a license to your content.", LicenseSold{}),
+	event(EventLicensePurchased, 1, "Your license purchase completed.", LicensePurchased{}),
+	event(EventFanSubscriptionStarted, 1, "A fan subscribed to you.", FanSubscriptionStarted{}),
+	event(EventFanSubscriptionCanceled, 1, "A fan canceled their subscription to you.", FanSubscriptionCanceled{}),
+	event(EventAnchorConfirmed, 1, "A content hash you anchored was confirmed on-chain.", AnchorConfirmed{}),
+	event(EventAnchorFailed, 1, "Anchoring one of your content hashes failed.", AnchorFailed{}),
+	event(EventModerationFlagRaised, 1, "One of your content items was flagged for review.", ModerationFlagRaised{}),
+	event(EventTakedownResolved, 1, "A takedown request against your content was resolved.", TakedownResolved{}),
+	event(EventConnectionRefreshed, 1, "One of your platform connections was refreshed.", ConnectionRefreshed{}),
+	event(EventConnectionExpired, 1, "One of your platform connections expired and needs reconnecting.", ConnectionExpired{}),
+	event(EventPayoutUpdated, 1, "The status of one of your payouts changed.", Payout{}),
+	event(EventPayoutCompleted, 1, "One of your payouts completed.", Payout{}),
+	event(EventOwnershipDisputed, 1, "An upload was found identical to earlier content and is under review.", OwnershipDisputed{}),
+	event(EventOwnershipResolved, 1, "An admin resolved an ownership conflict over your content.", OwnershipResolved{}),
+}
+
+var catalogueByName = func() map[string]EventType {
+	m := make(map[string]EventType, len(catalogue))
+	for _, e := range catalogue {
+		m[e.Name] = e
+	}
+	return m
+}()
+
+// Catalogue returns every event endpoints can subscribe to.
+func Catalogue() []EventType {
+	return append([]EventType(nil), catalogue...)
+}
+
+// LookupEvent returns the catalogue entry for name.
+func LookupEvent(name string) (EventType, bool) {
+	e, ok := catalogueByName[name]
+	return e, ok
+}
+
+// IsKnownEvent reports whether name is in the catalogue.
+func IsKnownEvent(name string) bool {
+	_, ok := catalogueByName[name]
+	return ok
+}
+
+// event builds a catalogue entry, documenting its fields from the payload
+// type's json and desc tags.
+func event(name string, version int, description string, payload interface{}) EventType {
+	t := reflect.TypeOf(payload)
+	fields := make([]Field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		field := Field{Name: jsonName, Description: f.Tag.Get("desc")}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			field.Nullable = true
+			ft = ft.Elem()
+		}
+		switch {
+		case ft == reflect.TypeOf(time.Time{}):
+			field.Type, field.Format = "string", "date-time"
+		case ft.Kind() == reflect.String:
+			field.Type = "string"
+		case ft.Kind() == reflect.Bool:
+			field.Type = "boolean"
+		case ft.Kind() >= reflect.Int && ft.Kind() <= reflect.Uint64:
+			field.Type = "integer"
+		case ft.Kind() == reflect.Float32 || ft.Kind() == reflect.Float64:
+			field.Type = "number"
+		default:
+			panic("webhook: unsupported field type " + ft.String() + " in " + name)
+		}
+		fields = append(fields, field)
+	}
+	return EventType{Name: name, Version: version, Description: description, Fields: fields}
+}
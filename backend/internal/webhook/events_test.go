@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogue_IsDocumented(t *testing.T) {
+	seen := map[string]bool{}
+	for _, e := range Catalogue() {
+		assert.False(t, seen[e.Name], "duplicate event %s", e.Name)
+		seen[e.Name] = true
+		assert.GreaterOrEqual(t, e.Version, 1, e.Name)
+		assert.NotEmpty(t, e.Description, e.Name)
+		require.NotEmpty(t, e.Fields, e.Name)
+		for _, f := range e.Fields {
+			assert.NotEmpty(t, f.Name, e.Name)
+			assert.NotEmpty(t, f.Type, "%s.%s", e.Name, f.Name)
+			assert.NotEmpty(t, f.Description, "%s.%s has no desc tag", e.Name, f.Name)
+		}
+	}
+}
+
+func TestCatalogue_FieldsFollowPayloadTags(t *testing.T) {
+	e, ok := LookupEvent(EventAnchorFailed)
+	require.True(t, ok)
+	assert.Equal(t, []Field{
+		{Name: "anchorId", Type: "string", Description: "Anchor ID"},
+		{Name: "contentId", Type: "string", Description: "Content item ID"},
+		{Name: "contentHash", Type: "string", Description: "SHA-256 of the content, hex encoded"},
+		{Name: "chain", Type: "string", Description: "Chain the hash was sent to"},
+		{Name: "txHash", Type: "string", Nullable: true, Description: "Failed transaction hash, if one was sent"},
+		{Name: "error", Type: "string", Description: "Why anchoring failed"},
+	}, e.Fields)
+
+	e, _ = LookupEvent(EventAnchorConfirmed)
+	for _, f := range e.Fields {
+		switch f.Name {
+		case "blockNumber":
+			assert.Equal(t, "integer", f.Type)
+		case "confirmedAt":
+			assert.Equal(t, "string", f.Type)
+			assert.Equal(t, "date-time", f.Format)
+		}
+	}
+}
+
+func TestIsKnownEvent(t *testing.T) {
+	assert.True(t, IsKnownEvent(EventTipReceived))
+	assert.True(t, IsKnownEvent("license.sold"))
+	assert.False(t, IsKnownEvent("tip.recieved"))
+	assert.False(t, IsKnownEvent(""))
+}
+
+func TestDispatch_VersionedEnvelope(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	require.NoError(t, st.CreateWebhookEndpoint(ctx, &store.WebhookEndpoint{
+		ID: "ep1", UserID: "u1", URL: "http://example.invalid", Events: []string{EventTipReceived}, IsActive: true,
+	}))
+
+	d := NewDispatcher(st)
+	d.Dispatch(ctx, "u1", EventTipReceived, TipReceived{TipID: "tip1", FromUserID: "u2", AmountCents: 500})
+	d.Dispatch(ctx, "u1", EventProfileViewed, ProfileViewed{Username: "someone"})
+
+	deliveries, total, err := st.ListWebhookDeliveries(ctx, "ep1", 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, total, "only subscribed events are queued")
+
+	var envelope struct {
+		Event   string          `json:"event"`
+		Version int             `json:"version"`
+		Data    json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &envelope))
+	assert.Equal(t, EventTipReceived, envelope.Event)
+	assert.Equal(t, 1, envelope.Version)
+	assert.JSONEq(t, `{"tipId":"tip1","fromUserId":"u2","amountCents":500,"message":null}`, string(envelope.Data))
+}
+
+func TestDispatch_DropsUnknownEvents(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	require.NoError(t, st.CreateWebhookEndpoint(ctx, &store.WebhookEndpoint{
+		ID: "ep1", UserID: "u1", URL: "http://example.invalid", Events: []string{"made.up"}, IsActive: true,
+	}))
+
+	NewDispatcher(st).Dispatch(ctx, "u1", "made.up", map[string]string{})
+	_, total, err := st.ListWebhookDeliveries(ctx, "ep1", 10, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	var nilDispatcher *Dispatcher
+	assert.NotPanics(t, func() { nilDispatcher.Dispatch(ctx, "u1", EventTipReceived, TipReceived{}) })
+}
+
+func TestHandleEvent_QueuesOutboxEvents(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	require.NoError(t, st.CreateWebhookEndpoint(ctx, &store.WebhookEndpoint{
+		ID: "ep1", UserID: "u1", URL: "http://example.invalid", Events: []string{EventTipReceived}, IsActive: true,
+	}))
+
+	d := NewDispatcher(st)
+	require.NoError(t, d.HandleEvent(ctx, &store.OutboxEvent{
+		Type: EventTipReceived, UserID: "u1", Data: json.RawMessage(`{"tipId":"tip1","amountCents":500}`),
+	}))
+	require.NoError(t, d.HandleEvent(ctx, &store.OutboxEvent{Type: "collaboration.responded", UserID: "u1"}),
+		"internal events are skipped, not failed")
+
+	deliveries, total, err := st.ListWebhookDeliveries(ctx, "ep1", 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &envelope))
+	assert.JSONEq(t, `{"tipId":"tip1","amountCents":500}`, string(envelope.Data))
+}
+
+func TestHandleEvent_RetryQueuesOncePerEndpoint(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	for _, id := range []string{"ep1", "ep2"} {
+		require.NoError(t, st.CreateWebhookEndpoint(ctx, &store.WebhookEndpoint{
+			ID: id, UserID: "u1", URL: "http://example.invalid", Events: []string{EventTipReceived}, IsActive: true,
+		}))
+	}
+
+	d := NewDispatcher(st)
+	ev := &store.OutboxEvent{ID: 7, Type: EventTipReceived, UserID: "u1", Data: json.RawMessage(`{"tipId":"tip1"}`)}
+	require.NoError(t, d.HandleEvent(ctx, ev))
+	require.NoError(t, d.HandleEvent(ctx, ev), "the relay retries the event")
+
+	for _, id := range []string{"ep1", "ep2"} {
+		_, total, err := st.ListWebhookDeliveries(ctx, id, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total, id)
+	}
+}
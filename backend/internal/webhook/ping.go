@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/nrednav/cuid2"
+)
+
+// EventPing is the synthetic event sent by a test ping. It is outside the
+// catalogue, so endpoints cannot subscribe to it and it is never queued.
+const EventPing = "ping"
+
+// Ping is the data of a test ping.
+type Ping struct {
+	EndpointID string `json:"endpointId"`
+	Message    string `json:"message"`
+}
+
+// PingResult is how an endpoint answered a test ping. StatusCode is 0 and
+// Error is set when no response came back.
+type PingResult struct {
+	DeliveryID string `json:"deliveryId"`
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"statusCode"`
+	Body       string `json:"body"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// SendPing sends a signed ping to the endpoint with client straight away,
+// bypassing the delivery queue, and reports what came back. It sends to
+// inactive endpoints too, so owners can check one is reachable before
+// re-enabling it.
+func SendPing(ctx context.Context, client *http.Client, ep *store.WebhookEndpoint) (*PingResult, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":     EventPing,
+		"version":   1,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"data":      Ping{EndpointID: ep.ID, Message: "Test ping from Creatrid"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result := &PingResult{DeliveryID: "ping_" + cuid2.Generate()}
+	req, err := newRequest(reqCtx, ep, result.DeliveryID, EventPing, payload)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.StatusCode = resp.StatusCode
+	result.Body = string(body)
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/pkg/safehttp"
+	"github.com/creatrid/creatrid/pkg/webhooksig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendPing_SignedAndReportsResponse(t *testing.T) {
+	var gotEvent string
+	var verifyErr error
+	var envelope struct {
+		Event string `json:"event"`
+		Data  Ping   `json:"data"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotEvent = r.Header.Get("X-Webhook-Event")
+		verifyErr = webhooksig.Verify(body, r.Header.Get(webhooksig.HeaderName), "whsec_test", webhooksig.DefaultTolerance)
+		_ = json.Unmarshal(body, &envelope)
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("not today"))
+	}))
+	defer srv.Close()
+
+	ep := &store.WebhookEndpoint{ID: "ep1", URL: srv.URL, Secret: "whsec_test"}
+	result, err := SendPing(context.Background(), srv.Client(), ep)
+	require.NoError(t, err)
+
+	assert.NoError(t, verifyErr)
+	assert.Equal(t, EventPing, gotEvent)
+	assert.Equal(t, EventPing, envelope.Event)
+	assert.Equal(t, "ep1", envelope.Data.EndpointID)
+
+	assert.False(t, result.Success)
+	assert.Equal(t, http.StatusTeapot, result.StatusCode)
+	assert.Equal(t, "not today", result.Body)
+	assert.Empty(t, result.Error)
+	assert.Contains(t, result.DeliveryID, "ping_")
+}
+
+func TestSendPing_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	result, err := SendPing(context.Background(), http.DefaultClient, &store.WebhookEndpoint{ID: "ep1", URL: url, Secret: "whsec_test"})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Zero(t, result.StatusCode)
+	assert.NotEmpty(t, result.Error)
+}
+
+func TestSendPing_SafeClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("ping reached a loopback address")
+	}))
+	defer srv.Close()
+
+	client := safehttp.NewClient(safehttp.Options{})
+	result, err := SendPing(context.Background(), client, &store.WebhookEndpoint{ID: "ep1", URL: srv.URL, Secret: "whsec_test"})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, safehttp.ErrBlockedAddress.Error())
+}
@@ -3,16 +3,18 @@ package webhook
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/pkg/safehttp"
+	"github.com/creatrid/creatrid/pkg/webhooksig"
+	"github.com/nrednav/cuid2"
 )
 
 // retryIntervals defines exponential backoff durations for each retry attempt.
@@ -24,32 +26,78 @@ var retryIntervals = []time.Duration{
 	2 * time.Hour,
 }
 
-// Worker is a background worker that polls for pending webhook deliveries
-// and dispatches them to their target endpoints with retries.
+const (
+	// DefaultConcurrency is how many deliveries a worker sends at once.
+	DefaultConcurrency = 8
+	// DefaultPerEndpoint caps in-flight deliveries per endpoint across all
+	// workers. At one, each endpoint receives its deliveries strictly in the
+	// order they were created: a failing delivery holds back later ones
+	// through its retries until it succeeds or dies.
+	DefaultPerEndpoint = 1
+
+	// leaseDuration is how long a claimed delivery belongs to a worker. It is
+	// well over the 10 second request timeout, so a lease only lapses when a
+	// worker dies mid-delivery.
+	leaseDuration = 2 * time.Minute
+
+	// minFailuresToDisable keeps a handful of isolated failures spread over
+	// the disable window from counting as an outage.
+	minFailuresToDisable = 5
+)
+
+// DisabledFunc is called once for each endpoint the worker switches off for
+// failing continuously, with the reason recorded on the endpoint.
+type DisabledFunc func(ctx context.Context, ep *store.WebhookEndpoint, reason string)
+
+// Worker is a background worker that claims pending webhook deliveries and
+// dispatches them to their target endpoints with retries. Deliveries are
+// leased in the database, so any number of replicas can run a worker without
+// sending the same delivery twice.
 type Worker struct {
-	store    *store.Store
-	client   *http.Client
-	interval time.Duration
-	stopCh   chan struct{}
+	store       store.WebhookRepository
+	client      *http.Client
+	interval    time.Duration
+	id          string
+	perEndpoint int
+	slots       chan struct{}
+	wake        chan struct{}
+	wg          sync.WaitGroup
+	stopCh      chan struct{}
+
+	disableAfter time.Duration
+	onDisabled   DisabledFunc
 }
 
-// NewWorker creates a new webhook delivery worker.
-func NewWorker(st *store.Store) *Worker {
+// NewWorker creates a new webhook delivery worker that sends up to
+// concurrency deliveries at once and no more than perEndpoint to any single
+// endpoint. Values below one use the defaults.
+func NewWorker(st store.WebhookRepository, concurrency, perEndpoint int) *Worker {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+	if perEndpoint < 1 {
+		perEndpoint = DefaultPerEndpoint
+	}
+	host, _ := os.Hostname()
 	return &Worker{
-		store: st,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		interval: 5 * time.Second,
-		stopCh:   make(chan struct{}),
+		store:       st,
+		client:      safehttp.NewClient(safehttp.Options{}),
+		interval:    5 * time.Second,
+		id:          fmt.Sprintf("%s-%s", host, cuid2.Generate()),
+		perEndpoint: perEndpoint,
+		slots:       make(chan struct{}, concurrency),
+		wake:        make(chan struct{}, 1),
+		stopCh:      make(chan struct{}),
 	}
 }
 
-// Start begins the polling loop. It blocks until the context is canceled or Stop is called.
+// Start begins the polling loop. It blocks until the context is canceled or
+// Stop is called, then waits for in-flight deliveries to finish.
 func (w *Worker) Start(ctx context.Context) {
-	log.Println("Webhook delivery worker started")
+	log.Printf("Webhook delivery worker %s started (concurrency %d, %d per endpoint)", w.id, cap(w.slots), w.perEndpoint)
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
+	defer w.wg.Wait()
 
 	for {
 		select {
@@ -61,25 +109,65 @@ func (w *Worker) Start(ctx context.Context) {
 			return
 		case <-ticker.C:
 			w.processPending(ctx)
+		case <-w.wake:
+			w.processPending(ctx)
 		}
 	}
 }
 
+// AutoDisable makes the worker switch off endpoints whose every attempt has
+// failed for at least window, calling notify once for each. A window of zero
+// leaves failing endpoints on. It must be called before Start.
+func (w *Worker) AutoDisable(window time.Duration, notify DisabledFunc) {
+	w.disableAfter = window
+	w.onDisabled = notify
+}
+
+// SetClient replaces the client deliveries are sent with. The default
+// refuses private addresses but allows plain http. It must be called before
+// Start.
+func (w *Worker) SetClient(c *http.Client) {
+	w.client = c
+}
+
 // Stop signals the worker to stop its polling loop.
 func (w *Worker) Stop() {
 	close(w.stopCh)
 }
 
-// processPending fetches up to 10 pending deliveries and delivers each one.
+// processPending claims as many ready deliveries as there are idle slots and
+// sends each on its own goroutine. A slow endpoint holds at most perEndpoint
+// slots, so it cannot starve deliveries to other endpoints.
 func (w *Worker) processPending(ctx context.Context) {
-	deliveries, err := w.store.ListPendingDeliveries(ctx, 10)
+	free := cap(w.slots) - len(w.slots)
+	if free == 0 {
+		return
+	}
+
+	deliveries, err := w.store.ClaimPendingDeliveries(ctx, w.id, free, w.perEndpoint, leaseDuration)
 	if err != nil {
-		log.Printf("Webhook worker: failed to list pending deliveries: %v", err)
+		log.Printf("Webhook worker: failed to claim pending deliveries: %v", err)
 		return
 	}
 
+	// In-flight deliveries run to completion even once ctx is canceled, so
+	// shutting down does not burn an attempt or strand a lease.
+	deliveryCtx := context.WithoutCancel(ctx)
 	for _, d := range deliveries {
-		w.deliver(ctx, d)
+		w.slots <- struct{}{}
+		w.wg.Add(1)
+		go func(d *store.WebhookDelivery) {
+			defer func() {
+				<-w.slots
+				w.wg.Done()
+				// Claim the endpoint's next delivery without waiting for the ticker
+				select {
+				case w.wake <- struct{}{}:
+				default:
+				}
+			}()
+			w.deliver(deliveryCtx, d)
+		}(d)
 	}
 }
 
@@ -93,46 +181,90 @@ func (w *Worker) deliver(ctx context.Context, delivery *store.WebhookDelivery) {
 		return
 	}
 
-	// Sign the payload with HMAC-SHA256
-	mac := hmac.New(sha256.New, []byte(ep.Secret))
-	mac.Write(delivery.Payload)
-	signature := hex.EncodeToString(mac.Sum(nil))
-
 	// Create the HTTP request
 	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, ep.URL, bytes.NewReader(delivery.Payload))
+	req, err := newRequest(reqCtx, ep, fmt.Sprintf("%d", delivery.ID), delivery.EventType, delivery.Payload)
 	if err != nil {
 		log.Printf("Webhook worker: failed to create request for delivery %d: %v", delivery.ID, err)
 		w.handleFailure(ctx, delivery, 0, err.Error())
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Webhook-Signature", fmt.Sprintf("sha256=%s", signature))
-	req.Header.Set("X-Webhook-Event", delivery.EventType)
-	req.Header.Set("X-Webhook-ID", fmt.Sprintf("%d", delivery.ID))
-
 	// Execute the request
+	start := time.Now()
 	resp, err := w.client.Do(req)
 	if err != nil {
+		w.recordHealth(ctx, ep, delivery.ID, false, 0, time.Since(start))
 		w.handleFailure(ctx, delivery, 0, err.Error())
 		return
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	elapsed := time.Since(start)
 	bodyStr := string(bodyBytes)
 
 	// Check if the response indicates success (2xx)
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		_ = w.store.IncrementDeliveryAttempt(ctx, delivery.ID, "success", resp.StatusCode, bodyStr, nil)
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	w.recordHealth(ctx, ep, delivery.ID, ok, resp.StatusCode, elapsed)
+	if ok {
+		w.complete(ctx, delivery, "success", resp.StatusCode, bodyStr, nil)
 	} else {
 		w.handleFailure(ctx, delivery, resp.StatusCode, bodyStr)
 	}
 }
 
+// newRequest builds a signed POST of payload to the endpoint.
+func newRequest(ctx context.Context, ep *store.WebhookEndpoint, id, eventType string, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	// Sign with a fresh timestamp on every attempt so retries stay within
+	// the receiver's replay window. While a secret is being rotated the
+	// header carries a signature for both the new and the old secret.
+	now := time.Now()
+	req.Header.Set(webhooksig.HeaderName, webhooksig.Header(payload, now, ep.SigningSecrets(now)...))
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set("X-Webhook-ID", id)
+	return req, nil
+}
+
+// recordHealth logs an attempt against its endpoint and switches the
+// endpoint off once it has failed continuously for the disable window.
+func (w *Worker) recordHealth(ctx context.Context, ep *store.WebhookEndpoint, deliveryID int64, success bool, responseStatus int, elapsed time.Duration) {
+	failures, failingSince, err := w.store.RecordEndpointAttempt(ctx, ep.ID, deliveryID, success, responseStatus, elapsed)
+	if err != nil {
+		log.Printf("Webhook worker: failed to record health for endpoint %s: %v", ep.ID, err)
+		return
+	}
+	if success || w.disableAfter <= 0 || failingSince == nil || failures < minFailuresToDisable {
+		return
+	}
+	if time.Since(*failingSince) < w.disableAfter {
+		return
+	}
+
+	reason := fmt.Sprintf("%d consecutive failed deliveries since %s", failures, failingSince.UTC().Format(time.RFC3339))
+	disabled, err := w.store.DisableWebhookEndpoint(ctx, ep.ID, reason)
+	if err != nil {
+		log.Printf("Webhook worker: failed to disable endpoint %s: %v", ep.ID, err)
+		return
+	}
+	if !disabled {
+		// Already off, and another worker has sent the notification
+		return
+	}
+	log.Printf("Webhook worker: disabled endpoint %s after %s", ep.ID, reason)
+	if w.onDisabled != nil {
+		w.onDisabled(ctx, ep, reason)
+	}
+}
+
 // handleFailure records a failed delivery attempt and schedules retries or marks as dead.
 func (w *Worker) handleFailure(ctx context.Context, delivery *store.WebhookDelivery, responseStatus int, responseBody string) {
 	nextAttempt := delivery.Attempts + 1 // current attempt count after this failure
@@ -143,7 +275,7 @@ func (w *Worker) handleFailure(ctx context.Context, delivery *store.WebhookDeliv
 
 	if nextAttempt >= maxAttempts {
 		// All retries exhausted
-		_ = w.store.IncrementDeliveryAttempt(ctx, delivery.ID, "dead", responseStatus, responseBody, nil)
+		w.complete(ctx, delivery, "dead", responseStatus, responseBody, nil)
 		log.Printf("Webhook worker: delivery %d exhausted all %d attempts, marked dead", delivery.ID, maxAttempts)
 		return
 	}
@@ -158,6 +290,18 @@ func (w *Worker) handleFailure(ctx context.Context, delivery *store.WebhookDeliv
 	}
 	nextRetry := time.Now().Add(retryIntervals[retryIdx])
 
-	_ = w.store.IncrementDeliveryAttempt(ctx, delivery.ID, "pending", responseStatus, responseBody, &nextRetry)
+	w.complete(ctx, delivery, "pending", responseStatus, responseBody, &nextRetry)
 	log.Printf("Webhook worker: delivery %d attempt %d failed, retrying at %s", delivery.ID, nextAttempt, nextRetry.Format(time.RFC3339))
 }
+
+// complete records an attempt's result and releases the lease.
+func (w *Worker) complete(ctx context.Context, delivery *store.WebhookDelivery, status string, responseStatus int, responseBody string, nextRetryAt *time.Time) {
+	held, err := w.store.CompleteDeliveryAttempt(ctx, delivery.ID, w.id, status, responseStatus, responseBody, nextRetryAt)
+	if err != nil {
+		log.Printf("Webhook worker: failed to record attempt for delivery %d: %v", delivery.ID, err)
+		return
+	}
+	if !held {
+		log.Printf("Webhook worker: lease on delivery %d expired before its attempt was recorded", delivery.ID)
+	}
+}
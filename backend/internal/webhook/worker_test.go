@@ -0,0 +1,521 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/internal/store"
+	"github.com/creatrid/creatrid/internal/store/storetest"
+	"github.com/creatrid/creatrid/pkg/safehttp"
+	"github.com/creatrid/creatrid/pkg/webhooksig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a webhook receiver that remembers which deliveries it saw, in
+// order, per endpoint path.
+type recorder struct {
+	mu   sync.Mutex
+	seen map[string][]string
+	hits map[string]int
+	// block, when set for a path, holds each request to it until a value is
+	// sent on the channel or it is closed
+	block map[string]chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{seen: map[string][]string{}, hits: map[string]int{}, block: map[string]chan struct{}{}}
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	ch := rec.block[r.URL.Path]
+	rec.mu.Unlock()
+	if ch != nil {
+		<-ch
+	}
+
+	id := r.Header.Get("X-Webhook-ID")
+	rec.mu.Lock()
+	rec.seen[r.URL.Path] = append(rec.seen[r.URL.Path], id)
+	rec.hits[id]++
+	rec.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rec *recorder) ids(path string) []string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]string(nil), rec.seen[path]...)
+}
+
+func seedEndpoint(t *testing.T, st *storetest.Store, id, url string) {
+	t.Helper()
+	require.NoError(t, st.CreateWebhookEndpoint(context.Background(), &store.WebhookEndpoint{
+		ID: id, UserID: "u1", URL: url, Secret: "whsec_test", Events: []string{"test"}, IsActive: true, CreatedAt: time.Now(),
+	}))
+}
+
+func seedDeliveries(t *testing.T, st *storetest.Store, endpointID string, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := 0; i < n; i++ {
+		id, err := st.CreateWebhookDelivery(context.Background(), endpointID, "test", []byte(`{}`))
+		require.NoError(t, err)
+		ids = append(ids, fmt.Sprintf("%d", id))
+	}
+	return ids
+}
+
+// newTestWorker returns a worker allowed to reach the loopback test servers.
+func newTestWorker(st store.WebhookRepository, concurrency, perEndpoint int) *Worker {
+	w := NewWorker(st, concurrency, perEndpoint)
+	w.SetClient(safehttp.NewClient(safehttp.Options{AllowPrivate: true}))
+	return w
+}
+
+func startWorker(t *testing.T, w *Worker) {
+	t.Helper()
+	w.interval = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestWorker_DeliversAndRecordsSuccess(t *testing.T) {
+	st := storetest.New()
+	rec := newRecorder()
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	seedEndpoint(t, st, "ep1", srv.URL+"/a")
+	ids := seedDeliveries(t, st, "ep1", 3)
+
+	startWorker(t, newTestWorker(st, 4, 1))
+
+	require.Eventually(t, func() bool { return len(rec.ids("/a")) == 3 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ids, rec.ids("/a"), "deliveries to one endpoint arrive in order")
+
+	require.Eventually(t, func() bool {
+		deliveries, _, _ := st.ListWebhookDeliveries(context.Background(), "ep1", 10, 0)
+		for _, d := range deliveries {
+			if d.Status != "success" {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestWorker_SlowEndpointDoesNotBlockOthers(t *testing.T) {
+	st := storetest.New()
+	rec := newRecorder()
+	release := make(chan struct{})
+	rec.block["/slow"] = release
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	defer close(release)
+
+	seedEndpoint(t, st, "slow", srv.URL+"/slow")
+	seedEndpoint(t, st, "fast", srv.URL+"/fast")
+	slowIDs := seedDeliveries(t, st, "slow", 3)
+	fastIDs := seedDeliveries(t, st, "fast", 5)
+
+	startWorker(t, newTestWorker(st, 2, 1))
+
+	require.Eventually(t, func() bool { return len(rec.ids("/fast")) == 5 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, fastIDs, rec.ids("/fast"))
+	assert.Empty(t, rec.ids("/slow"))
+
+	release <- struct{}{}
+	release <- struct{}{}
+	release <- struct{}{}
+	require.Eventually(t, func() bool { return len(rec.ids("/slow")) == 3 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, slowIDs, rec.ids("/slow"))
+}
+
+func TestWorker_ReplicasDeliverEachOnce(t *testing.T) {
+	st := storetest.New()
+	rec := newRecorder()
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	total := 0
+	for i := 0; i < 5; i++ {
+		ep := fmt.Sprintf("ep%d", i)
+		seedEndpoint(t, st, ep, fmt.Sprintf("%s/%d", srv.URL, i))
+		total += len(seedDeliveries(t, st, ep, 6))
+	}
+
+	startWorker(t, newTestWorker(st, 3, 2))
+	startWorker(t, newTestWorker(st, 3, 2))
+	startWorker(t, newTestWorker(st, 3, 2))
+
+	require.Eventually(t, func() bool {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		return len(rec.hits) == total
+	}, 3*time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	for id, n := range rec.hits {
+		assert.Equal(t, 1, n, "delivery %s", id)
+	}
+}
+
+func TestWorker_FailureSchedulesRetry(t *testing.T) {
+	st := storetest.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	seedEndpoint(t, st, "ep1", srv.URL)
+	seedDeliveries(t, st, "ep1", 1)
+
+	w := newTestWorker(st, 1, 1)
+	w.processPending(context.Background())
+	w.wg.Wait()
+
+	d, err := st.FindWebhookDeliveryByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	require.NotNil(t, d.NextRetryAt)
+	assert.True(t, d.NextRetryAt.After(time.Now()))
+
+	// Not ready again until the backoff has passed.
+	claimed, err := st.ClaimPendingDeliveries(context.Background(), "other", 10, 1, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestWorker_FailingDeliveryHoldsBackLaterOnes(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		resolve func(t *testing.T, st *storetest.Store, head int64, failing *atomic.Bool)
+		want    []string
+	}{
+		{"head succeeds", func(t *testing.T, st *storetest.Store, head int64, failing *atomic.Bool) {
+			failing.Store(false)
+			// As if its backoff had passed
+			require.NoError(t, st.ResetDeliveryForRetry(context.Background(), head))
+		}, []string{"1", "1", "2"}},
+		{"head dies", func(t *testing.T, st *storetest.Store, head int64, failing *atomic.Bool) {
+			failing.Store(false)
+			require.NoError(t, st.MarkDeliveryDead(context.Background(), head))
+		}, []string{"1", "2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := storetest.New()
+			var failing atomic.Bool
+			failing.Store(true)
+			var mu sync.Mutex
+			var seen []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				seen = append(seen, r.Header.Get("X-Webhook-ID"))
+				mu.Unlock()
+				if failing.Load() {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer srv.Close()
+
+			seedEndpoint(t, st, "ep1", srv.URL)
+			w := newTestWorker(st, 4, 1)
+			run := func() {
+				w.processPending(ctx)
+				w.wg.Wait()
+			}
+
+			seedDeliveries(t, st, "ep1", 1)
+			run()
+			seedDeliveries(t, st, "ep1", 1)
+			run()
+			run()
+
+			newer, err := st.FindWebhookDeliveryByID(ctx, 2)
+			require.NoError(t, err)
+			assert.Equal(t, 0, newer.Attempts, "the newer delivery waits behind the failing one")
+
+			tc.resolve(t, st, 1, &failing)
+			run()
+			run()
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, tc.want, seen)
+		})
+	}
+}
+
+func TestStore_ClaimRespectsLeases(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	seedEndpoint(t, st, "ep1", "http://example.invalid")
+	seedDeliveries(t, st, "ep1", 3)
+
+	a, err := st.ClaimPendingDeliveries(ctx, "a", 10, 2, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, a, 2)
+	assert.Equal(t, []int64{1, 2}, []int64{a[0].ID, a[1].ID})
+
+	b, err := st.ClaimPendingDeliveries(ctx, "b", 10, 2, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, b, "endpoint is at its in-flight cap")
+
+	held, err := st.CompleteDeliveryAttempt(ctx, 1, "b", "success", 200, "", nil)
+	require.NoError(t, err)
+	assert.False(t, held, "only the lease holder can complete")
+
+	held, err = st.CompleteDeliveryAttempt(ctx, 1, "a", "success", 200, "", nil)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	b, err = st.ClaimPendingDeliveries(ctx, "b", 10, 2, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, b, 1)
+	assert.Equal(t, int64(3), b[0].ID)
+}
+
+func TestStore_ClaimReclaimsExpiredLeases(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	seedEndpoint(t, st, "ep1", "http://example.invalid")
+	seedDeliveries(t, st, "ep1", 1)
+
+	// A worker that died holding an already-lapsed lease.
+	a, err := st.ClaimPendingDeliveries(ctx, "a", 10, 1, -time.Second)
+	require.NoError(t, err)
+	require.Len(t, a, 1)
+
+	b, err := st.ClaimPendingDeliveries(ctx, "b", 10, 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, b, 1)
+	assert.Equal(t, a[0].ID, b[0].ID)
+
+	held, err := st.CompleteDeliveryAttempt(ctx, a[0].ID, "a", "success", 200, "", nil)
+	require.NoError(t, err)
+	assert.False(t, held, "the original worker lost its lease")
+}
+
+func TestWorker_SignsWithBothSecretsDuringRotation(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	headers := make(chan string, 2)
+	bodies := make(chan []byte, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		headers <- r.Header.Get(webhooksig.HeaderName)
+		bodies <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	seedEndpoint(t, st, "ep1", srv.URL)
+	grace := time.Now().Add(time.Hour)
+	require.NoError(t, st.RotateWebhookSecret(ctx, "ep1", "whsec_new", &grace))
+
+	seedDeliveries(t, st, "ep1", 1)
+	w := newTestWorker(st, 1, 1)
+	w.processPending(ctx)
+	w.wg.Wait()
+
+	header, body := <-headers, <-bodies
+	assert.NoError(t, webhooksig.Verify(body, header, "whsec_new", webhooksig.DefaultTolerance))
+	assert.NoError(t, webhooksig.Verify(body, header, "whsec_test", webhooksig.DefaultTolerance))
+
+	// Rotating with no grace period retires the old secret at once.
+	require.NoError(t, st.RotateWebhookSecret(ctx, "ep1", "whsec_newer", nil))
+	seedDeliveries(t, st, "ep1", 1)
+	w.processPending(ctx)
+	w.wg.Wait()
+
+	header, body = <-headers, <-bodies
+	assert.NoError(t, webhooksig.Verify(body, header, "whsec_newer", webhooksig.DefaultTolerance))
+	assert.ErrorIs(t, webhooksig.Verify(body, header, "whsec_new", webhooksig.DefaultTolerance), webhooksig.ErrNoMatch)
+}
+
+func TestWorker_AutoDisablesFailingEndpoint(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	seedEndpoint(t, st, "ep1", srv.URL)
+	seedDeliveries(t, st, "ep1", minFailuresToDisable+2)
+
+	var notified []string
+	w := newTestWorker(st, 1, 1)
+	w.AutoDisable(time.Nanosecond, func(ctx context.Context, ep *store.WebhookEndpoint, reason string) {
+		notified = append(notified, ep.ID)
+	})
+	for i := 0; i < minFailuresToDisable+2; i++ {
+		w.processPending(ctx)
+		w.wg.Wait()
+		// The failing first delivery holds back the rest; retry it at once
+		require.NoError(t, st.ResetDeliveryForRetry(ctx, 1))
+	}
+
+	assert.Equal(t, []string{"ep1"}, notified, "owner is told once")
+	ep, err := st.FindWebhookEndpointByID(ctx, "ep1")
+	require.NoError(t, err)
+	assert.False(t, ep.IsActive)
+	assert.Equal(t, minFailuresToDisable, ep.ConsecutiveFailures, "nothing is sent once disabled")
+	require.NotNil(t, ep.DisabledReason)
+	assert.Contains(t, *ep.DisabledReason, "consecutive failed deliveries")
+
+	stats, err := st.GetWebhookAttemptStats(ctx, "ep1", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, minFailuresToDisable, stats.Attempts)
+	require.NotNil(t, stats.SuccessRate)
+	assert.Zero(t, *stats.SuccessRate)
+}
+
+func TestWorker_SuccessResetsFailureStreak(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	var mu sync.Mutex
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	seedEndpoint(t, st, "ep1", srv.URL)
+	seedDeliveries(t, st, "ep1", 4)
+
+	w := newTestWorker(st, 1, 1)
+	w.AutoDisable(time.Hour, func(context.Context, *store.WebhookEndpoint, string) {
+		t.Error("endpoint should not be disabled")
+	})
+	for i := 0; i < 3; i++ {
+		w.processPending(ctx)
+		w.wg.Wait()
+		require.NoError(t, st.ResetDeliveryForRetry(ctx, 1))
+	}
+	ep, _ := st.FindWebhookEndpointByID(ctx, "ep1")
+	assert.Equal(t, 3, ep.ConsecutiveFailures)
+	assert.NotNil(t, ep.FailingSince)
+
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+	w.processPending(ctx)
+	w.wg.Wait()
+
+	ep, _ = st.FindWebhookEndpointByID(ctx, "ep1")
+	assert.True(t, ep.IsActive)
+	assert.Zero(t, ep.ConsecutiveFailures)
+	assert.Nil(t, ep.FailingSince)
+	assert.NotNil(t, ep.LastSuccessAt)
+}
+
+func TestStore_EnableRequeuesDeadBacklog(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	seedEndpoint(t, st, "ep1", "http://example.invalid")
+	seedDeliveries(t, st, "ep1", 3)
+
+	// Delivery 1 died before the outage and is left alone.
+	require.NoError(t, st.MarkDeliveryDead(ctx, 1))
+	for _, id := range []int64{2, 3} {
+		_, _, err := st.RecordEndpointAttempt(ctx, "ep1", id, false, 500, time.Millisecond)
+		require.NoError(t, err)
+		require.NoError(t, st.MarkDeliveryDead(ctx, id))
+	}
+	disabled, err := st.DisableWebhookEndpoint(ctx, "ep1", "down")
+	require.NoError(t, err)
+	require.True(t, disabled)
+
+	claimed, err := st.ClaimPendingDeliveries(ctx, "a", 10, 5, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	requeued, err := st.EnableWebhookEndpoint(ctx, "ep1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, requeued)
+
+	claimed, err = st.ClaimPendingDeliveries(ctx, "a", 10, 5, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, []int64{2, 3}, []int64{claimed[0].ID, claimed[1].ID})
+}
+
+func TestStore_RedeliverFilters(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	seedEndpoint(t, st, "ep1", "http://example.invalid")
+	seedEndpoint(t, st, "ep2", "http://example.invalid")
+	seedDeliveries(t, st, "ep1", 3)
+	other, err := st.CreateWebhookDelivery(ctx, "ep1", "other", []byte(`{}`))
+	require.NoError(t, err)
+	seedDeliveries(t, st, "ep2", 1)
+
+	for _, id := range []int64{1, 2, other, 5} {
+		require.NoError(t, st.MarkDeliveryDead(ctx, id))
+	}
+	require.NoError(t, st.IncrementDeliveryAttempt(ctx, 3, "failed", 500, "", nil))
+
+	n, err := st.RedeliverWebhookDeliveries(ctx, "ep1", store.RedeliveryFilter{EventType: "test", Statuses: []string{"dead"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "only dead test deliveries on ep1")
+
+	future := time.Now().Add(time.Hour)
+	n, err = st.RedeliverWebhookDeliveries(ctx, "ep1", store.RedeliveryFilter{Since: &future, Statuses: []string{"failed", "dead"}})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = st.RedeliverWebhookDeliveries(ctx, "ep1", store.RedeliveryFilter{Statuses: []string{"failed", "dead"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	d, _ := st.FindWebhookDeliveryByID(ctx, 5)
+	assert.Equal(t, "dead", d.Status, "other endpoints are untouched")
+}
+
+func TestWorker_DefaultClientRefusesPrivateAddresses(t *testing.T) {
+	ctx := context.Background()
+	st := storetest.New()
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	seedEndpoint(t, st, "ep1", srv.URL)
+	seedDeliveries(t, st, "ep1", 1)
+
+	w := NewWorker(st, 1, 1)
+	w.processPending(ctx)
+	w.wg.Wait()
+
+	assert.False(t, hit)
+	d, err := st.FindWebhookDeliveryByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", d.Status)
+	require.NotNil(t, d.ResponseBody)
+	assert.Contains(t, *d.ResponseBody, safehttp.ErrBlockedAddress.Error())
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_search_bio;
+DROP INDEX IF EXISTS idx_users_search_username;
+DROP INDEX IF EXISTS idx_users_search_name;
@@ -0,0 +1,16 @@
+-- Purchases awaiting Stripe payment confirmation. Tokens are minted and tips
+-- completed only once payment_intent.succeeded arrives for the row.
+CREATE TABLE IF NOT EXISTS pending_purchases (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL, -- token_purchase, tip
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_id TEXT REFERENCES creator_tokens(id),
+    tip_id TEXT REFERENCES tips(id),
+    amount INT NOT NULL DEFAULT 0,
+    amount_cents BIGINT NOT NULL,
+    stripe_payment_intent_id TEXT NOT NULL UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, succeeded, failed, refunded
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_pending_purchases_user ON pending_purchases(user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS token_redemptions;
+DROP TABLE IF EXISTS token_rewards;
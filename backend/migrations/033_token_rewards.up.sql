@@ -0,0 +1,29 @@
+-- Creator-defined rewards that holders redeem by burning tokens
+CREATE TABLE IF NOT EXISTS token_rewards (
+    id TEXT PRIMARY KEY,
+    token_id TEXT NOT NULL REFERENCES creator_tokens(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    kind TEXT NOT NULL DEFAULT 'custom', -- shoutout, file, custom
+    content_id TEXT REFERENCES content_items(id) ON DELETE SET NULL,
+    cost INT NOT NULL,
+    quantity_remaining INT, -- NULL means unlimited
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_token_rewards_token ON token_rewards(token_id);
+
+CREATE TABLE IF NOT EXISTS token_redemptions (
+    id TEXT PRIMARY KEY,
+    reward_id TEXT NOT NULL REFERENCES token_rewards(id) ON DELETE CASCADE,
+    token_id TEXT NOT NULL REFERENCES creator_tokens(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cost INT NOT NULL,
+    note TEXT,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, fulfilled
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    fulfilled_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_token_redemptions_token ON token_redemptions(token_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_token_redemptions_user ON token_redemptions(user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE creator_tokens DROP COLUMN IF EXISTS price_tiers;
+ALTER TABLE creator_tokens DROP COLUMN IF EXISTS price_slope_millicents;
+ALTER TABLE creator_tokens DROP COLUMN IF EXISTS pricing_model;
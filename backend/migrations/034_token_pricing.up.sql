@@ -0,0 +1,5 @@
+-- Supply-dependent pricing for creator tokens. price_cents is the flat price,
+-- or the first-unit price of a linear curve; tiers are [{fromSupply, priceCents}].
+ALTER TABLE creator_tokens ADD COLUMN IF NOT EXISTS pricing_model TEXT NOT NULL DEFAULT 'flat'; -- flat, linear, tiered
+ALTER TABLE creator_tokens ADD COLUMN IF NOT EXISTS price_slope_millicents INT NOT NULL DEFAULT 0;
+ALTER TABLE creator_tokens ADD COLUMN IF NOT EXISTS price_tiers JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,10 @@
+-- Snapshot of a connection's metrics, appended on every profile refresh
+CREATE TABLE IF NOT EXISTS connection_metrics (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform TEXT NOT NULL,
+    follower_count INT,
+    metadata JSONB,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_connection_metrics_user ON connection_metrics(user_id, platform, recorded_at DESC);
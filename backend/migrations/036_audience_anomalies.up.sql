@@ -0,0 +1,21 @@
+-- Suspicious follower patterns found when a connection is refreshed, queued
+-- for admin review
+CREATE TABLE IF NOT EXISTS audience_anomalies (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    details TEXT,
+    previous_followers INT,
+    current_followers INT,
+    status TEXT NOT NULL DEFAULT 'pending',
+    resolved_by TEXT,
+    resolved_at TIMESTAMPTZ,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_audience_anomalies_status ON audience_anomalies(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audience_anomalies_user ON audience_anomalies(user_id);
+-- One open anomaly of each kind per connection; repeat detections are dropped
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audience_anomalies_open ON audience_anomalies(user_id, platform, kind) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_ready;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS locked_by;
@@ -0,0 +1,6 @@
+-- Lease columns so several worker replicas can claim deliveries without
+-- sending any twice. A claimed delivery is owned by locked_by until
+-- locked_until, after which another worker may reclaim it.
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS locked_by TEXT;
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_ready ON webhook_deliveries(endpoint_id, created_at, id) WHERE status = 'pending';
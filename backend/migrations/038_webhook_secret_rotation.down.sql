@@ -0,0 +1,2 @@
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS previous_secret_expires_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS previous_secret;
@@ -0,0 +1,4 @@
+-- Keep the replaced signing secret for a grace period after a rotation so
+-- deliveries can be signed with both until receivers switch over.
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS previous_secret TEXT;
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS previous_secret_expires_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS webhook_attempts;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS last_success_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS failing_since;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS consecutive_failures;
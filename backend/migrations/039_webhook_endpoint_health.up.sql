@@ -0,0 +1,18 @@
+-- Endpoint health: failure streaks on the endpoint itself, and one row per
+-- delivery attempt for success rates and latency percentiles.
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS consecutive_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS failing_since TIMESTAMPTZ;
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS last_success_at TIMESTAMPTZ;
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS disabled_reason TEXT;
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    endpoint_id TEXT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    delivery_id BIGINT NOT NULL,
+    success BOOLEAN NOT NULL,
+    response_status INT,
+    duration_ms INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_endpoint ON webhook_attempts(endpoint_id, created_at DESC);
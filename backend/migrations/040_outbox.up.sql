@@ -0,0 +1,20 @@
+-- Transactional outbox: domain events are written in the same transaction
+-- as the change they describe, then fanned out by the relay. done lists the
+-- subscribers that have already handled an event, so a retry only reruns
+-- the ones that failed.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    done TEXT[] NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ,
+    locked_by TEXT,
+    locked_until TIMESTAMPTZ,
+    processed_at TIMESTAMPTZ,
+    failed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(id) WHERE processed_at IS NULL AND failed_at IS NULL;
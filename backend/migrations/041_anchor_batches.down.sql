@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_content_anchors_queued;
+DROP INDEX IF EXISTS idx_content_anchors_batch;
+ALTER TABLE content_anchors DROP COLUMN IF EXISTS merkle_proof;
+ALTER TABLE content_anchors DROP COLUMN IF EXISTS leaf_index;
+ALTER TABLE content_anchors DROP COLUMN IF EXISTS batch_id;
+DROP TABLE IF EXISTS anchor_batches;
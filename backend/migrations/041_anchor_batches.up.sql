@@ -0,0 +1,22 @@
+-- Merkle-batched anchoring: content hashes queue up as pending anchors with
+-- no transaction, and the batcher anchors the root of a tree over a batch of
+-- them in one transaction. Each anchor keeps its path to the root.
+CREATE TABLE IF NOT EXISTS anchor_batches (
+    id TEXT PRIMARY KEY,
+    merkle_root TEXT NOT NULL,
+    leaf_count INT NOT NULL,
+    tx_hash TEXT,
+    chain TEXT NOT NULL,
+    block_number BIGINT,
+    status TEXT NOT NULL DEFAULT 'pending',
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    confirmed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_anchor_batches_pending ON anchor_batches(created_at) WHERE status = 'pending';
+
+ALTER TABLE content_anchors ADD COLUMN IF NOT EXISTS batch_id TEXT REFERENCES anchor_batches(id) ON DELETE SET NULL;
+ALTER TABLE content_anchors ADD COLUMN IF NOT EXISTS leaf_index INT;
+ALTER TABLE content_anchors ADD COLUMN IF NOT EXISTS merkle_proof JSONB;
+CREATE INDEX IF NOT EXISTS idx_content_anchors_batch ON content_anchors(batch_id);
+CREATE INDEX IF NOT EXISTS idx_content_anchors_queued ON content_anchors(created_at) WHERE anchor_status = 'pending' AND tx_hash IS NULL AND batch_id IS NULL;
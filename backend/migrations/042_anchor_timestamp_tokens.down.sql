@@ -0,0 +1 @@
+ALTER TABLE anchor_batches DROP COLUMN IF EXISTS timestamp_token;
@@ -0,0 +1,3 @@
+-- Batches anchored with an RFC 3161 timestamp authority keep the signed
+-- token for their root; tx_hash holds the token's serial number.
+ALTER TABLE anchor_batches ADD COLUMN IF NOT EXISTS timestamp_token BYTEA;
@@ -0,0 +1,19 @@
+-- Every transaction sent for an anchor batch. A stuck transaction is
+-- replaced by one with the same nonce and higher fees; the batch points at
+-- the latest, and the history keeps the rest, since any of them may be the
+-- one that is mined.
+CREATE TABLE IF NOT EXISTS anchor_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id TEXT NOT NULL REFERENCES anchor_batches(id) ON DELETE CASCADE,
+    tx_hash TEXT NOT NULL,
+    nonce BIGINT,
+    gas_limit BIGINT,
+    gas_tip_cap TEXT,
+    gas_fee_cap TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    replaced_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_anchor_transactions_batch ON anchor_transactions(batch_id, id);
+
+INSERT INTO anchor_transactions (batch_id, tx_hash, created_at)
+SELECT id, tx_hash, created_at FROM anchor_batches WHERE tx_hash IS NOT NULL;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_anchor_batches_pending;
+CREATE INDEX IF NOT EXISTS idx_anchor_batches_pending ON anchor_batches(created_at) WHERE status = 'pending';
+
+UPDATE content_anchors SET anchor_status = 'confirmed' WHERE anchor_status = 'finalized';
+UPDATE content_anchors SET anchor_status = 'pending' WHERE anchor_status = 'included';
+UPDATE anchor_batches SET status = 'confirmed' WHERE status = 'finalized';
+UPDATE anchor_batches SET status = 'pending' WHERE status = 'included';
+
+ALTER TABLE anchor_batches DROP COLUMN IF EXISTS finalized_at;
+ALTER TABLE anchor_batches DROP COLUMN IF EXISTS block_hash;
+ALTER TABLE content_anchors DROP COLUMN IF EXISTS finalized_at;
+ALTER TABLE content_anchors DROP COLUMN IF EXISTS block_hash;
@@ -0,0 +1,13 @@
+-- Anchors become final only once their block is deep enough to survive a
+-- reorg: pending → included (in a block) → finalized. What was confirmed
+-- is treated as final.
+ALTER TABLE content_anchors ADD COLUMN IF NOT EXISTS block_hash TEXT;
+ALTER TABLE content_anchors ADD COLUMN IF NOT EXISTS finalized_at TIMESTAMPTZ;
+ALTER TABLE anchor_batches ADD COLUMN IF NOT EXISTS block_hash TEXT;
+ALTER TABLE anchor_batches ADD COLUMN IF NOT EXISTS finalized_at TIMESTAMPTZ;
+
+UPDATE content_anchors SET anchor_status = 'finalized', finalized_at = confirmed_at WHERE anchor_status = 'confirmed';
+UPDATE anchor_batches SET status = 'finalized', finalized_at = confirmed_at WHERE status = 'confirmed';
+
+DROP INDEX IF EXISTS idx_anchor_batches_pending;
+CREATE INDEX IF NOT EXISTS idx_anchor_batches_pending ON anchor_batches(created_at) WHERE status IN ('pending', 'included');
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_content_anchors_user_created;
+ALTER TABLE content_anchors DROP COLUMN IF EXISTS policy;
+ALTER TABLE content_collections DROP COLUMN IF EXISTS anchor_policy;
+ALTER TABLE users DROP COLUMN IF EXISTS anchor_policy;
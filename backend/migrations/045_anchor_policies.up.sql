@@ -0,0 +1,7 @@
+-- Anchoring policies: creators, and collections for the items added to
+-- them, choose which items are anchored without being asked. Anchors record
+-- the policy that queued them, and count against a monthly quota.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anchor_policy TEXT NOT NULL DEFAULT 'never';
+ALTER TABLE content_collections ADD COLUMN IF NOT EXISTS anchor_policy TEXT;
+ALTER TABLE content_anchors ADD COLUMN IF NOT EXISTS policy TEXT;
+CREATE INDEX IF NOT EXISTS idx_content_anchors_user_created ON content_anchors(user_id, created_at);
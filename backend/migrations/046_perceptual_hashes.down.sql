@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ownership_conflicts;
+DROP TABLE IF EXISTS content_fingerprints;
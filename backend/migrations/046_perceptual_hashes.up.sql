@@ -0,0 +1,29 @@
+-- Perceptual (dHash) fingerprints of uploaded images. phash_bands holds the
+-- hash's eight bytes, each tagged with its position (position << 8 | byte);
+-- two hashes within 7 bits of each other share at least one, so the GIN
+-- index finds every near match.
+CREATE TABLE IF NOT EXISTS content_fingerprints (
+    content_id TEXT PRIMARY KEY REFERENCES content_items(id) ON DELETE CASCADE,
+    phash BIGINT NOT NULL,
+    phash_bands INTEGER[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_content_fingerprints_bands ON content_fingerprints USING GIN (phash_bands);
+
+-- Uploads that match another creator's earlier content, for admins to review.
+CREATE TABLE IF NOT EXISTS ownership_conflicts (
+    id TEXT PRIMARY KEY,
+    content_id TEXT NOT NULL REFERENCES content_items(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    original_content_id TEXT NOT NULL REFERENCES content_items(id) ON DELETE CASCADE,
+    original_user_id TEXT NOT NULL,
+    match_type TEXT NOT NULL,
+    distance INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_by TEXT,
+    resolved_at TIMESTAMPTZ,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (content_id, original_content_id)
+);
+CREATE INDEX IF NOT EXISTS idx_ownership_conflicts_status ON ownership_conflicts (status, created_at);
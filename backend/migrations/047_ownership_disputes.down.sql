@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_ownership_conflicts_content;
+DROP INDEX IF EXISTS idx_content_hash_created;
+ALTER TABLE content_items DROP COLUMN IF EXISTS dispute_status;
@@ -0,0 +1,5 @@
+-- An upload identical to another creator's earlier content is disputed
+-- until an admin resolves the ownership conflict it opens.
+ALTER TABLE content_items ADD COLUMN IF NOT EXISTS dispute_status TEXT;
+CREATE INDEX IF NOT EXISTS idx_content_hash_created ON content_items (hash_sha256, created_at);
+CREATE INDEX IF NOT EXISTS idx_ownership_conflicts_content ON ownership_conflicts (content_id);
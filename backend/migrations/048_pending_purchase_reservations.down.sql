@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_pending_purchases_token;
@@ -0,0 +1,2 @@
+-- Pending token purchases are summed per token to price new purchases.
+CREATE INDEX IF NOT EXISTS idx_pending_purchases_token ON pending_purchases(token_id, status, created_at);
@@ -0,0 +1 @@
+ALTER TABLE webhook_deliveries ALTER COLUMN status DROP NOT NULL;
@@ -0,0 +1,5 @@
+-- Forbid a NULL delivery status, so claims can filter on status = 'pending'
+-- and use the partial index idx_webhook_deliveries_ready.
+UPDATE webhook_deliveries SET status = CASE WHEN delivered_at IS NULL THEN 'pending' ELSE 'success' END WHERE status IS NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE webhook_deliveries ALTER COLUMN status SET NOT NULL;
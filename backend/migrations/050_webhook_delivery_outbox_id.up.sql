@@ -0,0 +1,4 @@
+-- Record the outbox event a delivery came from, so a retried event is
+-- queued at most once per endpoint.
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS outbox_id BIGINT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_outbox ON webhook_deliveries(outbox_id, endpoint_id) WHERE outbox_id IS NOT NULL;
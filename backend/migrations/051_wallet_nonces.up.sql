@@ -0,0 +1,9 @@
+-- The next nonce of each anchoring wallet, handed out under an advisory lock
+-- so replicas sending from the same wallet never reuse one.
+CREATE TABLE IF NOT EXISTS wallet_nonces (
+    chain TEXT NOT NULL,
+    wallet TEXT NOT NULL,
+    next_nonce BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chain, wallet)
+);
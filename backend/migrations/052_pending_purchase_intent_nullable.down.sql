@@ -0,0 +1,2 @@
+DELETE FROM pending_purchases WHERE stripe_payment_intent_id IS NULL;
+ALTER TABLE pending_purchases ALTER COLUMN stripe_payment_intent_id SET NOT NULL;
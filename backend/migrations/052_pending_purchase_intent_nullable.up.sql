@@ -0,0 +1,3 @@
+-- A token purchase is reserved first and gets its PaymentIntent once Stripe
+-- has created it, outside the lock on the token.
+ALTER TABLE pending_purchases ALTER COLUMN stripe_payment_intent_id DROP NOT NULL;
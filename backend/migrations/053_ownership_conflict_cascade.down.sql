@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS ownership_conflict_deleted_trigger ON ownership_conflicts;
+DROP FUNCTION IF EXISTS ownership_conflict_deleted();
@@ -0,0 +1,24 @@
+-- Deleting either item of an ownership conflict cascades to the conflict.
+-- When that removes the last open exact conflict over an item, its dispute
+-- is cleared, as dismissing the conflict would have done.
+CREATE OR REPLACE FUNCTION ownership_conflict_deleted() RETURNS trigger AS $$
+BEGIN
+    IF OLD.match_type = 'exact' AND OLD.status = 'open' THEN
+        UPDATE content_items SET dispute_status = NULL, updated_at = NOW()
+        WHERE id = OLD.content_id AND dispute_status = 'disputed'
+          AND NOT EXISTS (SELECT 1 FROM ownership_conflicts
+                          WHERE content_id = OLD.content_id AND match_type = 'exact' AND status = 'open');
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ownership_conflict_deleted_trigger
+    AFTER DELETE ON ownership_conflicts
+    FOR EACH ROW EXECUTE FUNCTION ownership_conflict_deleted();
+
+-- Release items already left in dispute by a deleted original
+UPDATE content_items c SET dispute_status = NULL, updated_at = NOW()
+WHERE dispute_status = 'disputed'
+  AND NOT EXISTS (SELECT 1 FROM ownership_conflicts
+                  WHERE content_id = c.id AND match_type = 'exact' AND status = 'open');
@@ -0,0 +1,5 @@
+DELETE FROM anchor_transactions WHERE anchor_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_anchor_transactions_anchor;
+ALTER TABLE anchor_transactions DROP CONSTRAINT IF EXISTS anchor_transactions_owner;
+ALTER TABLE anchor_transactions DROP COLUMN IF EXISTS anchor_id;
+ALTER TABLE anchor_transactions ALTER COLUMN batch_id SET NOT NULL;
@@ -0,0 +1,7 @@
+-- Unbatched anchors, which carry their own transaction, keep a history of
+-- it too, so one sent again after a reorg reuses its nonce and the hash it
+-- replaced is not lost. A row belongs to a batch or to an anchor.
+ALTER TABLE anchor_transactions ALTER COLUMN batch_id DROP NOT NULL;
+ALTER TABLE anchor_transactions ADD COLUMN IF NOT EXISTS anchor_id TEXT REFERENCES content_anchors(id) ON DELETE CASCADE;
+ALTER TABLE anchor_transactions ADD CONSTRAINT anchor_transactions_owner CHECK ((batch_id IS NULL) <> (anchor_id IS NULL));
+CREATE INDEX IF NOT EXISTS idx_anchor_transactions_anchor ON anchor_transactions(anchor_id, id) WHERE anchor_id IS NOT NULL;
@@ -0,0 +1,149 @@
+// Package merkle builds the Merkle trees Creatrid anchors on-chain and checks
+// inclusion proofs against them.
+//
+// Content hashes are batched into one tree and only its root is written to
+// the chain. Each item keeps the path from its leaf to the root, so anyone
+// holding the content can recompute the root and compare it with the data of
+// the anchoring transaction. The construction is:
+//
+//	leaf = SHA-256(0x00 || content hash)
+//	node = SHA-256(0x01 || left || right)
+//
+// Leaves are paired left to right; a node left without a partner at the end
+// of a level moves up unchanged. The prefixes keep a leaf from being passed
+// off as an interior node.
+//
+//	root, err := merkle.Root(contentHash, proof)
+//	ok := err == nil && bytes.Equal(root, onChainData)
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Algorithm identifies the construction above. It is returned with proofs so
+// that verifiers can tell which rules to apply.
+const Algorithm = "sha256-merkle-v1"
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// Sides of a proof step.
+const (
+	Left  = "left"
+	Right = "right"
+)
+
+var (
+	ErrEmpty        = errors.New("merkle: no leaves")
+	ErrInvalidProof = errors.New("merkle: malformed proof")
+)
+
+// Step is one level of an inclusion proof: the sibling hash, hex encoded,
+// and the side it is on.
+type Step struct {
+	Hash string `json:"hash"`
+	Side string `json:"side"`
+}
+
+// LeafHash returns the leaf hash of data.
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// NodeHash returns the hash of an interior node.
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// Tree is a Merkle tree over a fixed list of leaves.
+type Tree struct {
+	// levels[0] holds the leaf hashes and the last level the root.
+	levels [][][]byte
+}
+
+// New builds a tree over data, one leaf per element, in order.
+func New(data [][]byte) (*Tree, error) {
+	if len(data) == 0 {
+		return nil, ErrEmpty
+	}
+	level := make([][]byte, len(data))
+	for i, d := range data {
+		level[i] = LeafHash(d)
+	}
+	t := &Tree{levels: [][][]byte{level}}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, NodeHash(level[i], level[i+1]))
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t, nil
+}
+
+// Len returns the number of leaves.
+func (t *Tree) Len() int {
+	return len(t.levels[0])
+}
+
+// Root returns the root hash.
+func (t *Tree) Root() []byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Proof returns the inclusion proof for leaf i, ordered from the leaf up.
+// A tree with one leaf has an empty proof: its root is the leaf hash.
+func (t *Tree) Proof(i int) []Step {
+	if i < 0 || i >= t.Len() {
+		panic(fmt.Sprintf("merkle: leaf %d out of range", i))
+	}
+	proof := []Step{}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		switch {
+		case i%2 == 1:
+			proof = append(proof, Step{Hash: hex.EncodeToString(level[i-1]), Side: Left})
+		case i+1 < len(level):
+			proof = append(proof, Step{Hash: hex.EncodeToString(level[i+1]), Side: Right})
+		}
+		i /= 2
+	}
+	return proof
+}
+
+// Root recomputes the root from a leaf's data and its inclusion proof.
+func Root(data []byte, proof []Step) ([]byte, error) {
+	node := LeafHash(data)
+	for _, s := range proof {
+		sibling, err := hex.DecodeString(s.Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return nil, ErrInvalidProof
+		}
+		switch s.Side {
+		case Left:
+			node = NodeHash(sibling, node)
+		case Right:
+			node = NodeHash(node, sibling)
+		default:
+			return nil, ErrInvalidProof
+		}
+	}
+	return node, nil
+}
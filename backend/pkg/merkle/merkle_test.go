@@ -0,0 +1,76 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func contentHashes(n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		h := sha256.Sum256([]byte(fmt.Sprintf("file %d", i)))
+		out[i] = h[:]
+	}
+	return out
+}
+
+func TestTree_EveryProofReachesTheRoot(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		data := contentHashes(n)
+		tree, err := New(data)
+		require.NoError(t, err)
+		require.Equal(t, n, tree.Len())
+
+		for i, d := range data {
+			root, err := Root(d, tree.Proof(i))
+			require.NoError(t, err, "n=%d i=%d", n, i)
+			assert.True(t, bytes.Equal(tree.Root(), root), "n=%d i=%d", n, i)
+		}
+	}
+}
+
+func TestTree_KnownRoot(t *testing.T) {
+	data := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	tree, err := New(data)
+	require.NoError(t, err)
+
+	ab := NodeHash(LeafHash([]byte("a")), LeafHash([]byte("b")))
+	want := NodeHash(ab, LeafHash([]byte("c")))
+	assert.Equal(t, hex.EncodeToString(want), hex.EncodeToString(tree.Root()))
+
+	assert.Equal(t, []Step{{Hash: hex.EncodeToString(ab), Side: Left}}, tree.Proof(2),
+		"the odd leaf is promoted, so its proof skips a level")
+	single, _ := New(data[:1])
+	assert.Empty(t, single.Proof(0))
+	assert.Equal(t, LeafHash([]byte("a")), single.Root())
+}
+
+func TestRoot_RejectsTamperedProofs(t *testing.T) {
+	data := contentHashes(4)
+	tree, err := New(data)
+	require.NoError(t, err)
+	proof := tree.Proof(1)
+
+	root, err := Root(data[2], proof)
+	require.NoError(t, err)
+	assert.False(t, bytes.Equal(tree.Root(), root), "another item's hash does not fit this proof")
+
+	flipped := append([]Step(nil), proof...)
+	flipped[0].Side = Right
+	root, _ = Root(data[1], flipped)
+	assert.False(t, bytes.Equal(tree.Root(), root))
+
+	_, err = Root(data[1], []Step{{Hash: "zz", Side: Left}})
+	assert.ErrorIs(t, err, ErrInvalidProof)
+	_, err = Root(data[1], []Step{{Hash: proof[0].Hash, Side: "up"}})
+	assert.ErrorIs(t, err, ErrInvalidProof)
+
+	_, err = New(nil)
+	assert.ErrorIs(t, err, ErrEmpty)
+}
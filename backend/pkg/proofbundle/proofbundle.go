@@ -0,0 +1,343 @@
+// Package proofbundle signs and verifies Creatrid proof-of-ownership
+// bundles: self-contained JSON documents stating that an account uploaded a
+// file with a given SHA-256 at a given time, and how that hash was anchored.
+//
+// A bundle is signed with a platform Ed25519 key. The public keys are
+// published at /.well-known/creatrid-proof-keys.json; once a copy of that
+// document is saved, a bundle can be checked with no call to Creatrid:
+//
+//	ks, _ := proofbundle.ReadKeySet(keysFile)
+//	b, _ := proofbundle.Read(bundleFile)
+//	err := b.Verify(ks)           // signature and Merkle path
+//	err = b.VerifyFile(original)  // the file is the one the bundle is about
+//
+// The payload is carried as base64 of the exact bytes that were signed, and
+// the signature over those bytes is kept beside it, as in a JWS with a
+// detached header. Verifiers check the bytes before decoding them, so a
+// payload with fields they do not know still verifies, and reformatting the
+// bundle file does not break it. Bundles of the earlier creatrid.proof/v1
+// type, whose signature covers this package's re-encoding of the decoded
+// payload, are still accepted.
+package proofbundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/creatrid/creatrid/pkg/merkle"
+)
+
+// Type identifies the payload format.
+const Type = "creatrid.proof/v2"
+
+// TypeV1 is the type of bundles whose payload is a JSON object, signed as
+// re-encoded by this package.
+const TypeV1 = "creatrid.proof/v1"
+
+// Alg is the only signature algorithm.
+const Alg = "Ed25519"
+
+// WellKnownPath is where the platform publishes its public keys.
+const WellKnownPath = "/.well-known/creatrid-proof-keys.json"
+
+var (
+	ErrUnknownType    = errors.New("proofbundle: unsupported bundle type")
+	ErrUnknownKey     = errors.New("proofbundle: signed by an unknown key")
+	ErrBadSignature   = errors.New("proofbundle: signature does not match")
+	ErrHashMismatch   = errors.New("proofbundle: file does not match the bundle's SHA-256")
+	ErrMerkleMismatch = errors.New("proofbundle: Merkle path does not lead to the anchored root")
+	ErrInvalidKey     = errors.New("proofbundle: invalid key")
+)
+
+// Bundle is a signed proof document. Its JSON form carries the payload as
+// base64 of the signed bytes; Payload is those bytes decoded.
+type Bundle struct {
+	Payload   Payload
+	Signature Signature
+	// signed is the encoded payload the signature covers, nil for a v1
+	// bundle.
+	signed []byte
+}
+
+// bundleJSON is a bundle as written: Payload is a JSON string holding the
+// base64 signed bytes, or the payload object itself in a v1 bundle.
+type bundleJSON struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature Signature       `json:"signature"`
+}
+
+// MarshalJSON writes the signed payload bytes in base64, or a v1 bundle's
+// payload object as it was.
+func (b Bundle) MarshalJSON() ([]byte, error) {
+	var payload []byte
+	var err error
+	if b.signed != nil {
+		payload, err = json.Marshal(b.signed)
+	} else {
+		payload, err = json.Marshal(b.Payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(bundleJSON{Payload: payload, Signature: b.Signature})
+}
+
+// UnmarshalJSON reads a bundle, decoding the payload from its signed bytes.
+func (b *Bundle) UnmarshalJSON(data []byte) error {
+	var raw bundleJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	b.Signature = raw.Signature
+	b.Payload = Payload{}
+	b.signed = nil
+	if len(raw.Payload) == 0 || raw.Payload[0] != '"' {
+		return json.Unmarshal(raw.Payload, &b.Payload)
+	}
+	if err := json.Unmarshal(raw.Payload, &b.signed); err != nil {
+		return err
+	}
+	return json.Unmarshal(b.signed, &b.Payload)
+}
+
+// Payload is what the platform attests to.
+type Payload struct {
+	Type     string    `json:"type"`
+	Issuer   string    `json:"issuer"`
+	IssuedAt time.Time `json:"issuedAt"`
+	Content  Content   `json:"content"`
+	Owner    Owner     `json:"owner"`
+	Anchor   *Anchor   `json:"anchor"`
+}
+
+// Content describes the file. DisputeStatus is set while another creator
+// who uploaded the same file earlier disputes the claim ("disputed") or once
+// the dispute went against it ("rejected").
+type Content struct {
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	SHA256        string    `json:"sha256"`
+	UploadedAt    time.Time `json:"uploadedAt"`
+	DisputeStatus string    `json:"disputeStatus,omitempty"`
+}
+
+// Owner identifies the account that uploaded the file. AnchorPolicy is the
+// account's policy for anchoring its uploads without being asked.
+type Owner struct {
+	ID           string `json:"id"`
+	Username     string `json:"username,omitempty"`
+	Name         string `json:"name,omitempty"`
+	ProfileURL   string `json:"profileUrl,omitempty"`
+	Verified     bool   `json:"verified"`
+	AnchorPolicy string `json:"anchorPolicy,omitempty"`
+}
+
+// Anchor says where the hash, or the Merkle root of its batch, was recorded
+// outside Creatrid. The block fields are empty until the anchor is in a
+// block, and the record can still be lost in a reorg until Status is
+// "finalized". Policy names the anchoring policy that queued the anchor,
+// and is empty if the owner asked for it.
+type Anchor struct {
+	Chain          string     `json:"chain"`
+	Status         string     `json:"status"`
+	Policy         string     `json:"policy,omitempty"`
+	TxHash         string     `json:"txHash,omitempty"`
+	BlockNumber    int64      `json:"blockNumber,omitempty"`
+	BlockHash      string     `json:"blockHash,omitempty"`
+	ConfirmedAt    *time.Time `json:"confirmedAt,omitempty"`
+	Merkle         *Merkle    `json:"merkle,omitempty"`
+	TimestampToken []byte     `json:"timestampToken,omitempty"`
+}
+
+// Merkle is the path from the content hash to the root that was anchored.
+type Merkle struct {
+	Algorithm string        `json:"algorithm"`
+	LeafIndex int           `json:"leafIndex"`
+	LeafCount int           `json:"leafCount"`
+	Path      []merkle.Step `json:"path"`
+	Root      string        `json:"root"`
+}
+
+// Signature is the issuer's signature over the payload.
+type Signature struct {
+	Alg   string `json:"alg"`
+	KeyID string `json:"kid"`
+	Value []byte `json:"value"`
+}
+
+// KeyID names a public key: the first 8 bytes of its SHA-256, in hex.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePrivateKey decodes a base64 Ed25519 seed (32 bytes) or private key
+// (64 bytes).
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidKey, len(raw))
+	}
+}
+
+// Sign returns p signed with key.
+func Sign(p Payload, key ed25519.PrivateKey) (*Bundle, error) {
+	p.Type = Type
+	msg, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{
+		Payload: p,
+		Signature: Signature{
+			Alg:   Alg,
+			KeyID: KeyID(key.Public().(ed25519.PublicKey)),
+			Value: ed25519.Sign(key, msg),
+		},
+		signed: msg,
+	}, nil
+}
+
+// Read decodes a bundle.
+func Read(r io.Reader) (*Bundle, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("proofbundle: invalid bundle: %w", err)
+	}
+	return &b, nil
+}
+
+// Verify checks the bundle's signature against the key it names in ks, and
+// that its Merkle path, if any, leads from the content hash to the root.
+// It does not look the anchor up on chain. Payload is set again from the
+// signed bytes, so after Verify it holds what was signed.
+func (b *Bundle) Verify(ks *KeySet) error {
+	wantType := Type
+	if b.signed == nil {
+		wantType = TypeV1
+	}
+	if b.Payload.Type != wantType {
+		return fmt.Errorf("%w: %q", ErrUnknownType, b.Payload.Type)
+	}
+	if b.Signature.Alg != Alg {
+		return fmt.Errorf("%w: algorithm %q", ErrBadSignature, b.Signature.Alg)
+	}
+	pub, ok := ks.Find(b.Signature.KeyID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, b.Signature.KeyID)
+	}
+	msg := b.signed
+	if msg == nil {
+		var err error
+		if msg, err = json.Marshal(b.Payload); err != nil {
+			return err
+		}
+	}
+	if !ed25519.Verify(pub, msg, b.Signature.Value) {
+		return ErrBadSignature
+	}
+	if b.signed != nil {
+		b.Payload = Payload{}
+		if err := json.Unmarshal(b.signed, &b.Payload); err != nil {
+			return fmt.Errorf("proofbundle: invalid payload: %w", err)
+		}
+		if b.Payload.Type != Type {
+			return fmt.Errorf("%w: %q", ErrUnknownType, b.Payload.Type)
+		}
+	}
+
+	if b.Payload.Anchor == nil || b.Payload.Anchor.Merkle == nil {
+		return nil
+	}
+	m := b.Payload.Anchor.Merkle
+	leaf, err := hex.DecodeString(b.Payload.Content.SHA256)
+	if err != nil {
+		return fmt.Errorf("proofbundle: invalid content hash: %w", err)
+	}
+	root, err := merkle.Root(leaf, m.Path)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(root) != m.Root {
+		return ErrMerkleMismatch
+	}
+	return nil
+}
+
+// VerifyFile checks that r's SHA-256 is the bundle's content hash.
+func (b *Bundle) VerifyFile(r io.Reader) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	want, err := hex.DecodeString(b.Payload.Content.SHA256)
+	if err != nil || !bytes.Equal(h.Sum(nil), want) {
+		return ErrHashMismatch
+	}
+	return nil
+}
+
+// KeySet is the document published at WellKnownPath.
+type KeySet struct {
+	Keys []PublicKey `json:"keys"`
+}
+
+// PublicKey is one published verification key.
+type PublicKey struct {
+	KeyID string `json:"kid"`
+	Alg   string `json:"alg"`
+	Key   []byte `json:"publicKey"`
+}
+
+// NewKeySet returns the key set publishing pubs.
+func NewKeySet(pubs ...ed25519.PublicKey) *KeySet {
+	ks := &KeySet{Keys: []PublicKey{}}
+	for _, pub := range pubs {
+		ks.Keys = append(ks.Keys, PublicKey{KeyID: KeyID(pub), Alg: Alg, Key: pub})
+	}
+	return ks
+}
+
+// ReadKeySet decodes a key set.
+func ReadKeySet(r io.Reader) (*KeySet, error) {
+	var ks KeySet
+	if err := json.NewDecoder(r).Decode(&ks); err != nil {
+		return nil, fmt.Errorf("proofbundle: invalid key set: %w", err)
+	}
+	return &ks, nil
+}
+
+// Find returns the Ed25519 key with the given ID. A key whose ID does not
+// match its bytes is ignored.
+func (ks *KeySet) Find(kid string) (ed25519.PublicKey, bool) {
+	if ks == nil {
+		return nil, false
+	}
+	for _, k := range ks.Keys {
+		if k.Alg != Alg || len(k.Key) != ed25519.PublicKeySize {
+			continue
+		}
+		pub := ed25519.PublicKey(k.Key)
+		if k.KeyID == kid && KeyID(pub) == kid {
+			return pub, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,162 @@
+package proofbundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creatrid/creatrid/pkg/merkle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samplePayload(t *testing.T, file []byte) Payload {
+	t.Helper()
+	others := [][]byte{[]byte("a"), file, []byte("c")}
+	leaves := make([][]byte, len(others))
+	for i, f := range others {
+		sum := sha256.Sum256(f)
+		leaves[i] = sum[:]
+	}
+	tree, err := merkle.New(leaves)
+	require.NoError(t, err)
+
+	confirmed := time.Date(2026, 5, 2, 9, 0, 0, 0, time.UTC)
+	return Payload{
+		Issuer:   "https://creatrid.test",
+		IssuedAt: time.Date(2026, 6, 1, 10, 0, 0, 123000, time.UTC),
+		Content: Content{
+			ID: "c1", Title: "Sunset <final> & co", SHA256: hex.EncodeToString(leaves[1]),
+			UploadedAt: time.Date(2026, 5, 1, 8, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+		},
+		Owner: Owner{ID: "u1", Username: "maria", Name: "María", Verified: true, AnchorPolicy: "public"},
+		Anchor: &Anchor{
+			Chain: "base", Status: "finalized", Policy: "public", TxHash: "0xabc", BlockNumber: 42, BlockHash: "0xdef", ConfirmedAt: &confirmed,
+			Merkle: &Merkle{
+				Algorithm: merkle.Algorithm, LeafIndex: 1, LeafCount: 3,
+				Path: tree.Proof(1), Root: hex.EncodeToString(tree.Root()),
+			},
+		},
+	}
+}
+
+func TestBundle_RoundTripVerifies(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	file := []byte("original file")
+
+	b, err := Sign(samplePayload(t, file), key)
+	require.NoError(t, err)
+	assert.Equal(t, Type, b.Payload.Type)
+
+	// Reformatting the document must not break the signature
+	raw, err := json.MarshalIndent(b, "", "    ")
+	require.NoError(t, err)
+	read, err := Read(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	ksJSON, err := json.Marshal(NewKeySet(pub))
+	require.NoError(t, err)
+	ks, err := ReadKeySet(bytes.NewReader(ksJSON))
+	require.NoError(t, err)
+
+	require.NoError(t, read.Verify(ks))
+	require.NoError(t, read.VerifyFile(bytes.NewReader(file)))
+	assert.ErrorIs(t, read.VerifyFile(strings.NewReader("another file")), ErrHashMismatch)
+}
+
+func TestBundle_RejectsTampering(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	ks := NewKeySet(pub)
+
+	b, err := Sign(samplePayload(t, []byte("f")), key)
+	require.NoError(t, err)
+	b.signed = bytes.Replace(b.signed, []byte(`"maria"`), []byte(`"mallory"`), 1)
+	assert.ErrorIs(t, b.Verify(ks), ErrBadSignature)
+
+	b, _ = Sign(samplePayload(t, []byte("f")), key)
+	b.Payload.Owner.Username = "mallory"
+	require.NoError(t, b.Verify(ks), "the signed bytes are checked, not the decoded copy")
+	assert.Equal(t, "maria", b.Payload.Owner.Username, "Verify restores what was signed")
+
+	b, _ = Sign(samplePayload(t, []byte("f")), key)
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	assert.ErrorIs(t, b.Verify(NewKeySet(other)), ErrUnknownKey)
+
+	forged := &KeySet{Keys: []PublicKey{{KeyID: b.Signature.KeyID, Alg: Alg, Key: other}}}
+	assert.ErrorIs(t, b.Verify(forged), ErrUnknownKey, "a key filed under another key's ID is ignored")
+
+	p := samplePayload(t, []byte("f"))
+	p.Anchor.Merkle.LeafIndex = 0
+	p.Anchor.Merkle.Path = p.Anchor.Merkle.Path[1:]
+	b, _ = Sign(p, key)
+	assert.ErrorIs(t, b.Verify(ks), ErrMerkleMismatch, "a signed but wrong path still fails")
+}
+
+func TestBundle_UnknownFieldsVerify(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+
+	// A payload from a newer issuer, with fields this version does not know
+	msg := []byte(`{"type":"` + Type + `","issuer":"https://creatrid.test","issuedAt":"2026-06-01T10:00:00Z",` +
+		`"content":{"id":"c1","sha256":"ab","uploadedAt":"2026-05-01T08:00:00Z","license":"CC-BY-4.0"},` +
+		`"owner":{"id":"u1","verified":true},"anchor":null,"attestations":[{"kind":"c2pa"}]}`)
+	raw, err := json.Marshal(map[string]any{
+		"payload":   msg,
+		"signature": Signature{Alg: Alg, KeyID: KeyID(pub), Value: ed25519.Sign(key, msg)},
+	})
+	require.NoError(t, err)
+
+	b, err := Read(bytes.NewReader(raw))
+	require.NoError(t, err)
+	require.NoError(t, b.Verify(NewKeySet(pub)))
+	assert.Equal(t, "c1", b.Payload.Content.ID)
+
+	out, err := json.Marshal(b)
+	require.NoError(t, err)
+	again, err := Read(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.NoError(t, again.Verify(NewKeySet(pub)), "writing the bundle back keeps the signed bytes")
+}
+
+func TestBundle_V1StillVerifies(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	ks := NewKeySet(pub)
+
+	p := samplePayload(t, []byte("f"))
+	p.Type = TypeV1
+	msg, err := json.Marshal(p)
+	require.NoError(t, err)
+	raw, err := json.Marshal(map[string]any{
+		"payload":   p,
+		"signature": Signature{Alg: Alg, KeyID: KeyID(pub), Value: ed25519.Sign(key, msg)},
+	})
+	require.NoError(t, err)
+
+	b, err := Read(bytes.NewReader(raw))
+	require.NoError(t, err)
+	require.NoError(t, b.Verify(ks))
+
+	b.Payload.Owner.Username = "mallory"
+	assert.ErrorIs(t, b.Verify(ks), ErrBadSignature)
+
+	b.Payload.Type = Type
+	assert.ErrorIs(t, b.Verify(ks), ErrUnknownType, "a v2 payload must come as signed bytes")
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	_, _ = rand.Read(seed)
+	key, err := ParsePrivateKey(base64.StdEncoding.EncodeToString(seed))
+	require.NoError(t, err)
+	assert.Equal(t, ed25519.NewKeyFromSeed(seed), key)
+
+	_, err = ParsePrivateKey("c2hvcnQ=")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
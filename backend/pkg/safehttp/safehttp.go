@@ -0,0 +1,202 @@
+// Package safehttp provides an HTTP client for requests to user-supplied
+// URLs that will not connect to the host's own network.
+//
+// The destination is checked when each connection is dialled, against the
+// address actually being connected to, so a hostname that resolves to a
+// public address when validated and to 169.254.169.254 when fetched (DNS
+// rebinding) is still refused. Redirects go through the same dialler and are
+// additionally held to the client's scheme rules.
+//
+//	client := safehttp.NewClient(safehttp.Options{RequireHTTPS: true})
+//	resp, err := client.Post(userURL, "application/json", body)
+//	if errors.Is(err, safehttp.ErrBlockedAddress) { ... }
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultTimeout bounds a whole request, redirects included, when Options
+// does not set one.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultMaxRedirects is how many redirects a client follows when Options
+// does not set a limit.
+const DefaultMaxRedirects = 5
+
+var (
+	ErrInvalidURL       = errors.New("safehttp: invalid URL")
+	ErrSchemeNotAllowed = errors.New("safehttp: URL scheme not allowed")
+	ErrBlockedAddress   = errors.New("safehttp: destination address not allowed")
+	ErrTooManyRedirects = errors.New("safehttp: too many redirects")
+)
+
+// Options configures a client and URL validation.
+type Options struct {
+	// Timeout bounds each request. Zero uses DefaultTimeout.
+	Timeout time.Duration
+	// MaxRedirects caps redirects followed. Zero uses DefaultMaxRedirects;
+	// a negative value refuses every redirect.
+	MaxRedirects int
+	// RequireHTTPS rejects plain http URLs, including redirect targets.
+	RequireHTTPS bool
+	// AllowPrivate turns the address checks off. It exists for local
+	// development and tests against loopback servers; never set it in
+	// production.
+	AllowPrivate bool
+}
+
+// blockedPrefixes are the ranges a client refuses to connect to: this host,
+// private networks, link-local (cloud metadata services live there),
+// carrier-grade NAT, multicast, and ranges reserved for documentation or
+// future use.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// IsBlocked reports whether a client refuses to connect to ip. IPv4 addresses
+// written as IPv6 (::ffff:a.b.c.d) are checked as IPv4.
+func IsBlocked(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() {
+		return true
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL checks a user-supplied URL before it is stored: it must be an
+// absolute http or https URL (https only with RequireHTTPS) and must not name
+// a blocked address or localhost outright. Hostnames are not resolved here;
+// the client checks the resolved address on every connection.
+func ValidateURL(raw string, opts Options) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if err := checkScheme(u, opts); err != nil {
+		return err
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in URL", ErrInvalidURL)
+	}
+	if opts.AllowPrivate {
+		return nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlockedAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && IsBlocked(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+func checkScheme(u *url.URL, opts Options) error {
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if opts.RequireHTTPS {
+			return fmt.Errorf("%w: https is required", ErrSchemeNotAllowed)
+		}
+		return nil
+	default:
+		return ErrSchemeNotAllowed
+	}
+}
+
+// NewClient returns an HTTP client that only connects to public addresses,
+// ignores proxy environment variables (a proxy would dial on its behalf,
+// out of reach of the checks), and applies opts to every redirect.
+func NewClient(opts Options) *http.Client {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if !opts.AllowPrivate {
+		dialer.Control = controlDial
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		CheckRedirect: checkRedirect(opts),
+	}
+}
+
+// controlDial runs after the hostname has been resolved and before the
+// socket connects, so address is the IP really being dialled.
+func controlDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrBlockedAddress
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || IsBlocked(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+func checkRedirect(opts Options) func(*http.Request, []*http.Request) error {
+	limit := opts.MaxRedirects
+	if limit == 0 {
+		limit = DefaultMaxRedirects
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if limit < 0 || len(via) > limit {
+			return ErrTooManyRedirects
+		}
+		return ValidateURL(req.URL.String(), opts)
+	}
+}
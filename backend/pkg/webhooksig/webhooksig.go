@@ -0,0 +1,130 @@
+// Package webhooksig signs and verifies Creatrid webhook deliveries.
+//
+// Every delivery carries an X-Webhook-Signature header of the form
+//
+//	t=1700000000,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// where t is the Unix time the delivery was signed and each v1 is the hex
+// HMAC-SHA256 of "<t>.<raw request body>" under one of the endpoint's
+// signing secrets. While a secret is being rotated the header carries one v1
+// per active secret, so receivers holding either secret keep verifying.
+//
+// Receivers should verify the raw body before parsing it:
+//
+//	body, _ := io.ReadAll(r.Body)
+//	err := webhooksig.Verify(body, r.Header.Get(webhooksig.HeaderName), secret, webhooksig.DefaultTolerance)
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderName is the HTTP header carrying the signature.
+const HeaderName = "X-Webhook-Signature"
+
+// DefaultTolerance is how far a delivery's timestamp may be from the
+// receiver's clock before it is rejected as a replay.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrMissingHeader = errors.New("webhooksig: missing signature header")
+	ErrInvalidHeader = errors.New("webhooksig: malformed signature header")
+	ErrExpired       = errors.New("webhooksig: timestamp outside tolerance")
+	ErrNoMatch       = errors.New("webhooksig: no signature matches")
+)
+
+// Sign returns the hex v1 signature of payload signed at t with secret.
+func Sign(payload []byte, secret string, t time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(t.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Header builds the signature header for payload signed at t, with one v1
+// entry per secret.
+func Header(payload []byte, t time.Time, secrets ...string) string {
+	var b strings.Builder
+	b.WriteString("t=")
+	b.WriteString(strconv.FormatInt(t.Unix(), 10))
+	for _, secret := range secrets {
+		b.WriteString(",v1=")
+		b.WriteString(Sign(payload, secret, t))
+	}
+	return b.String()
+}
+
+// Verify checks that header carries a v1 signature of payload under secret
+// and that its timestamp is within tolerance of now. A tolerance of zero or
+// less skips the timestamp check.
+func Verify(payload []byte, header, secret string, tolerance time.Duration) error {
+	return VerifyAt(payload, header, secret, tolerance, time.Now())
+}
+
+// VerifyAt is Verify with an explicit current time.
+func VerifyAt(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	if header == "" {
+		return ErrMissingHeader
+	}
+	t, signatures, err := parse(header)
+	if err != nil {
+		return err
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(t)
+		if age > tolerance || age < -tolerance {
+			return ErrExpired
+		}
+	}
+
+	expected, _ := hex.DecodeString(Sign(payload, secret, t))
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrNoMatch
+}
+
+// parse splits a header into its timestamp and decoded v1 signatures.
+// Unknown keys are ignored so future schemes can be added alongside v1.
+func parse(header string) (time.Time, [][]byte, error) {
+	var ts time.Time
+	var haveTS bool
+	var signatures [][]byte
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return time.Time{}, nil, ErrInvalidHeader
+		}
+		switch key {
+		case "t":
+			sec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return time.Time{}, nil, ErrInvalidHeader
+			}
+			ts = time.Unix(sec, 0)
+			haveTS = true
+		case "v1":
+			sig, err := hex.DecodeString(value)
+			if err != nil {
+				continue
+			}
+			signatures = append(signatures, sig)
+		}
+	}
+
+	if !haveTS || len(signatures) == 0 {
+		return time.Time{}, nil, ErrInvalidHeader
+	}
+	return ts, signatures, nil
+}
@@ -0,0 +1,75 @@
+package webhooksig
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	payload = []byte(`{"event":"license.sold","data":{"id":"abc"}}`)
+	signed  = time.Unix(1_700_000_000, 0)
+)
+
+func TestHeaderFormat(t *testing.T) {
+	h := Header(payload, signed, "whsec_a", "whsec_b")
+	parts := strings.Split(h, ",")
+	assert.Len(t, parts, 3)
+	assert.Equal(t, "t=1700000000", parts[0])
+	assert.Equal(t, "v1="+Sign(payload, "whsec_a", signed), parts[1])
+	assert.Equal(t, "v1="+Sign(payload, "whsec_b", signed), parts[2])
+}
+
+func TestVerify(t *testing.T) {
+	h := Header(payload, signed, "whsec_a")
+	assert.NoError(t, VerifyAt(payload, h, "whsec_a", DefaultTolerance, signed.Add(time.Minute)))
+}
+
+func TestVerify_EitherSecretDuringRotation(t *testing.T) {
+	h := Header(payload, signed, "whsec_new", "whsec_old")
+	assert.NoError(t, VerifyAt(payload, h, "whsec_new", DefaultTolerance, signed))
+	assert.NoError(t, VerifyAt(payload, h, "whsec_old", DefaultTolerance, signed))
+	assert.ErrorIs(t, VerifyAt(payload, h, "whsec_other", DefaultTolerance, signed), ErrNoMatch)
+}
+
+func TestVerify_Replay(t *testing.T) {
+	h := Header(payload, signed, "whsec_a")
+	assert.ErrorIs(t, VerifyAt(payload, h, "whsec_a", DefaultTolerance, signed.Add(10*time.Minute)), ErrExpired)
+	assert.ErrorIs(t, VerifyAt(payload, h, "whsec_a", DefaultTolerance, signed.Add(-10*time.Minute)), ErrExpired)
+	assert.NoError(t, VerifyAt(payload, h, "whsec_a", 0, signed.Add(24*time.Hour)), "zero tolerance skips the check")
+}
+
+func TestVerify_TamperedTimestamp(t *testing.T) {
+	// Moving t forward to dodge the tolerance check breaks the signature.
+	h := Header(payload, signed, "whsec_a")
+	forged := strings.Replace(h, "t=1700000000", "t=1700000600", 1)
+	assert.ErrorIs(t, VerifyAt(payload, forged, "whsec_a", DefaultTolerance, signed.Add(10*time.Minute)), ErrNoMatch)
+}
+
+func TestVerify_TamperedBody(t *testing.T) {
+	h := Header(payload, signed, "whsec_a")
+	assert.ErrorIs(t, VerifyAt([]byte(`{"event":"license.sold"}`), h, "whsec_a", DefaultTolerance, signed), ErrNoMatch)
+}
+
+func TestVerify_MalformedHeaders(t *testing.T) {
+	tests := map[string]string{
+		"legacy format": "sha256=abcdef",
+		"no timestamp":  "v1=" + Sign(payload, "whsec_a", signed),
+		"no signature":  "t=1700000000",
+		"bad timestamp": "t=yesterday,v1=00",
+		"missing value": "t=1700000000,v1",
+	}
+	for name, h := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, VerifyAt(payload, h, "whsec_a", DefaultTolerance, signed), ErrInvalidHeader)
+		})
+	}
+	assert.ErrorIs(t, VerifyAt(payload, "", "whsec_a", DefaultTolerance, signed), ErrMissingHeader)
+}
+
+func TestVerify_IgnoresUnknownSchemes(t *testing.T) {
+	h := Header(payload, signed, "whsec_a") + ",v2=deadbeef"
+	assert.NoError(t, VerifyAt(payload, h, "whsec_a", DefaultTolerance, signed))
+}
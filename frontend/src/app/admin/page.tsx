@@ -4,7 +4,7 @@ import { useAuth } from "@/lib/auth-context";
 import { useRouter } from "next/navigation";
 import { useEffect, useState } from "react";
 import { api } from "@/lib/api";
-import { Users, BarChart3, Eye, MousePointerClick, Link2, CheckCircle, Shield, ClipboardList, AlertTriangle, Flag } from "@/components/icons";
+import { Users, BarChart3, Eye, MousePointerClick, Link2, CheckCircle, Shield, ClipboardList, AlertTriangle, Flag, Image as ImageIcon } from "@/components/icons";
 import { useTranslation } from "react-i18next";
 
 type AdminStats = {
@@ -37,7 +37,7 @@ export default function AdminPage() {
   const [users, setUsers] = useState<AdminUser[]>([]);
   const [total, setTotal] = useState(0);
   const [page, setPage] = useState(0);
-  const [activeTab, setActiveTab] = useState<"users" | "audit" | "errors" | "moderation">("users");
+  const [activeTab, setActiveTab] = useState<"users" | "audit" | "errors" | "moderation" | "ownership">("users");
   const [auditEntries, setAuditEntries] = useState<any[]>([]);
   const [auditTotal, setAuditTotal] = useState(0);
   const [auditPage, setAuditPage] = useState(0);
@@ -52,6 +52,13 @@ export default function AdminPage() {
   const [modActionId, setModActionId] = useState<string | null>(null);
   const [modActionType, setModActionType] = useState<"resolved" | "dismissed">("resolved");
   const [modNotes, setModNotes] = useState("");
+  const [conflicts, setConflicts] = useState<any[]>([]);
+  const [conflictTotal, setConflictTotal] = useState(0);
+  const [conflictPage, setConflictPage] = useState(0);
+  const [conflictStatus, setConflictStatus] = useState("open");
+  const [conflictActionId, setConflictActionId] = useState<string | null>(null);
+  const [conflictActionType, setConflictActionType] = useState<"upheld" | "dismissed">("upheld");
+  const [conflictNotes, setConflictNotes] = useState("");
 
   useEffect(() => {
     if (!loading && !user) router.push("/sign-in");
@@ -110,6 +117,29 @@ export default function AdminPage() {
     }
   }, [user, activeTab, modPage, modStatus]);
 
+  useEffect(() => {
+    if (user?.role === "ADMIN" && activeTab === "ownership") {
+      api.adminOwnership.list(conflictStatus, 20, conflictPage * 20).then((r) => {
+        if (r.data) {
+          setConflicts(r.data.conflicts || []);
+          setConflictTotal(r.data.total);
+        }
+      });
+    }
+  }, [user, activeTab, conflictPage, conflictStatus]);
+
+  async function handleConflictResolve() {
+    if (!conflictActionId) return;
+    const result = await api.adminOwnership.resolve(conflictActionId, conflictActionType, conflictNotes);
+    if (result.data) {
+      setConflicts((prev) =>
+        prev.map((c) => (c.id === conflictActionId ? { ...c, status: conflictActionType } : c))
+      );
+      setConflictActionId(null);
+      setConflictNotes("");
+    }
+  }
+
   async function handleModResolve() {
     if (!modActionId) return;
     const result = await api.adminModeration.resolve(modActionId, modActionType, modNotes);
@@ -187,6 +217,13 @@ export default function AdminPage() {
           <Flag className="mr-1.5 inline h-4 w-4" />
           {t("admin.moderation.title")}
         </button>
+        <button
+          onClick={() => setActiveTab("ownership")}
+          className={`border-b-2 px-4 py-2 text-sm font-medium ${activeTab === "ownership" ? "border-zinc-900 text-zinc-900 dark:border-zinc-100 dark:text-zinc-100" : "border-transparent text-zinc-500 hover:text-zinc-700 dark:hover:text-zinc-300"}`}
+        >
+          <ImageIcon className="mr-1.5 inline h-4 w-4" />
+          {t("admin.ownership.title")}
+        </button>
       </div>
 
       {/* Audit Log */}
@@ -400,6 +437,137 @@ export default function AdminPage() {
         </div>
       )}
 
+      {/* Ownership Conflicts */}
+      {activeTab === "ownership" && (
+        <div className="rounded-xl border border-zinc-200 dark:border-zinc-800">
+          <div className="flex items-center justify-between border-b border-zinc-200 px-6 py-4 dark:border-zinc-800">
+            <h2 className="font-semibold">{t("admin.ownership.title")} ({conflictTotal})</h2>
+            <select
+              value={conflictStatus}
+              onChange={(e) => { setConflictStatus(e.target.value); setConflictPage(0); }}
+              className="rounded-lg border border-zinc-200 bg-white px-3 py-1.5 text-xs dark:border-zinc-700 dark:bg-zinc-900"
+            >
+              <option value="open">{t("admin.ownership.open")}</option>
+              <option value="upheld">{t("admin.ownership.upheld")}</option>
+              <option value="dismissed">{t("admin.ownership.dismissed")}</option>
+              <option value="all">{t("admin.ownership.all")}</option>
+            </select>
+          </div>
+          <div className="overflow-x-auto">
+            <table className="w-full text-left text-sm">
+              <thead className="border-b border-zinc-200 text-xs text-zinc-500 dark:border-zinc-800">
+                <tr>
+                  <th className="px-6 py-3 font-medium">{t("admin.moderation.time")}</th>
+                  <th className="px-6 py-3 font-medium">{t("admin.ownership.upload")}</th>
+                  <th className="px-6 py-3 font-medium">{t("admin.ownership.original")}</th>
+                  <th className="px-6 py-3 font-medium">{t("admin.ownership.match")}</th>
+                  <th className="px-6 py-3 font-medium">{t("admin.moderation.status")}</th>
+                  <th className="px-6 py-3 font-medium">{t("admin.moderation.actions")}</th>
+                </tr>
+              </thead>
+              <tbody className="divide-y divide-zinc-200 dark:divide-zinc-800">
+                {conflicts.map((c: any) => (
+                  <tr key={c.id}>
+                    <td className="px-6 py-3 text-xs text-zinc-500 whitespace-nowrap">{new Date(c.createdAt).toLocaleString()}</td>
+                    <td className="px-6 py-3">
+                      <div className="flex items-center gap-2">
+                        {c.contentThumbnailUrl && <img src={c.contentThumbnailUrl} alt="" className="h-10 w-10 rounded object-cover" />}
+                        <div>
+                          <span className="block max-w-[10rem] truncate text-xs" title={c.contentTitle}>{c.contentTitle}</span>
+                          <AnchorEvidence anchor={c.contentAnchor} first={c.earlierAnchor === "content"} />
+                        </div>
+                      </div>
+                    </td>
+                    <td className="px-6 py-3">
+                      <div className="flex items-center gap-2">
+                        {c.originalThumbnailUrl && <img src={c.originalThumbnailUrl} alt="" className="h-10 w-10 rounded object-cover" />}
+                        <div>
+                          <span className="block max-w-[10rem] truncate text-xs" title={c.originalTitle}>{c.originalTitle}</span>
+                          <AnchorEvidence anchor={c.originalAnchor} first={c.earlierAnchor === "original"} />
+                        </div>
+                      </div>
+                    </td>
+                    <td className="px-6 py-3 text-xs whitespace-nowrap">
+                      {c.matchType === "exact" ? t("admin.ownership.sameFile") : c.distance === 0 ? t("admin.ownership.exact") : t("admin.ownership.bits", { count: c.distance })}
+                    </td>
+                    <td className="px-6 py-3">
+                      <span className={`rounded-full px-2 py-0.5 text-xs font-medium ${
+                        c.status === "open" ? "bg-yellow-50 text-yellow-600 dark:bg-yellow-900/30 dark:text-yellow-400" :
+                        c.status === "upheld" ? "bg-red-50 text-red-600 dark:bg-red-900/30 dark:text-red-400" :
+                        "bg-zinc-100 text-zinc-600 dark:bg-zinc-800 dark:text-zinc-400"
+                      }`}>{c.status}</span>
+                    </td>
+                    <td className="px-6 py-3">
+                      {c.status === "open" ? (
+                        <div className="flex gap-2">
+                          <button
+                            onClick={() => { setConflictActionId(c.id); setConflictActionType("upheld"); }}
+                            className="rounded-lg border border-red-200 px-3 py-1 text-xs font-medium text-red-600 transition-colors hover:bg-red-50 dark:border-red-800 dark:text-red-400 dark:hover:bg-red-900/30"
+                          >
+                            {t("admin.ownership.uphold")}
+                          </button>
+                          <button
+                            onClick={() => { setConflictActionId(c.id); setConflictActionType("dismissed"); }}
+                            className="rounded-lg border border-zinc-200 px-3 py-1 text-xs font-medium transition-colors hover:bg-zinc-50 dark:border-zinc-700 dark:hover:bg-zinc-800"
+                          >
+                            {t("admin.ownership.dismiss")}
+                          </button>
+                        </div>
+                      ) : (
+                        <span className="text-xs text-zinc-400">{c.notes || t("common.noData")}</span>
+                      )}
+                    </td>
+                  </tr>
+                ))}
+                {conflicts.length === 0 && (
+                  <tr>
+                    <td colSpan={6} className="px-6 py-8 text-center text-sm text-zinc-500">{t("admin.ownership.noConflicts")}</td>
+                  </tr>
+                )}
+              </tbody>
+            </table>
+          </div>
+          {conflictTotal > 20 && (
+            <div className="flex items-center justify-between border-t border-zinc-200 px-6 py-3 dark:border-zinc-800">
+              <button onClick={() => setConflictPage((p) => Math.max(0, p - 1))} disabled={conflictPage === 0} className="rounded-lg border border-zinc-200 px-3 py-1 text-xs font-medium disabled:opacity-50 dark:border-zinc-700">{t("common.previous")}</button>
+              <span className="text-xs text-zinc-500">{t("common.page", { current: conflictPage + 1, total: Math.ceil(conflictTotal / 20) })}</span>
+              <button onClick={() => setConflictPage((p) => p + 1)} disabled={(conflictPage + 1) * 20 >= conflictTotal} className="rounded-lg border border-zinc-200 px-3 py-1 text-xs font-medium disabled:opacity-50 dark:border-zinc-700">{t("common.next")}</button>
+            </div>
+          )}
+          {/* Uphold/Dismiss Modal */}
+          {conflictActionId && (
+            <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/50">
+              <div className="w-full max-w-md rounded-xl border border-zinc-200 bg-white p-6 dark:border-zinc-800 dark:bg-zinc-900">
+                <h3 className="text-lg font-semibold">
+                  {conflictActionType === "upheld" ? t("admin.ownership.uphold") : t("admin.ownership.dismiss")}
+                </h3>
+                <textarea
+                  value={conflictNotes}
+                  onChange={(e) => setConflictNotes(e.target.value)}
+                  placeholder={t("admin.moderation.notesPlaceholder")}
+                  className="mt-4 w-full rounded-lg border border-zinc-200 bg-white px-3 py-2 text-sm dark:border-zinc-700 dark:bg-zinc-900"
+                  rows={3}
+                />
+                <div className="mt-4 flex justify-end gap-2">
+                  <button
+                    onClick={() => { setConflictActionId(null); setConflictNotes(""); }}
+                    className="rounded-lg border border-zinc-200 px-4 py-2 text-sm font-medium transition-colors hover:bg-zinc-50 dark:border-zinc-700 dark:hover:bg-zinc-800"
+                  >
+                    {t("common.cancel")}
+                  </button>
+                  <button
+                    onClick={handleConflictResolve}
+                    className="rounded-lg bg-zinc-900 px-4 py-2 text-sm font-medium text-white transition-colors hover:bg-zinc-800 dark:bg-zinc-100 dark:text-zinc-900 dark:hover:bg-zinc-200"
+                  >
+                    {t("admin.moderation.confirm")}
+                  </button>
+                </div>
+              </div>
+            </div>
+          )}
+        </div>
+      )}
+
       {/* Users Table */}
       {activeTab === "users" && (
       <div className="rounded-xl border border-zinc-200 dark:border-zinc-800">
@@ -520,3 +688,25 @@ function StatCard({
     </div>
   );
 }
+
+function AnchorEvidence({
+  anchor,
+  first,
+}: {
+  anchor?: { chain: string; status: string; confirmedAt: string | null } | null;
+  first: boolean;
+}) {
+  const { t } = useTranslation();
+
+  if (!anchor) {
+    return <span className="block text-[11px] text-zinc-400">{t("admin.ownership.notAnchored")}</span>;
+  }
+  return (
+    <span className={`block text-[11px] ${first ? "font-medium text-emerald-600 dark:text-emerald-400" : "text-zinc-400"}`}>
+      {anchor.status === "finalized" && anchor.confirmedAt
+        ? t("admin.ownership.anchoredAt", { chain: anchor.chain, date: new Date(anchor.confirmedAt).toLocaleDateString() })
+        : `${anchor.chain} · ${anchor.status}`}
+      {first && ` · ${t("admin.ownership.anchoredFirst")}`}
+    </span>
+  );
+}
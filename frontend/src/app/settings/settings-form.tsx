@@ -26,6 +26,13 @@ const THEME_LABEL_MAP: Record<string, string> = {
   rose: "settings.themeRose",
 };
 
+const ANCHOR_POLICIES = [
+  { key: "all", label: "settings.anchorAll" },
+  { key: "public", label: "settings.anchorPublic" },
+  { key: "licensed", label: "settings.anchorLicensed" },
+  { key: "never", label: "settings.anchorNever" },
+];
+
 interface SettingsFormProps {
   name: string;
   username: string;
@@ -34,6 +41,7 @@ interface SettingsFormProps {
   theme: string;
   customLinks: CustomLink[];
   emailPrefs: EmailPrefs;
+  anchorPolicy: string;
   totpEnabled: boolean;
 }
 
@@ -45,6 +53,7 @@ export function SettingsForm({
   theme,
   customLinks,
   emailPrefs: initialEmailPrefs,
+  anchorPolicy: initialAnchorPolicy,
   totpEnabled: initialTotpEnabled,
 }: SettingsFormProps) {
   const router = useRouter();
@@ -53,6 +62,7 @@ export function SettingsForm({
   const [formData, setFormData] = useState({ name, username, bio, theme });
   const [links, setLinks] = useState<CustomLink[]>(customLinks || []);
   const [emailPrefs, setEmailPrefs] = useState<EmailPrefs>(initialEmailPrefs || { welcome: true, connectionAlert: true, weeklyDigest: true, collaborations: true });
+  const [anchorPolicy, setAnchorPolicy] = useState(initialAnchorPolicy);
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
   const [deleting, setDeleting] = useState(false);
@@ -129,6 +139,7 @@ export function SettingsForm({
       theme: formData.theme,
       customLinks: validLinks,
       emailPrefs,
+      anchorPolicy,
     });
 
     if (result.error) {
@@ -375,6 +386,23 @@ export function SettingsForm({
         </div>
       </div>
 
+      {/* Automatic Anchoring */}
+      <div className="space-y-4">
+        <h2 className="text-lg font-semibold">{t("settings.anchorSection")}</h2>
+        <p className="text-sm text-zinc-500">{t("settings.anchorDescription")}</p>
+        <select
+          value={anchorPolicy}
+          onChange={(e) => setAnchorPolicy(e.target.value)}
+          className="w-full rounded-lg border border-zinc-200 bg-transparent px-3 py-2 text-sm dark:border-zinc-700"
+        >
+          {ANCHOR_POLICIES.map((p) => (
+            <option key={p.key} value={p.key}>
+              {t(p.label)}
+            </option>
+          ))}
+        </select>
+      </div>
+
       {/* Security: Two-Factor Authentication */}
       <div className="space-y-4">
         <div className="flex items-center gap-2">
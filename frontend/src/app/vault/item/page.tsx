@@ -604,12 +604,12 @@ function VaultDetailContent() {
               <div className="mt-4">
                 {/* Status badge */}
                 <div className="mb-4">
-                  {anchor.anchorStatus === "confirmed" ? (
+                  {anchor.anchorStatus === "finalized" ? (
                     <span className="inline-flex items-center gap-1.5 rounded-full bg-emerald-50 px-3 py-1 text-sm font-medium text-emerald-700 dark:bg-emerald-950 dark:text-emerald-300">
                       <CheckCircle className="h-4 w-4" />
                       {t("blockchain.verified")}
                     </span>
-                  ) : anchor.anchorStatus === "pending" ? (
+                  ) : (anchor.anchorStatus === "pending" || anchor.anchorStatus === "included") ? (
                     <span className="inline-flex items-center gap-1.5 rounded-full bg-amber-50 px-3 py-1 text-sm font-medium text-amber-700 dark:bg-amber-950 dark:text-amber-300">
                       <Clock className="h-4 w-4" />
                       {t("blockchain.pending")}
@@ -3,7 +3,7 @@
 import { useSearchParams } from "next/navigation";
 import { useEffect, useState, Suspense, useCallback } from "react";
 import { api } from "@/lib/api";
-import { Shield, Search, ExternalLink, CheckCircle, Clock, AlertTriangle } from "@/components/icons";
+import { Shield, Search, ExternalLink, CheckCircle, Clock, AlertTriangle, Image as ImageIcon } from "@/components/icons";
 import { QRCodeSVG } from "qrcode.react";
 import { useTranslation } from "react-i18next";
 
@@ -22,17 +22,41 @@ type AnchorData = {
   confirmedAt: string | null;
 };
 
+type ImageMatch = {
+  contentId: string;
+  title: string;
+  thumbnailUrl: string | null;
+  createdAt: string;
+  distance: number;
+  exact: boolean;
+  owner?: string | null;
+  anchorStatus?: string;
+  disputeStatus?: string | null;
+};
+
 type ContentData = {
   id: string;
   title: string;
   contentType: string;
   createdAt: string;
+  disputeStatus?: string | null;
 };
 
+function DisputeBadge({ status }: { status: string }) {
+  const { t } = useTranslation();
+
+  return (
+    <span className="inline-flex items-center gap-1.5 rounded-full bg-orange-50 px-3 py-1 text-sm font-medium text-orange-700 dark:bg-orange-950 dark:text-orange-300">
+      <AlertTriangle className="h-4 w-4" />
+      {status === "rejected" ? t("blockchain.disputeRejected") : t("blockchain.disputed")}
+    </span>
+  );
+}
+
 function StatusBadge({ status }: { status: string }) {
   const { t } = useTranslation();
 
-  if (status === "confirmed") {
+  if (status === "finalized") {
     return (
       <span className="inline-flex items-center gap-1.5 rounded-full bg-emerald-50 px-3 py-1 text-sm font-medium text-emerald-700 dark:bg-emerald-950 dark:text-emerald-300">
         <CheckCircle className="h-4 w-4" />
@@ -40,7 +64,7 @@ function StatusBadge({ status }: { status: string }) {
       </span>
     );
   }
-  if (status === "pending") {
+  if (status === "pending" || status === "included") {
     return (
       <span className="inline-flex items-center gap-1.5 rounded-full bg-amber-50 px-3 py-1 text-sm font-medium text-amber-700 dark:bg-amber-950 dark:text-amber-300">
         <Clock className="h-4 w-4" />
@@ -68,6 +92,8 @@ function VerifyContent() {
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState("");
   const [searched, setSearched] = useState(false);
+  const [imageMatches, setImageMatches] = useState<ImageMatch[] | null>(null);
+  const [imageLoading, setImageLoading] = useState(false);
 
   const doVerify = useCallback(async (hash: string) => {
     if (!hash.trim()) return;
@@ -87,6 +113,25 @@ function VerifyContent() {
     setLoading(false);
   }, [t]);
 
+  const handleImage = async (e: React.ChangeEvent<HTMLInputElement>) => {
+    const file = e.target.files?.[0];
+    e.target.value = "";
+    if (!file) return;
+    setImageLoading(true);
+    setError("");
+    setImageMatches(null);
+
+    const result = await api.blockchain.verifyImage(file);
+    if (result.data) {
+      setImageMatches(result.data.matches);
+      setSearchHash(result.data.sha256);
+    } else {
+      setError(result.error || t("blockchain.noImageMatches"));
+      setSearched(true);
+    }
+    setImageLoading(false);
+  };
+
   // Auto-fetch if hash or tx is provided
   useEffect(() => {
     if (hashParam) {
@@ -145,6 +190,49 @@ function VerifyContent() {
         </div>
       </form>
 
+      {/* Image Search */}
+      <div className="-mt-6 mb-10">
+        <label className="flex cursor-pointer items-center justify-center gap-2 rounded-xl border border-dashed border-zinc-300 px-4 py-3 text-sm text-zinc-600 transition-colors hover:border-emerald-400 hover:text-emerald-700 dark:border-zinc-700 dark:text-zinc-400 dark:hover:border-emerald-500">
+          <ImageIcon className="h-4 w-4" />
+          {imageLoading ? t("common.loading") : t("blockchain.imageSearch")}
+          <input type="file" accept="image/jpeg,image/png" className="hidden" onChange={handleImage} disabled={imageLoading} />
+        </label>
+        <p className="mt-2 text-center text-xs text-zinc-400">{t("blockchain.imageSearchHint")}</p>
+
+        {imageMatches && (
+          <div className="mt-4 rounded-2xl border border-zinc-200 bg-white p-4 shadow-sm dark:border-zinc-800 dark:bg-zinc-900">
+            <h2 className="mb-3 text-sm font-semibold text-zinc-900 dark:text-zinc-100">
+              {t("blockchain.imageMatches")}
+            </h2>
+            {imageMatches.length === 0 ? (
+              <p className="text-sm text-zinc-500">{t("blockchain.noImageMatches")}</p>
+            ) : (
+              <ul className="space-y-3">
+                {imageMatches.map((m) => (
+                  <li key={m.contentId} className="flex items-center gap-3">
+                    {m.thumbnailUrl ? (
+                      <img src={m.thumbnailUrl} alt="" className="h-12 w-12 rounded-lg object-cover" />
+                    ) : (
+                      <div className="h-12 w-12 rounded-lg bg-zinc-100 dark:bg-zinc-800" />
+                    )}
+                    <div className="min-w-0 flex-1">
+                      <p className="truncate text-sm font-medium text-zinc-900 dark:text-zinc-100">{m.title}</p>
+                      <p className="text-xs text-zinc-500">
+                        {m.owner ? `@${m.owner} · ` : ""}
+                        {new Date(m.createdAt).toLocaleDateString()} ·{" "}
+                        {m.exact ? t("blockchain.exactMatch") : t("blockchain.bitsDifferent", { count: m.distance })}
+                      </p>
+                    </div>
+                    {m.disputeStatus && <DisputeBadge status={m.disputeStatus} />}
+                    {m.anchorStatus && <StatusBadge status={m.anchorStatus} />}
+                  </li>
+                ))}
+              </ul>
+            )}
+          </div>
+        )}
+      </div>
+
       {/* Error State */}
       {error && searched && (
         <div className="rounded-xl border border-red-200 bg-red-50 p-6 text-center dark:border-red-800 dark:bg-red-950">
@@ -159,16 +247,16 @@ function VerifyContent() {
           {/* Status Banner */}
           <div
             className={`rounded-2xl border p-6 text-center ${
-              anchor.anchorStatus === "confirmed"
+              anchor.anchorStatus === "finalized"
                 ? "border-emerald-200 bg-gradient-to-br from-emerald-50 to-teal-50 dark:border-emerald-800 dark:from-emerald-950 dark:to-teal-950"
-                : anchor.anchorStatus === "pending"
+                : (anchor.anchorStatus === "pending" || anchor.anchorStatus === "included")
                   ? "border-amber-200 bg-gradient-to-br from-amber-50 to-yellow-50 dark:border-amber-800 dark:from-amber-950 dark:to-yellow-950"
                   : "border-red-200 bg-gradient-to-br from-red-50 to-pink-50 dark:border-red-800 dark:from-red-950 dark:to-pink-950"
             }`}
           >
-            {anchor.anchorStatus === "confirmed" ? (
+            {anchor.anchorStatus === "finalized" ? (
               <CheckCircle className="mx-auto mb-3 h-12 w-12 text-emerald-500" />
-            ) : anchor.anchorStatus === "pending" ? (
+            ) : (anchor.anchorStatus === "pending" || anchor.anchorStatus === "included") ? (
               <Clock className="mx-auto mb-3 h-12 w-12 text-amber-500" />
             ) : (
               <AlertTriangle className="mx-auto mb-3 h-12 w-12 text-red-500" />
@@ -179,6 +267,12 @@ function VerifyContent() {
                 {content.title}
               </p>
             )}
+            {content?.disputeStatus && (
+              <div className="mt-3">
+                <DisputeBadge status={content.disputeStatus} />
+                <p className="mt-2 text-xs text-zinc-500">{t("blockchain.disputeHint")}</p>
+              </div>
+            )}
           </div>
 
           {/* Proof Certificate */}
@@ -265,6 +359,18 @@ function VerifyContent() {
                   </div>
                 )}
               </div>
+
+              {/* Anchoring Policy */}
+              {anchor.policy && (
+                <div>
+                  <dt className="text-xs font-medium uppercase tracking-wider text-zinc-500">
+                    {t("blockchain.autoAnchored")}
+                  </dt>
+                  <dd className="mt-1 text-sm text-zinc-900 dark:text-zinc-100">
+                    {t(`settings.anchor${anchor.policy.charAt(0).toUpperCase()}${anchor.policy.slice(1)}`)}
+                  </dd>
+                </div>
+              )}
             </dl>
 
           </div>
@@ -7,10 +7,23 @@ import { useTranslation } from "react-i18next";
 import { Webhook, Plus, Trash2, ToggleLeft, ToggleRight, ChevronDown, ChevronUp, Clock } from "@/components/icons";
 
 const AVAILABLE_EVENTS = [
-  { value: "license.sold", label: "License Sold" },
-  { value: "content.uploaded", label: "Content Uploaded" },
   { value: "profile.viewed", label: "Profile Viewed" },
+  { value: "content.uploaded", label: "Content Uploaded" },
   { value: "collaboration.received", label: "Collaboration Received" },
+  { value: "token.transferred", label: "Token Transferred" },
+  { value: "token.redeemed", label: "Token Redeemed" },
+  { value: "tip.received", label: "Tip Received" },
+  { value: "license.sold", label: "License Sold" },
+  { value: "license.purchased", label: "License Purchased" },
+  { value: "fan_subscription.started", label: "Fan Subscription Started" },
+  { value: "fan_subscription.canceled", label: "Fan Subscription Canceled" },
+  { value: "anchor.confirmed", label: "Anchor Confirmed" },
+  { value: "anchor.failed", label: "Anchor Failed" },
+  { value: "moderation.flag_raised", label: "Moderation Flag Raised" },
+  { value: "takedown.resolved", label: "Takedown Resolved" },
+  { value: "connection.refreshed", label: "Connection Refreshed" },
+  { value: "connection.expired", label: "Connection Expired" },
+  { value: "payout.updated", label: "Payout Updated" },
   { value: "payout.completed", label: "Payout Completed" },
 ];
 
@@ -178,6 +178,12 @@ const en = {
     emailDigestDesc: "Weekly summary of your profile views and clicks",
     emailCollab: "Collaboration requests",
     emailCollabDesc: "Notifications about collaboration requests",
+    anchorSection: "Automatic Anchoring",
+    anchorDescription: "Choose which uploads are anchored on the blockchain for you. Anchors count against your monthly quota, which grows with your creator tier.",
+    anchorAll: "Every upload",
+    anchorPublic: "Public items only",
+    anchorLicensed: "Items with a license offering",
+    anchorNever: "Never (anchor by hand)",
     exportSection: "Export Data",
     exportDescription: "Download a copy of your profile data in JSON format.",
     exporting: "Exporting...",
@@ -316,6 +322,25 @@ const en = {
       noFlags: "No moderation flags found",
       confirm: "Confirm",
     },
+    ownership: {
+      title: "Ownership Conflicts",
+      open: "Open",
+      upheld: "Upheld",
+      dismissed: "Dismissed",
+      all: "All",
+      upload: "Upload",
+      original: "Earlier content",
+      match: "Match",
+      exact: "Identical",
+      bits: "{{count}} bits apart",
+      uphold: "Uphold",
+      dismiss: "Dismiss",
+      noConflicts: "No ownership conflicts found",
+      sameFile: "Same file",
+      notAnchored: "Not anchored",
+      anchoredAt: "Finalized on {{chain}} {{date}}",
+      anchoredFirst: "first on chain",
+    },
   },
 
   // Landing Page
@@ -967,12 +992,22 @@ const en = {
     chain: "Blockchain",
     contentHash: "Content Hash",
     anchoredAt: "Anchored At",
+    autoAnchored: "Anchored Automatically",
     verifyTitle: "Verify Content Authenticity",
     verifySubtitle: "Enter a content hash to verify its blockchain anchor.",
     searchPlaceholder: "Enter SHA-256 hash...",
     verifyButton: "Verify",
     notFound: "No blockchain anchor found for this hash.",
     proofCertificate: "Proof Certificate",
+    imageSearch: "Or check an image",
+    imageSearchHint: "Upload an image to find registered copies, even resized or re-encoded ones.",
+    imageMatches: "Similar registered content",
+    noImageMatches: "No similar public content found.",
+    disputed: "Ownership disputed",
+    disputeRejected: "Ownership claim rejected",
+    disputeHint: "Another creator uploaded this exact file earlier.",
+    exactMatch: "Exact copy",
+    bitsDifferent: "{{count}} bits different",
     myAnchors: "My Anchored Content",
     noAnchors: "No anchored content yet.",
   },
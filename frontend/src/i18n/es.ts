@@ -176,6 +176,12 @@ const es = {
     emailDigestDesc: "Resumen semanal de las visitas y clics de tu perfil",
     emailCollab: "Solicitudes de colaboracion",
     emailCollabDesc: "Notificaciones sobre solicitudes de colaboracion",
+    anchorSection: "Anclaje automatico",
+    anchorDescription: "Elige que subidas se anclan en la blockchain por ti. Los anclajes cuentan para tu cuota mensual, que crece con tu nivel de creador.",
+    anchorAll: "Cada subida",
+    anchorPublic: "Solo elementos publicos",
+    anchorLicensed: "Elementos con oferta de licencia",
+    anchorNever: "Nunca (anclar a mano)",
     exportSection: "Exportar datos",
     exportDescription: "Descarga una copia de tus datos de perfil en formato JSON.",
     exporting: "Exportando...",
@@ -314,6 +320,25 @@ const es = {
       noFlags: "No se encontraron flags de moderacion",
       confirm: "Confirmar",
     },
+    ownership: {
+      title: "Conflictos de propiedad",
+      open: "Abierto",
+      upheld: "Confirmado",
+      dismissed: "Descartado",
+      all: "Todos",
+      upload: "Subida",
+      original: "Contenido anterior",
+      match: "Coincidencia",
+      exact: "Identico",
+      bits: "{{count}} bits de diferencia",
+      uphold: "Confirmar",
+      dismiss: "Descartar",
+      noConflicts: "No se encontraron conflictos de propiedad",
+      sameFile: "Mismo archivo",
+      notAnchored: "Sin anclar",
+      anchoredAt: "Finalizado en {{chain}} {{date}}",
+      anchoredFirst: "primero en la cadena",
+    },
   },
 
   // Landing Page
@@ -961,12 +986,22 @@ const es = {
     chain: "Blockchain",
     contentHash: "Hash de Contenido",
     anchoredAt: "Anclado el",
+    autoAnchored: "Anclado automaticamente",
     verifyTitle: "Verificar Autenticidad del Contenido",
     verifySubtitle: "Introduce un hash de contenido para verificar su anclaje en blockchain.",
     searchPlaceholder: "Introduce hash SHA-256...",
     verifyButton: "Verificar",
     notFound: "No se encontro anclaje en blockchain para este hash.",
     proofCertificate: "Certificado de Prueba",
+    imageSearch: "O comprueba una imagen",
+    imageSearchHint: "Sube una imagen para encontrar copias registradas, aunque esten redimensionadas o recodificadas.",
+    imageMatches: "Contenido registrado similar",
+    noImageMatches: "No se encontro contenido publico similar.",
+    disputed: "Propiedad en disputa",
+    disputeRejected: "Reclamo de propiedad rechazado",
+    disputeHint: "Otro creador subio este mismo archivo antes.",
+    exactMatch: "Copia exacta",
+    bitsDifferent: "{{count}} bits distintos",
     myAnchors: "Mi Contenido Anclado",
     noAnchors: "Sin contenido anclado aun.",
   },
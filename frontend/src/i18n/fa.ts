@@ -176,6 +176,12 @@ const fa = {
     emailDigestDesc: "\u062e\u0644\u0627\u0635\u0647 \u0647\u0641\u062a\u06af\u06cc \u0628\u0627\u0632\u062f\u06cc\u062f\u0647\u0627 \u0648 \u06a9\u0644\u06cc\u06a9\u200c\u0647\u0627\u06cc \u067e\u0631\u0648\u0641\u0627\u06cc\u0644 \u0634\u0645\u0627",
     emailCollab: "\u062f\u0631\u062e\u0648\u0627\u0633\u062a\u200c\u0647\u0627\u06cc \u0647\u0645\u06a9\u0627\u0631\u06cc",
     emailCollabDesc: "\u0627\u0639\u0644\u0627\u0646\u200c\u0647\u0627 \u062f\u0631\u0628\u0627\u0631\u0647 \u062f\u0631\u062e\u0648\u0627\u0633\u062a\u200c\u0647\u0627\u06cc \u0647\u0645\u06a9\u0627\u0631\u06cc",
+    anchorSection: "\u062b\u0628\u062a \u062e\u0648\u062f\u06a9\u0627\u0631",
+    anchorDescription: "\u0627\u0646\u062a\u062e\u0627\u0628 \u06a9\u0646\u06cc\u062f \u06a9\u062f\u0627\u0645 \u0628\u0627\u0631\u06af\u0630\u0627\u0631\u06cc\u200c\u0647\u0627 \u0628\u0647\u200c\u0637\u0648\u0631 \u062e\u0648\u062f\u06a9\u0627\u0631 \u0631\u0648\u06cc \u0628\u0644\u0627\u06a9\u0686\u06cc\u0646 \u062b\u0628\u062a \u0634\u0648\u0646\u062f. \u062b\u0628\u062a\u200c\u0647\u0627 \u0627\u0632 \u0633\u0647\u0645\u06cc\u0647 \u0645\u0627\u0647\u0627\u0646\u0647 \u0634\u0645\u0627 \u06a9\u0645 \u0645\u06cc\u200c\u0634\u0648\u0646\u062f \u06a9\u0647 \u0628\u0627 \u0633\u0637\u062d \u0633\u0627\u0632\u0646\u062f\u0647 \u0634\u0645\u0627 \u0627\u0641\u0632\u0627\u06cc\u0634 \u0645\u06cc\u200c\u06cc\u0627\u0628\u062f.",
+    anchorAll: "\u0647\u0645\u0647 \u0628\u0627\u0631\u06af\u0630\u0627\u0631\u06cc\u200c\u0647\u0627",
+    anchorPublic: "\u0641\u0642\u0637 \u0645\u0648\u0627\u0631\u062f \u0639\u0645\u0648\u0645\u06cc",
+    anchorLicensed: "\u0645\u0648\u0627\u0631\u062f\u06cc \u06a9\u0647 \u067e\u06cc\u0634\u0646\u0647\u0627\u062f \u0645\u062c\u0648\u0632 \u062f\u0627\u0631\u0646\u062f",
+    anchorNever: "\u0647\u0631\u06af\u0632 (\u062b\u0628\u062a \u062f\u0633\u062a\u06cc)",
     exportSection: "\u062e\u0631\u0648\u062c\u06cc \u062f\u0627\u062f\u0647\u200c\u0647\u0627",
     exportDescription: "\u06cc\u06a9 \u06a9\u067e\u06cc \u0627\u0632 \u062f\u0627\u062f\u0647\u200c\u0647\u0627\u06cc \u067e\u0631\u0648\u0641\u0627\u06cc\u0644 \u062e\u0648\u062f \u0631\u0627 \u062f\u0631 \u0642\u0627\u0644\u0628 JSON \u062f\u0627\u0646\u0644\u0648\u062f \u06a9\u0646\u06cc\u062f.",
     exporting: "\u062f\u0631 \u062d\u0627\u0644 \u062e\u0631\u0648\u062c\u06cc...",
@@ -314,6 +320,25 @@ const fa = {
       noFlags: "\u0647\u06cc\u0686 \u067e\u0631\u0686\u0645 \u0645\u062f\u06cc\u0631\u06cc\u062a\u06cc \u06cc\u0627\u0641\u062a \u0646\u0634\u062f",
       confirm: "\u062a\u0623\u06cc\u06cc\u062f",
     },
+    ownership: {
+      title: "\u062a\u0639\u0627\u0631\u0636\u200c\u0647\u0627\u06cc \u0645\u0627\u0644\u06a9\u06cc\u062a",
+      open: "\u0628\u0627\u0632",
+      upheld: "\u062a\u0623\u06cc\u06cc\u062f\u0634\u062f\u0647",
+      dismissed: "\u0631\u062f\u0634\u062f\u0647",
+      all: "\u0647\u0645\u0647",
+      upload: "\u0628\u0627\u0631\u06af\u0630\u0627\u0631\u06cc",
+      original: "\u0645\u062d\u062a\u0648\u0627\u06cc \u0642\u0628\u0644\u06cc",
+      match: "\u062a\u0637\u0627\u0628\u0642",
+      exact: "\u06cc\u06a9\u0633\u0627\u0646",
+      bits: "{{count}} \u0628\u06cc\u062a \u062a\u0641\u0627\u0648\u062a",
+      uphold: "\u062a\u0623\u06cc\u06cc\u062f",
+      dismiss: "\u0631\u062f",
+      noConflicts: "\u0647\u06cc\u0686 \u062a\u0639\u0627\u0631\u0636 \u0645\u0627\u0644\u06a9\u06cc\u062a\u06cc \u06cc\u0627\u0641\u062a \u0646\u0634\u062f",
+      sameFile: "\u0647\u0645\u0627\u0646 \u0641\u0627\u06cc\u0644",
+      notAnchored: "\u062b\u0628\u062a \u0646\u0634\u062f\u0647",
+      anchoredAt: "\u0646\u0647\u0627\u06cc\u06cc\u200c\u0634\u062f\u0647 \u062f\u0631 {{chain}} {{date}}",
+      anchoredFirst: "\u0627\u0648\u0644 \u062f\u0631 \u0632\u0646\u062c\u06cc\u0631\u0647",
+    },
   },
 
   // Landing Page
@@ -961,12 +986,22 @@ const fa = {
     chain: "\u0628\u0644\u0627\u06a9\u0686\u06cc\u0646",
     contentHash: "\u0647\u0634 \u0645\u062d\u062a\u0648\u0627",
     anchoredAt: "\u062a\u0627\u0631\u06cc\u062e \u0644\u0646\u06af\u0631\u06af\u0630\u0627\u0631\u06cc",
+    autoAnchored: "\u062b\u0628\u062a \u062e\u0648\u062f\u06a9\u0627\u0631",
     verifyTitle: "\u062a\u0623\u06cc\u06cc\u062f \u0627\u0635\u0627\u0644\u062a \u0645\u062d\u062a\u0648\u0627",
     verifySubtitle: "\u0647\u0634 \u0645\u062d\u062a\u0648\u0627 \u0631\u0627 \u0648\u0627\u0631\u062f \u06a9\u0646\u06cc\u062f \u062a\u0627 \u0644\u0646\u06af\u0631 \u0628\u0644\u0627\u06a9\u0686\u06cc\u0646 \u0622\u0646 \u0631\u0627 \u062a\u0623\u06cc\u06cc\u062f \u06a9\u0646\u06cc\u062f.",
     searchPlaceholder: "\u0647\u0634 SHA-256 \u0631\u0627 \u0648\u0627\u0631\u062f \u06a9\u0646\u06cc\u062f...",
     verifyButton: "\u062a\u0623\u06cc\u06cc\u062f",
     notFound: "\u0647\u06cc\u0686 \u0644\u0646\u06af\u0631 \u0628\u0644\u0627\u06a9\u0686\u06cc\u0646\u06cc \u0628\u0631\u0627\u06cc \u0627\u06cc\u0646 \u0647\u0634 \u06cc\u0627\u0641\u062a \u0646\u0634\u062f.",
     proofCertificate: "\u06af\u0648\u0627\u0647\u06cc \u0627\u062b\u0628\u0627\u062a",
+    imageSearch: "\u06cc\u0627 \u06cc\u06a9 \u062a\u0635\u0648\u06cc\u0631 \u0631\u0627 \u0628\u0631\u0631\u0633\u06cc \u06a9\u0646\u06cc\u062f",
+    imageSearchHint: "\u062a\u0635\u0648\u06cc\u0631\u06cc \u0628\u0627\u0631\u06af\u0630\u0627\u0631\u06cc \u06a9\u0646\u06cc\u062f \u062a\u0627 \u0646\u0633\u062e\u0647\u200c\u0647\u0627\u06cc \u062b\u0628\u062a\u200c\u0634\u062f\u0647 \u0622\u0646\u060c \u062d\u062a\u06cc \u0628\u0627 \u0627\u0646\u062f\u0627\u0632\u0647 \u06cc\u0627 \u0641\u0634\u0631\u062f\u0647\u200c\u0633\u0627\u0632\u06cc \u0645\u062a\u0641\u0627\u0648\u062a\u060c \u067e\u06cc\u062f\u0627 \u0634\u0648\u0646\u062f.",
+    imageMatches: "\u0645\u062d\u062a\u0648\u0627\u06cc \u062b\u0628\u062a\u200c\u0634\u062f\u0647 \u0645\u0634\u0627\u0628\u0647",
+    noImageMatches: "\u0645\u062d\u062a\u0648\u0627\u06cc \u0639\u0645\u0648\u0645\u06cc \u0645\u0634\u0627\u0628\u0647\u06cc \u067e\u06cc\u062f\u0627 \u0646\u0634\u062f.",
+    disputed: "\u0645\u0627\u0644\u06a9\u06cc\u062a \u0645\u0648\u0631\u062f \u0627\u062e\u062a\u0644\u0627\u0641",
+    disputeRejected: "\u0627\u062f\u0639\u0627\u06cc \u0645\u0627\u0644\u06a9\u06cc\u062a \u0631\u062f \u0634\u062f",
+    disputeHint: "\u0633\u0627\u0632\u0646\u062f\u0647 \u062f\u06cc\u06af\u0631\u06cc \u0647\u0645\u06cc\u0646 \u0641\u0627\u06cc\u0644 \u0631\u0627 \u0632\u0648\u062f\u062a\u0631 \u0628\u0627\u0631\u06af\u0630\u0627\u0631\u06cc \u06a9\u0631\u062f\u0647 \u0627\u0633\u062a.",
+    exactMatch: "\u0646\u0633\u062e\u0647 \u062f\u0642\u06cc\u0642",
+    bitsDifferent: "{{count}} \u0628\u06cc\u062a \u062a\u0641\u0627\u0648\u062a",
     myAnchors: "\u0645\u062d\u062a\u0648\u0627\u06cc \u0644\u0646\u06af\u0631\u06af\u0630\u0627\u0631\u06cc \u0634\u062f\u0647 \u0645\u0646",
     noAnchors: "\u0647\u0646\u0648\u0632 \u0645\u062d\u062a\u0648\u0627\u06cc\u06cc \u0644\u0646\u06af\u0631\u06af\u0630\u0627\u0631\u06cc \u0646\u0634\u062f\u0647 \u0627\u0633\u062a.",
   },
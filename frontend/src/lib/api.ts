@@ -7,6 +7,14 @@ type ApiResponse<T> =
   | { data: T; error?: never }
   | { data?: never; error: string };
 
+type OwnershipAnchor = {
+  chain: string;
+  status: string;
+  txHash: string | null;
+  blockNumber: number | null;
+  confirmedAt: string | null;
+};
+
 async function request<T>(
   path: string,
   options?: RequestInit
@@ -77,6 +85,7 @@ export const api = {
       theme?: string;
       customLinks?: { title: string; url: string }[];
       emailPrefs?: EmailPrefs;
+      anchorPolicy?: "all" | "public" | "licensed" | "never";
     }) =>
       request<{ success: boolean }>("/api/users/profile", {
         method: "PATCH",
@@ -312,7 +321,8 @@ export const api = {
     delete: (id: string) =>
       request<{ success: boolean }>(`/api/content/${id}`, { method: "DELETE" }),
     download: (id: string) => `${API_URL}/api/content/${id}/download`,
-    proof: (id: string) => request<{ id: string; hashSha256: string; createdAt: string; title: string }>(`/api/content/${id}/proof`),
+    proof: (id: string) => request<{ id: string; hashSha256: string; createdAt: string; title: string; disputeStatus?: string; anchorPolicy?: string }>(`/api/content/${id}/proof`),
+    proofBundleUrl: (id: string) => `${API_URL}/api/content/${id}/proof/bundle`,
     publicList: (username: string) =>
       request<{ items: any[] }>(`/api/users/${username}/content`),
   },
@@ -384,16 +394,16 @@ export const api = {
       request<{ payouts: any[]; total: number }>(`/api/payouts?limit=${limit}&offset=${offset}`),
   },
   collections: {
-    create: (title: string, description?: string, isPublic = true) =>
+    create: (title: string, description?: string, isPublic = true, anchorPolicy?: string) =>
       request<any>("/api/collections", {
         method: "POST",
-        body: JSON.stringify({ title, description, isPublic }),
+        body: JSON.stringify({ title, description, isPublic, anchorPolicy }),
       }),
     list: (limit = 20, offset = 0) =>
       request<{ collections: any[]; total: number }>(`/api/collections?limit=${limit}&offset=${offset}`),
     get: (id: string) =>
       request<{ collection: any; items: any[] }>(`/api/collections/${id}`),
-    update: (id: string, data: { title?: string; description?: string; isPublic?: boolean }) =>
+    update: (id: string, data: { title?: string; description?: string; isPublic?: boolean; anchorPolicy?: string }) =>
       request<{ success: boolean }>(`/api/collections/${id}`, {
         method: "PATCH",
         body: JSON.stringify(data),
@@ -433,13 +443,46 @@ export const api = {
         body: JSON.stringify({ url, events }),
       }),
     list: () => request<{ endpoints: any[] }>("/api/webhooks"),
+    events: () =>
+      request<{ events: { name: string; version: number; description: string; fields: any[] }[] }>("/api/webhooks/events"),
     update: (id: string, data: { url?: string; events?: string[]; isActive?: boolean }) =>
-      request<{ success: boolean }>(`/api/webhooks/${id}`, {
+      request<{ success: boolean; requeued: number }>(`/api/webhooks/${id}`, {
         method: "PATCH",
         body: JSON.stringify(data),
       }),
     delete: (id: string) =>
       request<{ success: boolean }>(`/api/webhooks/${id}`, { method: "DELETE" }),
+    rotateSecret: (id: string, gracePeriodHours?: number) =>
+      request<{ id: string; secret: string; previousSecretExpiresAt: string | null }>(`/api/webhooks/${id}/rotate-secret`, {
+        method: "POST",
+        body: JSON.stringify(gracePeriodHours === undefined ? {} : { gracePeriodHours }),
+      }),
+    health: (id: string) =>
+      request<{
+        windowHours: number;
+        attempts: number;
+        successes: number;
+        successRate: number | null;
+        p95LatencyMs: number | null;
+        lastSuccessAt: string | null;
+        consecutiveFailures: number;
+        failingSince: string | null;
+        isActive: boolean;
+        disabledAt: string | null;
+        disabledReason: string | null;
+      }>(`/api/webhooks/${id}/health`),
+    enable: (id: string) =>
+      request<{ success: boolean; requeued: number }>(`/api/webhooks/${id}/enable`, { method: "POST" }),
+    redeliver: (id: string, filter: { since?: string; until?: string; eventType?: string; status?: "failed" | "dead" } = {}) =>
+      request<{ success: boolean; requeued: number }>(`/api/webhooks/${id}/redeliver`, {
+        method: "POST",
+        body: JSON.stringify(filter),
+      }),
+    test: (id: string) =>
+      request<{ deliveryId: string; success: boolean; statusCode: number; body: string; error?: string; durationMs: number }>(
+        `/api/webhooks/${id}/test`,
+        { method: "POST" }
+      ),
     deliveries: (id: string, limit = 20, offset = 0) =>
       request<{ deliveries: any[]; total: number }>(`/api/webhooks/${id}/deliveries?limit=${limit}&offset=${offset}`),
     retryDelivery: (endpointId: string, deliveryId: string) =>
@@ -481,6 +524,20 @@ export const api = {
           reporterName: string | null;
         }[];
         total: number;
+        anomalies: {
+          id: string;
+          userId: string;
+          username: string | null;
+          platform: string;
+          kind: string;
+          severity: string;
+          details: string | null;
+          previousFollowers: number | null;
+          currentFollowers: number | null;
+          status: string;
+          createdAt: string;
+        }[];
+        anomalyTotal: number;
       }>(`/api/admin/moderation?${params}`);
     },
     resolve: async (id: string, status: string, notes: string) => {
@@ -489,6 +546,46 @@ export const api = {
         body: JSON.stringify({ status, notes }),
       });
     },
+    resolveAnomaly: async (id: string, status: "confirmed" | "dismissed", notes: string) => {
+      return request<{ success: boolean }>(`/api/admin/moderation/anomalies/${id}/resolve`, {
+        method: "POST",
+        body: JSON.stringify({ status, notes }),
+      });
+    },
+  },
+  adminOwnership: {
+    list: async (status?: string, limit = 20, offset = 0) => {
+      const params = new URLSearchParams({ limit: String(limit), offset: String(offset) });
+      if (status) params.set("status", status);
+      return request<{
+        conflicts: {
+          id: string;
+          contentId: string;
+          userId: string;
+          originalContentId: string;
+          originalUserId: string;
+          matchType: string;
+          distance: number;
+          status: string;
+          notes: string | null;
+          createdAt: string;
+          contentTitle?: string;
+          contentThumbnailUrl?: string | null;
+          originalTitle?: string;
+          originalThumbnailUrl?: string | null;
+          contentAnchor?: OwnershipAnchor | null;
+          originalAnchor?: OwnershipAnchor | null;
+          earlierAnchor?: "content" | "original";
+        }[];
+        total: number;
+      }>(`/api/admin/ownership-conflicts?${params}`);
+    },
+    resolve: async (id: string, status: "upheld" | "dismissed", notes: string) => {
+      return request<{ success: boolean }>(`/api/admin/ownership-conflicts/${id}/resolve`, {
+        method: "POST",
+        body: JSON.stringify({ status, notes }),
+      });
+    },
   },
   adminErrors: {
     list: (source?: string, limit = 50, offset = 0) => {
@@ -535,12 +632,72 @@ export const api = {
     contentUrl: () => `${API_URL}/api/content-analytics/export`,
   },
   blockchain: {
-    anchor: (contentId: string) =>
-      request<{ anchor: any }>(`/api/content/${contentId}/anchor`, { method: "POST" }),
+    anchor: (contentId: string, chain?: string) =>
+      request<{ anchor: any }>(`/api/content/${contentId}/anchor`, {
+        method: "POST",
+        body: chain ? JSON.stringify({ chain }) : undefined,
+      }),
     getAnchor: (contentId: string) =>
-      request<{ anchor: any }>(`/api/content/${contentId}/anchor`),
+      request<{
+        anchor: any;
+        transactions: {
+          txHash: string;
+          nonce?: number;
+          gasLimit?: number;
+          gasTipCap?: string;
+          gasFeeCap?: string;
+          createdAt: string;
+          replacedAt?: string;
+        }[];
+      }>(`/api/content/${contentId}/anchor`),
     verify: (hash: string) =>
-      request<{ anchor: any; content: any }>(`/api/verify/${hash}`),
+      request<{
+        anchor: any;
+        content: any;
+        proof: {
+          algorithm: string;
+          leafIndex: number;
+          leafCount: number;
+          path: { hash: string; side: "left" | "right" }[];
+          root: string;
+          txHash: string | null;
+          chain: string;
+          blockNumber: number | null;
+          blockHash?: string | null;
+          timestampToken?: string;
+        } | null;
+      }>(`/api/verify/${hash}`),
+    verifyImage: async (file: File): Promise<ApiResponse<{
+      sha256: string;
+      perceptualHash: string;
+      matches: {
+        contentId: string;
+        title: string;
+        thumbnailUrl: string | null;
+        createdAt: string;
+        distance: number;
+        exact: boolean;
+        owner?: string | null;
+        anchorStatus?: string;
+        chain?: string;
+        disputeStatus?: string | null;
+      }[];
+    }>> => {
+      try {
+        const formData = new FormData();
+        formData.append("file", file);
+        const res = await fetch(`${API_URL}/api/verify`, {
+          method: "POST",
+          credentials: "include",
+          body: formData,
+        });
+        const json = await res.json();
+        if (!res.ok) return { error: json.error || "Verification failed" };
+        return { data: json };
+      } catch {
+        return { error: "Network error" };
+      }
+    },
     list: (limit = 20, offset = 0) =>
       request<{ anchors: any[]; total: number }>(`/api/anchors?limit=${limit}&offset=${offset}`),
   },
@@ -561,7 +718,7 @@ export const api = {
     transactions: (tokenId: string, limit = 20, offset = 0) =>
       request<{ transactions: any[]; total: number }>(`/api/tokens/${tokenId}/transactions?limit=${limit}&offset=${offset}`),
     purchase: (tokenId: string, amount: number) =>
-      request<{ success: boolean; amountCents: number; tokensMinted: number }>(`/api/tokens/${tokenId}/purchase`, {
+      request<{ success: boolean; purchaseId: string; status: string; clientSecret: string; amountCents: number; pendingAmount: number }>(`/api/tokens/${tokenId}/purchase`, {
         method: "POST",
         body: JSON.stringify({ amount }),
       }),
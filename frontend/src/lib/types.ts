@@ -27,6 +27,7 @@ export interface User {
   theme: string;
   customLinks: CustomLink[];
   emailPrefs: EmailPrefs;
+  anchorPolicy: "all" | "public" | "licensed" | "never";
   createdAt: string;
   updatedAt: string;
 }